@@ -22,6 +22,8 @@ type OidcCredential struct {
 
 	token string
 
+	serviceConnectionId string
+
 	cred *azidentity.ClientAssertionCredential
 }
 
@@ -32,6 +34,8 @@ type OidcCredentialOptions struct {
 	RequestToken string
 	RequestUrl   string
 	Token        string
+	// ServiceConnectionID is the Azure DevOps service connection id. If specified, the ID token is requested from the Azure DevOps pipeline's OIDC provider.
+	ServiceConnectionID string
 }
 
 func NewOidcCredential(options *OidcCredentialOptions) (*OidcCredential, error) {
@@ -39,6 +43,8 @@ func NewOidcCredential(options *OidcCredentialOptions) (*OidcCredential, error)
 		requestToken: options.RequestToken,
 		requestUrl:   options.RequestUrl,
 		token:        options.Token,
+
+		serviceConnectionId: options.ServiceConnectionID,
 	}
 
 	cred, err := azidentity.NewClientAssertionCredential(options.TenantID, options.ClientID, w.getAssertion, &azidentity.ClientAssertionCredentialOptions{ClientOptions: options.ClientOptions})
@@ -58,6 +64,9 @@ func (w *OidcCredential) getAssertion(ctx context.Context) (string, error) {
 	if w.token != "" {
 		return w.token, nil
 	}
+	if w.serviceConnectionId != "" {
+		return w.getAssertionADO(ctx)
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.requestUrl, http.NoBody)
 	if err != nil {
@@ -108,3 +117,55 @@ func (w *OidcCredential) getAssertion(ctx context.Context) (string, error) {
 
 	return *tokenRes.Value, nil
 }
+
+// getAssertionADO requests the ID token from the Azure DevOps pipeline's OIDC provider, for the service connection.
+// See: https://learn.microsoft.com/en-us/rest/api/azure/devops/distributedtask/oidctoken/create
+func (w *OidcCredential) getAssertionADO(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.requestUrl, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("getAssertion: failed to build request")
+	}
+
+	query, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		return "", fmt.Errorf("getAssertion: cannot parse URL query")
+	}
+	if query.Get("api-version") == "" {
+		query.Set("api-version", "7.1")
+	}
+	query.Set("serviceConnectionId", w.serviceConnectionId)
+	req.URL.RawQuery = query.Encode()
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", w.requestToken))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("getAssertion: cannot request token: %v", err)
+	}
+
+	// #nosec G307
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("getAssertion: cannot parse response: %v", err)
+	}
+
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return "", fmt.Errorf("getAssertion: received HTTP status %d with response: %s", resp.StatusCode, body)
+	}
+
+	var tokenRes struct {
+		OidcToken *string `json:"oidcToken"`
+	}
+	if err := json.Unmarshal(body, &tokenRes); err != nil {
+		return "", fmt.Errorf("getAssertion: cannot unmarshal response: %v", err)
+	}
+
+	if tokenRes.OidcToken == nil {
+		return "", fmt.Errorf("getAssertion: nil JWT assertion received from OIDC provider")
+	}
+
+	return *tokenRes.OidcToken, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOidcCredentialGetAssertionADO(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		require.Equal(t, "7.1", r.URL.Query().Get("api-version"))
+		require.Equal(t, "conn-id", r.URL.Query().Get("serviceConnectionId"))
+		w.Write([]byte(`{"oidcToken": "id-token"}`))
+	}))
+	defer srv.Close()
+
+	cred, err := NewOidcCredential(&OidcCredentialOptions{
+		TenantID:            "00000000-0000-0000-0000-000000000000",
+		ClientID:            "00000000-0000-0000-0000-000000000000",
+		RequestToken:        "request-token",
+		RequestUrl:          srv.URL,
+		ServiceConnectionID: "conn-id",
+	})
+	require.NoError(t, err)
+
+	token, err := cred.getAssertion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "id-token", token)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+)
+
+// backupDirPrefix is the prefix of the backup directory (suffixed by the timestamp) of the existing config files, which is created under the output directory.
+const backupDirPrefix = "aztfexportBackup-"
+
+// configFileNames returns the names of the Terraform config files (i.e. the ".tf" and ".tf.json" files) directly under the directory, in alphabetical order.
+func configFileNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading the directory %s: %v", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if name := entry.Name(); strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// backupConfigFiles moves the existing config files in the directory to a new backup directory under it, so that they are replaced by the generated ones, rather than being appended to.
+// It returns the backup directory, or an empty string if there is no config file to back up.
+func backupConfigFiles(dir string) (string, error) {
+	names, err := configFileNames(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	backupDir := filepath.Join(dir, backupDirPrefix+time.Now().Format("20060102150405"))
+	if err := os.Mkdir(backupDir, 0750); err != nil {
+		return "", fmt.Errorf("creating the backup directory %s: %v", backupDir, err)
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(dir, name), filepath.Join(backupDir, name)); err != nil {
+			return "", fmt.Errorf("backing up %s: %v", name, err)
+		}
+	}
+	return backupDir, nil
+}
+
+// restoreConfigFiles removes the config files in the directory, and moves the backed up ones back. The backup directory is removed afterwards.
+func restoreConfigFiles(dir, backupDir string) error {
+	names, err := configFileNames(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("removing %s: %v", name, err)
+		}
+	}
+	names, err = configFileNames(backupDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(backupDir, name), filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("restoring %s: %v", name, err)
+		}
+	}
+	return os.RemoveAll(backupDir)
+}
+
+// configDiff returns the unified diff of the config files from the backup directory to the directory, or an empty string if there is no change.
+func configDiff(dir, backupDir string) (string, error) {
+	oldNames, err := configFileNames(backupDir)
+	if err != nil {
+		return "", err
+	}
+	newNames, err := configFileNames(dir)
+	if err != nil {
+		return "", err
+	}
+	nameSet := map[string]bool{}
+	for _, name := range append(oldNames, newNames...) {
+		nameSet[name] = true
+	}
+	var names []string
+	for name := range nameSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	readFile := func(path string) (string, error) {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("reading %s: %v", path, err)
+		}
+		return string(b), nil
+	}
+	var sb strings.Builder
+	for _, name := range names {
+		oldContent, err := readFile(filepath.Join(backupDir, name))
+		if err != nil {
+			return "", err
+		}
+		newContent, err := readFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		edits := myers.ComputeEdits(span.URIFromPath(name), oldContent, newContent)
+		if len(edits) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprint(gotextdiff.ToUnified("a/"+name, "b/"+name, oldContent, edits)))
+	}
+	return sb.String(), nil
+}
+
+// backupOnFailure points out the backup directory (if any) of the existing config files in the error of the failed export.
+// The config files are not restored, as the state might have been changed by the export already.
+func backupOnFailure(err error, backupDir string) error {
+	if err == nil || backupDir == "" {
+		return err
+	}
+	if _, serr := os.Stat(backupDir); serr != nil {
+		// The backup directory is removed once the config files are restored (e.g. when reviewing the changes)
+		return err
+	}
+	return fmt.Errorf("%v\n\nThe existing config files are backed up to %s", err, backupDir)
+}
+
+// reviewConfigChanges shows the changes of the config files against the backed up ones, and asks the user whether to keep them when confirm is true.
+// The backed up config files are restored if the user declines.
+func reviewConfigChanges(w io.Writer, r io.Reader, dir, backupDir string, confirm bool) error {
+	if !confirm {
+		fmt.Fprintf(w, "The existing config files are backed up to %s\n", backupDir)
+		return nil
+	}
+	diff, err := configDiff(dir, backupDir)
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Fprintf(w, "No change to the existing config files, which are backed up to %s\n", backupDir)
+		return nil
+	}
+	fmt.Fprintf(w, "%s\nKeep the changes above to the existing config files? The existing config files will be restored otherwise (y/N)\n\n> ", diff)
+	var ans string
+	// #nosec G104
+	fmt.Fscanf(r, "%s", &ans)
+	if strings.ToLower(ans) != "y" {
+		if err := restoreConfigFiles(dir, backupDir); err != nil {
+			return fmt.Errorf("restoring the existing config files from %s: %v", backupDir, err)
+		}
+		fmt.Fprintln(w, "The existing config files are restored")
+		return nil
+	}
+	fmt.Fprintf(w, "The existing config files are backed up to %s\n", backupDir)
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"foo\" \"a\" {}\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "removed.tf.json"), []byte("{}\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte("{}"), 0600))
+
+	backupDir, err := backupConfigFiles(dir)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(filepath.Base(backupDir), backupDirPrefix))
+	names, err := configFileNames(backupDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.tf", "removed.tf.json"}, names)
+	// The non config files are kept as is
+	names, err = configFileNames(dir)
+	require.NoError(t, err)
+	require.Empty(t, names)
+	require.FileExists(t, filepath.Join(dir, "terraform.tfstate"))
+
+	// The generated config files
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"foo\" \"b\" {}\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "provider.tf"), []byte("provider \"foo\" {}\n"), 0600))
+
+	diff, err := configDiff(dir, backupDir)
+	require.NoError(t, err)
+	require.Equal(t, `--- a/main.tf
++++ b/main.tf
+@@ -1 +1 @@
+-resource "foo" "a" {}
++resource "foo" "b" {}
+--- a/provider.tf
++++ b/provider.tf
+@@ -1 +1 @@
++provider "foo" {}
+--- a/removed.tf.json
++++ b/removed.tf.json
+@@ -1 +1 @@
+-{}
+`, diff)
+
+	// Declining the changes restores the backed up config files
+	var out bytes.Buffer
+	require.NoError(t, reviewConfigChanges(&out, strings.NewReader("n\n"), dir, backupDir, true))
+	require.Contains(t, out.String(), diff)
+	names, err = configFileNames(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"main.tf", "removed.tf.json"}, names)
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	require.Equal(t, "resource \"foo\" \"a\" {}\n", string(b))
+	require.NoDirExists(t, backupDir)
+}
+
+func TestBackupConfigFilesNothingToBackup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte("{}"), 0600))
+	backupDir, err := backupConfigFiles(dir)
+	require.NoError(t, err)
+	require.Empty(t, backupDir)
+}
+
+func TestReviewConfigChangesAccepted(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"foo\" \"a\" {}\n"), 0600))
+	backupDir, err := backupConfigFiles(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"foo\" \"b\" {}\n"), 0600))
+
+	var out bytes.Buffer
+	require.NoError(t, reviewConfigChanges(&out, strings.NewReader("y\n"), dir, backupDir, true))
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	require.Equal(t, "resource \"foo\" \"b\" {}\n", string(b))
+	require.FileExists(t, filepath.Join(backupDir, "main.tf"))
+}
+
+func TestBackupOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("foo"), 0644))
+	backupDir, err := backupConfigFiles(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, backupOnFailure(nil, backupDir))
+	require.EqualError(t, backupOnFailure(errors.New("failed"), ""), "failed")
+	require.EqualError(t, backupOnFailure(errors.New("failed"), backupDir), "failed\n\nThe existing config files are backed up to "+backupDir)
+
+	// The backup directory is removed once restored
+	require.NoError(t, restoreConfigFiles(dir, backupDir))
+	require.EqualError(t, backupOnFailure(errors.New("failed"), backupDir), "failed")
+}
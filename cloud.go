@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// cloudEnvironment returns the canonical environment name (as is accepted by the providers) and the cloud configuration of the specified environment.
+// Apart from the canonical names, the cloud names used by the Azure CLI (e.g. "AzureUSGovernment") are also accepted. The match is case insensitive.
+func cloudEnvironment(name string) (string, cloud.Configuration, error) {
+	switch strings.ToLower(name) {
+	case "public", "azurecloud", "azurepubliccloud":
+		return "public", cloud.AzurePublic, nil
+	case "usgovernment", "azureusgovernment", "azureusgovernmentcloud":
+		return "usgovernment", cloud.AzureGovernment, nil
+	case "china", "azurechinacloud":
+		return "china", cloud.AzureChina, nil
+	default:
+		return "", cloud.Configuration{}, fmt.Errorf("unknown environment specified: %q", name)
+	}
+}
+
+// cloudMetadata is an entry of the response of the metadata endpoint of the Azure Resource Manager.
+type cloudMetadata struct {
+	Name            string `json:"name"`
+	ResourceManager string `json:"resourceManager"`
+	Authentication  struct {
+		LoginEndpoint string   `json:"loginEndpoint"`
+		Audiences     []string `json:"audiences"`
+	} `json:"authentication"`
+}
+
+// cloudEnvironmentFromMetadataHost returns the environment name and the cloud configuration of a custom cloud (e.g. Azure Stack Hub),
+// which are retrieved from the metadata endpoint of the specified metadata host (e.g. "management.local.azurestack.external").
+// The environment is selected by the name among the clouds returned by the metadata endpoint, unless there is only one.
+func cloudEnvironmentFromMetadataHost(ctx context.Context, host, name string) (string, cloud.Configuration, error) {
+	url := fmt.Sprintf("https://%s/metadata/endpoints?api-version=2022-09-01", host)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return "", cloud.Configuration{}, fmt.Errorf("building request for %s: %v", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", cloud.Configuration{}, fmt.Errorf("requesting %s: %v", url, err)
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", cloud.Configuration{}, fmt.Errorf("reading the response of %s: %v", url, err)
+	}
+	if c := resp.StatusCode; c < 200 || c > 299 {
+		return "", cloud.Configuration{}, fmt.Errorf("requesting %s: received HTTP status %d with response: %s", url, resp.StatusCode, body)
+	}
+
+	var clouds []cloudMetadata
+	if err := json.Unmarshal(body, &clouds); err != nil {
+		return "", cloud.Configuration{}, fmt.Errorf("unmarshalling the response of %s: %v", url, err)
+	}
+
+	var md *cloudMetadata
+	var names []string
+	for i := range clouds {
+		if strings.EqualFold(clouds[i].Name, name) {
+			md = &clouds[i]
+			break
+		}
+		names = append(names, clouds[i].Name)
+	}
+	if md == nil {
+		if len(clouds) != 1 {
+			return "", cloud.Configuration{}, fmt.Errorf("no environment named %q found from the metadata host %s, available environments: %s", name, host, strings.Join(names, ", "))
+		}
+		md = &clouds[0]
+	}
+
+	if md.ResourceManager == "" || md.Authentication.LoginEndpoint == "" || len(md.Authentication.Audiences) == 0 {
+		return "", cloud.Configuration{}, fmt.Errorf("the environment %q retrieved from the metadata host %s misses the resource manager endpoint or the authentication settings", md.Name, host)
+	}
+
+	return md.Name, cloud.Configuration{
+		ActiveDirectoryAuthorityHost: md.Authentication.LoginEndpoint,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Audience: md.Authentication.Audiences[0],
+				Endpoint: md.ResourceManager,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudEnvironment(t *testing.T) {
+	cases := []struct {
+		name     string
+		env      string
+		cloudCfg cloud.Configuration
+		err      bool
+	}{
+		{
+			name:     "public",
+			env:      "public",
+			cloudCfg: cloud.AzurePublic,
+		},
+		{
+			name:     "AzureCloud",
+			env:      "public",
+			cloudCfg: cloud.AzurePublic,
+		},
+		{
+			name:     "USGovernment",
+			env:      "usgovernment",
+			cloudCfg: cloud.AzureGovernment,
+		},
+		{
+			name:     "AzureUSGovernment",
+			env:      "usgovernment",
+			cloudCfg: cloud.AzureGovernment,
+		},
+		{
+			name:     "AzureChinaCloud",
+			env:      "china",
+			cloudCfg: cloud.AzureChina,
+		},
+		{
+			name: "german",
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		env, cloudCfg, err := cloudEnvironment(c.name)
+		if c.err {
+			require.Error(t, err, c.name)
+			continue
+		}
+		require.NoError(t, err, c.name)
+		require.Equal(t, c.env, env, c.name)
+		require.Equal(t, c.cloudCfg, cloudCfg, c.name)
+	}
+}
+
+func TestCloudEnvironmentFromMetadataHost(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/metadata/endpoints", r.URL.Path)
+		w.Write([]byte(`[
+  {
+    "name": "AzureStackCloud",
+    "resourceManager": "https://management.local.azurestack.external/",
+    "authentication": {
+      "loginEndpoint": "https://adfs.local.azurestack.external/adfs/",
+      "audiences": ["https://management.adfs.azurestack.local/00000000-0000-0000-0000-000000000000"]
+    }
+  }
+]`))
+	}))
+	defer srv.Close()
+
+	defaultClient := http.DefaultClient
+	http.DefaultClient = srv.Client()
+	defer func() { http.DefaultClient = defaultClient }()
+
+	host := strings.TrimPrefix(srv.URL, "https://")
+
+	// The only environment is selected regardless of the name
+	env, cloudCfg, err := cloudEnvironmentFromMetadataHost(context.Background(), host, "public")
+	require.NoError(t, err)
+	require.Equal(t, "AzureStackCloud", env)
+	require.Equal(t, cloud.Configuration{
+		ActiveDirectoryAuthorityHost: "https://adfs.local.azurestack.external/adfs/",
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Audience: "https://management.adfs.azurestack.local/00000000-0000-0000-0000-000000000000",
+				Endpoint: "https://management.local.azurestack.external/",
+			},
+		},
+	}, cloudCfg)
+}
@@ -6,20 +6,53 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/Azure/aztfexport/internal"
+	"github.com/Azure/aztfexport/internal/ui/common"
 	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/gofrs/uuid"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v2"
 )
 
+// isTerminal tells whether the file is a terminal, which is replaceable in tests.
+var isTerminal = func(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
 func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 	return func(ctx *cli.Context) error {
+		// The interactive mode (and the spinner of the non-interactive mode) renders the control sequences, which are garbage when the stdout is not a terminal (e.g. in CI or piped).
+		if !isTerminal(os.Stdout) {
+			if !fset.flagNonInteractive {
+				fmt.Fprintln(os.Stderr, "Warning: the stdout is not a terminal, switching to the non-interactive mode (specify `--non-interactive` to suppress this warning)")
+				fset.flagNonInteractive = true
+			}
+			fset.flagPlainUI = true
+		}
+
 		// Common flags check
 		if fset.flagAppend {
 			if fset.flagOverwrite {
 				return fmt.Errorf("`--append` conflicts with `--overwrite`")
 			}
 		}
+		if fset.flagForce {
+			if fset.flagOverwrite {
+				return fmt.Errorf("`--force` conflicts with `--overwrite`")
+			}
+			if fset.flagAppend {
+				return fmt.Errorf("`--force` conflicts with `--append`")
+			}
+			if fset.flagResume {
+				return fmt.Errorf("`--force` conflicts with `--resume`")
+			}
+			if fset.flagRetryFailed {
+				return fmt.Errorf("`--force` conflicts with `--retry-failed`")
+			}
+		}
 		if !fset.flagNonInteractive {
 			if fset.flagContinue {
 				return fmt.Errorf("`--continue` must be used together with `--non-interactive`")
@@ -27,6 +60,118 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 			if fset.flagGenerateMappingFile {
 				return fmt.Errorf("`--generate-mapping-file` must be used together with `--non-interactive`")
 			}
+			if fset.flagRollbackOnFailure {
+				return fmt.Errorf("`--rollback-on-failure` must be used together with `--non-interactive`")
+			}
+			if fset.flagRetryFailed {
+				return fmt.Errorf("`--retry-failed` must be used together with `--non-interactive`")
+			}
+			if fset.flagDryRun {
+				return fmt.Errorf("`--dry-run` must be used together with `--non-interactive`")
+			}
+			if fset.flagDeadline != 0 {
+				return fmt.Errorf("`--deadline` must be used together with `--non-interactive`")
+			}
+		}
+		if fset.flagPartnerId != "" {
+			if _, err := uuid.FromString(fset.flagPartnerId); err != nil {
+				return fmt.Errorf("invalid value of `--partner-id`: %v", err)
+			}
+		}
+		if fset.flagParallelism < 0 {
+			return fmt.Errorf("`--parallelism` can't be negative")
+		}
+		if fset.flagMaxRetries < 0 {
+			return fmt.Errorf("`--max-retries` can't be negative")
+		}
+		if fset.flagRetryDelay < 0 {
+			return fmt.Errorf("`--retry-delay` can't be negative")
+		}
+		if fset.flagMaxRetryDelay < 0 {
+			return fmt.Errorf("`--max-retry-delay` can't be negative")
+		}
+		if fset.flagImportTimeout < 0 {
+			return fmt.Errorf("`--timeout` can't be negative")
+		}
+		if fset.flagDeadline < 0 {
+			return fmt.Errorf("`--deadline` can't be negative")
+		}
+		if fset.flagTheme != "" && !slices.Contains(common.Themes, fset.flagTheme) {
+			return fmt.Errorf("invalid value of `--theme`, possible values are %s", strings.Join(common.Themes, ", "))
+		}
+		if fset.flagDryRunFormat != "" {
+			if !fset.flagDryRun {
+				return fmt.Errorf("`--dry-run-format` must be used together with `--dry-run`")
+			}
+			if fset.flagDryRunFormat != internal.DryRunFormatText && fset.flagDryRunFormat != internal.DryRunFormatJSON {
+				return fmt.Errorf("invalid value of `--dry-run-format`")
+			}
+		}
+		if fset.flagDryRun {
+			if fset.flagGenerateMappingFile {
+				return fmt.Errorf("`--dry-run` conflicts with `--generate-mapping-file`")
+			}
+			if fset.flagResume {
+				return fmt.Errorf("`--dry-run` conflicts with `--resume`")
+			}
+			if fset.flagRetryFailed {
+				return fmt.Errorf("`--dry-run` conflicts with `--retry-failed`")
+			}
+		}
+		if fset.flagResume {
+			if fset.flagGenerateMappingFile {
+				return fmt.Errorf("`--resume` conflicts with `--generate-mapping-file`")
+			}
+			if fset.flagTFClientPluginPath != "" {
+				return fmt.Errorf("`--resume` conflicts with `--tfclient-plugin-path`")
+			}
+			if fset.flagRetryFailed {
+				return fmt.Errorf("`--resume` conflicts with `--retry-failed`")
+			}
+		}
+		if fset.flagRetryFailed {
+			if fset.flagGenerateMappingFile {
+				return fmt.Errorf("`--retry-failed` conflicts with `--generate-mapping-file`")
+			}
+			if fset.flagTFClientPluginPath != "" {
+				return fmt.Errorf("`--retry-failed` conflicts with `--tfclient-plugin-path`")
+			}
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--retry-failed` conflicts with `--hcl-only`")
+			}
+		}
+		if fset.flagDelta {
+			if fset.flagOverwrite {
+				return fmt.Errorf("`--delta` conflicts with `--overwrite`")
+			}
+			if fset.flagResume {
+				return fmt.Errorf("`--delta` conflicts with `--resume`")
+			}
+			if fset.flagRetryFailed {
+				return fmt.Errorf("`--delta` conflicts with `--retry-failed`")
+			}
+		}
+		if fset.flagJSONSyntax && fset.flagAppend {
+			return fmt.Errorf("`--json-syntax` conflicts with `--append`")
+		}
+		switch fset.flagCDKTFLanguage {
+		case "", "typescript", "python", "csharp", "java", "go":
+		default:
+			return fmt.Errorf("invalid value of `--cdktf-language`")
+		}
+		if fset.flagStateOnly {
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--state-only` conflicts with `--hcl-only`")
+			}
+			if fset.flagAsModule {
+				return fmt.Errorf("`--state-only` conflicts with `--as-module`")
+			}
+			if fset.flagTerragrunt {
+				return fmt.Errorf("`--state-only` conflicts with `--terragrunt`")
+			}
+			if fset.flagGenerateImportBlock {
+				return fmt.Errorf("`--state-only` conflicts with `--generate-import-block`")
+			}
 		}
 		if fset.flagHCLOnly {
 			if fset.flagAppend {
@@ -35,20 +180,106 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 			if fset.flagModulePath != "" {
 				return fmt.Errorf("`--module-path` conflicts with `--hcl-only`")
 			}
+			if fset.flagVerify {
+				return fmt.Errorf("`--verify` conflicts with `--hcl-only`")
+			}
+			if fset.flagIncrementalPush {
+				return fmt.Errorf("`--incremental-push` conflicts with `--hcl-only`")
+			}
+			if fset.flagStateFile != "" {
+				return fmt.Errorf("`--state` conflicts with `--hcl-only`")
+			}
+		}
+		switch fset.flagOutputLayout {
+		case "", config.OutputLayoutSingle, config.OutputLayoutResource, config.OutputLayoutType, config.OutputLayoutService:
+		default:
+			return fmt.Errorf("invalid value of `--output-layout`")
+		}
+		if fset.flagTerragrunt {
+			if fset.flagAsModule {
+				return fmt.Errorf("`--terragrunt` conflicts with `--as-module`")
+			}
+			if fset.flagAppend {
+				return fmt.Errorf("`--terragrunt` conflicts with `--append`")
+			}
+		}
+		if fset.flagEnvironment != "" {
+			if fset.flagHCLOnly {
+				return fmt.Errorf("`--environment` conflicts with `--hcl-only`")
+			}
+			if fset.flagAsModule {
+				return fmt.Errorf("`--environment` conflicts with `--as-module`")
+			}
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--environment` conflicts with `--module-path`")
+			}
+			if fset.flagTerragrunt {
+				return fmt.Errorf("`--environment` conflicts with `--terragrunt`")
+			}
+			if fset.flagExtractSensitive {
+				return fmt.Errorf("`--environment` conflicts with `--extract-sensitive`")
+			}
+			if fset.flagCommonTags {
+				return fmt.Errorf("`--environment` conflicts with `--common-tags`")
+			}
+			if fset.flagGenerateImportBlock {
+				return fmt.Errorf("`--environment` conflicts with `--generate-import-block`")
+			}
+		}
+		if fset.flagImportViaPlan {
+			if fset.flagAsModule {
+				return fmt.Errorf("`--import-via-plan` conflicts with `--as-module`")
+			}
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--import-via-plan` conflicts with `--module-path`")
+			}
+			if fset.flagTFClientPluginPath != "" {
+				return fmt.Errorf("`--import-via-plan` conflicts with `--tfclient-plugin-path`")
+			}
 		}
 		if fset.flagModulePath != "" {
 			if !fset.flagAppend {
 				return fmt.Errorf("`--module-path` must be used together with `--append`")
 			}
+			if fset.flagAsModule {
+				return fmt.Errorf("`--module-path` conflicts with `--as-module`")
+			}
+		}
+		if fset.flagTFOffline && fset.flagTFVersion == "" {
+			return fmt.Errorf("`--tf-offline` must be used together with `--tf-version`")
+		}
+		if fset.flagTFBin != "" {
+			if _, err := os.Stat(fset.flagTFBin); err != nil {
+				return fmt.Errorf("invalid value of `--tf-bin`: %v", err)
+			}
 		}
 		if fset.flagDevProvider {
 			if fset.flagProviderVersion != "" {
 				return fmt.Errorf("`--dev-provider` conflicts with `--provider-version`")
 			}
+			if fset.flagPinVersions {
+				return fmt.Errorf("`--dev-provider` conflicts with `--pin-versions`")
+			}
+			if fset.flagProviderMirror != "" {
+				return fmt.Errorf("`--dev-provider` conflicts with `--provider-mirror`")
+			}
+		}
+		if fset.flagMetadataHost != "" {
+			if fset.flagProviderName == "azapi" {
+				return fmt.Errorf("`--metadata-host` only works for the azurerm provider")
+			}
 		}
-		if fset.hflagTFClientPluginPath != "" {
-			if !fset.flagHCLOnly {
-				return fmt.Errorf("`--tfclient-plugin-path` must be used together with `--hcl-only`")
+		if len(fset.flagTypeResolverFiles.Value()) != 0 || fset.flagTypeResolverCommand != "" {
+			if fset.flagProviderName == "azapi" {
+				return fmt.Errorf("`--type-resolver-file` and `--type-resolver-command` only work for the azurerm provider")
+			}
+		}
+		if fset.flagAzAPIFallback {
+			if fset.flagProviderName == "azapi" {
+				return fmt.Errorf("`--azapi-fallback` only works for the azurerm provider")
+			}
+			if fset.flagTFClientPluginPath != "" {
+				return fmt.Errorf("`--azapi-fallback` conflicts with `--tfclient-plugin-path`")
 			}
 		}
 
@@ -104,6 +335,15 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 			return err
 		}
 
+		if fset.flagWorkspacePerSubscription {
+			if fset.flagAppend || fset.flagResume || fset.flagRetryFailed {
+				return fmt.Errorf("`--workspace-per-subscription` conflicts with `--append`, `--resume` and `--retry-failed`")
+			}
+			if fset.flagModulePath != "" {
+				return fmt.Errorf("`--workspace-per-subscription` conflicts with `--module-path`")
+			}
+		}
+
 		// Mode specific flags check
 		switch mode {
 		case ModeResource:
@@ -115,6 +355,14 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 					return fmt.Errorf("`--name` can't be specified for multi-resource mode")
 				}
 			}
+			if fset.flagRecursive {
+				if fset.flagResType != "" {
+					return fmt.Errorf("`--type` can't be specified together with `--recursive`")
+				}
+				if fset.flagResName != "" {
+					return fmt.Errorf("`--name` can't be specified together with `--recursive`")
+				}
+			}
 		case ModeQuery:
 			if fset.flagARGAuthorizationScopeFilter != "" {
 				if !slices.Contains(armresourcegraph.PossibleAuthorizationScopeFilterValues(), armresourcegraph.AuthorizationScopeFilter(fset.flagARGAuthorizationScopeFilter)) {
@@ -137,22 +385,31 @@ func commandBeforeFunc(fset *FlagSet, mode Mode) func(ctx *cli.Context) error {
 		var tfblock *utils.TerraformBlockDetail
 		if !empty {
 			switch {
+			case fset.flagWorkspacePerSubscription && !fset.flagDryRun:
+				return fmt.Errorf("`--workspace-per-subscription` requires an empty output directory %q", fset.flagOutputDir)
 			case fset.flagOverwrite:
-			case fset.flagAppend:
+			case fset.flagDryRun:
+				// Nothing will be written to the output directory in dry-run mode.
+			case fset.flagForce:
+				fset.backupDir, err = backupConfigFiles(fset.flagOutputDir)
+				if err != nil {
+					return fmt.Errorf("backing up the existing config files: %v", err)
+				}
+			case fset.flagAppend, fset.flagResume, fset.flagRetryFailed:
 				tfblock, err = utils.InspecTerraformBlock(fset.flagOutputDir)
 				if err != nil {
 					return fmt.Errorf("determine the backend type from the existing files: %v", err)
 				}
 			default:
 				if fset.flagNonInteractive {
-					return fmt.Errorf("the output directory %q is not empty", fset.flagOutputDir)
+					return fmt.Errorf("the output directory %q is not empty (use `--force` to replace the existing config files with backups)", fset.flagOutputDir)
 				}
 
 				// Interactive mode
 				fmt.Printf(`
 The output directory is not empty. Please choose one of actions below:
 
-* Press "Y" to proceed that will likely pollute the existing files and cause errors
+* Press "Y" to replace the existing config files, which are backed up, you'll review the changes and confirm at the end
 * Press "N" to append new files and add to the existing state instead
 * Press other keys to quit
 
@@ -162,6 +419,11 @@ The output directory is not empty. Please choose one of actions below:
 				fmt.Scanf("%s", &ans)
 				switch strings.ToLower(ans) {
 				case "y":
+					fset.backupDir, err = backupConfigFiles(fset.flagOutputDir)
+					if err != nil {
+						return fmt.Errorf("backing up the existing config files: %v", err)
+					}
+					fset.confirmConfigChanges = true
 				case "n":
 					if fset.flagHCLOnly {
 						return fmt.Errorf("`--hcl-only` can only run within an empty directory. Use `-o` to specify an empty directory.")
@@ -177,6 +439,39 @@ The output directory is not empty. Please choose one of actions below:
 			}
 		}
 
+		// The cloud workspace flags imply the cloud block
+		if fset.flagCloudHostname != "" || fset.flagCloudOrganization != "" || fset.flagCloudWorkspace != "" {
+			if fset.flagCloudOrganization == "" || fset.flagCloudWorkspace == "" {
+				return fmt.Errorf("`--cloud-organization` and `--cloud-workspace` must be specified together")
+			}
+			if fset.flagBackendType == "" {
+				fset.flagBackendType = "cloud"
+			}
+			if fset.flagBackendType != "cloud" {
+				return fmt.Errorf("the cloud workspace flags (e.g. `--cloud-workspace`) only work for the cloud backend type")
+			}
+			// The plan runs remotely in the cloud workspace, which can't be saved for the verification.
+			if fset.flagVerify {
+				return fmt.Errorf("`--verify` conflicts with the cloud workspace flags (e.g. `--cloud-workspace`)")
+			}
+			if fset.flagEnvironment != "" {
+				return fmt.Errorf("`--environment` conflicts with the cloud workspace flags (e.g. `--cloud-workspace`)")
+			}
+		}
+		if fset.flagCloudUploadConfig && fset.flagCloudOrganization == "" {
+			return fmt.Errorf("`--cloud-upload-config` must be used together with `--cloud-organization` and `--cloud-workspace`")
+		}
+
+		// The azurerm backend block flags imply the azurerm backend
+		if fset.backendBlock() != nil {
+			if fset.flagBackendType == "" {
+				fset.flagBackendType = "azurerm"
+			}
+			if fset.flagBackendType != "azurerm" {
+				return fmt.Errorf("the backend block flags (e.g. `--backend-storage-account-name`) only work for the azurerm backend")
+			}
+		}
+
 		// Deterimine the real backend type to use
 		var existingBackendType string
 		if tfblock != nil {
@@ -206,11 +501,23 @@ The output directory is not empty. Please choose one of actions below:
 			if fset.flagBackendType == "local" {
 				return fmt.Errorf("`--backend-config` only works for non-local backend")
 			}
+			if fset.flagBackendType == "cloud" {
+				return fmt.Errorf("`--backend-config` doesn't work for the cloud backend type")
+			}
+		}
+		if fset.flagBackendType == "cloud" && existingBackendType == "" && fset.flagCloudOrganization == "" {
+			return fmt.Errorf("`--backend-type=cloud` must be used together with `--cloud-organization` and `--cloud-workspace`")
+		}
+		if fset.backendBlock() != nil && existingBackendType != "" {
+			return fmt.Errorf("the backend block flags (e.g. `--backend-storage-account-name`) should not be specified when appending to a workspace that has terraform block already defined")
 		}
 		if fset.flagBackendType != "local" {
 			if fset.flagHCLOnly {
 				return fmt.Errorf("`--hcl-only` only works for local backend")
 			}
+			if fset.flagWorkspacePerSubscription {
+				return fmt.Errorf("`--workspace-per-subscription` only works for local backend")
+			}
 		}
 
 		// Determine any existing provider version constraint if not using a dev provider and the provider version not specified.
@@ -228,10 +535,10 @@ The output directory is not empty. Please choose one of actions below:
 		// - Command line option
 		// - Env variable: AZTFEXPORT_SUBSCRIPTION_ID
 		// - Env variable: ARM_SUBSCRIPTION_ID
-		// - Output of azure cli, the current active subscription
+		// - Output of azure cli, the current active subscription (or the subscription of the tenant specified by `--tenant-id`)
 		if fset.flagSubscriptionId == "" {
 			var err error
-			fset.flagSubscriptionId, err = subscriptionIdFromCLI()
+			fset.flagSubscriptionId, err = subscriptionIdFromCLI(fset.flagTenantId)
 			if err != nil {
 				return fmt.Errorf("retrieving subscription id from CLI: %v", err)
 			}
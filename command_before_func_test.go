@@ -4,12 +4,18 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli/v2"
 )
 
 func TestCommondBeforeFunc(t *testing.T) {
+	nonTTY := false
+	origIsTerminal := isTerminal
+	isTerminal = func(*os.File) bool { return !nonTTY }
+	t.Cleanup(func() { isTerminal = origIsTerminal })
+
 	dirGenEmpty := func(t *testing.T) string {
 		return t.TempDir()
 	}
@@ -26,6 +32,7 @@ func TestCommondBeforeFunc(t *testing.T) {
 	cases := []struct {
 		name      string
 		fset      FlagSet
+		nonTTY    bool
 		dirGen    func(t *testing.T) string
 		err       string
 		postCheck func(t *testing.T, flagset FlagSet)
@@ -79,6 +86,282 @@ func TestCommondBeforeFunc(t *testing.T) {
 				flagNonInteractive:      true,
 			},
 		},
+		{
+			name: "it switches to the non-interactive mode with plain UI when the stdout is not a terminal",
+			fset: FlagSet{
+				flagContinue: true,
+			},
+			nonTTY: true,
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.True(t, flagset.flagNonInteractive)
+				require.True(t, flagset.flagPlainUI)
+			},
+		},
+		{
+			name:   "it refuses to prompt for the non empty dir when the stdout is not a terminal",
+			fset:   FlagSet{},
+			nonTTY: true,
+			dirGen: dirGenWithTFBlock(`terraform {
+  backend "local" {}
+}`),
+			err: "is not empty",
+		},
+		{
+			name: "--resume works in interactive mode for non empty dir",
+			fset: FlagSet{
+				flagResume: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	backend azurerm {}
+}`),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "azurerm", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "--resume conflicts with --generate-mapping-file",
+			fset: FlagSet{
+				flagResume:              true,
+				flagGenerateMappingFile: true,
+				flagNonInteractive:      true,
+			},
+			err: "`--resume` conflicts with `--generate-mapping-file`",
+		},
+		{
+			name: "--resume with --non-interactive works for non empty dir",
+			fset: FlagSet{
+				flagResume:         true,
+				flagNonInteractive: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	backend azurerm {}
+}`),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "azurerm", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "--import-via-plan conflicts with --as-module",
+			fset: FlagSet{
+				flagImportViaPlan: true,
+				flagAsModule:      true,
+			},
+			err: "`--import-via-plan` conflicts with `--as-module`",
+		},
+		{
+			name: "--delta conflicts with --retry-failed",
+			fset: FlagSet{
+				flagNonInteractive: true,
+				flagDelta:          true,
+				flagRetryFailed:    true,
+			},
+			err: "`--delta` conflicts with `--retry-failed`",
+		},
+		{
+			name: "negative --timeout",
+			fset: FlagSet{
+				flagImportTimeout: -time.Second,
+			},
+			err: "`--timeout` can't be negative",
+		},
+		{
+			name: "--deadline without --non-interactive",
+			fset: FlagSet{
+				flagDeadline: time.Hour,
+			},
+			err: "`--deadline` must be used together with `--non-interactive`",
+		},
+		{
+			name: "negative --parallelism",
+			fset: FlagSet{
+				flagParallelism: -1,
+			},
+			err: "`--parallelism` can't be negative",
+		},
+		{
+			name: "--dev-provider conflicts with --provider-mirror",
+			fset: FlagSet{
+				flagDevProvider:    true,
+				flagProviderMirror: "/mirror",
+			},
+			err: "`--dev-provider` conflicts with `--provider-mirror`",
+		},
+		{
+			name: "--tf-offline without --tf-version",
+			fset: FlagSet{
+				flagTFOffline: true,
+			},
+			err: "`--tf-offline` must be used together with `--tf-version`",
+		},
+		{
+			name: "--tf-bin doesn't exist",
+			fset: FlagSet{
+				flagTFBin: "/not/exist/terraform",
+			},
+			err: "invalid value of `--tf-bin`",
+		},
+		{
+			name: "--retry-failed shouldn't be used in interactive mode",
+			fset: FlagSet{
+				flagRetryFailed: true,
+			},
+			err: "`--retry-failed` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--retry-failed conflicts with --resume",
+			fset: FlagSet{
+				flagRetryFailed:    true,
+				flagResume:         true,
+				flagNonInteractive: true,
+			},
+			err: "`--resume` conflicts with `--retry-failed`",
+		},
+		{
+			name: "--retry-failed conflicts with --hcl-only",
+			fset: FlagSet{
+				flagRetryFailed:    true,
+				flagHCLOnly:        true,
+				flagNonInteractive: true,
+			},
+			err: "`--retry-failed` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--retry-failed with --non-interactive works for non empty dir",
+			fset: FlagSet{
+				flagRetryFailed:    true,
+				flagNonInteractive: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	backend azurerm {}
+}`),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "azurerm", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "--dry-run shouldn't be used in interactive mode",
+			fset: FlagSet{
+				flagDryRun: true,
+			},
+			err: "`--dry-run` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--dry-run conflicts with --resume",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagResume:         true,
+				flagNonInteractive: true,
+			},
+			err: "`--dry-run` conflicts with `--resume`",
+		},
+		{
+			name: "--dry-run with --non-interactive works for non empty dir",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagNonInteractive: true,
+			},
+			dirGen: dirGenWithTFBlock("foo {}"),
+		},
+		{
+			name: "--partner-id must be a GUID",
+			fset: FlagSet{
+				flagPartnerId: "foo",
+			},
+			err: "invalid value of `--partner-id`",
+		},
+		{
+			name: "--max-retries can't be negative",
+			fset: FlagSet{
+				flagMaxRetries: -1,
+			},
+			err: "`--max-retries` can't be negative",
+		},
+		{
+			name: "--retry-delay can't be negative",
+			fset: FlagSet{
+				flagRetryDelay: -time.Second,
+			},
+			err: "`--retry-delay` can't be negative",
+		},
+		{
+			name: "--theme with invalid value",
+			fset: FlagSet{
+				flagTheme: "solarized",
+			},
+			err: "invalid value of `--theme`",
+		},
+		{
+			name: "--theme with high-contrast works",
+			fset: FlagSet{
+				flagTheme:   "high-contrast",
+				flagNoColor: true,
+			},
+		},
+		{
+			name: "--dry-run-format should be used together with --dry-run",
+			fset: FlagSet{
+				flagDryRunFormat:   "json",
+				flagNonInteractive: true,
+			},
+			err: "`--dry-run-format` must be used together with `--dry-run`",
+		},
+		{
+			name: "--dry-run-format with invalid value",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagDryRunFormat:   "yaml",
+				flagNonInteractive: true,
+			},
+			err: "invalid value of `--dry-run-format`",
+		},
+		{
+			name: "--dry-run-format with --dry-run works",
+			fset: FlagSet{
+				flagDryRun:         true,
+				flagDryRunFormat:   "json",
+				flagNonInteractive: true,
+			},
+		},
+		{
+			name: "--metadata-host doesn't work for azapi",
+			fset: FlagSet{
+				flagMetadataHost: "management.local.azurestack.external",
+				flagProviderName: "azapi",
+			},
+			err: "`--metadata-host` only works for the azurerm provider",
+		},
+		{
+			name: "--azapi-fallback doesn't work for azapi",
+			fset: FlagSet{
+				flagAzAPIFallback: true,
+				flagProviderName:  "azapi",
+			},
+			err: "`--azapi-fallback` only works for the azurerm provider",
+		},
+		{
+			name: "--azapi-fallback with --tfclient-plugin-path",
+			fset: FlagSet{
+				flagAzAPIFallback:      true,
+				flagHCLOnly:            true,
+				flagTFClientPluginPath: "/path/to/plugin",
+			},
+			err: "`--azapi-fallback` conflicts with `--tfclient-plugin-path`",
+		},
+		{
+			name: "invalid --output-layout",
+			fset: FlagSet{
+				flagOutputLayout: "foo",
+			},
+			err: "invalid value of `--output-layout`",
+		},
+		{
+			name: "--type-resolver-command doesn't work for azapi",
+			fset: FlagSet{
+				flagTypeResolverCommand: "resolver",
+				flagProviderName:        "azapi",
+			},
+			err: "only work for the azurerm provider",
+		},
 		{
 			name: "--hcl-only shouldn't be used with --append since it doesn't make sense to generate config/state to an existing workspace for hcl only",
 			fset: FlagSet{
@@ -87,6 +370,59 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			err: "`--append` conflicts with `--hcl-only`",
 		},
+		{
+			name: "--json-syntax shouldn't be used with --append since the existing config would be converted",
+			fset: FlagSet{
+				flagJSONSyntax: true,
+				flagAppend:     true,
+			},
+			err: "`--json-syntax` conflicts with `--append`",
+		},
+		{
+			name: "invalid --cdktf-language",
+			fset: FlagSet{
+				flagCDKTFLanguage: "ruby",
+			},
+			err: "invalid value of `--cdktf-language`",
+		},
+		{
+			name: "--hcl-only shouldn't be used with --verify since there is no state to plan against",
+			fset: FlagSet{
+				flagHCLOnly: true,
+				flagVerify:  true,
+			},
+			err: "`--verify` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--rollback-on-failure must be used together with --non-interactive",
+			fset: FlagSet{
+				flagRollbackOnFailure: true,
+			},
+			err: "`--rollback-on-failure` must be used together with `--non-interactive`",
+		},
+		{
+			name: "--state-only conflicts with --hcl-only",
+			fset: FlagSet{
+				flagStateOnly: true,
+				flagHCLOnly:   true,
+			},
+			err: "`--state-only` conflicts with `--hcl-only`",
+		},
+		{
+			name: "--state-only works with --append",
+			fset: FlagSet{
+				flagStateOnly: true,
+				flagAppend:    true,
+			},
+		},
+		{
+			name: "--hcl-only shouldn't be used with --incremental-push since the state is not kept",
+			fset: FlagSet{
+				flagHCLOnly:         true,
+				flagIncrementalPush: true,
+			},
+			err: "`--incremental-push` conflicts with `--hcl-only`",
+		},
 		{
 			name: "--hcl-only works alone",
 			fset: FlagSet{
@@ -115,6 +451,60 @@ func TestCommondBeforeFunc(t *testing.T) {
 				flagAppend:     true,
 			},
 		},
+		{
+			name: "--module-path conflicts with --as-module",
+			fset: FlagSet{
+				flagModulePath: "foo",
+				flagAppend:     true,
+				flagAsModule:   true,
+			},
+			err: "`--module-path` conflicts with `--as-module`",
+		},
+		{
+			name: "--terragrunt conflicts with --as-module",
+			fset: FlagSet{
+				flagTerragrunt: true,
+				flagAsModule:   true,
+			},
+			err: "`--terragrunt` conflicts with `--as-module`",
+		},
+		{
+			name: "--terragrunt conflicts with --append",
+			fset: FlagSet{
+				flagTerragrunt: true,
+				flagAppend:     true,
+			},
+			err: "`--terragrunt` conflicts with `--append`",
+		},
+		{
+			name: "--environment conflicts with --as-module",
+			fset: FlagSet{
+				flagEnvironment: "prod",
+				flagAsModule:    true,
+			},
+			err: "`--environment` conflicts with `--as-module`",
+		},
+		{
+			name: "--environment conflicts with --generate-import-block",
+			fset: FlagSet{
+				flagEnvironment:         "prod",
+				flagGenerateImportBlock: true,
+			},
+			err: "`--environment` conflicts with `--generate-import-block`",
+		},
+		{
+			name: "--environment works with --append",
+			fset: FlagSet{
+				flagEnvironment: "prod",
+				flagAppend:      true,
+			},
+		},
+		{
+			name: "--as-module works",
+			fset: FlagSet{
+				flagAsModule: true,
+			},
+		},
 		{
 			name: "--dev-provider conflicts with --provider-version",
 			fset: FlagSet{
@@ -123,6 +513,14 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			err: "`--dev-provider` conflicts with `--provider-version`",
 		},
+		{
+			name: "--dev-provider with --pin-versions",
+			fset: FlagSet{
+				flagDevProvider: true,
+				flagPinVersions: true,
+			},
+			err: "`--dev-provider` conflicts with `--pin-versions`",
+		},
 		{
 			name: "non empty dir but overwrite",
 			fset: FlagSet{
@@ -130,6 +528,35 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			dirGen: dirGenWithTFBlock("foo {}"),
 		},
+		{
+			name: "non empty dir but force",
+			fset: FlagSet{
+				flagForce: true,
+			},
+			dirGen: dirGenWithTFBlock("foo {}"),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.NotEmpty(t, flagset.backupDir)
+				require.FileExists(t, filepath.Join(flagset.backupDir, "terraform.tf"))
+				require.NoFileExists(t, filepath.Join(flagset.flagOutputDir, "terraform.tf"))
+				require.False(t, flagset.confirmConfigChanges)
+			},
+		},
+		{
+			name: "--force conflicts with --append",
+			fset: FlagSet{
+				flagForce:  true,
+				flagAppend: true,
+			},
+			err: "`--force` conflicts with `--append`",
+		},
+		{
+			name: "--force conflicts with --overwrite",
+			fset: FlagSet{
+				flagForce:     true,
+				flagOverwrite: true,
+			},
+			err: "`--force` conflicts with `--overwrite`",
+		},
 		{
 			name: "default backend type is local",
 			fset: FlagSet{},
@@ -188,6 +615,67 @@ func TestCommondBeforeFunc(t *testing.T) {
 }`),
 			err: "the backend type defined in existing files (foo) are not the same as is specified in the CLI (azurerm)",
 		},
+		{
+			name: "cloud workspace flags imply the cloud backend type",
+			fset: FlagSet{
+				flagCloudOrganization: "org",
+				flagCloudWorkspace:    "ws",
+			},
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "cloud", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "cloud workspace flags must be specified together",
+			fset: FlagSet{
+				flagCloudOrganization: "org",
+			},
+			err: "`--cloud-organization` and `--cloud-workspace` must be specified together",
+		},
+		{
+			name: "cloud workspace flags conflict with --verify as the plan runs remotely",
+			fset: FlagSet{
+				flagCloudOrganization: "org",
+				flagCloudWorkspace:    "ws",
+				flagVerify:            true,
+			},
+			err: "`--verify` conflicts with the cloud workspace flags (e.g. `--cloud-workspace`)",
+		},
+		{
+			name: "cloud workspace flags only work for the cloud backend type",
+			fset: FlagSet{
+				flagCloudOrganization: "org",
+				flagCloudWorkspace:    "ws",
+				flagBackendType:       "azurerm",
+			},
+			err: "the cloud workspace flags (e.g. `--cloud-workspace`) only work for the cloud backend type",
+		},
+		{
+			name: "--cloud-upload-config requires the cloud workspace flags",
+			fset: FlagSet{
+				flagCloudUploadConfig: true,
+			},
+			err: "`--cloud-upload-config` must be used together with `--cloud-organization` and `--cloud-workspace`",
+		},
+		{
+			name: "append to a dir with the cloud block ends up backend type cloud",
+			fset: FlagSet{
+				flagAppend: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	cloud {}
+}`),
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "cloud", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "--backend-type=cloud requires the cloud workspace flags",
+			fset: FlagSet{
+				flagBackendType: "cloud",
+			},
+			err: "`--backend-type=cloud` must be used together with `--cloud-organization` and `--cloud-workspace`",
+		},
 		{
 			name: "--backend-config shouldn't be used with local backend",
 			fset: FlagSet{
@@ -204,6 +692,34 @@ func TestCommondBeforeFunc(t *testing.T) {
 			dirGen: dirGenWithTFBlock(`terraform {}`),
 			err:    "`--backend-config` should not be specified when appending to a workspace that has terraform block already defined",
 		},
+		{
+			name: "backend block flags imply the azurerm backend",
+			fset: FlagSet{
+				flagBackendSAName: "sa",
+			},
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "azurerm", flagset.flagBackendType)
+			},
+		},
+		{
+			name: "backend block flags only work for the azurerm backend",
+			fset: FlagSet{
+				flagBackendType: "local",
+				flagBackendKey:  "foo.tfstate",
+			},
+			err: "the backend block flags (e.g. `--backend-storage-account-name`) only work for the azurerm backend",
+		},
+		{
+			name: "backend block flags shouldn't be used when appending to a workspace with backend config defined",
+			fset: FlagSet{
+				flagAppend:           true,
+				flagBackendContainer: "tfstate",
+			},
+			dirGen: dirGenWithTFBlock(`terraform {
+	backend azurerm {}
+}`),
+			err: "the backend block flags (e.g. `--backend-storage-account-name`) should not be specified when appending to a workspace that has terraform block already defined",
+		},
 		{
 			name: "--hcl-only can't work for remote backend",
 			fset: FlagSet{
@@ -212,6 +728,40 @@ func TestCommondBeforeFunc(t *testing.T) {
 			},
 			err: "`--hcl-only` only works for local backend",
 		},
+		{
+			name: "--workspace-per-subscription can't work for remote backend",
+			fset: FlagSet{
+				flagBackendType:              "azurerm",
+				flagWorkspacePerSubscription: true,
+			},
+			err: "`--workspace-per-subscription` only works for local backend",
+		},
+		{
+			name: "--workspace-per-subscription conflicts with --append",
+			fset: FlagSet{
+				flagAppend:                   true,
+				flagWorkspacePerSubscription: true,
+			},
+			err: "`--workspace-per-subscription` conflicts with `--append`, `--resume` and `--retry-failed`",
+		},
+		{
+			name: "--workspace-per-subscription requires an empty output directory",
+			fset: FlagSet{
+				flagForce:                    true,
+				flagWorkspacePerSubscription: true,
+			},
+			dirGen: dirGenWithTFBlock(`terraform {}`),
+			err:    "`--workspace-per-subscription` requires an empty output directory",
+		},
+		{
+			name: "--workspace-per-subscription works for local backend",
+			fset: FlagSet{
+				flagWorkspacePerSubscription: true,
+			},
+			postCheck: func(t *testing.T, flagset FlagSet) {
+				require.Equal(t, "local", flagset.flagBackendType)
+			},
+		},
 	}
 
 	for _, tt := range cases {
@@ -220,6 +770,7 @@ func TestCommondBeforeFunc(t *testing.T) {
 				tt.dirGen = dirGenEmpty
 			}
 			tt.fset.flagOutputDir = tt.dirGen(t)
+			nonTTY = tt.nonTTY
 
 			// This is to avoid reading the subscription id from az cli, which is not setup in CI.
 			if tt.fset.flagSubscriptionId == "" {
@@ -105,12 +105,13 @@ func NewDefaultAzureCredential(logger slog.Logger, opt *DefaultAzureCredentialOp
 	} else {
 		logger.Info("Building credential via OIDC")
 		if cred, err := NewOidcCredential(&OidcCredentialOptions{
-			ClientOptions: opt.ClientOptions,
-			TenantID:      opt.AuthConfig.TenantID,
-			ClientID:      opt.AuthConfig.ClientID,
-			RequestToken:  opt.AuthConfig.OIDCTokenRequestToken,
-			RequestUrl:    opt.AuthConfig.OIDCTokenRequestURL,
-			Token:         opt.AuthConfig.OIDCAssertionToken,
+			ClientOptions:       opt.ClientOptions,
+			TenantID:            opt.AuthConfig.TenantID,
+			ClientID:            opt.AuthConfig.ClientID,
+			RequestToken:        opt.AuthConfig.OIDCTokenRequestToken,
+			RequestUrl:          opt.AuthConfig.OIDCTokenRequestURL,
+			Token:               opt.AuthConfig.OIDCAssertionToken,
+			ServiceConnectionID: opt.AuthConfig.OIDCAzureServiceConnectionID,
 		}); err != nil {
 			thisErr := fmt.Errorf("Building credential via OIDC failed: %v", err)
 			logger.Warn(thisErr.Error())
@@ -125,10 +126,14 @@ func NewDefaultAzureCredential(logger slog.Logger, opt *DefaultAzureCredentialOp
 		logger.Info("managed identity credential skipped")
 	} else {
 		logger.Info("Building credential via managed identity")
-		if cred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+		msiOpt := &azidentity.ManagedIdentityCredentialOptions{
 			ClientOptions: opt.ClientOptions,
-			ID:            azidentity.ClientID(opt.AuthConfig.ClientID),
-		}); err != nil {
+		}
+		// The client id is only specified for the user assigned identity. Otherwise, the system assigned identity is used.
+		if opt.AuthConfig.ClientID != "" {
+			msiOpt.ID = azidentity.ClientID(opt.AuthConfig.ClientID)
+		}
+		if cred, err := azidentity.NewManagedIdentityCredential(msiOpt); err != nil {
 			thisErr := fmt.Errorf("Building credential via managed identity failed: %v", err)
 			logger.Warn(thisErr.Error())
 			errors = multierror.Append(errors, thisErr)
@@ -169,3 +174,13 @@ func (c *DefaultAzureCredential) GetToken(ctx context.Context, opts policy.Token
 }
 
 var _ azcore.TokenCredential = (*DefaultAzureCredential)(nil)
+
+// pemToPKCS12 converts the PEM encoded certificate(s) and the private key to a PKCS#12 bundle, which is protected by the password.
+// The first certificate is regarded as the leaf certificate, and the others as the CA certificates.
+func pemToPKCS12(b []byte, password string) ([]byte, error) {
+	certs, key, err := azidentity.ParseCertificates(b, nil)
+	if err != nil {
+		return nil, err
+	}
+	return pkcs12.Modern.Encode(key, certs[0], certs[1:], password)
+}
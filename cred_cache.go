@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/cfgfile"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const (
+	tokenCacheDirName  = "aztfexport"
+	tokenCacheKeyName  = "token_cache.key"
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// CachedCredential wraps a credential, persisting the acquired access tokens in the user cache directory, so that they can be reused across runs.
+// The tokens are encrypted by a key that is stored in the aztfexport config directory, separately from the tokens.
+type CachedCredential struct {
+	cred   azcore.TokenCredential
+	logger slog.Logger
+	// The identity of the wrapped credential, which is part of the cache key.
+	identity string
+	dir      string
+	aead     cipher.AEAD
+	mu       sync.Mutex
+}
+
+type cachedToken struct {
+	Token     string    `json:"token"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+// NewCachedCredential creates a CachedCredential for the credential built from the auth config, whose tokens are cached per tenant, client and the enabled credential types.
+// When the Azure CLI credential is enabled, the account signed in to the Azure CLI is part of the cache key as well, so that switching the account via `az login` doesn't reuse the tokens of the previous one.
+// If that account can't be determined, the credential is returned as is, without caching.
+func NewCachedCredential(logger slog.Logger, cred azcore.TokenCredential, authConfig config.AuthConfig) (azcore.TokenCredential, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving the user's cache directory: %v", err)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving the user's HOME directory: %v", err)
+	}
+	identity := fmt.Sprintf("%s/%s/oidc=%t/msi=%t/cli=%t", authConfig.TenantID, authConfig.ClientID, authConfig.UseOIDC, authConfig.UseManagedIdentity, authConfig.UseAzureCLI)
+	if authConfig.UseAzureCLI {
+		account, err := azureCLIAccount(azureCLIConfigDir(homeDir))
+		if err != nil {
+			logger.Warn("Token cache disabled as the Azure CLI account can't be determined", "error", err)
+			return cred, nil
+		}
+		identity += "/" + account
+	}
+	return newCachedCredential(logger, cred, identity,
+		filepath.Join(cacheDir, tokenCacheDirName, "tokens"),
+		filepath.Join(homeDir, cfgfile.CfgDirName, tokenCacheKeyName),
+	)
+}
+
+// azureCLIConfigDir returns the config directory of the Azure CLI, which honors the AZURE_CONFIG_DIR environment variable.
+func azureCLIConfigDir(homeDir string) string {
+	if dir := os.Getenv("AZURE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir, ".azure")
+}
+
+// azureCLIAccount returns the account signed in to the Azure CLI, in form of "<tenant id>/<user type>/<user name>", based on the default subscription recorded in the azureProfile.json under the Azure CLI config dir.
+func azureCLIAccount(dir string) (string, error) {
+	path := filepath.Join(dir, "azureProfile.json")
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %v", path, err)
+	}
+	// The Azure CLI writes the profile with an UTF-8 BOM.
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	var profile struct {
+		Subscriptions []struct {
+			TenantID  string `json:"tenantId"`
+			IsDefault bool   `json:"isDefault"`
+			User      struct {
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"user"`
+		} `json:"subscriptions"`
+	}
+	if err := json.Unmarshal(b, &profile); err != nil {
+		return "", fmt.Errorf("unmarshalling %s: %v", path, err)
+	}
+	for _, sub := range profile.Subscriptions {
+		if sub.IsDefault {
+			return fmt.Sprintf("%s/%s/%s", sub.TenantID, sub.User.Type, sub.User.Name), nil
+		}
+	}
+	return "", fmt.Errorf("no default subscription found in %s", path)
+}
+
+func newCachedCredential(logger slog.Logger, cred azcore.TokenCredential, identity, dir, keyPath string) (*CachedCredential, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating the token cache directory %s: %v", dir, err)
+	}
+	key, err := loadOrCreateTokenCacheKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("building the token cache cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("building the token cache cipher: %v", err)
+	}
+	return &CachedCredential{
+		cred:     cred,
+		logger:   logger,
+		identity: identity,
+		dir:      dir,
+		aead:     aead,
+	}, nil
+}
+
+func loadOrCreateTokenCacheKey(path string) ([]byte, error) {
+	// #nosec G304
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("invalid token cache key %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading the token cache key %s: %v", path, err)
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generating the token cache key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating the directory of the token cache key: %v", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("writing the token cache key %s: %v", path, err)
+	}
+	return key, nil
+}
+
+// GetToken returns the cached token if it is not about to expire, otherwise requests a new one from the wrapped credential and caches it.
+func (c *CachedCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	// Tokens that are requested for a claims challenge shouldn't be cached.
+	if opts.Claims != "" {
+		return c.cred.GetToken(ctx, opts)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := filepath.Join(c.dir, c.entryName(opts))
+	if token, ok := c.read(path); ok {
+		return token, nil
+	}
+
+	token, err := c.cred.GetToken(ctx, opts)
+	if err != nil {
+		return token, err
+	}
+	if err := c.write(path, token); err != nil {
+		// Failing to cache the token shouldn't fail the request.
+		c.logger.Warn("Failed to cache the token", "error", err)
+	}
+	return token, nil
+}
+
+func (c *CachedCredential) entryName(opts policy.TokenRequestOptions) string {
+	h := sha256.New()
+	for _, v := range []string{c.identity, opts.TenantID, strings.Join(opts.Scopes, " "), fmt.Sprint(opts.EnableCAE)} {
+		h.Write([]byte(v + "\n"))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *CachedCredential) read(path string) (azcore.AccessToken, bool) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return azcore.AccessToken{}, false
+	}
+	nonceSize := c.aead.NonceSize()
+	if len(b) < nonceSize {
+		return azcore.AccessToken{}, false
+	}
+	plain, err := c.aead.Open(nil, b[:nonceSize], b[nonceSize:], nil)
+	if err != nil {
+		c.logger.Warn("Failed to decrypt the cached token", "error", err)
+		return azcore.AccessToken{}, false
+	}
+	var token cachedToken
+	if err := json.Unmarshal(plain, &token); err != nil {
+		return azcore.AccessToken{}, false
+	}
+	if time.Until(token.ExpiresOn) < tokenRefreshMargin {
+		return azcore.AccessToken{}, false
+	}
+	return azcore.AccessToken{Token: token.Token, ExpiresOn: token.ExpiresOn}, true
+}
+
+func (c *CachedCredential) write(path string, token azcore.AccessToken) error {
+	plain, err := json.Marshal(cachedToken{Token: token.Token, ExpiresOn: token.ExpiresOn})
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return os.WriteFile(path, c.aead.Seal(nonce, nonce, plain, nil), 0600)
+}
+
+var _ azcore.TokenCredential = (*CachedCredential)(nil)
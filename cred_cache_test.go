@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/stretchr/testify/require"
+)
+
+type countingCredential struct {
+	count     int
+	expiresOn time.Time
+}
+
+func (c *countingCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.count++
+	return azcore.AccessToken{Token: "token", ExpiresOn: c.expiresOn}, nil
+}
+
+func TestCachedCredential(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key")
+	cacheDir := filepath.Join(dir, "tokens")
+	opts := policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com//.default"}}
+	logger := *slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	inner := &countingCredential{expiresOn: time.Now().Add(time.Hour).Round(time.Second)}
+	cred, err := newCachedCredential(logger, inner, "identity", cacheDir, keyPath)
+	require.NoError(t, err)
+	token, err := cred.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "token", token.Token)
+	require.Equal(t, 1, inner.count)
+
+	// The token is reused by another run of the same identity
+	inner = &countingCredential{expiresOn: time.Now().Add(time.Hour)}
+	cred, err = newCachedCredential(logger, inner, "identity", cacheDir, keyPath)
+	require.NoError(t, err)
+	token, err = cred.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, "token", token.Token)
+	require.Equal(t, 0, inner.count)
+
+	// The token is not reused by another identity
+	cred, err = newCachedCredential(logger, inner, "another identity", cacheDir, keyPath)
+	require.NoError(t, err)
+	_, err = cred.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, 1, inner.count)
+
+	// The token cached is encrypted
+	entries, err := os.ReadDir(cacheDir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		b, err := os.ReadFile(filepath.Join(cacheDir, entry.Name()))
+		require.NoError(t, err)
+		require.NotContains(t, string(b), "token")
+	}
+
+	// The token that is about to expire is not reused
+	inner = &countingCredential{expiresOn: time.Now().Add(time.Minute)}
+	cred, err = newCachedCredential(logger, inner, "expiring identity", cacheDir, keyPath)
+	require.NoError(t, err)
+	_, err = cred.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	_, err = cred.GetToken(context.Background(), opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.count)
+}
+
+func TestAzureCLIAccount(t *testing.T) {
+	dir := t.TempDir()
+	_, err := azureCLIAccount(dir)
+	require.Error(t, err)
+
+	profile := `{"subscriptions": [
+		{"id": "sub1", "tenantId": "tenant1", "isDefault": false, "user": {"name": "alice@contoso.com", "type": "user"}},
+		{"id": "sub2", "tenantId": "tenant2", "isDefault": true, "user": {"name": "bob@contoso.com", "type": "user"}}
+	]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "azureProfile.json"), []byte("\xef\xbb\xbf"+profile), 0600))
+	account, err := azureCLIAccount(dir)
+	require.NoError(t, err)
+	require.Equal(t, "tenant2/user/bob@contoso.com", account)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "azureProfile.json"), []byte(`{"subscriptions": []}`), 0600))
+	_, err = azureCLIAccount(dir)
+	require.Error(t, err)
+}
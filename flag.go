@@ -1,24 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/Azure/aztfexport/internal/cfgfile"
 	"github.com/Azure/aztfexport/internal/log"
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/telemetry"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 	azlog "github.com/Azure/azure-sdk-for-go/sdk/azcore/log"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/gofrs/uuid"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 )
 
 var flagset FlagSet
@@ -28,27 +35,90 @@ type FlagSet struct {
 	flagSubscriptionId      string
 	flagOutputDir           string
 	flagOverwrite           bool
+	flagForce               bool
 	flagAppend              bool
 	flagDevProvider         bool
 	flagProviderVersion     string
+	flagTFBin               string
+	flagTFVersion           string
+	flagTFOffline           bool
+	flagProviderMirror      string
 	flagProviderName        string
+	flagPinVersions         bool
 	flagBackendType         string
 	flagBackendConfig       cli.StringSlice
+	flagBackendRGName       string
+	flagBackendSAName       string
+	flagBackendContainer    string
+	flagBackendKey          string
+	flagIncrementalPush     bool
+	flagCloudHostname       string
+	flagCloudOrganization   string
+	flagCloudWorkspace      string
+	flagCloudUploadConfig   bool
 	flagFullConfig          bool
 	flagMaskSensitive       bool
 	flagParallelism         int
+	flagMaxRetries          int
+	flagRetryDelay          time.Duration
+	flagMaxRetryDelay       time.Duration
+	flagImportTimeout       time.Duration
+	flagDeadline            time.Duration
 	flagContinue            bool
+	flagRollbackOnFailure   bool
 	flagNonInteractive      bool
 	flagPlainUI             bool
+	flagNoColor             bool
+	flagTheme               string
+	flagYes                 bool
 	flagGenerateMappingFile bool
+	flagResume              bool
+	flagRetryFailed         bool
+	flagDryRun              bool
+	flagDryRunFormat        string
 	flagHCLOnly             bool
+	flagStateOnly           bool
+	flagStateFile           string
+	flagVerify              bool
 	flagModulePath          string
+	flagAsModule            bool
+	flagTerragrunt          bool
+	flagEnvironment         string
+	flagOutputLayout        string
+	flagExtractVariables    bool
+	flagExtractSensitive    bool
+	flagGenerateOutputs     bool
+	flagCommonTags          bool
+	flagARMDependency       bool
+	flagCache               bool
+	flagDelta               bool
+	flagAzAPIFallback       bool
+	flagCoverageReport      bool
+	flagSourceMetadata      bool
+	flagJSONSyntax          bool
+	flagCDKTFLanguage       string
 	flagGenerateImportBlock bool
+	flagImportViaPlan       bool
+	flagResolveAddrConflict bool
+	flagTypeResolverFiles   cli.StringSlice
+	flagTypeResolverCommand string
+	flagIncludeTypes        cli.StringSlice
+	flagExcludeTypes        cli.StringSlice
+	flagNameFilter          string
+	flagSkipFile            string
+	flagLifecycleFile       string
+	flagProviderConfigFile  string
+	flagOverrideDir         string
 	flagLogPath             string
 	flagLogLevel            string
+	flagPartnerId           string
+	flagUserAgentSuffix     string
+	flagOptionFile          string
 
 	// common flags (auth)
 	flagEnv                       string
+	flagMetadataHost              string
+	flagCABundle                  string
 	flagTenantId                  string
 	flagAuxiliaryTenantIds        cli.StringSlice
 	flagClientId                  string
@@ -62,14 +132,16 @@ type FlagSet struct {
 	flagOIDCRequestURL            string
 	flagOIDCTokenFilePath         string
 	flagOIDCToken                 string
+	flagOIDCServiceConnectionId   string
 	flagUseManagedIdentityCred    bool
 	flagUseAzureCLICred           bool
 	flagUseOIDCCred               bool
+	flagUseTokenCache             bool
+	flagTFClientPluginPath        string
 
 	// common flags (hidden)
-	hflagMockClient         bool
-	hflagProfile            string
-	hflagTFClientPluginPath string
+	hflagMockClient bool
+	hflagProfile    string
 
 	// Subcommand specific flags
 	//
@@ -77,15 +149,50 @@ type FlagSet struct {
 	// flagResName (for single resource)
 	// flagResType (for single resource)
 	// flagPattern (for multi resources)
+	// flagRecursive
+	// flagNoChildren
 	//
 	// rg:
 	// flagPattern
 	// flagIncludeRoleAssignment
+	// flagIncludeLock
+	// flagDataPlane
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagNoChildren
+	// flagExpandEmbedded
+	//
+	// sub:
+	// flagPattern
+	// flagIncludeRoleAssignment
+	// flagIncludeLock
+	// flagDataPlane
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagNoChildren
+	// flagExpandEmbedded
+	//
+	// mg:
+	// flagPattern
+	// flagIncludeRoleAssignment
+	// flagIncludeLock
+	// flagDataPlane
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagNoChildren
+	// flagExpandEmbedded
+	// flagWorkspacePerSubscription
 	//
 	// query:
 	// flagPattern
 	// flagRecursive
 	// flagIncludeRoleAssignment
+	// flagIncludeLock
+	// flagDataPlane
+	// flagIncludeTags
+	// flagExcludeTags
+	// flagNoChildren
+	// flagExpandEmbedded
 	// flagIncludeResourceGroup
 	// flagARGTable
 	// flagARGAuthorizationScopeFilter
@@ -94,18 +201,34 @@ type FlagSet struct {
 	flagResName                     string
 	flagResType                     string
 	flagIncludeRoleAssignment       bool
+	flagIncludeLock                 bool
+	flagDataPlane                   bool
+	flagIncludeTags                 cli.StringSlice
+	flagExcludeTags                 cli.StringSlice
+	flagNoChildren                  bool
+	flagExpandEmbedded              bool
+	flagWorkspacePerSubscription    bool
 	flagIncludeResourceGroup        bool
 	flagARGTable                    string
 	flagARGAuthorizationScopeFilter string
+
+	// The states determined when checking the flags (not flags)
+	//
+	// The backup directory of the existing config files in the output directory, which are replaced by the generated ones
+	backupDir string
+	// Whether to review and confirm the changes to the backed up config files at the end
+	confirmConfigChanges bool
 }
 
 type Mode string
 
 const (
-	ModeResource      Mode = "resource"
-	ModeResourceGroup Mode = "resource-group"
-	ModeQuery         Mode = "query"
-	ModeMappingFile   Mode = "mapping-file"
+	ModeResource        Mode = "resource"
+	ModeResourceGroup   Mode = "resource-group"
+	ModeQuery           Mode = "query"
+	ModeMappingFile     Mode = "mapping-file"
+	ModeSubscription    Mode = "subscription"
+	ModeManagementGroup Mode = "management-group"
 )
 
 // DescribeCLI construct a description of the CLI based on the flag set and the specified mode.
@@ -126,18 +249,63 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagOverwrite {
 		args = append(args, "--overwrite=true")
 	}
+	if flag.flagForce {
+		args = append(args, "--force=true")
+	}
 	if flag.flagAppend {
 		args = append(args, "--append=true")
 	}
 	if flag.flagProviderVersion != "" {
 		args = append(args, fmt.Sprintf(`-provider-version=%s`, flag.flagProviderVersion))
 	}
+	if flag.flagTFBin != "" {
+		args = append(args, "--tf-bin="+flag.flagTFBin)
+	}
+	if flag.flagTFVersion != "" {
+		args = append(args, "--tf-version="+flag.flagTFVersion)
+	}
+	if flag.flagTFOffline {
+		args = append(args, "--tf-offline=true")
+	}
+	if flag.flagProviderMirror != "" {
+		args = append(args, "--provider-mirror="+flag.flagProviderMirror)
+	}
 	if flag.flagProviderName != "" {
 		args = append(args, fmt.Sprintf(`-provider-name=%s`, flag.flagProviderName))
 	}
+	if flag.flagPinVersions {
+		args = append(args, "--pin-versions=true")
+	}
 	if flag.flagBackendType != "" {
 		args = append(args, "--backend-type="+flag.flagBackendType)
 	}
+	if flag.flagBackendRGName != "" {
+		args = append(args, "--backend-resource-group-name=*")
+	}
+	if flag.flagBackendSAName != "" {
+		args = append(args, "--backend-storage-account-name=*")
+	}
+	if flag.flagBackendContainer != "" {
+		args = append(args, "--backend-container-name=*")
+	}
+	if flag.flagBackendKey != "" {
+		args = append(args, "--backend-key=*")
+	}
+	if flag.flagIncrementalPush {
+		args = append(args, "--incremental-push=true")
+	}
+	if flag.flagCloudHostname != "" {
+		args = append(args, "--cloud-hostname="+flag.flagCloudHostname)
+	}
+	if flag.flagCloudOrganization != "" {
+		args = append(args, "--cloud-organization=*")
+	}
+	if flag.flagCloudWorkspace != "" {
+		args = append(args, "--cloud-workspace=*")
+	}
+	if flag.flagCloudUploadConfig {
+		args = append(args, "--cloud-upload-config=true")
+	}
 	if flag.flagFullConfig {
 		args = append(args, "--full-properties=true")
 	}
@@ -147,27 +315,165 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagParallelism != 0 {
 		args = append(args, fmt.Sprintf("--parallelism=%d", flag.flagParallelism))
 	}
+	if flag.flagMaxRetries != defaultMaxRetries {
+		args = append(args, fmt.Sprintf("--max-retries=%d", flag.flagMaxRetries))
+	}
+	if flag.flagRetryDelay != defaultRetryDelay {
+		args = append(args, "--retry-delay="+flag.flagRetryDelay.String())
+	}
+	if flag.flagMaxRetryDelay != defaultMaxRetryDelay {
+		args = append(args, "--max-retry-delay="+flag.flagMaxRetryDelay.String())
+	}
+	if flag.flagImportTimeout != 0 {
+		args = append(args, "--timeout="+flag.flagImportTimeout.String())
+	}
+	if flag.flagDeadline != 0 {
+		args = append(args, "--deadline="+flag.flagDeadline.String())
+	}
+	if flag.flagOptionFile != "" {
+		args = append(args, "--config="+flag.flagOptionFile)
+	}
+	if flag.flagPartnerId != "" {
+		args = append(args, "--partner-id="+flag.flagPartnerId)
+	}
+	if flag.flagUserAgentSuffix != "" {
+		args = append(args, "--user-agent-suffix="+flag.flagUserAgentSuffix)
+	}
 	if flag.flagNonInteractive {
 		args = append(args, "--non-interactive=true")
 	}
 	if flag.flagPlainUI {
 		args = append(args, "--plain-ui=true")
 	}
+	if flag.flagNoColor {
+		args = append(args, "--no-color=true")
+	}
+	if flag.flagTheme != "" {
+		args = append(args, "--theme="+flag.flagTheme)
+	}
+	if flag.flagYes {
+		args = append(args, "--yes=true")
+	}
 	if flag.flagContinue {
 		args = append(args, "--continue=true")
 	}
+	if flag.flagRollbackOnFailure {
+		args = append(args, "--rollback-on-failure=true")
+	}
 	if flag.flagGenerateMappingFile {
 		args = append(args, "--generate-mapping-file=true")
 	}
+	if flag.flagResume {
+		args = append(args, "--resume=true")
+	}
+	if flag.flagRetryFailed {
+		args = append(args, "--retry-failed=true")
+	}
+	if flag.flagDryRun {
+		args = append(args, "--dry-run=true")
+	}
+	if flag.flagDryRunFormat != "" {
+		args = append(args, "--dry-run-format="+flag.flagDryRunFormat)
+	}
 	if flag.flagHCLOnly {
 		args = append(args, "--hcl-only=true")
 	}
+	if flag.flagStateOnly {
+		args = append(args, "--state-only=true")
+	}
+	if flag.flagStateFile != "" {
+		args = append(args, "--state=*")
+	}
+	if flag.flagVerify {
+		args = append(args, "--verify=true")
+	}
 	if flag.flagModulePath != "" {
 		args = append(args, "--module-path="+flag.flagModulePath)
 	}
-	if !flag.flagGenerateImportBlock {
+	if flag.flagAsModule {
+		args = append(args, "--as-module=true")
+	}
+	if flag.flagTerragrunt {
+		args = append(args, "--terragrunt=true")
+	}
+	if flag.flagEnvironment != "" {
+		args = append(args, "--environment="+flag.flagEnvironment)
+	}
+	if flag.flagOutputLayout != "" {
+		args = append(args, "--output-layout="+flag.flagOutputLayout)
+	}
+	if flag.flagExtractVariables {
+		args = append(args, "--extract-variables=true")
+	}
+	if flag.flagExtractSensitive {
+		args = append(args, "--extract-sensitive=true")
+	}
+	if flag.flagGenerateOutputs {
+		args = append(args, "--generate-outputs=true")
+	}
+	if flag.flagCommonTags {
+		args = append(args, "--common-tags=true")
+	}
+	if flag.flagARMDependency {
+		args = append(args, "--arm-dependency=true")
+	}
+	if flag.flagCache {
+		args = append(args, "--cache=true")
+	}
+	if flag.flagDelta {
+		args = append(args, "--delta=true")
+	}
+	if flag.flagAzAPIFallback {
+		args = append(args, "--azapi-fallback=true")
+	}
+	if flag.flagCoverageReport {
+		args = append(args, "--coverage-report=true")
+	}
+	if flag.flagSourceMetadata {
+		args = append(args, "--source-metadata=true")
+	}
+	if flag.flagJSONSyntax {
+		args = append(args, "--json-syntax=true")
+	}
+	if flag.flagCDKTFLanguage != "" {
+		args = append(args, "--cdktf-language="+flag.flagCDKTFLanguage)
+	}
+	if flag.flagGenerateImportBlock {
 		args = append(args, "--generate-import-block=true")
 	}
+	if flag.flagImportViaPlan {
+		args = append(args, "--import-via-plan=true")
+	}
+	if flag.flagResolveAddrConflict {
+		args = append(args, "--resolve-address-conflict=true")
+	}
+	if v := flag.flagTypeResolverFiles.Value(); len(v) != 0 {
+		args = append(args, fmt.Sprintf("--type-resolver-file=[%d]", len(v)))
+	}
+	if flag.flagTypeResolverCommand != "" {
+		args = append(args, "--type-resolver-command=*")
+	}
+	if v := flag.flagIncludeTypes.Value(); len(v) != 0 {
+		args = append(args, fmt.Sprintf("--include-type=[%d]", len(v)))
+	}
+	if v := flag.flagExcludeTypes.Value(); len(v) != 0 {
+		args = append(args, fmt.Sprintf("--exclude-type=[%d]", len(v)))
+	}
+	if flag.flagNameFilter != "" {
+		args = append(args, "--name-filter=*")
+	}
+	if flag.flagSkipFile != "" {
+		args = append(args, "--skip-file=*")
+	}
+	if flag.flagLifecycleFile != "" {
+		args = append(args, "--lifecycle-file=*")
+	}
+	if flag.flagProviderConfigFile != "" {
+		args = append(args, "--provider-config-file=*")
+	}
+	if flag.flagOverrideDir != "" {
+		args = append(args, "--override-dir=*")
+	}
 
 	if flag.flagEnv != "" {
 		args = append(args, "--env="+flag.flagEnv)
@@ -211,6 +517,15 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagOIDCToken != "" {
 		args = append(args, "--oidc-token=*")
 	}
+	if flag.flagOIDCServiceConnectionId != "" {
+		args = append(args, "--oidc-azure-service-connection-id="+flag.flagOIDCServiceConnectionId)
+	}
+	if flag.flagCABundle != "" {
+		args = append(args, "--ca-bundle=*")
+	}
+	if flag.flagMetadataHost != "" {
+		args = append(args, "--metadata-host="+flag.flagMetadataHost)
+	}
 	if flag.flagUseManagedIdentityCred {
 		args = append(args, "--use-managed-identity-cred=true")
 	}
@@ -220,9 +535,12 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 	if flag.flagUseOIDCCred {
 		args = append(args, "--use-oidc-cred=true")
 	}
+	if flag.flagUseTokenCache {
+		args = append(args, "--use-token-cache=true")
+	}
 
-	if flag.hflagTFClientPluginPath != "" {
-		args = append(args, "--tfclient-plugin-path="+flag.hflagTFClientPluginPath)
+	if flag.flagTFClientPluginPath != "" {
+		args = append(args, "--tfclient-plugin-path="+flag.flagTFClientPluginPath)
 	}
 	switch mode {
 	case ModeResource:
@@ -235,13 +553,40 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 		if flag.flagPattern != "" {
 			args = append(args, "--name-pattern="+flag.flagPattern)
 		}
-	case ModeResourceGroup:
+		if flag.flagRecursive {
+			args = append(args, "--recursive=true")
+		}
+		if flag.flagNoChildren {
+			args = append(args, "--no-children=true")
+		}
+	case ModeResourceGroup, ModeSubscription, ModeManagementGroup:
 		if flag.flagPattern != "" {
 			args = append(args, "--name-pattern="+flag.flagPattern)
 		}
 		if flag.flagIncludeRoleAssignment {
 			args = append(args, "--include-role-assignment=true")
 		}
+		if flag.flagIncludeLock {
+			args = append(args, "--include-lock=true")
+		}
+		if flag.flagDataPlane {
+			args = append(args, "--data-plane=true")
+		}
+		if v := flag.flagIncludeTags.Value(); len(v) != 0 {
+			args = append(args, fmt.Sprintf("--include-tag=[%d]", len(v)))
+		}
+		if v := flag.flagExcludeTags.Value(); len(v) != 0 {
+			args = append(args, fmt.Sprintf("--exclude-tag=[%d]", len(v)))
+		}
+		if flag.flagNoChildren {
+			args = append(args, "--no-children=true")
+		}
+		if flag.flagExpandEmbedded {
+			args = append(args, "--expand-embedded=true")
+		}
+		if flag.flagWorkspacePerSubscription {
+			args = append(args, "--workspace-per-subscription=true")
+		}
 	case ModeQuery:
 		if flag.flagPattern != "" {
 			args = append(args, "--name-pattern="+flag.flagPattern)
@@ -252,9 +597,27 @@ func (flag FlagSet) DescribeCLI(mode Mode) string {
 		if flag.flagIncludeRoleAssignment {
 			args = append(args, "--include-role-assignment=true")
 		}
+		if flag.flagIncludeLock {
+			args = append(args, "--include-lock=true")
+		}
+		if flag.flagDataPlane {
+			args = append(args, "--data-plane=true")
+		}
 		if flag.flagIncludeResourceGroup {
 			args = append(args, "--include-resource-group=true")
 		}
+		if v := flag.flagIncludeTags.Value(); len(v) != 0 {
+			args = append(args, fmt.Sprintf("--include-tag=[%d]", len(v)))
+		}
+		if v := flag.flagExcludeTags.Value(); len(v) != 0 {
+			args = append(args, fmt.Sprintf("--exclude-tag=[%d]", len(v)))
+		}
+		if flag.flagNoChildren {
+			args = append(args, "--no-children=true")
+		}
+		if flag.flagExpandEmbedded {
+			args = append(args, "--expand-embedded=true")
+		}
 		if flag.flagARGTable != "" {
 			args = append(args, "--arg-table="+flag.flagARGTable)
 		}
@@ -318,6 +681,13 @@ func (f FlagSet) buildAuthConfig() (*config.AuthConfig, error) {
 		if err != nil {
 			return nil, fmt.Errorf("reading Client certificate from file %q: %v", path, err)
 		}
+		// The PEM encoded certificate is converted to PKCS#12, which is the only format supported by the providers.
+		if bytes.Contains(b, []byte("-----BEGIN")) {
+			b, err = pemToPKCS12(b, f.flagClientCertificatePassword)
+			if err != nil {
+				return nil, fmt.Errorf("converting the PEM encoded Client certificate %q to PKCS#12: %v", path, err)
+			}
+		}
 		clientCertEncoded = base64.StdEncoding.EncodeToString(b)
 	}
 
@@ -332,19 +702,21 @@ func (f FlagSet) buildAuthConfig() (*config.AuthConfig, error) {
 	}
 
 	c := config.AuthConfig{
-		Environment:               f.flagEnv,
-		TenantID:                  f.flagTenantId,
-		AuxiliaryTenantIDs:        f.flagAuxiliaryTenantIds.Value(),
-		ClientID:                  clientId,
-		ClientSecret:              clientSecret,
-		ClientCertificateEncoded:  clientCertEncoded,
-		ClientCertificatePassword: f.flagClientCertificatePassword,
-		OIDCTokenRequestToken:     f.flagOIDCRequestToken,
-		OIDCTokenRequestURL:       f.flagOIDCRequestURL,
-		OIDCAssertionToken:        oidcToken,
-		UseAzureCLI:               f.flagUseAzureCLICred,
-		UseManagedIdentity:        f.flagUseManagedIdentityCred,
-		UseOIDC:                   f.flagUseOIDCCred,
+		Environment:                  f.flagEnv,
+		MetadataHost:                 f.flagMetadataHost,
+		TenantID:                     f.flagTenantId,
+		AuxiliaryTenantIDs:           f.flagAuxiliaryTenantIds.Value(),
+		ClientID:                     clientId,
+		ClientSecret:                 clientSecret,
+		ClientCertificateEncoded:     clientCertEncoded,
+		ClientCertificatePassword:    f.flagClientCertificatePassword,
+		OIDCTokenRequestToken:        f.flagOIDCRequestToken,
+		OIDCTokenRequestURL:          f.flagOIDCRequestURL,
+		OIDCAssertionToken:           oidcToken,
+		OIDCAzureServiceConnectionID: f.flagOIDCServiceConnectionId,
+		UseAzureCLI:                  f.flagUseAzureCLICred,
+		UseManagedIdentity:           f.flagUseManagedIdentityCred,
+		UseOIDC:                      f.flagUseOIDCCred,
 	}
 
 	return &c, nil
@@ -382,17 +754,42 @@ func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 		return config.CommonConfig{}, err
 	}
 
-	var cloudCfg cloud.Configuration
-	switch env := f.flagEnv; strings.ToLower(env) {
-	case "public":
-		cloudCfg = cloud.AzurePublic
-	case "usgovernment":
-		cloudCfg = cloud.AzureGovernment
-	case "china":
-		cloudCfg = cloud.AzureChina
-	default:
-		return config.CommonConfig{}, fmt.Errorf("unknown environment specified: %q", env)
+	var transport policy.Transporter
+	if path := f.flagCABundle; path != "" {
+		tr, err := newCABundleTransport(path)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+		transport = &http.Client{Transport: tr}
+
+		// Also use it for the requests that are not sent via the Azure SDK (e.g. the OIDC token request)
+		http.DefaultTransport = tr
+
+		// The terraform and provider processes (on Linux) honor SSL_CERT_FILE, while the Azure CLI honors REQUESTS_CA_BUNDLE.
+		for _, k := range []string{"SSL_CERT_FILE", "REQUESTS_CA_BUNDLE"} {
+			if _, ok := os.LookupEnv(k); !ok {
+				os.Setenv(k, path) // #nosec G104
+			}
+		}
+	}
+
+	var (
+		env      string
+		cloudCfg cloud.Configuration
+	)
+	if host := f.flagMetadataHost; host != "" {
+		env, cloudCfg, err = cloudEnvironmentFromMetadataHost(context.Background(), host, f.flagEnv)
+		if err != nil {
+			return config.CommonConfig{}, fmt.Errorf("retrieving the environment from the metadata host: %v", err)
+		}
+	} else {
+		env, cloudCfg, err = cloudEnvironment(f.flagEnv)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
 	}
+	// Always pass the canonical environment name to the provider.
+	authConfig.Environment = env
 
 	clientOpt := arm.ClientOptions{
 		ClientOptions: policy.ClientOptions{
@@ -404,41 +801,139 @@ func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 			Logging: policy.LogOptions{
 				IncludeBody: true,
 			},
+			// The SDK retries on throttling (429) and transient server errors, with exponential backoff and jitter, and honors the Retry-After header.
+			Retry:     f.buildRetryOptions(),
+			Transport: transport,
 		},
 		AuxiliaryTenants:      authConfig.AuxiliaryTenantIDs,
 		DisableRPRegistration: true,
 	}
+	if f.flagPartnerId != "" || f.flagUserAgentSuffix != "" {
+		clientOpt.PerCallPolicies = append(clientOpt.PerCallPolicies, userAgentPolicy{partnerId: f.flagPartnerId, suffix: f.flagUserAgentSuffix})
+	}
 
 	cred, err := NewDefaultAzureCredential(*logger, &DefaultAzureCredentialOptions{
-		AuthConfig:               *authConfig,
-		ClientOptions:            clientOpt.ClientOptions,
-		DisableInstanceDiscovery: false,
+		AuthConfig:    *authConfig,
+		ClientOptions: clientOpt.ClientOptions,
+		// The instance discovery is not supported by the custom clouds (e.g. Azure Stack Hub that uses ADFS).
+		DisableInstanceDiscovery: f.flagMetadataHost != "",
 		SendCertificateChain:     false,
 	})
 	if err != nil {
 		return config.CommonConfig{}, fmt.Errorf("failed to new credential: %v", err)
 	}
+	var sdkCred azcore.TokenCredential = cred
+	if f.flagUseTokenCache {
+		sdkCred, err = NewCachedCredential(*logger, cred, *authConfig)
+		if err != nil {
+			return config.CommonConfig{}, fmt.Errorf("failed to new cached credential: %v", err)
+		}
+	}
+
+	typeResolver, err := f.buildTypeResolver()
+	if err != nil {
+		return config.CommonConfig{}, err
+	}
+
+	var skipResources []string
+	if path := f.flagSkipFile; path != "" {
+		skipResources, err = readSkipFile(path)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+	}
+
+	var lifecycleRules []config.LifecycleRule
+	if path := f.flagLifecycleFile; path != "" {
+		lifecycleRules, err = readLifecycleFile(path)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+	}
+
+	var providerBlocks string
+	if path := f.flagProviderConfigFile; path != "" {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return config.CommonConfig{}, fmt.Errorf("reading the provider config file %q: %v", path, err)
+		}
+		providerBlocks = string(b)
+	}
+
+	var overrideTemplates map[string]string
+	if path := f.flagOverrideDir; path != "" {
+		overrideTemplates, err = readOverrideDir(path)
+		if err != nil {
+			return config.CommonConfig{}, err
+		}
+	}
 
 	cfg := config.CommonConfig{
 		Logger:               logger,
 		AuthConfig:           *authConfig,
 		SubscriptionId:       f.flagSubscriptionId,
-		AzureSDKCredential:   cred,
+		AzureSDKCredential:   sdkCred,
 		AzureSDKClientOption: clientOpt,
 		OutputDir:            f.flagOutputDir,
 		ProviderVersion:      f.flagProviderVersion,
+		TerraformBinary:      f.flagTFBin,
+		TerraformVersion:     f.flagTFVersion,
+		TerraformOffline:     f.flagTFOffline,
+		ProviderMirror:       f.flagProviderMirror,
 		ProviderName:         f.flagProviderName,
+		PinVersions:          f.flagPinVersions,
 		DevProvider:          f.flagDevProvider,
 		ContinueOnError:      f.flagContinue,
+		RollbackOnFailure:    f.flagRollbackOnFailure,
 		BackendType:          f.flagBackendType,
 		BackendConfig:        f.flagBackendConfig.Value(),
+		BackendBlock:         f.backendBlock(),
+		IncrementalPush:      f.flagIncrementalPush,
+		CloudHostname:        f.flagCloudHostname,
+		CloudOrganization:    f.flagCloudOrganization,
+		CloudWorkspace:       f.flagCloudWorkspace,
+		CloudUploadConfig:    f.flagCloudUploadConfig,
 		FullConfig:           f.flagFullConfig,
 		MaskSensitive:        f.flagMaskSensitive,
 		Parallelism:          f.flagParallelism,
+		ImportTimeout:        f.flagImportTimeout,
 		HCLOnly:              f.flagHCLOnly,
+		StateOnly:            f.flagStateOnly,
+		StateFile:            f.flagStateFile,
+		Verify:               f.flagVerify,
 		ModulePath:           f.flagModulePath,
+		AsModule:             f.flagAsModule,
+		Terragrunt:           f.flagTerragrunt,
+		Environment:          f.flagEnvironment,
 		GenerateImportBlock:  f.flagGenerateImportBlock,
+		ImportViaPlan:        f.flagImportViaPlan,
+		IncludeTypes:         f.flagIncludeTypes.Value(),
+		ExcludeTypes:         f.flagExcludeTypes.Value(),
+		NameFilter:           f.flagNameFilter,
+		PartnerId:            f.flagPartnerId,
+		UserAgentSuffix:      f.flagUserAgentSuffix,
 		TelemetryClient:      initTelemetryClient(f.flagSubscriptionId),
+
+		ResolveAddressConflict: f.flagResolveAddrConflict,
+		TypeResolver:           typeResolver,
+		SkipResources:          skipResources,
+		LifecycleRules:         lifecycleRules,
+		ProviderBlocks:         providerBlocks,
+		OverrideTemplates:      overrideTemplates,
+		OutputLayout:           f.flagOutputLayout,
+		ExtractVariables:       f.flagExtractVariables,
+		ExtractSensitive:       f.flagExtractSensitive,
+		GenerateOutputs:        f.flagGenerateOutputs,
+		CommonTags:             f.flagCommonTags,
+		ARMDependency:          f.flagARMDependency,
+		Cache:                  f.flagCache,
+		Delta:                  f.flagDelta,
+		AzAPIFallback:          f.flagAzAPIFallback,
+		CoverageReport:         f.flagCoverageReport,
+		SourceMetadata:         f.flagSourceMetadata,
+		JSONSyntax:             f.flagJSONSyntax,
+		CDKTFLanguage:          f.flagCDKTFLanguage,
 	}
 
 	if f.flagAppend {
@@ -447,12 +942,153 @@ func (f FlagSet) BuildCommonConfig() (config.CommonConfig, error) {
 			ProviderFileName:    "provider.aztfexport.tf",
 			MainFileName:        "main.aztfexport.tf",
 			ImportBlockFileName: "import.aztfexport.tf",
+			VariableFileName:    "variables.aztfexport.tf",
+			OutputFileName:      "outputs.aztfexport.tf",
+			LocalsFileName:      "locals.aztfexport.tf",
 		}
 	}
 
 	return cfg, nil
 }
 
+// backendBlock returns the attributes of the azurerm backend block that are specified in the CLI.
+func (f FlagSet) backendBlock() map[string]string {
+	m := map[string]string{}
+	for k, v := range map[string]string{
+		"resource_group_name":  f.flagBackendRGName,
+		"storage_account_name": f.flagBackendSAName,
+		"container_name":       f.flagBackendContainer,
+		"key":                  f.flagBackendKey,
+	} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// readSkipFile reads the Azure resource id patterns from the skip file, one per line. Empty lines and lines starting with "#" are ignored.
+func readSkipFile(path string) ([]string, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the skip file %q: %v", path, err)
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// readLifecycleFile reads the lifecycle rules from the lifecycle file in YAML or JSON (determined by the file extension), which is a list of the rules.
+func readLifecycleFile(path string) ([]config.LifecycleRule, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the lifecycle file %q: %v", path, err)
+	}
+	var rules []config.LifecycleRule
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &rules)
+	case ".json":
+		err = json.Unmarshal(b, &rules)
+	default:
+		return nil, fmt.Errorf("unsupported lifecycle file extension %q, must be one of %q, %q and %q", ext, ".yaml", ".yml", ".json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling the lifecycle file %q: %v", path, err)
+	}
+	return rules, nil
+}
+
+// overrideTemplateExt is the file extension of the override templates, whose base names are the TF resource types. E.g. "azurerm_storage_account.tf.tmpl".
+const overrideTemplateExt = ".tf.tmpl"
+
+// readOverrideDir reads the override templates from the override directory, keyed by the TF resource types. Other files are ignored.
+func readOverrideDir(path string) (map[string]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the override directory %q: %v", path, err)
+	}
+	tpls := map[string]string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), overrideTemplateExt) {
+			continue
+		}
+		// #nosec G304
+		b, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading the override template %q: %v", entry.Name(), err)
+		}
+		tpls[strings.TrimSuffix(entry.Name(), overrideTemplateExt)] = string(b)
+	}
+	return tpls, nil
+}
+
+const (
+	defaultMaxRetries    = 3
+	defaultRetryDelay    = 800 * time.Millisecond
+	defaultMaxRetryDelay = 60 * time.Second
+)
+
+// buildRetryOptions builds the retry options of the Azure SDK clients from the FlagSet.
+func (f FlagSet) buildRetryOptions() policy.RetryOptions {
+	opt := policy.RetryOptions{
+		MaxRetries:    int32(f.flagMaxRetries),
+		RetryDelay:    f.flagRetryDelay,
+		MaxRetryDelay: f.flagMaxRetryDelay,
+	}
+	// The SDK regards the zero value as the default, while a negative value disables the retry.
+	if opt.MaxRetries == 0 {
+		opt.MaxRetries = -1
+	}
+	return opt
+}
+
+// buildTagFilters builds the include and exclude tag filters from the FlagSet.
+func (f FlagSet) buildTagFilters() (include, exclude map[string]*string, err error) {
+	include, err = parseTagFilter(f.flagIncludeTags.Value())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing `--include-tag`: %v", err)
+	}
+	exclude, err = parseTagFilter(f.flagExcludeTags.Value())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing `--exclude-tag`: %v", err)
+	}
+	return include, exclude, nil
+}
+
+// parseTagFilter parses a list of tag filters, each in the form of "key=value", "key=" (the tag has an empty value) or "key" (the tag has any value).
+// The any value is represented by a nil value in the returned map.
+func parseTagFilter(filters []string) (map[string]*string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+	m := map[string]*string{}
+	for _, filter := range filters {
+		k, v, hasValue := strings.Cut(filter, "=")
+		k = strings.TrimSpace(k)
+		if k == "" {
+			return nil, fmt.Errorf("invalid tag filter %q: empty tag name", filter)
+		}
+		if !hasValue {
+			m[k] = nil
+			continue
+		}
+		m[k] = &v
+	}
+	return m, nil
+}
+
 func logLevel(level string) (slog.Level, error) {
 	switch strings.ToUpper(level) {
 	case "ERROR":
@@ -469,3 +1105,26 @@ func logLevel(level string) (slog.Level, error) {
 		return slog.Level(0), fmt.Errorf("unknown log level: %s", level)
 	}
 }
+
+// buildRealMainOption builds the option of realMain from the FlagSet, including the states determined when checking the flags, for the specified mode.
+func (f FlagSet) buildRealMainOption(mode Mode) realMainOption {
+	return realMainOption{
+		batch:                f.flagNonInteractive,
+		mockMeta:             f.hflagMockClient,
+		plainUI:              f.flagPlainUI,
+		genMapFile:           f.flagGenerateMappingFile,
+		resume:               f.flagResume,
+		retryFailed:          f.flagRetryFailed,
+		deadline:             f.flagDeadline,
+		dryRun:               f.flagDryRun,
+		dryRunFormat:         f.flagDryRunFormat,
+		profileType:          f.hflagProfile,
+		effectiveCLI:         f.DescribeCLI(mode),
+		tfClientPluginPath:   f.flagTFClientPluginPath,
+		yes:                  f.flagYes,
+		noColor:              f.flagNoColor,
+		theme:                f.flagTheme,
+		backupDir:            f.backupDir,
+		confirmConfigChanges: f.confirmConfigChanges,
+	}
+}
@@ -1,8 +1,12 @@
 package client
 
 import (
+	"strings"
+
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/keyvault/armkeyvault"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 )
@@ -35,3 +39,22 @@ func (b *ClientBuilder) NewResourcesClient(subscriptionId string) (*armresources
 		&b.Opt,
 	)
 }
+
+func (b *ClientBuilder) NewResourceGroupsClient(subscriptionId string) (*armresources.ResourceGroupsClient, error) {
+	return armresources.NewResourceGroupsClient(
+		subscriptionId,
+		b.Credential,
+		&b.Opt,
+	)
+}
+
+// NewDataPlanePipeline builds a pipeline for calling the data plane API of the specified endpoint (e.g. https://foo.azconfig.io), which is authenticated by the AAD token of that endpoint.
+// For the services whose token audience is not the endpoint itself, the audience is passed instead (e.g. https://vault.azure.net for Key Vault).
+func (b *ClientBuilder) NewDataPlanePipeline(endpoint string) runtime.Pipeline {
+	opt := b.Opt.ClientOptions
+	return runtime.NewPipeline("aztfexport", "", runtime.PipelineOptions{
+		PerRetry: []policy.Policy{
+			runtime.NewBearerTokenPolicy(b.Credential, []string{strings.TrimSuffix(endpoint, "/") + "/.default"}, nil),
+		},
+	}, &opt)
+}
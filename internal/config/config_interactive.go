@@ -6,4 +6,12 @@ type InteractiveModeConfig struct {
 	config.Config
 
 	MockMeta bool
+	// Resume restores the import list (including the mapping edits and the import statuses) from the session file saved on quit
+	Resume bool
+	// NoColor disables the colors of the TUI
+	NoColor bool
+	// Theme is the color theme of the TUI
+	Theme string
+	// Confirm prompts for the confirmation of the import summary before importing
+	Confirm bool
 }
@@ -1,6 +1,10 @@
 package config
 
-import "github.com/Azure/aztfexport/pkg/config"
+import (
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/config"
+)
 
 type NonInteractiveModeConfig struct {
 	config.Config
@@ -8,4 +12,10 @@ type NonInteractiveModeConfig struct {
 	MockMeta           bool
 	PlainUI            bool
 	GenMappingFileOnly bool
+	Resume             bool
+	RetryFailed        bool
+	// The deadline of the run, 0 means no deadline
+	Deadline     time.Duration
+	DryRun       bool
+	DryRunFormat string
 }
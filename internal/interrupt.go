@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// interruptWatcher stops the run gracefully on the first interrupt (SIGINT or SIGTERM), i.e. the in-flight imports are finished and checkpointed before stopping,
+// while the second interrupt aborts the run immediately.
+type interruptWatcher struct {
+	w           io.Writer
+	abort       context.CancelFunc
+	interrupted atomic.Bool
+	sigCh       chan os.Signal
+	doneCh      chan struct{}
+}
+
+// watchInterrupt starts watching the interrupts, where abort cancels the run. The notices of the interrupts are written to w.
+func watchInterrupt(w io.Writer, abort context.CancelFunc) *interruptWatcher {
+	iw := &interruptWatcher{
+		w:      w,
+		abort:  abort,
+		sigCh:  make(chan os.Signal, 2),
+		doneCh: make(chan struct{}),
+	}
+	signal.Notify(iw.sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for {
+			select {
+			case <-iw.doneCh:
+				return
+			case <-iw.sigCh:
+				iw.Interrupt()
+			}
+		}
+	}()
+	return iw
+}
+
+// Interrupt interrupts the run, which is also called when the interrupt is captured by the UI (e.g. the Ctrl-C key press) instead of a signal.
+func (iw *interruptWatcher) Interrupt() {
+	if iw.interrupted.CompareAndSwap(false, true) {
+		fmt.Fprintln(iw.w, "Interrupted, stopping once the in-flight imports finish (interrupt again to abort immediately)...")
+		return
+	}
+	fmt.Fprintln(iw.w, "Interrupted again, aborting...")
+	iw.abort()
+}
+
+// Interrupted tells whether the run is interrupted.
+func (iw *interruptWatcher) Interrupted() bool {
+	return iw.interrupted.Load()
+}
+
+// Stop stops watching the interrupts.
+func (iw *interruptWatcher) Stop() {
+	signal.Stop(iw.sigCh)
+	close(iw.doneCh)
+}
+
+// switchMessager forwards the messages to the messager, which can be switched to another one (e.g. once the spinner quits on interrupt, while the run is still finishing).
+type switchMessager struct {
+	mu  sync.Mutex
+	msg Messager
+}
+
+func (m *switchMessager) Switch(msg Messager) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.msg = msg
+}
+
+func (m *switchMessager) current() Messager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.msg
+}
+
+func (m *switchMessager) SetStatus(msg string) {
+	m.current().SetStatus(msg)
+}
+
+func (m *switchMessager) SetDetail(msg string) {
+	m.current().SetDetail(msg)
+}
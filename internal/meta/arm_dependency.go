@@ -0,0 +1,199 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/magodo/armid"
+)
+
+// armDependencies maps the upper cased Azure resource ids to the Azure resource ids they depend on, as is declared in the "dependsOn" of the ARM template.
+type armDependencies map[string][]string
+
+// armTemplate is the part of the exported ARM template that is relevant to the dependencies.
+type armTemplate struct {
+	Resources []armTemplateResource `json:"resources"`
+}
+
+type armTemplateResource struct {
+	Type      string                `json:"type"`
+	Name      string                `json:"name"`
+	Scope     string                `json:"scope"`
+	DependsOn []string              `json:"dependsOn"`
+	Resources []armTemplateResource `json:"resources"`
+}
+
+var (
+	armResourceIdFuncRegexp = regexp.MustCompile(`^\[resourceId\((.*)\)\]$`)
+	armStringLiteralRegexp  = regexp.MustCompile(`\s*'((?:[^']|'')*)'\s*(,|$)`)
+)
+
+// listARMDependencies exports the ARM templates of the resource groups that contain the resources, and returns the dependencies declared in them.
+// Only the resources (and the dependencies) whose names are not parameterized can be resolved. Failing to export the template of a resource group is not regarded as an error, but is logged.
+func (meta baseMeta) listARMDependencies(ctx context.Context, l ImportList) armDependencies {
+	// Upper cased resource group id to the resource group and the resources in it.
+	rgs := map[string]*armid.ResourceGroup{}
+	rgResources := map[string][]*string{}
+	for _, item := range l {
+		if _, ok := item.AzureResourceID.(*armid.ScopedResourceId); !ok {
+			continue
+		}
+		rg, ok := item.AzureResourceID.RootScope().(*armid.ResourceGroup)
+		if !ok {
+			continue
+		}
+		key := strings.ToUpper(rg.String())
+		rgs[key] = rg
+		rgResources[key] = append(rgResources[key], to.Ptr(item.AzureResourceID.String()))
+	}
+
+	b := client.ClientBuilder{
+		Credential: meta.azureSDKCred,
+		Opt:        meta.azureSDKClientOpt,
+	}
+	deps := armDependencies{}
+	for key, resources := range rgResources {
+		rg := rgs[key]
+		var cacheKey string
+		if meta.cache != nil {
+			var tpl armTemplate
+			var ok bool
+			if cacheKey, ok = meta.cache.armTemplateKey(rg, resources); ok && meta.cache.read(resourceCacheKindARMTemplate, cacheKey, &tpl) {
+				meta.Logger().Debug("Use the cached ARM template for the dependencies", "resource_group", rg.Name)
+				addARMTemplateDependencies(deps, *rg, "", "", tpl.Resources)
+				continue
+			}
+		}
+		tpl, err := exportARMTemplate(ctx, b, rg, resources)
+		if err != nil {
+			meta.Logger().Warn("Failed to export the ARM template for the dependencies", "resource_group", rg.Name, "error", err)
+			continue
+		}
+		if cacheKey != "" {
+			if err := meta.cache.write(resourceCacheKindARMTemplate, cacheKey, tpl); err != nil {
+				meta.Logger().Warn("Failed to cache the ARM template", "resource_group", rg.Name, "error", err)
+			}
+		}
+		addARMTemplateDependencies(deps, *rg, "", "", tpl.Resources)
+	}
+	return deps
+}
+
+func exportARMTemplate(ctx context.Context, b client.ClientBuilder, rg *armid.ResourceGroup, resources []*string) (*armTemplate, error) {
+	c, err := b.NewResourceGroupsClient(rg.SubscriptionId)
+	if err != nil {
+		return nil, fmt.Errorf("new resource groups client: %v", err)
+	}
+	poller, err := c.BeginExportTemplate(ctx, rg.Name, armresources.ExportTemplateRequest{
+		Options:   to.Ptr("SkipAllParameterization"),
+		Resources: resources,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("exporting template: %v", err)
+	}
+	resp, err := poller.PollUntilDone(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("polling the template export: %v", err)
+	}
+	// The template is still returned for the resources that can be exported, even if some can't.
+	if resp.Template == nil {
+		return nil, fmt.Errorf("no template exported")
+	}
+	b2, err := json.Marshal(resp.Template)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling the template: %v", err)
+	}
+	var tpl armTemplate
+	if err := json.Unmarshal(b2, &tpl); err != nil {
+		return nil, fmt.Errorf("unmarshalling the template: %v", err)
+	}
+	return &tpl, nil
+}
+
+// addARMTemplateDependencies adds the dependencies of the template resources to deps. The parentType and parentName are for the resources nested in another resource.
+func addARMTemplateDependencies(deps armDependencies, rg armid.ResourceGroup, parentType, parentName string, resources []armTemplateResource) {
+	for _, res := range resources {
+		// Skip the extension resources
+		if res.Scope != "" {
+			continue
+		}
+		typ, name := res.Type, res.Name
+		// The nested resources can have either the full or the relative type and name
+		if parentType != "" && !strings.Contains(typ, "/") {
+			typ, name = parentType+"/"+typ, parentName+"/"+name
+		}
+		id, ok := armTemplateResourceId(rg, typ, strings.Split(name, "/"))
+		if !ok {
+			continue
+		}
+		key := strings.ToUpper(id)
+		for _, dep := range res.DependsOn {
+			if depId, ok := armTemplateDependencyId(rg, dep); ok {
+				deps[key] = append(deps[key], depId)
+			}
+		}
+		// The nested resources depend on their parent implicitly, which is covered by the parent child dependency already.
+		addARMTemplateDependencies(deps, rg, typ, name, res.Resources)
+	}
+}
+
+// armTemplateDependencyId resolves the Azure resource id of a "dependsOn" entry, which is expected to be a resourceId() expression with string literal arguments.
+// E.g. "[resourceId('Microsoft.Network/virtualNetworks/subnets', 'vnet', 'subnet')]".
+func armTemplateDependencyId(rg armid.ResourceGroup, dep string) (string, bool) {
+	matches := armResourceIdFuncRegexp.FindStringSubmatch(dep)
+	if matches == nil {
+		return "", false
+	}
+	var args []string
+	argStr := strings.TrimSpace(matches[1])
+	for argStr != "" {
+		loc := armStringLiteralRegexp.FindStringSubmatchIndex(argStr)
+		if loc == nil || loc[0] != 0 {
+			return "", false
+		}
+		args = append(args, strings.ReplaceAll(argStr[loc[2]:loc[3]], "''", "'"))
+		argStr = argStr[loc[1]:]
+	}
+
+	// The optional leading arguments are the subscription id and the resource group name.
+	typeIdx := -1
+	for i, arg := range args {
+		if strings.Contains(arg, "/") {
+			typeIdx = i
+			break
+		}
+	}
+	switch typeIdx {
+	case 0:
+	case 1:
+		rg.Name = args[0]
+	case 2:
+		rg.SubscriptionId, rg.Name = args[0], args[1]
+	default:
+		return "", false
+	}
+	return armTemplateResourceId(rg, args[typeIdx], args[typeIdx+1:])
+}
+
+// armTemplateResourceId builds the Azure resource id from the resource type (e.g. "Microsoft.Network/virtualNetworks/subnets") and the names of each type segment.
+func armTemplateResourceId(rg armid.ResourceGroup, typ string, names []string) (string, bool) {
+	segs := strings.Split(typ, "/")
+	if len(segs) < 2 || len(segs)-1 != len(names) {
+		return "", false
+	}
+	id := rg.String() + "/providers/" + segs[0]
+	for i, name := range names {
+		// The name is an unresolved expression
+		if name == "" || strings.HasPrefix(name, "[") {
+			return "", false
+		}
+		id += "/" + segs[i+1] + "/" + name
+	}
+	return id, true
+}
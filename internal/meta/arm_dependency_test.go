@@ -0,0 +1,106 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArmTemplateDependencyId(t *testing.T) {
+	rg := armid.ResourceGroup{SubscriptionId: "123", Name: "rg"}
+	cases := []struct {
+		name   string
+		dep    string
+		expect string
+		ok     bool
+	}{
+		{
+			name:   "top level resource",
+			dep:    "[resourceId('Microsoft.Network/virtualNetworks', 'vnet')]",
+			expect: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			ok:     true,
+		},
+		{
+			name:   "child resource",
+			dep:    "[resourceId('Microsoft.Network/virtualNetworks/subnets', 'vnet', 'subnet')]",
+			expect: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+			ok:     true,
+		},
+		{
+			name:   "resource in another resource group",
+			dep:    "[resourceId('rg2', 'Microsoft.Network/virtualNetworks', 'vnet')]",
+			expect: "/subscriptions/123/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet",
+			ok:     true,
+		},
+		{
+			name:   "resource in another subscription",
+			dep:    "[resourceId('456', 'rg2', 'Microsoft.Network/virtualNetworks', 'vnet')]",
+			expect: "/subscriptions/456/resourceGroups/rg2/providers/Microsoft.Network/virtualNetworks/vnet",
+			ok:     true,
+		},
+		{
+			name: "parameterized name",
+			dep:  "[resourceId('Microsoft.Network/virtualNetworks', parameters('vnetName'))]",
+		},
+		{
+			name: "mismatched names",
+			dep:  "[resourceId('Microsoft.Network/virtualNetworks/subnets', 'vnet')]",
+		},
+		{
+			name: "not a resourceId expression",
+			dep:  "vnet",
+		},
+	}
+
+	for _, c := range cases {
+		id, ok := armTemplateDependencyId(rg, c.dep)
+		require.Equal(t, c.ok, ok, c.name)
+		require.Equal(t, c.expect, id, c.name)
+	}
+}
+
+func TestAddARMTemplateDependencies(t *testing.T) {
+	var tpl armTemplate
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "resources": [
+    {
+      "type": "Microsoft.Network/virtualNetworks",
+      "name": "vnet",
+      "resources": [
+        {
+          "type": "subnets",
+          "name": "subnet",
+          "dependsOn": ["[resourceId('Microsoft.Network/networkSecurityGroups', 'nsg')]"]
+        }
+      ]
+    },
+    {
+      "type": "Microsoft.Network/networkInterfaces",
+      "name": "nic",
+      "dependsOn": [
+        "[resourceId('Microsoft.Network/virtualNetworks/subnets', 'vnet', 'subnet')]",
+        "[variables('foo')]"
+      ]
+    },
+    {
+      "type": "Microsoft.Authorization/locks",
+      "name": "lock",
+      "scope": "[concat('Microsoft.Network/networkInterfaces/', 'nic')]",
+      "dependsOn": ["[resourceId('Microsoft.Network/networkInterfaces', 'nic')]"]
+    }
+  ]
+}`), &tpl))
+
+	deps := armDependencies{}
+	addARMTemplateDependencies(deps, armid.ResourceGroup{SubscriptionId: "123", Name: "rg"}, "", "", tpl.Resources)
+	require.Equal(t, armDependencies{
+		"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET/SUBNETS/SUBNET": {
+			"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg",
+		},
+		"/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/PROVIDERS/MICROSOFT.NETWORK/NETWORKINTERFACES/NIC": {
+			"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet",
+		},
+	}, deps)
+}
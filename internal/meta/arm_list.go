@@ -0,0 +1,172 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/magodo/armid"
+	"github.com/magodo/azlist/azlist"
+)
+
+// armListFunc lists the tracked resources via ARM, which is used in place of the Resource Graph query of the lister.
+type armListFunc func(ctx context.Context) ([]azlist.AzureResource, error)
+
+// listResources lists the resources via the lister, whose tracked resources are queried from Azure Resource Graph by the predicate.
+// In case Resource Graph is unavailable (e.g. not permitted, throttled or not supported by the cloud), the tracked resources are listed via ARM by armList instead,
+// followed by the same child resource and extension resource listing of the lister.
+// Note that the resources listed via ARM only have the top level properties (e.g. the "tags", "kind" and "managedBy").
+func (meta baseMeta) listResources(ctx context.Context, lister *azlist.Lister, predicate string, armList armListFunc) (*azlist.ListResult, error) {
+	result, err := lister.List(ctx, predicate)
+	if err == nil {
+		return result, nil
+	}
+	if !resourceGraphUnavailable(err) {
+		return nil, err
+	}
+	meta.Logger().Warn("Resource Graph is unavailable, fall back to listing via ARM", "error", err)
+
+	rl, err := armList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing via ARM: %w", err)
+	}
+	if !lister.IncludeManaged {
+		orl := rl
+		rl = []azlist.AzureResource{}
+		for _, res := range orl {
+			if v, ok := res.Properties["managedBy"]; ok && v != "" && v != nil {
+				meta.Logger().Debug("Removing managed resource", "id", res.Id.String(), "managed by", v)
+				continue
+			}
+			rl = append(rl, res)
+		}
+	}
+	sort.Slice(rl, func(i, j int) bool {
+		return rl[i].Id.String() < rl[j].Id.String()
+	})
+
+	var el []azlist.ListError
+	if lister.Recursive {
+		rl, el, err = lister.ListChildResource(ctx, rl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(lister.ExtensionResourceTypes) != 0 {
+		var extEl []azlist.ListError
+		rl, extEl, err = lister.ListExtensionResource(ctx, rl)
+		if err != nil {
+			return nil, err
+		}
+		el = append(el, extEl...)
+	}
+	return &azlist.ListResult{Resources: rl, Errors: el}, nil
+}
+
+// resourceGraphUnavailable tells whether the error of a Resource Graph query means Resource Graph is unavailable, where ARM can still be used instead.
+func resourceGraphUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		switch {
+		case respErr.StatusCode == http.StatusForbidden,
+			respErr.StatusCode == http.StatusNotFound,
+			respErr.StatusCode == http.StatusTooManyRequests,
+			respErr.StatusCode >= http.StatusInternalServerError:
+			return true
+		}
+		return false
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// armResources converts the resources listed via ARM to the azlist resources, whose properties are the JSON representation of the resources.
+func armResources[T json.Marshaler](l []T, getId func(T) *string) ([]azlist.AzureResource, error) {
+	var rl []azlist.AzureResource
+	for _, res := range l {
+		idp := getId(res)
+		if idp == nil {
+			continue
+		}
+		id, err := armid.ParseResourceId(*idp)
+		if err != nil {
+			return nil, fmt.Errorf("parsing resource id %s: %v", *idp, err)
+		}
+		b, err := res.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling resource %s: %v", *idp, err)
+		}
+		var props map[string]interface{}
+		if err := json.Unmarshal(b, &props); err != nil {
+			return nil, fmt.Errorf("unmarshalling resource %s: %v", *idp, err)
+		}
+		rl = append(rl, azlist.AzureResource{Id: id, Properties: props})
+	}
+	return rl, nil
+}
+
+// armListResourceGroups lists the resource groups of the subscription via ARM. Only the resource group of the name (case insensitive) is listed, if specified.
+func (meta baseMeta) armListResourceGroups(subscriptionId, name string) armListFunc {
+	return func(ctx context.Context) ([]azlist.AzureResource, error) {
+		client, err := armresources.NewResourceGroupsClient(subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+		if err != nil {
+			return nil, fmt.Errorf("building resource groups client: %v", err)
+		}
+		var l []*armresources.ResourceGroup
+		pager := client.NewListPager(nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("listing resource groups: %w", err)
+			}
+			for _, rg := range page.Value {
+				if name != "" && (rg.Name == nil || !strings.EqualFold(*rg.Name, name)) {
+					continue
+				}
+				l = append(l, rg)
+			}
+		}
+		return armResources(l, func(rg *armresources.ResourceGroup) *string { return rg.ID })
+	}
+}
+
+// armListResources lists the resources of the subscription via ARM. Only the resources within the resource group are listed, if specified.
+func (meta baseMeta) armListResources(subscriptionId, resourceGroup string) armListFunc {
+	return func(ctx context.Context) ([]azlist.AzureResource, error) {
+		client, err := armresources.NewClient(subscriptionId, meta.azureSDKCred, &meta.azureSDKClientOpt)
+		if err != nil {
+			return nil, fmt.Errorf("building resources client: %v", err)
+		}
+		var l []*armresources.GenericResourceExpanded
+		if resourceGroup != "" {
+			pager := client.NewListByResourceGroupPager(resourceGroup, nil)
+			for pager.More() {
+				page, err := pager.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("listing resources of resource group %s: %w", resourceGroup, err)
+				}
+				l = append(l, page.Value...)
+			}
+		} else {
+			pager := client.NewListPager(nil)
+			for pager.More() {
+				page, err := pager.NextPage(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("listing resources: %w", err)
+				}
+				l = append(l, page.Value...)
+			}
+		}
+		return armResources(l, func(res *armresources.GenericResourceExpanded) *string { return res.ID })
+	}
+}
@@ -0,0 +1,60 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceGraphUnavailable(t *testing.T) {
+	respErr := func(code int) error {
+		return fmt.Errorf("executing ARG query: %w", &azcore.ResponseError{StatusCode: code})
+	}
+	cases := []struct {
+		name string
+		err  error
+		fail bool
+	}{
+		{name: "forbidden", err: respErr(http.StatusForbidden), fail: true},
+		{name: "throttled", err: respErr(http.StatusTooManyRequests), fail: true},
+		{name: "server error", err: respErr(http.StatusServiceUnavailable), fail: true},
+		{name: "bad request", err: respErr(http.StatusBadRequest)},
+		{name: "unauthorized", err: respErr(http.StatusUnauthorized)},
+		{name: "dns error", err: fmt.Errorf("executing ARG query: %w", &net.DNSError{Name: "management.azure.com"}), fail: true},
+		{name: "canceled", err: fmt.Errorf("executing ARG query: %w", context.Canceled)},
+		{name: "other error", err: errors.New("foo")},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.fail, resourceGraphUnavailable(tt.err))
+		})
+	}
+}
+
+func TestARMResources(t *testing.T) {
+	id := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	l := []*armresources.GenericResourceExpanded{
+		{
+			ID:        ptr(id),
+			ManagedBy: ptr("foo"),
+			Tags:      map[string]*string{"env": ptr("test")},
+		},
+		{},
+	}
+	rl, err := armResources(l, func(res *armresources.GenericResourceExpanded) *string { return res.ID })
+	require.NoError(t, err)
+	require.Len(t, rl, 1)
+	require.Equal(t, id, rl[0].Id.String())
+	require.Equal(t, "foo", rl[0].Properties["managedBy"])
+	require.Equal(t, map[string]interface{}{"env": "test"}, rl[0].Properties["tags"])
+
+	_, err = armResources([]*armresources.GenericResourceExpanded{{ID: ptr("invalid")}}, func(res *armresources.GenericResourceExpanded) *string { return res.ID })
+	require.ErrorContains(t, err, "parsing resource id invalid")
+}
@@ -0,0 +1,82 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AzAPIResourceType is the TF resource type of the azapi provider that can represent any Azure resource.
+const AzAPIResourceType = "azapi_resource"
+
+const azapiAPIVersionQuery = "?api-version="
+
+// azapiProviderConfigKeys are the keys of the provider config that are shared between the azurerm and the azapi provider.
+// They are used to build the azapi provider block from the azurerm provider config, when both providers are used.
+var azapiProviderConfigKeys = []string{
+	"subscription_id",
+	"environment",
+	"tenant_id",
+	"auxiliary_tenant_ids",
+	"client_id",
+	"client_secret",
+	"client_certificate",
+	"client_certificate_password",
+	"oidc_request_token",
+	"oidc_request_url",
+	"oidc_token",
+	"use_msi",
+	"use_cli",
+	"use_oidc",
+}
+
+// isAzAPIResourceType tells whether the TF resource type belongs to the azapi provider.
+func isAzAPIResourceType(tfType string) bool {
+	return strings.HasPrefix(tfType, "azapi_")
+}
+
+// azapiImportId returns the import id of the azapi_resource, which pins the API version if specified.
+func azapiImportId(resourceId, apiVersion string) string {
+	if apiVersion == "" || strings.Contains(resourceId, azapiAPIVersionQuery) {
+		return resourceId
+	}
+	return resourceId + azapiAPIVersionQuery + apiVersion
+}
+
+// splitAzAPIImportId splits the import id of the azapi_resource into the resource id and the API version (empty if not pinned).
+func splitAzAPIImportId(importId string) (resourceId, apiVersion string) {
+	resourceId, apiVersion, _ = strings.Cut(importId, azapiAPIVersionQuery)
+	return resourceId, apiVersion
+}
+
+// fallbackToAzAPI maps the TF resources that have no azurerm resource type to the azapi_resource, whose import id is the Azure resource id (i.e. using the latest API version),
+// so that they are exported by the azapi provider (with the body read from the Azure resource), instead of being skipped.
+func (meta baseMeta) fallbackToAzAPI(rl []resourceset.TFResource) []resourceset.TFResource {
+	for i, res := range rl {
+		if res.TFType != "" {
+			continue
+		}
+		meta.Logger().Info("Fall back to the azapi provider for the resource that has no azurerm resource type", "id", res.AzureId)
+		rl[i].TFType = AzAPIResourceType
+		rl[i].TFId = res.AzureId.String()
+	}
+	return rl
+}
+
+// buildAzAPIProviderBlock builds the azapi provider block from the azurerm provider config, which is used when the azapi resources are exported together with the azurerm ones.
+// The secrets from the auth config are only included when withAuthSecrets is true.
+func (meta baseMeta) buildAzAPIProviderBlock(withAuthSecrets bool) *hclwrite.Block {
+	blk := hclwrite.NewBlock("provider", []string{"azapi"})
+	body := blk.Body()
+	for _, k := range azapiProviderConfigKeys {
+		v, ok := meta.providerConfig[k]
+		if !ok || (!withAuthSecrets && meta.authSecretKeys[k]) {
+			continue
+		}
+		body.SetAttributeValue(k, v)
+	}
+	body.SetAttributeValue("skip_provider_registration", cty.BoolVal(true))
+	return blk
+}
@@ -0,0 +1,108 @@
+package meta
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAzAPIImportId(t *testing.T) {
+	id := "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo"
+	require.Equal(t, id, azapiImportId(id, ""))
+	require.Equal(t, id+"?api-version=2024-01-01", azapiImportId(id, "2024-01-01"))
+	require.Equal(t, id+"?api-version=2023-01-01", azapiImportId(id+"?api-version=2023-01-01", "2024-01-01"))
+
+	resourceId, apiVersion := splitAzAPIImportId(id + "?api-version=2024-01-01")
+	require.Equal(t, id, resourceId)
+	require.Equal(t, "2024-01-01", apiVersion)
+	resourceId, apiVersion = splitAzAPIImportId(id)
+	require.Equal(t, id, resourceId)
+	require.Empty(t, apiVersion)
+}
+
+func TestFallbackToAzAPI(t *testing.T) {
+	vnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+	fooId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo")
+	require.NoError(t, err)
+
+	meta := baseMeta{logger: slog.Default()}
+	rl := meta.fallbackToAzAPI([]resourceset.TFResource{
+		{AzureId: vnetId, TFId: vnetId.String(), TFType: "azurerm_virtual_network"},
+		{AzureId: fooId, TFId: fooId.String()},
+	})
+	require.Equal(t, []resourceset.TFResource{
+		{AzureId: vnetId, TFId: vnetId.String(), TFType: "azurerm_virtual_network"},
+		{AzureId: fooId, TFId: fooId.String(), TFType: AzAPIResourceType},
+	}, rl)
+}
+
+func TestBuildProviderConfigWithAzAPI(t *testing.T) {
+	meta := baseMeta{
+		providerName: "azurerm",
+		withAzAPI:    true,
+		providerConfig: map[string]cty.Value{
+			"subscription_id":                 cty.StringVal("123"),
+			"client_secret":                   cty.StringVal("secret"),
+			"resource_provider_registrations": cty.StringVal("none"),
+		},
+		authSecretKeys: map[string]bool{
+			"client_secret": true,
+		},
+	}
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(meta.buildAzAPIProviderBlock(false))
+	require.Equal(t, `provider "azapi" {
+  subscription_id            = "123"
+  skip_provider_registration = true
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	f = hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(meta.buildAzAPIProviderBlock(true))
+	require.Equal(t, `provider "azapi" {
+  subscription_id            = "123"
+  client_secret              = "secret"
+  skip_provider_registration = true
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	cfg := meta.buildProviderConfig(false)
+	require.Contains(t, cfg, `provider "azurerm" {`)
+	require.Contains(t, cfg, `provider "azapi" {`)
+	require.Contains(t, meta.buildTerraformConfig(""), `source = "azure/azapi"`)
+}
+
+func TestExportResourceMappingAzAPI(t *testing.T) {
+	azureId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo")
+	require.NoError(t, err)
+	l := ImportList{
+		{
+			AzureResourceID: azureId,
+			TFResourceId:    azureId.String() + "?api-version=2024-01-01",
+			TFAddr:          tfaddr.TFAddr{Type: AzAPIResourceType, Name: "foo"},
+		},
+	}
+
+	meta := baseMeta{outdir: t.TempDir()}
+	require.NoError(t, meta.ExportResourceMapping(context.Background(), l))
+	m, err := resmap.Load(filepath.Join(meta.outdir, ResourceMappingFileName))
+	require.NoError(t, err)
+	require.Equal(t, resmap.ResourceMapping{
+		azureId.String(): {
+			ResourceId:   azureId.String(),
+			ResourceType: AzAPIResourceType,
+			ResourceName: "foo",
+			APIVersion:   "2024-01-01",
+		},
+	}, m)
+}
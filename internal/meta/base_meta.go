@@ -8,6 +8,8 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,11 +20,13 @@ import (
 
 	"github.com/Azure/aztfexport/internal/client"
 	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/utils"
 	"github.com/Azure/aztfexport/pkg/telemetry"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
@@ -58,21 +62,39 @@ type BaseMeta interface {
 	DeInit(ctx context.Context) error
 	// Workspace returns the path of the output directory.
 	Workspace() string
+	// ReadState reads the state of the output directory without initializing it, which is meant to be used instead of Init when nothing is going to be imported (e.g. in dry-run mode),
+	// so that the resources already managed in the state are skipped when listing the resources. It returns false if the state can't be read without initializing (e.g. a remote backend).
+	ReadState(ctx context.Context) (bool, error)
 	// ParallelImport imports the specified import list in parallel (parallelism is set during the meta builder function).
 	// Import error won't be returned in the error, but is recorded in each ImportItem.
 	ParallelImport(ctx context.Context, items []*ImportItem) error
 	// PushState pushes the terraform state file (the base state of the workspace, adding the newly imported resources) back to the workspace.
 	PushState(ctx context.Context) error
+	// StateBackupFile returns the path of the backup of the workspace's state prior to the import, which is taken during the Init. Empty means there is no backup (e.g. the state is empty).
+	StateBackupFile() string
+	// RollbackState rolls back the workspace's state to the backup, which is meant to be used when the export fails halfway (e.g. the state is pushed incrementally).
+	// This is a no-op if there is no backup, or the state isn't changed.
+	RollbackState(ctx context.Context) error
 	// CleanTFState clean up the specified TF resource from the workspace's state file.
 	CleanTFState(ctx context.Context, addr string)
 	// GenerateCfg generates the TF configuration of the import list. Only resources successfully imported will be processed.
 	GenerateCfg(ctx context.Context, l ImportList) error
+	// PreviewCfg returns the TF configuration that would be generated for the import item, by reading the resource without importing it into the workspace's state.
+	PreviewCfg(ctx context.Context, item ImportItem) (string, error)
 	// ExportSkippedResources writes a file listing record resources that are skipped to be imported to the output directory.
 	ExportSkippedResources(ctx context.Context, l ImportList) error
 	// ExportResourceMapping writes a resource mapping file to the output directory.
 	ExportResourceMapping(ctx context.Context, l ImportList) error
+	// SaveSession writes a session file to the output directory, which records the import list and the state of the resources imported so far.
+	SaveSession(ctx context.Context, l ImportList) error
+	// LoadSession reads the session file from the output directory, restores the state of the resources imported so far, and returns the recorded import list.
+	// This must be called after Init.
+	LoadSession(ctx context.Context) (ImportList, error)
+	// LoadFailedImports reads the session file from the output directory, and returns the import list of the failed imports recorded in it (if any), so that they can be retried.
+	// This must be called after Init.
+	LoadFailedImports(ctx context.Context) (ImportList, error)
 	// CleanUpWorkspace is a weired method that is only meant to be used internally by aztfexport, which under the hood will remove everything in the output directory, except the generated TF config.
-	// This method does nothing if HCLOnly in the Config is not set.
+	// The session file (unless it records failed imports) and the state backup (if any) are always removed, apart from that, this method does nothing if HCLOnly in the Config is not set.
 	CleanUpWorkspace(ctx context.Context) error
 
 	SetPreImportHook(config.ImportCallback)
@@ -95,7 +117,29 @@ type baseMeta struct {
 	providerName      string
 	backendType       string
 	backendConfig     []string
+	backendBlock      map[string]string
+	incrementalPush   bool
+	// The Terraform Cloud (or Enterprise) workspace used for state, which is written to the cloud block of the generated terraform block
+	cloudHostname     string
+	cloudOrganization string
+	cloudWorkspace    string
+	// Whether to upload the generated config as a configuration version of the cloud workspace
+	cloudUploadConfig bool
+	pinVersions       bool
+	verify            bool
+	sourceMetadata    bool
+	jsonSyntax        bool
+	cdktfLanguage     string
 	providerConfig    map[string]cty.Value
+	// The Terraform version constraint of the generated terraform block, which is set when pinning the versions
+	requiredVersion string
+	// The keys of the provider config that are secrets from the auth config
+	authSecretKeys map[string]bool
+	// The Azure DevOps service connection id used for OIDC authentication, which is passed to the provider via environment variables
+	oidcServiceConnectionId string
+	// The partner id and the user agent suffix, which are passed to the provider via environment variables
+	partnerId       string
+	userAgentSuffix string
 
 	// tfadd options
 	fullConfig    bool
@@ -105,9 +149,68 @@ type baseMeta struct {
 	preImportHook      config.ImportCallback
 	postImportHook     config.ImportCallback
 	generateImportFile bool
-
-	hclOnly  bool
-	tfclient tfclient.Client
+	// Whether to import the items of each round via a single plan with the import blocks, instead of importing them one by one
+	importViaPlan bool
+	// The timeout of importing each item, 0 means no timeout
+	importTimeout time.Duration
+	// Whether to rename the resources with conflicting addresses, instead of reporting the conflicts as an error
+	resolveAddressConflict bool
+	// The optional resolver of the TF resource type, which takes precedence over aztft
+	typeResolver config.TypeResolver
+	// Whether the azapi provider is used together with the azurerm provider, for the azapi resources specified in the mapping file
+	withAzAPI bool
+	// How the generated resource configs are laid out in files
+	outputLayout string
+	// Whether to lift the recurring literal values of the generated resources into variables
+	variableExtraction bool
+	// Whether to replace the sensitive attribute values of the generated resources by sensitive variables
+	sensitiveExtraction bool
+	// Whether to generate the outputs of the key attributes of the generated resources
+	outputGeneration bool
+	// Whether to lift the tags shared by the generated resources into a local value
+	commonTags bool
+	// Whether to add the dependencies declared in the exported ARM templates of the resource groups
+	armDependency bool
+	// Whether to export the resources that have no azurerm resource type mapped as azapi_resource
+	azapiFallback bool
+	// Whether to write the coverage report of the resources that are not imported
+	coverageReport bool
+	// The resources that are filtered out (by the type filter, the name filter or the skip filter) during listing, which are recorded in the coverage report
+	filteredResources []resourceset.TFResource
+	// The dependencies declared in the exported ARM templates, which are listed during the config generation
+	armDependencies armDependencies
+	// The cache of the Azure call results between runs, nil if not enabled
+	cache *resourceCache
+	// Whether to only process the resources that are neither managed in the state nor recorded in the inventory of the former delta runs
+	delta bool
+
+	// The terraform binary to use, and the version it is required to be (otherwise, that version is looked up or downloaded unless offline)
+	tfBin     string
+	tfVersion string
+	tfOffline bool
+	// The provider mirror (a network mirror URL, or a filesystem mirror directory) to install the providers from, and the temporary terraform CLI config file that uses it
+	providerMirror string
+	cliConfigFile  string
+
+	hclOnly bool
+	// Whether to only import the resources to the state, without generating the config
+	stateOnly bool
+	// The existing state file used as the base state, empty means the state of the output directory is used
+	stateFile string
+	tfclient  tfclient.Client
+
+	// The filter of the exported resources by their types
+	typeFilter typeFilter
+	// The filter of the exported resources by their Azure resource names, nil means no filter
+	nameFilter *regexp.Regexp
+	// The filter of the resources that are always excluded by their Azure resource ids
+	skipFilter skipFilter
+	// The rules of the attributes to ignore changes for the generated resources
+	lifecycleRules lifecycleRules
+	// The user supplied HCL of the provider blocks, which is validated
+	providerBlocks string
+	// The user supplied templates that override the generated config of the resources by their TF resource types
+	overrideTemplates overrideTemplates
 
 	// The module address prefix in the resource addr. E.g. module.mod1.module.mod2.azurerm_resource_group.test.
 	// This is an empty string if module path is not specified.
@@ -115,6 +218,12 @@ type baseMeta struct {
 	// The module directory in the fs where the terraform config should be generated to. This does not necessarily have the same structure as moduleAddr.
 	// This is the same as the outdir if module path is not specified.
 	moduleDir string
+	// Whether the resources are generated as a child module, which is scaffolded during the init
+	asModule bool
+	// Whether to generate the terragrunt config, which makes the output directory a terragrunt unit
+	terragrunt bool
+	// The environment of the exported resources, which is the Terraform workspace of the output directory, empty means the default workspace is used
+	environment string
 
 	// Parallel import supports
 	importBaseDirs   []string
@@ -124,8 +233,13 @@ type baseMeta struct {
 	// The original base state, which is retrieved prior to the import, and is compared with the actual base state prior to the mutated state is pushed,
 	// to ensure the base state has no out of band changes during the importing.
 	originBaseState []byte
+	// The state pushed by the last round in incremental push mode, which replaces the origin base state in the out of band changes check.
+	// The origin base state is kept as is, which is what the state is rolled back to on failure.
+	pushedState []byte
 	// The current base state, which is mutated during the importing
 	baseState []byte
+	// The backup file of the origin base state, which is used to roll back the state on failure
+	stateBackupFile string
 
 	tc telemetry.Client
 }
@@ -134,11 +248,112 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	if cfg.Parallelism == 0 {
 		return nil, fmt.Errorf("Parallelism not set in the config")
 	}
+	if cfg.TerraformVersion != "" {
+		if _, err := version.NewVersion(cfg.TerraformVersion); err != nil {
+			return nil, fmt.Errorf("invalid TerraformVersion in the config: %v", err)
+		}
+	}
+	if cfg.ProviderMirror != "" && cfg.DevProvider {
+		return nil, fmt.Errorf("ProviderMirror conflicts with DevProvider in the config")
+	}
 	if cfg.ProviderVersion != "" && cfg.DevProvider {
 		return nil, fmt.Errorf("ProviderVersion conflicts with DevProvider in the config")
 	}
-	if cfg.TFClient != nil && !cfg.HCLOnly {
-		return nil, fmt.Errorf("TFClient must be used together with HCLOnly")
+	if cfg.CloudOrganization != "" || cfg.CloudWorkspace != "" {
+		if cfg.CloudOrganization == "" || cfg.CloudWorkspace == "" {
+			return nil, fmt.Errorf("CloudOrganization and CloudWorkspace must be specified together in the config")
+		}
+		if cfg.BackendType != CloudBackendType {
+			return nil, fmt.Errorf("CloudOrganization and CloudWorkspace require the BackendType to be %q in the config", CloudBackendType)
+		}
+		// The plan runs remotely in the cloud workspace, which can't be saved for the verification. The workspace is determined by the cloud block, rather than selected.
+		if cfg.Verify || cfg.Environment != "" {
+			return nil, fmt.Errorf("the cloud workspace conflicts with Verify and Environment in the config")
+		}
+	}
+	if cfg.CloudUploadConfig && cfg.CloudOrganization == "" {
+		return nil, fmt.Errorf("CloudUploadConfig requires CloudOrganization and CloudWorkspace in the config")
+	}
+	if cfg.StateOnly && (cfg.HCLOnly || cfg.AsModule || cfg.Terragrunt || cfg.GenerateImportBlock) {
+		return nil, fmt.Errorf("StateOnly conflicts with HCLOnly, AsModule, Terragrunt and GenerateImportBlock in the config")
+	}
+	if cfg.ImportViaPlan && (cfg.AsModule || cfg.ModulePath != "" || cfg.TFClient != nil) {
+		return nil, fmt.Errorf("ImportViaPlan conflicts with AsModule, ModulePath and TFClient in the config")
+	}
+	if cfg.StateFile != "" && cfg.HCLOnly {
+		return nil, fmt.Errorf("StateFile conflicts with HCLOnly in the config")
+	}
+	if cfg.IncrementalPush && cfg.HCLOnly {
+		return nil, fmt.Errorf("IncrementalPush conflicts with HCLOnly in the config")
+	}
+	if cfg.AzAPIFallback {
+		if cfg.ProviderName == "azapi" {
+			return nil, fmt.Errorf("AzAPIFallback only applies to the azurerm provider in the config")
+		}
+		if cfg.TFClient != nil {
+			return nil, fmt.Errorf("AzAPIFallback can't be used together with TFClient in the config")
+		}
+	}
+	if cfg.Environment != "" {
+		if !environmentNamePattern.MatchString(cfg.Environment) {
+			return nil, fmt.Errorf("invalid Environment %q in the config", cfg.Environment)
+		}
+		if cfg.HCLOnly || cfg.AsModule || cfg.ModulePath != "" || cfg.Terragrunt || cfg.ExtractSensitive || cfg.CommonTags || cfg.GenerateImportBlock {
+			return nil, fmt.Errorf("Environment conflicts with HCLOnly, AsModule, ModulePath, Terragrunt, ExtractSensitive, CommonTags and GenerateImportBlock in the config")
+		}
+	}
+	switch cfg.OutputLayout {
+	case "", config.OutputLayoutSingle, config.OutputLayoutResource, config.OutputLayoutType, config.OutputLayoutService:
+	default:
+		return nil, fmt.Errorf("unknown OutputLayout %q in the config", cfg.OutputLayout)
+	}
+
+	var nameFilter *regexp.Regexp
+	if cfg.NameFilter != "" {
+		var err error
+		nameFilter, err = regexp.Compile(cfg.NameFilter)
+		if err != nil {
+			return nil, fmt.Errorf("compiling the name filter %q: %v", cfg.NameFilter, err)
+		}
+	}
+
+	skipFilter, err := newSkipFilter(cfg.SkipResources)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycleRules, err := newLifecycleRules(cfg.LifecycleRules)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CDKTFLanguage != "" {
+		if _, ok := cdktfLanguageExts[cfg.CDKTFLanguage]; !ok {
+			return nil, fmt.Errorf("unsupported CDKTFLanguage %q in the config", cfg.CDKTFLanguage)
+		}
+	}
+
+	overrideTemplates, err := newOverrideTemplates(cfg.OverrideTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	var cache *resourceCache
+	if cfg.Cache {
+		cache, err = newResourceCache()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.ProviderBlocks != "" {
+		providerNames := []string{cfg.ProviderName}
+		if cfg.ProviderName != "azapi" {
+			providerNames = append(providerNames, "azapi")
+		}
+		if err := validateProviderBlocks(cfg.ProviderBlocks, providerNames...); err != nil {
+			return nil, err
+		}
 	}
 
 	// Determine the module directory and module address
@@ -146,6 +361,16 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		moduleAddr string
 		moduleDir  = cfg.OutputDir
 	)
+	if cfg.Terragrunt && (cfg.AsModule || cfg.ModulePath != "") {
+		return nil, fmt.Errorf("Terragrunt conflicts with AsModule and ModulePath in the config")
+	}
+	if cfg.AsModule {
+		if cfg.ModulePath != "" {
+			return nil, fmt.Errorf("AsModule conflicts with ModulePath in the config")
+		}
+		moduleAddr = "module." + ChildModuleName
+		moduleDir = filepath.Join(cfg.OutputDir, ChildModuleSource)
+	}
 	if cfg.ModulePath != "" {
 		modulePaths := strings.Split(cfg.ModulePath, ".")
 
@@ -186,6 +411,15 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	if outputFileNames.ImportBlockFileName == "" {
 		outputFileNames.ImportBlockFileName = "import.tf"
 	}
+	if outputFileNames.VariableFileName == "" {
+		outputFileNames.VariableFileName = "variables.tf"
+	}
+	if outputFileNames.OutputFileName == "" {
+		outputFileNames.OutputFileName = "outputs.tf"
+	}
+	if outputFileNames.LocalsFileName == "" {
+		outputFileNames.LocalsFileName = "locals.tf"
+	}
 
 	tc := cfg.TelemetryClient
 	if tc == nil {
@@ -213,6 +447,15 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		}
 	}
 
+	// The secrets from the auth config are only used for importing, they are not written to the provider config in the output directory.
+	authSecretKeys := map[string]bool{}
+	setSecretIfNoExist := func(k string, v cty.Value) {
+		if _, ok := providerConfig[k]; !ok {
+			providerConfig[k] = v
+			authSecretKeys[k] = true
+		}
+	}
+
 	// Update provider config for auth config
 	if cfg.SubscriptionId != "" {
 		setIfNoExist("subscription_id", cty.StringVal(cfg.SubscriptionId))
@@ -220,6 +463,9 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 	if v := cfg.AuthConfig.Environment; v != "" {
 		setIfNoExist("environment", cty.StringVal(v))
 	}
+	if v := cfg.AuthConfig.MetadataHost; v != "" {
+		setIfNoExist("metadata_host", cty.StringVal(v))
+	}
 	if v := cfg.AuthConfig.TenantID; v != "" {
 		setIfNoExist("tenant_id", cty.StringVal(v))
 	}
@@ -236,22 +482,22 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		setIfNoExist("client_id", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.ClientSecret; v != "" {
-		setIfNoExist("client_secret", cty.StringVal(v))
+		setSecretIfNoExist("client_secret", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.ClientCertificateEncoded; v != "" {
-		setIfNoExist("client_certificate", cty.StringVal(v))
+		setSecretIfNoExist("client_certificate", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.ClientCertificatePassword; v != "" {
-		setIfNoExist("client_certificate_password", cty.StringVal(v))
+		setSecretIfNoExist("client_certificate_password", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.OIDCTokenRequestToken; v != "" {
-		setIfNoExist("oidc_request_token", cty.StringVal(v))
+		setSecretIfNoExist("oidc_request_token", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.OIDCTokenRequestURL; v != "" {
 		setIfNoExist("oidc_request_url", cty.StringVal(v))
 	}
 	if v := cfg.AuthConfig.OIDCAssertionToken; v != "" {
-		setIfNoExist("oidc_token", cty.StringVal(v))
+		setSecretIfNoExist("oidc_token", cty.StringVal(v))
 	}
 	setIfNoExist("use_msi", cty.BoolVal(cfg.AuthConfig.UseManagedIdentity))
 	setIfNoExist("use_cli", cty.BoolVal(cfg.AuthConfig.UseAzureCLI))
@@ -275,8 +521,24 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		resourceClient:     resClient,
 		providerVersion:    cfg.ProviderVersion,
 		devProvider:        cfg.DevProvider,
+		tfBin:              cfg.TerraformBinary,
+		tfVersion:          cfg.TerraformVersion,
+		tfOffline:          cfg.TerraformOffline,
+		providerMirror:     cfg.ProviderMirror,
 		backendType:        cfg.BackendType,
 		backendConfig:      cfg.BackendConfig,
+		backendBlock:       cfg.BackendBlock,
+		incrementalPush:    cfg.IncrementalPush,
+		cloudHostname:      cfg.CloudHostname,
+		cloudOrganization:  cfg.CloudOrganization,
+		cloudWorkspace:     cfg.CloudWorkspace,
+		cloudUploadConfig:  cfg.CloudUploadConfig,
+		pinVersions:        cfg.PinVersions,
+		verify:             cfg.Verify,
+		sourceMetadata:     cfg.SourceMetadata,
+		jsonSyntax:         cfg.JSONSyntax,
+		cdktfLanguage:      cfg.CDKTFLanguage,
+		authSecretKeys:     authSecretKeys,
 		providerConfig:     providerConfig,
 		providerName:       cfg.ProviderName,
 		fullConfig:         cfg.FullConfig,
@@ -285,11 +547,44 @@ func NewBaseMeta(cfg config.CommonConfig) (*baseMeta, error) {
 		preImportHook:      cfg.PreImportHook,
 		postImportHook:     cfg.PostImportHook,
 		generateImportFile: cfg.GenerateImportBlock,
+		importViaPlan:      cfg.ImportViaPlan,
+		importTimeout:      cfg.ImportTimeout,
 		hclOnly:            cfg.HCLOnly,
+		stateOnly:          cfg.StateOnly,
+		stateFile:          cfg.StateFile,
 		tfclient:           cfg.TFClient,
-
-		moduleAddr: moduleAddr,
-		moduleDir:  moduleDir,
+		typeFilter:         newTypeFilter(cfg.IncludeTypes, cfg.ExcludeTypes),
+		nameFilter:         nameFilter,
+		skipFilter:         skipFilter,
+		lifecycleRules:     lifecycleRules,
+		providerBlocks:     cfg.ProviderBlocks,
+		overrideTemplates:  overrideTemplates,
+
+		moduleAddr:  moduleAddr,
+		moduleDir:   moduleDir,
+		asModule:    cfg.AsModule,
+		terragrunt:  cfg.Terragrunt,
+		environment: cfg.Environment,
+
+		resolveAddressConflict: cfg.ResolveAddressConflict,
+		typeResolver:           cfg.TypeResolver,
+		outputLayout:           cfg.OutputLayout,
+		variableExtraction:     cfg.ExtractVariables || cfg.AsModule || cfg.Terragrunt || cfg.Environment != "",
+		sensitiveExtraction:    cfg.ExtractSensitive,
+		outputGeneration:       cfg.GenerateOutputs || cfg.AsModule,
+		commonTags:             cfg.CommonTags,
+		armDependency:          cfg.ARMDependency,
+		azapiFallback:          cfg.AzAPIFallback,
+		coverageReport:         cfg.CoverageReport,
+		cache:                  cache,
+		delta:                  cfg.Delta,
+
+		// Whether any resource falls back to the azapi provider is only known after listing (i.e. after the initialization), hence it is always used then.
+		withAzAPI: cfg.AzAPIFallback,
+
+		oidcServiceConnectionId: cfg.AuthConfig.OIDCAzureServiceConnectionID,
+		partnerId:               cfg.PartnerId,
+		userAgentSuffix:         cfg.UserAgentSuffix,
 
 		tc: tc,
 	}
@@ -313,7 +608,19 @@ func (meta *baseMeta) Init(ctx context.Context) error {
 	meta.tc.Trace(telemetry.Info, "Init Enter")
 	defer meta.tc.Trace(telemetry.Info, "Init Leave")
 
+	if meta.asModule {
+		if err := meta.initChildModule(); err != nil {
+			return err
+		}
+	}
+
 	if meta.tfclient != nil {
+		// The terraform binary is still used to manage the state of the output directory, unless in hcl only mode.
+		if !meta.hclOnly {
+			if err := meta.init_tf(ctx); err != nil {
+				return err
+			}
+		}
 		return meta.init_notf(ctx)
 	}
 
@@ -325,6 +632,11 @@ func (meta baseMeta) DeInit(ctx context.Context) error {
 	defer meta.tc.Trace(telemetry.Info, "DeInit Leave")
 
 	if meta.tfclient != nil {
+		if !meta.hclOnly {
+			if err := meta.deinit_tf(ctx); err != nil {
+				return err
+			}
+		}
 		return meta.deinit_notf(ctx)
 	}
 
@@ -345,6 +657,15 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 	meta.tc.Trace(telemetry.Info, "ParallelImport Enter")
 	defer meta.tc.Trace(telemetry.Info, "ParallelImport Leave")
 
+	// Import the items via a single plan first, which falls back to importing them one by one on failure.
+	if meta.importViaPlan && meta.tfclient == nil {
+		if err := meta.importItemsViaPlan(ctx, items); err != nil {
+			meta.Logger().Warn("Failed to import via plan, fall back to importing one by one", "error", err)
+		} else {
+			items = nil
+		}
+	}
+
 	total := len(items)
 	itemsCh := make(chan *ImportItem, total)
 	for _, item := range items {
@@ -409,15 +730,46 @@ func (meta *baseMeta) ParallelImport(ctx context.Context, items []*ImportItem) e
 		return err
 	}
 
+	// The states imported via the tfclient are only held in memory by the import items, which are merged to the base state unless in hcl only mode.
+	if meta.tfclient != nil && !meta.hclOnly {
+		if err := meta.mergeImportedState(ctx, items); err != nil {
+			return err
+		}
+	}
+
+	// Push the merged state of this round to the backend, which then becomes the base state of the next round.
+	if meta.incrementalPush {
+		if err := meta.PushState(ctx); err != nil {
+			return fmt.Errorf("pushing the state of this round: %v", err)
+		}
+		baseState, err := meta.tf.StatePull(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to pull state: %v", err)
+		}
+		meta.baseState = []byte(baseState)
+		meta.pushedState = []byte(baseState)
+		if err := meta.backupEmptyState(meta.pushedState); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// lastPushedState returns the state that the backend is expected to hold, i.e. the state pushed by the last round in incremental push mode, or the origin base state otherwise.
+func (meta baseMeta) lastPushedState() []byte {
+	if meta.pushedState != nil {
+		return meta.pushedState
+	}
+	return meta.originBaseState
+}
+
 func (meta baseMeta) PushState(ctx context.Context) error {
 	meta.tc.Trace(telemetry.Info, "PushState Enter")
 	defer meta.tc.Trace(telemetry.Info, "PushState Leave")
 
-	// Noop if tfclient is set
-	if meta.tfclient != nil {
+	// Noop if tfclient is set in hcl only mode
+	if meta.tfclient != nil && meta.hclOnly {
 		return nil
 	}
 
@@ -425,15 +777,20 @@ func (meta baseMeta) PushState(ctx context.Context) error {
 	if len(meta.baseState) == 0 {
 		return nil
 	}
+	// Don't push state if nothing is imported since the last push (e.g. the state is pushed incrementally).
+	if meta.incrementalPush && bytes.Equal(meta.baseState, meta.lastPushedState()) {
+		return nil
+	}
 
 	// Ensure there is no out of band change on the base state
 	baseState, err := meta.tf.StatePull(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to pull state: %v", err)
 	}
-	if baseState != string(meta.originBaseState) {
-		edits := myers.ComputeEdits(span.URIFromPath("origin.tfstate"), string(meta.originBaseState), baseState)
-		changes := fmt.Sprint(gotextdiff.ToUnified("origin.tfstate", "current.tfstate", string(meta.originBaseState), edits))
+	expectState := string(meta.lastPushedState())
+	if baseState != expectState {
+		edits := myers.ComputeEdits(span.URIFromPath("origin.tfstate"), expectState, baseState)
+		changes := fmt.Sprint(gotextdiff.ToUnified("origin.tfstate", "current.tfstate", expectState, edits))
 		return fmt.Errorf("there is out-of-band changes on the state file:\n%s", changes)
 	}
 
@@ -462,7 +819,97 @@ func (meta baseMeta) PushState(ctx context.Context) error {
 func (meta baseMeta) GenerateCfg(ctx context.Context, l ImportList) error {
 	meta.tc.Trace(telemetry.Info, "GenerateCfg Enter")
 	defer meta.tc.Trace(telemetry.Info, "GenerateCfg Leave")
-	return meta.generateCfg(ctx, l, meta.lifecycleAddon, meta.addDependency)
+	// The config is maintained by the users in the state-only mode, only the report of the resources not imported is written.
+	if meta.stateOnly {
+		meta.Logger().Info("Skip generating the config in the state-only mode")
+		if meta.coverageReport {
+			if err := meta.exportCoverageReport(l); err != nil {
+				return err
+			}
+		}
+		if meta.delta {
+			if err := meta.exportInventory(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if meta.armDependency {
+		meta.Logger().Info("List the dependencies from the ARM templates")
+		meta.armDependencies = meta.listARMDependencies(ctx, l.Imported())
+	}
+	cfgTrans := []TFConfigTransformer{meta.lifecycleAddon, meta.removeEmbeddedResource, meta.addDependency, meta.addProviderAlias}
+	// The common tags are consolidated ahead of the variable extraction, so that the consolidated tags aren't extracted as variables again.
+	if meta.commonTags {
+		cfgTrans = append(cfgTrans, meta.consolidateCommonTags)
+	}
+	if meta.sensitiveExtraction {
+		cfgTrans = append(cfgTrans, meta.extractSensitiveVariables)
+	}
+	if meta.variableExtraction {
+		cfgTrans = append(cfgTrans, meta.extractVariables)
+	}
+	// The resources shared with the other environments are removed after the variable extraction, so that their values are still written to the variable definitions file of this environment.
+	if meta.environment != "" {
+		cfgTrans = append(cfgTrans, meta.removeSharedResources)
+	}
+	if meta.outputGeneration {
+		cfgTrans = append(cfgTrans, meta.generateOutputs)
+	}
+	// The override templates are applied at last, as the rendered config isn't necessarily a single resource block anymore.
+	if len(meta.overrideTemplates) != 0 {
+		cfgTrans = append(cfgTrans, meta.applyOverrideTemplates)
+	}
+	if err := meta.generateCfg(ctx, l, cfgTrans...); err != nil {
+		return err
+	}
+	// Regenerate the "import" blocks for only the resources that are imported, as the others have no config generated.
+	if meta.generateImportFile {
+		if err := meta.exportImportBlocks(l.Imported()); err != nil {
+			return err
+		}
+	}
+	if meta.coverageReport {
+		if err := meta.exportCoverageReport(l); err != nil {
+			return err
+		}
+	}
+	if meta.delta {
+		if err := meta.exportInventory(l); err != nil {
+			return err
+		}
+	}
+	if meta.environment != "" {
+		if err := meta.exportEnvironmentResources(l); err != nil {
+			return err
+		}
+	}
+	if meta.terragrunt {
+		if err := meta.generateTerragruntConfig(); err != nil {
+			return fmt.Errorf("generating the terragrunt config: %w", err)
+		}
+	}
+	if meta.cdktfLanguage != "" {
+		if err := meta.convertToCDKTF(); err != nil {
+			return fmt.Errorf("converting the config to CDK for Terraform: %w", err)
+		}
+	}
+	if meta.jsonSyntax {
+		if err := meta.convertToJSONSyntax(); err != nil {
+			return fmt.Errorf("converting the config to JSON syntax: %w", err)
+		}
+	}
+	if meta.verify {
+		if err := meta.verifyCfg(ctx); err != nil {
+			return err
+		}
+	}
+	if meta.cloudUploadConfig {
+		if err := meta.uploadCloudConfig(ctx); err != nil {
+			return fmt.Errorf("uploading the config to the cloud workspace: %w", err)
+		}
+	}
+	return nil
 }
 
 func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) error {
@@ -473,11 +920,15 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 		}
 
 		// The JSON mapping record
-		m[item.AzureResourceID.String()] = resmap.ResourceMapEntity{
+		entity := resmap.ResourceMapEntity{
 			ResourceId:   item.TFResourceId,
 			ResourceType: item.TFAddr.Type,
 			ResourceName: item.TFAddr.Name,
 		}
+		if item.TFAddr.Type == AzAPIResourceType {
+			entity.ResourceId, entity.APIVersion = splitAzAPIImportId(item.TFResourceId)
+		}
+		m[item.AzureResourceID.String()] = entity
 	}
 	b, err := json.MarshalIndent(m, "", "\t")
 	if err != nil {
@@ -490,26 +941,51 @@ func (meta baseMeta) ExportResourceMapping(ctx context.Context, l ImportList) er
 	}
 
 	if meta.generateImportFile {
-		f := hclwrite.NewFile()
-		body := f.Body()
-		for _, item := range l {
-			if item.Skip() {
-				continue
-			}
+		if err := meta.exportImportBlocks(l); err != nil {
+			return err
+		}
+	}
 
-			// The import block
-			blk := hclwrite.NewBlock("import", nil)
-			blk.Body().SetAttributeValue("id", cty.StringVal(item.TFResourceId))
-			blk.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}})
-			body.AppendBlock(blk)
+	return nil
+}
+
+// exportImportBlocks writes the "import" blocks of the non-skipped resources to the import block file.
+// The file is written to the root module (i.e. the output directory), as Terraform only allows "import" blocks in the root module.
+func (meta baseMeta) exportImportBlocks(l ImportList) error {
+	f := hclwrite.NewFile()
+	body := f.Body()
+	for _, item := range l {
+		if item.Skip() {
+			continue
 		}
-		oImportFile := filepath.Join(meta.moduleDir, meta.outputFileNames.ImportBlockFileName)
-		// #nosec G306
-		if err := os.WriteFile(oImportFile, f.Bytes(), 0644); err != nil {
-			return fmt.Errorf("writing the import block to %s: %v", oImportFile, err)
+
+		to := hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}}
+		if meta.moduleAddr != "" {
+			to = nil
+			for i, seg := range strings.Split(meta.moduleAddr, ".") {
+				if i == 0 {
+					to = append(to, hcl.TraverseRoot{Name: seg})
+					continue
+				}
+				to = append(to, hcl.TraverseAttr{Name: seg})
+			}
+			to = append(to, hcl.TraverseAttr{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name})
 		}
-	}
 
+		blk := hclwrite.NewBlock("import", nil)
+		blk.Body().SetAttributeValue("id", cty.StringVal(item.TFResourceId))
+		blk.Body().SetAttributeTraversal("to", to)
+		// The aliased provider is defined in the module directory, which can only be referred to by the "import" block when it is the root module.
+		if alias, _ := meta.providerAlias(item.AzureResourceID, item.TFAddr.Type); alias != "" && meta.moduleAddr == "" {
+			blk.Body().SetAttributeTraversal("provider", meta.providerAliasTraversal(alias))
+		}
+		body.AppendBlock(blk)
+	}
+	oImportFile := filepath.Join(meta.outdir, meta.outputFileNames.ImportBlockFileName)
+	// #nosec G306
+	if err := os.WriteFile(oImportFile, f.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing the import block to %s: %v", oImportFile, err)
+	}
 	return nil
 }
 
@@ -536,6 +1012,23 @@ func (meta baseMeta) ExportSkippedResources(_ context.Context, l ImportList) err
 }
 
 func (meta baseMeta) CleanUpWorkspace(_ context.Context) error {
+	// The session file is kept if there are failed imports (i.e. continued on error), so that they can be retried later.
+	failed, err := meta.hasFailedImports()
+	if err != nil {
+		return err
+	}
+	if !failed {
+		if err := os.RemoveAll(filepath.Join(meta.outdir, SessionFileName)); err != nil {
+			return err
+		}
+	}
+	// The state backup is only kept when the export fails
+	if meta.stateBackupFile != "" {
+		if err := os.Remove(meta.stateBackupFile); err != nil {
+			return err
+		}
+	}
+
 	// For hcl only mode with using terraform binary, we will have to clean up the state and terraform cli/provider related files the output directory,
 	// except for the TF code, resource mapping file and ignore list file.
 	if meta.hclOnly && meta.tfclient == nil {
@@ -578,50 +1071,135 @@ func (meta *baseMeta) useAzAPI() bool {
 	return meta.providerName == "azapi"
 }
 
+// providerSource returns the source address of the provider used to export the resources.
+func (meta *baseMeta) providerSource() string {
+	if meta.useAzAPI() {
+		return "azure/azapi"
+	}
+	return "hashicorp/azurerm"
+}
+
 func (meta *baseMeta) buildTerraformConfig(backendType string) string {
 	backendLine := ""
-	if backendType != "" {
-		backendLine = "\n  backend \"" + backendType + "\" {}\n"
+	if backendType == CloudBackendType {
+		backendLine = meta.buildCloudBlockLines()
+	} else if backendType != "" {
+		backendLine = "\n  backend \"" + backendType + "\" {" + meta.buildBackendBlockLines() + "}\n"
 	}
 
-	providerName := meta.providerName
-
-	providerSource := "hashicorp/azurerm"
-	if meta.useAzAPI() {
-		providerSource = "azure/azapi"
+	requiredVersionLine := ""
+	if meta.requiredVersion != "" {
+		requiredVersionLine = "\n  required_version = \"" + meta.requiredVersion + "\"\n"
 	}
 
+	providerName := meta.providerName
+	providerSource := meta.providerSource()
+
 	providerVersionLine := ""
 	if meta.providerVersion != "" {
 		providerVersionLine = "\n      version = \"" + meta.providerVersion + "\"\n"
 	}
 
-	return fmt.Sprintf(`terraform {%s
+	// The azapi provider used together with the azurerm provider is pinned to the version that the config generation is based on.
+	azapiProviderLines := ""
+	if meta.withAzAPI {
+		azapiProviderLines = fmt.Sprintf(`
+    azapi = {
+      source = "azure/azapi"
+      version = %q
+    }`, azapi.ProviderSchemaInfo.Version)
+	}
+
+	return fmt.Sprintf(`terraform {%s%s
   required_providers {
     %s = {
       source = %q%s
-    }
+    }%s
   }
 }
-`, backendLine, providerName, providerSource, providerVersionLine)
+`, backendLine, requiredVersionLine, providerName, providerSource, providerVersionLine, azapiProviderLines)
+}
+
+// buildBackendBlockLines builds the attribute lines of the backend block from the backend block config, in the order of the keys.
+func (meta *baseMeta) buildBackendBlockLines() string {
+	if len(meta.backendBlock) == 0 {
+		return ""
+	}
+	var keys []string
+	for k := range meta.backendBlock {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := "\n"
+	for _, k := range keys {
+		lines += fmt.Sprintf("    %s = %s\n", k, hclwrite.TokensForValue(cty.StringVal(meta.backendBlock[k])).Bytes())
+	}
+	return lines + "  "
 }
 
-func (meta *baseMeta) buildProviderConfig() string {
+// buildProviderConfig builds the provider config. The secrets from the auth config are only included when withAuthSecrets is true.
+// The provider config written to the output directory shouldn't include the secrets, the provider is expected to read them from the environment variables (e.g. ARM_CLIENT_SECRET) instead.
+func (meta *baseMeta) buildProviderConfig(withAuthSecrets bool) string {
 	f := hclwrite.NewEmptyFile()
 
-	var body *hclwrite.Body
+	// The user supplied provider blocks take precedence, while the attributes that are not defined by them are still populated.
+	userBlocks, userOtherBlocks := meta.userProviderBlocks()
+
+	providerName := "azurerm"
 	if meta.useAzAPI() {
-		body = f.Body().AppendNewBlock("provider", []string{"azapi"}).Body()
+		providerName = "azapi"
+	}
+	var body *hclwrite.Body
+	if blk, ok := userBlocks[providerName]; ok {
+		body = f.Body().AppendBlock(blk).Body()
+		delete(userBlocks, providerName)
 	} else {
-		body = f.Body().AppendNewBlock("provider", []string{"azurerm"}).Body()
+		body = f.Body().AppendNewBlock("provider", []string{providerName}).Body()
+	}
+	if !meta.useAzAPI() && body.FirstMatchingBlock("features", nil) == nil {
 		body.AppendNewBlock("features", nil)
 	}
-	for k, v := range meta.providerConfig {
-		body.SetAttributeValue(k, v)
+	for _, k := range meta.providerConfigKeys() {
+		if !withAuthSecrets && meta.authSecretKeys[k] {
+			continue
+		}
+		if body.GetAttribute(k) != nil {
+			continue
+		}
+		body.SetAttributeValue(k, meta.providerConfig[k])
+	}
+	if meta.withAzAPI {
+		f.Body().AppendNewline()
+		if blk, ok := userBlocks["azapi"]; ok {
+			f.Body().AppendBlock(blk)
+			delete(userBlocks, "azapi")
+		} else {
+			f.Body().AppendBlock(meta.buildAzAPIProviderBlock(withAuthSecrets))
+		}
+	}
+	// The remaining default blocks are not used by the resources (e.g. the azapi provider block when no azapi resource is exported), but still kept as is.
+	for _, name := range []string{"azurerm", "azapi"} {
+		if blk, ok := userBlocks[name]; ok {
+			userOtherBlocks = append([]*hclwrite.Block{blk}, userOtherBlocks...)
+		}
+	}
+	for _, blk := range userOtherBlocks {
+		f.Body().AppendNewline()
+		f.Body().AppendBlock(blk)
 	}
 	return string(f.Bytes())
 }
 
+// providerConfigKeys returns the keys of the provider config in alphabetical order, so that the generated provider blocks are the same across runs.
+func (meta baseMeta) providerConfigKeys() []string {
+	var keys []string
+	for k := range meta.providerConfig {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (meta *baseMeta) init_notf(ctx context.Context) error {
 	schResp, diags := meta.tfclient.GetProviderSchema()
 	if diags.HasErrors() {
@@ -664,11 +1242,35 @@ func (meta *baseMeta) init_tf(ctx context.Context) error {
 	// AzureRM provider will honor env.var "AZURE_HTTP_USER_AGENT" when constructing for HTTP "User-Agent" header.
 	// The setting for notf version is done during the tfclient initialization in main function.
 	// #nosec G104
-	os.Setenv("AZURE_HTTP_USER_AGENT", meta.azureSDKClientOpt.Telemetry.ApplicationID)
+	os.Setenv("AZURE_HTTP_USER_AGENT", strings.TrimSpace(meta.azureSDKClientOpt.Telemetry.ApplicationID+" "+meta.userAgentSuffix))
 
-	// Create the import directories per parallelism
-	if err := meta.initImportDirs(); err != nil {
-		return err
+	// The providers append the partner id to the HTTP "User-Agent" header by themselves.
+	// The setting for notf version is done during the tfclient initialization in main function.
+	if v := meta.partnerId; v != "" {
+		// #nosec G104
+		os.Setenv("ARM_PARTNER_ID", v)
+	}
+
+	// The providers read the Azure DevOps service connection id for OIDC authentication from the environment variables.
+	// The setting for notf version is done during the tfclient initialization in main function.
+	if v := meta.oidcServiceConnectionId; v != "" {
+		// #nosec G104
+		os.Setenv("ARM_OIDC_AZURE_SERVICE_CONNECTION_ID", v)
+		// #nosec G104
+		os.Setenv("ARM_ADO_PIPELINE_SERVICE_CONNECTION_ID", v)
+	}
+
+	if meta.providerMirror != "" {
+		if err := meta.initProviderMirror(); err != nil {
+			return err
+		}
+	}
+
+	// Create the import directories per parallelism, which are not needed when importing via the tfclient
+	if meta.tfclient == nil {
+		if err := meta.initImportDirs(); err != nil {
+			return err
+		}
 	}
 
 	// Init terraform
@@ -688,6 +1290,19 @@ func (meta *baseMeta) init_tf(ctx context.Context) error {
 	}
 	meta.baseState = []byte(baseState)
 	meta.originBaseState = []byte(baseState)
+	if err := meta.backupState(); err != nil {
+		return err
+	}
+
+	// The base state is replaced by the state file, while the origin is kept as the current state, which is checked against prior to pushing.
+	if meta.stateFile != "" {
+		meta.Logger().Info("Use the state file as the base state", "file", meta.stateFile)
+		b, err := baseStateFromFile(meta.stateFile, meta.originBaseState)
+		if err != nil {
+			return err
+		}
+		meta.baseState = b
+	}
 
 	return nil
 }
@@ -737,7 +1352,15 @@ func (meta *baseMeta) initImportDirs() error {
 
 func (meta *baseMeta) initTF(ctx context.Context) error {
 	meta.Logger().Info("Init Terraform")
-	execPath, err := FindTerraform(ctx)
+	var installDir string
+	if meta.tfVersion != "" {
+		var err error
+		installDir, err = terraformInstallDir(meta.tfVersion)
+		if err != nil {
+			return err
+		}
+	}
+	execPath, err := EnsureTerraform(ctx, meta.tfBin, meta.tfVersion, installDir, meta.tfOffline)
 	if err != nil {
 		return fmt.Errorf("error finding a terraform exectuable: %w", err)
 	}
@@ -776,6 +1399,19 @@ func (meta *baseMeta) initTF(ctx context.Context) error {
 	return nil
 }
 
+// hasRequiredProviders tells whether the module requires all the providers used to export the resources.
+func (meta *baseMeta) hasRequiredProviders(module *tfconfig.Module) bool {
+	if _, ok := module.RequiredProviders[meta.providerName]; !ok {
+		return false
+	}
+	if meta.withAzAPI {
+		if _, ok := module.RequiredProviders["azapi"]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
 func (meta *baseMeta) initProvider(ctx context.Context) error {
 	meta.Logger().Info("Init provider")
 
@@ -793,9 +1429,18 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 		meta.Logger().Info("Output directory doesn't contain provider setting, create one then")
 		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)
 		// #nosec G306
-		if err := os.WriteFile(cfgFile, []byte(meta.buildProviderConfig()), 0644); err != nil {
+		if err := os.WriteFile(cfgFile, []byte(meta.buildProviderConfig(false)), 0644); err != nil {
 			return fmt.Errorf("error creating provider config: %w", err)
 		}
+	} else if meta.withAzAPI && module.ProviderConfigs["azapi"] == nil {
+		meta.Logger().Info("Output directory doesn't contain the azapi provider setting, append one then")
+		f := hclwrite.NewEmptyFile()
+		f.Body().AppendNewline()
+		f.Body().AppendBlock(meta.buildAzAPIProviderBlock(false))
+		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.ProviderFileName)
+		if err := appendToFile(cfgFile, string(f.Bytes())); err != nil {
+			return fmt.Errorf("error appending the azapi provider config: %w", err)
+		}
 	}
 
 	if tfblock == nil {
@@ -805,6 +1450,19 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 		if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig(meta.backendType)), 0644); err != nil {
 			return fmt.Errorf("error creating terraform config: %w", err)
 		}
+	} else if !meta.hasRequiredProviders(module) {
+		// The existing terraform block (e.g. in append mode) doesn't require the provider, add another terraform block that only requires the provider.
+		// Otherwise, Terraform will infer the provider source as "hashicorp/<provider name>", which is not correct for azapi.
+		cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.TerraformFileName)
+		if _, err := os.Stat(cfgFile); err == nil {
+			meta.Logger().Warn("Output directory doesn't contain the required provider setting, while the terraform config file already exists", "provider", meta.providerName, "file", cfgFile)
+		} else {
+			meta.Logger().Info("Output directory doesn't contain the required provider setting, create one then", "provider", meta.providerName)
+			// #nosec G306
+			if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig("")), 0644); err != nil {
+				return fmt.Errorf("error creating terraform config: %w", err)
+			}
+		}
 	}
 
 	// Initialize provider for the output directory.
@@ -813,20 +1471,67 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 		opts = append(opts, tfexec.BackendConfig(opt))
 	}
 
-	meta.Logger().Debug(`Run "terraform init" for the output directory`, "dir", meta.outdir)
-	if err := meta.tf.Init(ctx, opts...); err != nil {
-		return fmt.Errorf("error running terraform init for the output directory: %s", err)
+	// The output directory initialized by a former run (e.g. in append mode) is not initialized again.
+	initialized := false
+	if tfblock != nil {
+		initialized, err = meta.outputDirInitialized()
+		if err != nil {
+			return err
+		}
+	}
+	if initialized {
+		meta.Logger().Debug(`Skip running "terraform init" for the output directory (already initialized)`, "dir", meta.outdir)
+	} else {
+		meta.Logger().Debug(`Run "terraform init" for the output directory`, "dir", meta.outdir)
+		if err := meta.tf.Init(ctx, opts...); err != nil {
+			return fmt.Errorf("error running terraform init for the output directory: %s", err)
+		}
+	}
+
+	if meta.environment != "" {
+		if err := meta.selectWorkspace(ctx); err != nil {
+			return err
+		}
+	}
+
+	if meta.pinVersions {
+		if tfblock != nil {
+			meta.Logger().Warn("Skip pinning the versions as the output directory contains terraform block already")
+		} else if err := meta.pinTerraformConfig(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Initialize provider for the import directories.
-	wp := workerpool.NewWorkPool(meta.parallelism)
+	// The plugin cache directory is not concurrency safe, the providers are installed one directory at a time then, which are cached by the init of the output directory above anyway.
+	cacheDir, err := pluginCacheDir()
+	if err != nil {
+		return err
+	}
+	initParallelism := meta.parallelism
+	if cacheDir != "" {
+		meta.Logger().Info("Use the plugin cache directory", "dir", cacheDir)
+		initParallelism = 1
+	}
+
+	// The import directories share the providers initialized once in the user cache directory, except for the module path, which requires the modules to be initialized.
+	var sharedDir string
+	if !meta.devProvider && meta.moduleAddr == "" && len(meta.importBaseDirs) != 0 {
+		sharedDir, err = meta.ensureSharedProviderDir(ctx)
+		if err != nil {
+			meta.Logger().Warn("Failed to initialize the shared provider directory, initialize the import directories instead", "error", err)
+			sharedDir = ""
+		}
+	}
+
+	wp := workerpool.NewWorkPool(initParallelism)
 	wp.Run(nil)
 	for i := range meta.importBaseDirs {
 		i := i
 		wp.AddTask(func() (interface{}, error) {
 			providerFile := filepath.Join(meta.importBaseDirs[i], "provider.tf")
 			// #nosec G306
-			if err := os.WriteFile(providerFile, []byte(meta.buildProviderConfig()), 0644); err != nil {
+			if err := os.WriteFile(providerFile, []byte(meta.buildProviderConfig(true)), 0644); err != nil {
 				return nil, fmt.Errorf("error creating provider config: %w", err)
 			}
 			terraformFile := filepath.Join(meta.importBaseDirs[i], "terraform.tf")
@@ -836,11 +1541,19 @@ func (meta *baseMeta) initProvider(ctx context.Context) error {
 			}
 			if meta.devProvider {
 				meta.Logger().Debug(`Skip running "terraform init" for the import directory (dev provider)`, "dir", meta.importBaseDirs[i])
-			} else {
-				meta.Logger().Debug(`Run "terraform init" for the import directory`, "dir", meta.importBaseDirs[i])
-				if err := meta.importTFs[i].Init(ctx); err != nil {
-					return nil, fmt.Errorf("error running terraform init: %s", err)
+				return nil, nil
+			}
+			if sharedDir != "" {
+				err := linkSharedProviderDir(sharedDir, meta.importBaseDirs[i])
+				if err == nil {
+					meta.Logger().Debug(`Skip running "terraform init" for the import directory (shared provider directory)`, "dir", meta.importBaseDirs[i], "shared", sharedDir)
+					return nil, nil
 				}
+				meta.Logger().Warn("Failed to link the shared provider directory", "dir", meta.importBaseDirs[i], "error", err)
+			}
+			meta.Logger().Debug(`Run "terraform init" for the import directory`, "dir", meta.importBaseDirs[i])
+			if err := meta.importTFs[i].Init(ctx); err != nil {
+				return nil, fmt.Errorf("error running terraform init: %s", err)
 			}
 			return nil, nil
 		})
@@ -858,6 +1571,20 @@ func (meta *baseMeta) importItem(ctx context.Context, item *ImportItem, importId
 		return
 	}
 
+	if meta.importTimeout > 0 {
+		parentCtx := ctx
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, meta.importTimeout)
+		defer cancel()
+		defer func() {
+			// Only the timeout of this import is annotated, rather than the cancellation of the whole run.
+			if item.ImportError != nil && ctx.Err() == context.DeadlineExceeded && parentCtx.Err() == nil {
+				meta.Logger().Error("Importing a resource timed out", "tf_addr", item.TFAddr, "timeout", meta.importTimeout)
+				item.ImportError = fmt.Errorf("importing timed out after %s: %w", meta.importTimeout, item.ImportError)
+			}
+		}()
+	}
+
 	if meta.tfclient != nil {
 		meta.importItem_notf(ctx, item, importIdx)
 		return
@@ -873,6 +1600,13 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 	// Construct the empty cfg file for importing
 	cfgFile := filepath.Join(moduleDir, "tmp.aztfexport.tf")
 	tpl := fmt.Sprintf(`resource "%s" "%s" {}`, item.TFAddr.Type, item.TFAddr.Name)
+	// Resources reside in other subscriptions are imported by the aliased provider targeting to that subscription.
+	if alias, subscriptionId := meta.providerAlias(item.AzureResourceID, item.TFAddr.Type); alias != "" {
+		f := hclwrite.NewEmptyFile()
+		f.Body().AppendBlock(meta.buildAliasProviderBlock(alias, subscriptionId, true))
+		f.Body().AppendBlock(hclwrite.NewBlock("resource", []string{item.TFAddr.Type, item.TFAddr.Name})).Body().SetAttributeTraversal("provider", meta.providerAliasTraversal(alias))
+		tpl = string(f.Bytes())
+	}
 	// #nosec G306
 	if err := os.WriteFile(cfgFile, []byte(tpl), 0644); err != nil {
 		err := fmt.Errorf("generating resource template file for %s: %w", item.TFAddr, err)
@@ -902,9 +1636,21 @@ func (meta *baseMeta) importItem_tf(ctx context.Context, item *ImportItem, impor
 	}
 	item.ImportError = err
 	item.Imported = err == nil
+	if item.Imported {
+		item.ImportedAt = time.Now()
+	}
 }
 
 func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, importIdx int) {
+	// The provider plugin is configured for the subscription specified for the tool only, which can't read the resources residing in other subscriptions.
+	if _, subscriptionId := meta.providerAlias(item.AzureResourceID, item.TFAddr.Type); subscriptionId != "" {
+		err := fmt.Errorf("importing the resource in another subscription (%s) via the provider plugin is not supported, export it without `--tfclient-plugin-path` instead", subscriptionId)
+		meta.Logger().Error("Terraform import failed", "tf_addr", item.TFAddr, "error", err)
+		item.ImportError = err
+		item.Imported = false
+		return
+	}
+
 	// Import resources
 	addr := item.TFAddr.String()
 	meta.Logger().Debug("Importing a resource", "tf_id", item.TFResourceId, "tf_addr", addr)
@@ -957,8 +1703,10 @@ func (meta *baseMeta) importItem_notf(ctx context.Context, item *ImportItem, imp
 
 	meta.Logger().Debug("Finish importing a resource", "tf_id", item.TFResourceId, "tf_addr", addr)
 	item.State = readResp.NewState
+	item.StatePrivate = readResp.Private
 	item.ImportError = nil
 	item.Imported = true
+	item.ImportedAt = time.Now()
 	return
 }
 
@@ -1044,16 +1792,45 @@ func (meta baseMeta) terraformMetaHook(configs ConfigInfos, cfgTrans ...TFConfig
 }
 
 func (meta baseMeta) generateConfig(cfgs ConfigInfos) error {
-	cfgFile := filepath.Join(meta.moduleDir, meta.outputFileNames.MainFileName)
-	buf := bytes.NewBuffer([]byte{})
+	mainFileName := meta.outputFileNames.MainFileName
+	bufs := map[string]*bytes.Buffer{
+		mainFileName: bytes.NewBuffer([]byte{}),
+	}
+	fileNames := []string{mainFileName}
+
+	// Define the aliased providers for the resources reside in other subscriptions, if not defined yet.
+	aliasProviderCfg, err := meta.buildAliasProviderConfig(cfgs)
+	if err != nil {
+		return err
+	}
+	bufs[mainFileName].WriteString(aliasProviderCfg)
+
 	for _, cfg := range cfgs {
+		fileName := meta.configFileName(cfg.ImportItem)
+		buf, ok := bufs[fileName]
+		if !ok {
+			buf = bytes.NewBuffer([]byte{})
+			bufs[fileName] = buf
+			fileNames = append(fileNames, fileName)
+		}
+		if meta.sourceMetadata {
+			buf.WriteString(sourceMetadataComment(cfg.ImportItem))
+		}
 		if _, err := cfg.DumpHCL(buf); err != nil {
 			return err
 		}
 		buf.Write([]byte("\n"))
 	}
-	if err := appendToFile(cfgFile, buf.String()); err != nil {
-		return fmt.Errorf("generating main configuration file: %w", err)
+
+	for _, fileName := range fileNames {
+		buf := bufs[fileName]
+		// The main file is always written in the single layout, otherwise it is only written when there are aliased providers.
+		if fileName == mainFileName && buf.Len() == 0 && len(fileNames) != 1 {
+			continue
+		}
+		if err := appendToFile(filepath.Join(meta.moduleDir, fileName), buf.String()); err != nil {
+			return fmt.Errorf("generating configuration file %s: %w", fileName, err)
+		}
 	}
 
 	return nil
@@ -1079,10 +1856,54 @@ func (meta baseMeta) cleanupTerraformAdd(tpl string) string {
 func (meta baseMeta) lifecycleAddon(configs ConfigInfos) (ConfigInfos, error) {
 	out := make(ConfigInfos, len(configs))
 	for i, cfg := range configs {
+		var ignoreChanges []string
 		switch cfg.TFAddr.Type {
 		case "azurerm_application_insights_web_test":
-			if err := hclBlockAppendLifecycle(cfg.hcl.Body().Blocks()[0].Body(), []string{"tags"}); err != nil {
-				return nil, fmt.Errorf("azurerm_application_insights_web_test: %v", err)
+			ignoreChanges = []string{"tags"}
+		}
+		ignoreChanges = appendIgnoreChanges(ignoreChanges, meta.lifecycleRules.IgnoreChanges(cfg.TFAddr.Type)...)
+		if err := hclBlockAppendLifecycle(cfg.hcl.Body().Blocks()[0].Body(), ignoreChanges); err != nil {
+			return nil, fmt.Errorf("%s: %v", cfg.TFAddr, err)
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+// removeEmbeddedResource removes the embedded child resource settings from the parent resource, in case the child resources are also exported as separate resources.
+// Otherwise, the parent resource and the child resources will conflict with each other.
+func (meta baseMeta) removeEmbeddedResource(configs ConfigInfos) (ConfigInfos, error) {
+	// The key is the Azure resource id (in upper case) of the parent resource, the value is the set of the embedded settings to remove.
+	parents := map[string]map[string]bool{}
+	for _, cfg := range configs {
+		switch cfg.TFAddr.Type {
+		case "azurerm_key_vault_access_policy":
+			parent := cfg.AzureResourceID.Parent()
+			if parent == nil {
+				continue
+			}
+			pid := strings.ToUpper(parent.String())
+			if parents[pid] == nil {
+				parents[pid] = map[string]bool{}
+			}
+			parents[pid]["access_policy"] = true
+		}
+	}
+	if len(parents) == 0 {
+		return configs, nil
+	}
+
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		if names, ok := parents[strings.ToUpper(cfg.AzureResourceID.String())]; ok {
+			body := cfg.hcl.Body().Blocks()[0].Body()
+			for name := range names {
+				body.RemoveAttribute(name)
+				for _, blk := range body.Blocks() {
+					if blk.Type() == name {
+						body.RemoveBlock(blk)
+					}
+				}
 			}
 		}
 		out[i] = cfg
@@ -1091,7 +1912,10 @@ func (meta baseMeta) lifecycleAddon(configs ConfigInfos) (ConfigInfos, error) {
 }
 
 func (meta baseMeta) addDependency(configs ConfigInfos) (ConfigInfos, error) {
-	if err := configs.AddDependency(); err != nil {
+	if err := configs.AddDependency(meta.armDependencies); err != nil {
+		return nil, err
+	}
+	if err := configs.ResolveReferences(); err != nil {
 		return nil, err
 	}
 
@@ -1125,6 +1949,10 @@ func (meta *baseMeta) deinit_tf(ctx context.Context) error {
 		// #nosec G104
 		os.RemoveAll(dir)
 	}
+	if meta.cliConfigFile != "" {
+		// #nosec G104
+		os.Remove(meta.cliConfigFile)
+	}
 	return nil
 }
 
@@ -0,0 +1,136 @@
+package meta
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/telemetry"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/magodo/terraform-client-go/tfclient"
+	"github.com/magodo/terraform-client-go/tfclient/typ"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestBuildTerraformConfigWithBackendBlock(t *testing.T) {
+	meta := baseMeta{
+		providerName: "azurerm",
+		backendBlock: map[string]string{
+			"storage_account_name": "sa",
+			"container_name":       "tfstate",
+			"key":                  "prod.terraform.tfstate",
+			"resource_group_name":  "rg",
+		},
+	}
+	require.Equal(t, `terraform {
+  backend "azurerm" {
+    container_name = "tfstate"
+    key = "prod.terraform.tfstate"
+    resource_group_name = "rg"
+    storage_account_name = "sa"
+  }
+
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+    }
+  }
+}
+`, meta.buildTerraformConfig("azurerm"))
+
+	// The backend block config doesn't apply to the terraform block without backend
+	require.NotContains(t, meta.buildTerraformConfig(""), "backend")
+}
+
+func TestBuildProviderConfigIsDeterministic(t *testing.T) {
+	meta := baseMeta{
+		providerName: "azurerm",
+		providerConfig: map[string]cty.Value{
+			"subscription_id":      cty.StringVal("123"),
+			"tenant_id":            cty.StringVal("456"),
+			"environment":          cty.StringVal("public"),
+			"use_cli":              cty.True,
+			"use_msi":              cty.False,
+			"use_oidc":             cty.False,
+			"auxiliary_tenant_ids": cty.ListValEmpty(cty.String),
+		},
+	}
+	expect := `provider "azurerm" {
+  features {
+  }
+  auxiliary_tenant_ids = []
+  environment          = "public"
+  subscription_id      = "123"
+  tenant_id            = "456"
+  use_cli              = true
+  use_msi              = false
+  use_oidc             = false
+}
+`
+	// The map iteration order is random, hence it is built for several times
+	for i := 0; i < 10; i++ {
+		require.Equal(t, expect, string(hclwrite.Format([]byte(meta.buildProviderConfig(false)))))
+	}
+}
+
+func TestGenerateCfgStateOnly(t *testing.T) {
+	dir := t.TempDir()
+	meta := baseMeta{
+		logger:         slog.Default(),
+		tc:             telemetry.NewNullClient(),
+		outdir:         dir,
+		moduleDir:      dir,
+		stateOnly:      true,
+		coverageReport: true,
+	}
+	require.NoError(t, meta.GenerateCfg(context.Background(), nil))
+
+	// Only the coverage report is written
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, CoverageReportFileName, entries[0].Name())
+}
+
+// stuckClient is a tfclient whose import never finishes until the context is done.
+type stuckClient struct {
+	tfclient.Client
+}
+
+func (stuckClient) ImportResourceState(ctx context.Context, _ typ.ImportResourceStateRequest) (*typ.ImportResourceStateResponse, typ.Diagnostics) {
+	<-ctx.Done()
+	return nil, typ.RPCErrorDiagnostics(ctx.Err())
+}
+
+func TestImportItemTimeout(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg")
+	require.NoError(t, err)
+	meta := baseMeta{
+		logger:        slog.Default(),
+		tc:            telemetry.NewNullClient(),
+		tfclient:      stuckClient{},
+		importTimeout: 10 * time.Millisecond,
+	}
+	item := &ImportItem{
+		AzureResourceID: id,
+		TFResourceId:    id.String(),
+		TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"},
+	}
+	meta.importItem(context.Background(), item, 0)
+	require.False(t, item.Imported)
+	require.ErrorContains(t, item.ImportError, "importing timed out after 10ms")
+
+	// The cancellation of the whole run is not regarded as the timeout of the import
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	meta.importTimeout = time.Hour
+	item.ImportError = nil
+	meta.importItem(ctx, item, 0)
+	require.Error(t, item.ImportError)
+	require.NotContains(t, item.ImportError.Error(), "importing timed out")
+}
@@ -0,0 +1,71 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CDKTFDirName is the directory in the output directory where the CDK for Terraform code converted from the generated config is written to.
+const CDKTFDirName = "cdktf"
+
+// cdktfLanguageExts maps the CDK for Terraform languages supported by "cdktf convert" to their source file extensions.
+var cdktfLanguageExts = map[string]string{
+	"typescript": ".ts",
+	"python":     ".py",
+	"csharp":     ".cs",
+	"java":       ".java",
+	"go":         ".go",
+}
+
+// convertToCDKTF converts the generated config of the output directory (i.e. the root module) to the CDK for Terraform code by "cdktf convert",
+// which is written to the "main" file of the language in the CDKTF directory. The "cdktf" CLI is expected to be installed.
+func (meta baseMeta) convertToCDKTF() error {
+	ext, ok := cdktfLanguageExts[meta.cdktfLanguage]
+	if !ok {
+		return fmt.Errorf("unsupported CDK for Terraform language %q", meta.cdktfLanguage)
+	}
+	paths, err := filepath.Glob(filepath.Join(meta.outdir, "*.tf"))
+	if err != nil {
+		return err
+	}
+	var input bytes.Buffer
+	for _, path := range paths {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %v", path, err)
+		}
+		input.Write(b)
+		input.WriteString("\n")
+	}
+
+	provider := meta.providerSource()
+	if meta.providerVersion != "" {
+		provider += "@" + meta.providerVersion
+	}
+	var stdout, stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.Command("cdktf", "convert", "--language", meta.cdktfLanguage, "--provider", provider)
+	cmd.Stdin = &input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	meta.Logger().Info("Convert the generated config to CDK for Terraform", "language", meta.cdktfLanguage)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running cdktf convert: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	dir := filepath.Join(meta.outdir, CDKTFDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating the CDK for Terraform directory %s: %v", dir, err)
+	}
+	output := filepath.Join(dir, "main"+ext)
+	// #nosec G306
+	if err := os.WriteFile(output, stdout.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing the CDK for Terraform code to %s: %v", output, err)
+	}
+	return nil
+}
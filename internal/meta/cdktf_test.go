@@ -0,0 +1,40 @@
+package meta
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertToCDKTF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake cdktf CLI is a shell script")
+	}
+	// The fake cdktf CLI outputs its arguments and the input config
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "cdktf"), []byte("#!/bin/sh\necho \"// $*\"\ncat\n"), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azurerm_resource_group" "rg" {}
+`), 0644))
+	meta := baseMeta{
+		logger:          slog.Default(),
+		outdir:          dir,
+		providerName:    "azurerm",
+		providerVersion: "3.99.0",
+		cdktfLanguage:   "typescript",
+	}
+	require.NoError(t, meta.convertToCDKTF())
+
+	b, err := os.ReadFile(filepath.Join(dir, CDKTFDirName, "main.ts"))
+	require.NoError(t, err)
+	require.Equal(t, `// convert --language typescript --provider hashicorp/azurerm@3.99.0
+resource "azurerm_resource_group" "rg" {}
+
+`, string(b))
+}
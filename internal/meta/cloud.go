@@ -0,0 +1,247 @@
+package meta
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// CloudBackendType is the pseudo backend type of the cloud block, which integrates with a Terraform Cloud (or Enterprise) workspace.
+const CloudBackendType = "cloud"
+
+// DefaultCloudHostname is the hostname of Terraform Cloud, which is used when the cloud hostname isn't specified.
+const DefaultCloudHostname = "app.terraform.io"
+
+// cloudConfigFileExts are the extensions of the files in the output directory that are uploaded as the configuration version.
+var cloudConfigFileExts = []string{".tf", ".tf.json", ".tfvars", ".tfvars.json", ".lock.hcl"}
+
+// buildCloudBlockLines builds the cloud block of the generated terraform block, which uses the workspace of the organization (on the hostname, if specified) for state.
+func (meta *baseMeta) buildCloudBlockLines() string {
+	lines := "\n  cloud {\n"
+	if meta.cloudHostname != "" {
+		lines += fmt.Sprintf("    hostname = %s\n", hclwrite.TokensForValue(cty.StringVal(meta.cloudHostname)).Bytes())
+	}
+	lines += fmt.Sprintf("    organization = %s\n", hclwrite.TokensForValue(cty.StringVal(meta.cloudOrganization)).Bytes())
+	lines += fmt.Sprintf("    workspaces {\n      name = %s\n    }\n", hclwrite.TokensForValue(cty.StringVal(meta.cloudWorkspace)).Bytes())
+	return lines + "  }\n"
+}
+
+// uploadCloudConfig uploads the generated config in the output directory as a new configuration version of the cloud workspace. The configuration version doesn't queue a run,
+// so that the run can be started after reviewing the config.
+func (meta baseMeta) uploadCloudConfig(ctx context.Context) error {
+	hostname := meta.cloudHostname
+	if hostname == "" {
+		hostname = DefaultCloudHostname
+	}
+	token, err := cloudToken(hostname)
+	if err != nil {
+		return err
+	}
+	archive, err := archiveCloudConfig(meta.outdir)
+	if err != nil {
+		return fmt.Errorf("archiving the config: %v", err)
+	}
+	meta.Logger().Info("Upload the config as a configuration version", "organization", meta.cloudOrganization, "workspace", meta.cloudWorkspace)
+	// The configured transport (e.g. the one trusting the CA bundle) is used the same as the Azure API calls.
+	var client policy.Transporter = http.DefaultClient
+	if tr := meta.azureSDKClientOpt.Transport; tr != nil {
+		client = tr
+	}
+	id, err := uploadConfigurationVersion(ctx, client, "https://"+hostname, token, meta.cloudOrganization, meta.cloudWorkspace, archive)
+	if err != nil {
+		return fmt.Errorf("uploading the configuration version: %v", err)
+	}
+	meta.Logger().Info("The configuration version is uploaded", "id", id)
+	return nil
+}
+
+// cloudToken returns the API token of the hostname, which is read from the "TF_TOKEN_<hostname>" environment variable, or the credentials file written by "terraform login".
+func cloudToken(hostname string) (string, error) {
+	envName := "TF_TOKEN_" + strings.NewReplacer(".", "_", "-", "__").Replace(hostname)
+	if v := os.Getenv(envName); v != "" {
+		return v, nil
+	}
+
+	var dir string
+	if runtime.GOOS == "windows" {
+		d, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(d, "terraform.d")
+	} else {
+		d, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(d, ".terraform.d")
+	}
+	credFile := filepath.Join(dir, "credentials.tfrc.json")
+	// #nosec G304
+	b, err := os.ReadFile(credFile)
+	if err != nil {
+		return "", fmt.Errorf("no API token found for %s in neither the environment variable %s nor the credentials file %s (run `terraform login` first)", hostname, envName, credFile)
+	}
+	var creds struct {
+		Credentials map[string]struct {
+			Token string `json:"token"`
+		} `json:"credentials"`
+	}
+	if err := json.Unmarshal(b, &creds); err != nil {
+		return "", fmt.Errorf("unmarshalling the credentials file %s: %v", credFile, err)
+	}
+	if cred, ok := creds.Credentials[hostname]; ok && cred.Token != "" {
+		return cred.Token, nil
+	}
+	return "", fmt.Errorf("no API token found for %s in the credentials file %s (run `terraform login` first)", hostname, credFile)
+}
+
+// archiveCloudConfig archives the config files (see cloudConfigFileExts) in the directory (recursively, except the ".terraform" directories) as a tar.gz.
+func archiveCloudConfig(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	if err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".terraform" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || !hasCloudConfigFileExt(info.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0644, Size: int64(len(b)), ModTime: info.ModTime()}); err != nil {
+			return err
+		}
+		_, err = tw.Write(b)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hasCloudConfigFileExt(name string) bool {
+	for _, ext := range cloudConfigFileExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadConfigurationVersion creates a configuration version of the workspace via the API (at the base URL), and uploads the archive to it. The id of the configuration version is returned.
+func uploadConfigurationVersion(ctx context.Context, client policy.Transporter, baseURL, token, organization, workspace string, archive []byte) (string, error) {
+	var ws struct {
+		Data struct {
+			Id string `json:"id"`
+		} `json:"data"`
+	}
+	wsURL := fmt.Sprintf("%s/api/v2/organizations/%s/workspaces/%s", baseURL, url.PathEscape(organization), url.PathEscape(workspace))
+	if err := cloudAPIRequest(ctx, client, http.MethodGet, wsURL, token, nil, &ws); err != nil {
+		return "", fmt.Errorf("reading the workspace %s/%s: %v", organization, workspace, err)
+	}
+
+	var cv struct {
+		Data struct {
+			Id         string `json:"id"`
+			Attributes struct {
+				UploadURL string `json:"upload-url"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	body := map[string]interface{}{
+		"data": map[string]interface{}{
+			"type": "configuration-versions",
+			"attributes": map[string]interface{}{
+				"auto-queue-runs": false,
+			},
+		},
+	}
+	cvURL := fmt.Sprintf("%s/api/v2/workspaces/%s/configuration-versions", baseURL, url.PathEscape(ws.Data.Id))
+	if err := cloudAPIRequest(ctx, client, http.MethodPost, cvURL, token, body, &cv); err != nil {
+		return "", fmt.Errorf("creating the configuration version: %v", err)
+	}
+
+	// The upload URL is pre-signed, which needs no token.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, cv.Data.Attributes.UploadURL, bytes.NewReader(archive))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("uploading the archive: %v", err)
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("uploading the archive: unexpected status %s", resp.Status)
+	}
+	return cv.Data.Id, nil
+}
+
+// cloudAPIRequest sends a JSON:API request, and unmarshals the response to the out.
+func cloudAPIRequest(ctx context.Context, client policy.Transporter, method, endpoint, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/vnd.api+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// #nosec G307
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, string(b))
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,153 @@
+package meta
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTerraformConfigWithCloudBlock(t *testing.T) {
+	meta := baseMeta{
+		providerName:      "azurerm",
+		cloudHostname:     "tfe.example.com",
+		cloudOrganization: "org",
+		cloudWorkspace:    "ws",
+	}
+	require.Equal(t, `terraform {
+  cloud {
+    hostname = "tfe.example.com"
+    organization = "org"
+    workspaces {
+      name = "ws"
+    }
+  }
+
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+    }
+  }
+}
+`, meta.buildTerraformConfig(CloudBackendType))
+}
+
+func TestCloudToken(t *testing.T) {
+	t.Setenv("TF_TOKEN_tfe_example-corp_com", "")
+	t.Setenv("TF_TOKEN_tfe_example__corp_com", "secret")
+	token, err := cloudToken("tfe.example-corp.com")
+	require.NoError(t, err)
+	require.Equal(t, "secret", token)
+}
+
+func TestArchiveCloudConfig(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.tf", "terraform.tf.json", ".terraform.lock.hcl", "terraform.tfstate", "aztfexportResourceMapping.json", ".terraform/terraform.tfstate", "modules/resources/main.tf"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0600))
+	}
+
+	b, err := archiveCloudConfig(dir)
+	require.NoError(t, err)
+
+	gr, err := gzip.NewReader(bytes.NewReader(b))
+	require.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		require.Equal(t, hdr.Name, string(content))
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{".terraform.lock.hcl", "main.tf", "modules/resources/main.tf", "terraform.tf.json"}, names)
+}
+
+func TestUploadConfigurationVersion(t *testing.T) {
+	var uploaded []byte
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload" {
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/organizations/org/workspaces/ws":
+			w.Write([]byte(`{"data": {"id": "ws-123"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/workspaces/ws-123/configuration-versions":
+			var body struct {
+				Data struct {
+					Type       string                 `json:"type"`
+					Attributes map[string]interface{} `json:"attributes"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "configuration-versions", body.Data.Type)
+			require.Equal(t, false, body.Data.Attributes["auto-queue-runs"])
+			w.Write([]byte(`{"data": {"id": "cv-123", "attributes": {"upload-url": "` + server.URL + `/upload"}}}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/upload":
+			b, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			uploaded = b
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	id, err := uploadConfigurationVersion(context.Background(), server.Client(), server.URL, "token", "org", "ws", []byte("archive"))
+	require.NoError(t, err)
+	require.Equal(t, "cv-123", id)
+	require.Equal(t, "archive", string(uploaded))
+
+	_, err = uploadConfigurationVersion(context.Background(), server.Client(), server.URL, "token", "org", "notexist", []byte("archive"))
+	require.ErrorContains(t, err, "reading the workspace org/notexist")
+}
+
+func TestUploadCloudConfigUsesConfiguredTransport(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet:
+			w.Write([]byte(`{"data": {"id": "ws-123"}}`))
+		case r.Method == http.MethodPost:
+			w.Write([]byte(`{"data": {"id": "cv-123", "attributes": {"upload-url": "` + server.URL + `/upload"}}}`))
+		}
+	}))
+	defer server.Close()
+
+	hostname := strings.TrimPrefix(server.URL, "https://")
+	t.Setenv("TF_TOKEN_"+strings.NewReplacer(".", "_", "-", "__").Replace(hostname), "token")
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(""), 0644))
+	meta := baseMeta{
+		logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+		outdir:            dir,
+		cloudHostname:     hostname,
+		cloudOrganization: "org",
+		cloudWorkspace:    "ws",
+	}
+
+	// The default client doesn't trust the certificate of the test server
+	require.Error(t, meta.uploadCloudConfig(context.Background()))
+
+	meta.azureSDKClientOpt.Transport = server.Client()
+	require.NoError(t, meta.uploadCloudConfig(context.Background()))
+}
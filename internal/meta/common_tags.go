@@ -0,0 +1,181 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// commonTagsLocalName is the name of the local value of the common tags, appended with a counter if the name is already used (e.g. "common_tags_2").
+const commonTagsLocalName = "common_tags"
+
+// tagPair is a key value pair of the tags.
+type tagPair struct {
+	key   string
+	value string
+}
+
+// consolidateCommonTags lifts the tags that are shared by the resources into a local value, which is written to the locals file.
+// The resources that have all the common tags are rewritten to either reference the local value, or merge it with the rest of their tags.
+// The common tags are chosen greedily from the most shared tags, as long as the number of the tags being deduplicated doesn't decrease.
+func (meta baseMeta) consolidateCommonTags(configs ConfigInfos) (ConfigInfos, error) {
+	type taggedBody struct {
+		body *hclwrite.Body
+		tags map[string]string
+	}
+	var bodies []taggedBody
+	freq := map[tagPair]int{}
+	for _, cfg := range configs {
+		blocks := cfg.hcl.Body().Blocks()
+		if len(blocks) == 0 {
+			continue
+		}
+		body := blocks[0].Body()
+		attr := body.GetAttribute("tags")
+		if attr == nil {
+			continue
+		}
+		v, ok := literalVariableValue(attr.Expr())
+		if !ok || !v.Type().IsMapType() {
+			continue
+		}
+		tags := map[string]string{}
+		for k, ev := range v.AsValueMap() {
+			tags[k] = ev.AsString()
+			freq[tagPair{key: k, value: ev.AsString()}]++
+		}
+		bodies = append(bodies, taggedBody{body: body, tags: tags})
+	}
+
+	var pairs []tagPair
+	for p, n := range freq {
+		if n > 1 {
+			pairs = append(pairs, p)
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if freq[pairs[i]] != freq[pairs[j]] {
+			return freq[pairs[i]] > freq[pairs[j]]
+		}
+		if pairs[i].key != pairs[j].key {
+			return pairs[i].key < pairs[j].key
+		}
+		return pairs[i].value < pairs[j].value
+	})
+
+	// The number of the bodies that have all the tags
+	countMatched := func(common []tagPair) int {
+		cnt := 0
+		for _, b := range bodies {
+			matched := true
+			for _, p := range common {
+				if v, ok := b.tags[p.key]; !ok || v != p.value {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				cnt++
+			}
+		}
+		return cnt
+	}
+	var (
+		common  []tagPair
+		savings int
+	)
+	keys := map[string]bool{}
+	for _, p := range pairs {
+		if keys[p.key] {
+			continue
+		}
+		candidate := append(append([]tagPair{}, common...), p)
+		cnt := countMatched(candidate)
+		if cnt < 2 || cnt*len(candidate) < savings {
+			continue
+		}
+		common, savings = candidate, cnt*len(candidate)
+		keys[p.key] = true
+	}
+	if len(common) == 0 {
+		return configs, nil
+	}
+
+	name, err := meta.unusedLocalName(commonTagsLocalName)
+	if err != nil {
+		return nil, err
+	}
+	ref := hclwrite.TokensForTraversal(hcl.Traversal{hcl.TraverseRoot{Name: "local"}, hcl.TraverseAttr{Name: name}})
+	commonMap := map[string]cty.Value{}
+	for _, p := range common {
+		commonMap[p.key] = cty.StringVal(p.value)
+	}
+	for _, b := range bodies {
+		rest := map[string]cty.Value{}
+		matched := 0
+		for k, v := range b.tags {
+			if cv, ok := commonMap[k]; ok && cv.AsString() == v {
+				matched++
+				continue
+			}
+			rest[k] = cty.StringVal(v)
+		}
+		if matched != len(common) {
+			continue
+		}
+		if len(rest) == 0 {
+			b.body.SetAttributeRaw("tags", ref)
+			continue
+		}
+		tokens := hclwrite.TokensForFunctionCall("merge", ref, hclwrite.TokensForValue(cty.ObjectVal(rest)))
+		b.body.SetAttributeRaw("tags", tokens)
+	}
+
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendNewBlock("locals", nil).Body().SetAttributeValue(name, cty.ObjectVal(commonMap))
+	f.Body().AppendNewline()
+	localsFile := filepath.Join(meta.moduleDir, meta.outputFileNames.LocalsFileName)
+	if err := appendToFile(localsFile, string(hclwrite.Format(f.Bytes()))); err != nil {
+		return nil, fmt.Errorf("generating the locals file: %w", err)
+	}
+	return configs, nil
+}
+
+// unusedLocalName returns the name (appended with a counter if necessary) that isn't used by the local values defined in the module directory (e.g. in the append mode).
+func (meta baseMeta) unusedLocalName(name string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(meta.moduleDir, "*.tf"))
+	if err != nil {
+		return "", err
+	}
+	used := map[string]bool{}
+	for _, path := range paths {
+		// #nosec G304
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("reading %s: %v", path, err)
+		}
+		f, diags := hclsyntax.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return "", fmt.Errorf("parsing %s: %v", path, diags.Error())
+		}
+		for _, blk := range f.Body.(*hclsyntax.Body).Blocks {
+			if blk.Type != "locals" {
+				continue
+			}
+			for k := range blk.Body.Attributes {
+				used[k] = true
+			}
+		}
+	}
+	candidate := name
+	for cnt := 2; used[candidate]; cnt++ {
+		candidate = fmt.Sprintf("%s_%d", name, cnt)
+	}
+	return candidate, nil
+}
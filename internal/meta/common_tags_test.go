@@ -0,0 +1,117 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsolidateCommonTags(t *testing.T) {
+	newConfig := func(tfType, tfName, src string) ConfigInfo {
+		f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return ConfigInfo{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: tfType, Name: tfName}}, hcl: f}
+	}
+	cfgs := ConfigInfos{
+		newConfig("azurerm_resource_group", "rg", `resource "azurerm_resource_group" "rg" {
+  tags = {
+    env   = "prod"
+    owner = "team"
+  }
+}
+`),
+		newConfig("azurerm_virtual_network", "vnet", `resource "azurerm_virtual_network" "vnet" {
+  tags = {
+    env   = "prod"
+    owner = "team"
+    tier  = "network"
+  }
+}
+`),
+		// The resource that doesn't have all the common tags is kept as is
+		newConfig("azurerm_storage_account", "sa", `resource "azurerm_storage_account" "sa" {
+  tags = {
+    env = "prod"
+  }
+}
+`),
+		// The non-literal tags are skipped
+		newConfig("azurerm_key_vault", "kv", `resource "azurerm_key_vault" "kv" {
+  tags = var.tags
+}
+`),
+	}
+
+	dir := t.TempDir()
+	// The local value that is already defined in the module directory
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.tf"), []byte(`locals {
+  common_tags = {}
+}
+`), 0600))
+
+	meta := baseMeta{
+		outdir:          dir,
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{LocalsFileName: "locals.tf"},
+	}
+	cfgs, err := meta.consolidateCommonTags(cfgs)
+	require.NoError(t, err)
+
+	require.Equal(t, `resource "azurerm_resource_group" "rg" {
+  tags = local.common_tags_2
+}
+`, string(hclwrite.Format(cfgs[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_virtual_network" "vnet" {
+  tags = merge(local.common_tags_2, {
+    tier = "network"
+  })
+}
+`, string(hclwrite.Format(cfgs[1].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_storage_account" "sa" {
+  tags = {
+    env = "prod"
+  }
+}
+`, string(hclwrite.Format(cfgs[2].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_key_vault" "kv" {
+  tags = var.tags
+}
+`, string(hclwrite.Format(cfgs[3].hcl.Bytes())))
+
+	b, err := os.ReadFile(filepath.Join(dir, "locals.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `locals {
+  common_tags_2 = {
+    env   = "prod"
+    owner = "team"
+  }
+}
+
+`, string(b))
+}
+
+func TestConsolidateCommonTagsNoneShared(t *testing.T) {
+	f, diags := hclwrite.ParseConfig([]byte(`resource "azurerm_resource_group" "rg" {
+  tags = {
+    env = "prod"
+  }
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	dir := t.TempDir()
+	meta := baseMeta{
+		outdir:          dir,
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{LocalsFileName: "locals.tf"},
+	}
+	_, err := meta.consolidateCommonTags(ConfigInfos{{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"}}, hcl: f}})
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "locals.tf"))
+}
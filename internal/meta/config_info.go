@@ -30,11 +30,13 @@ func (cfg ConfigInfo) DumpHCL(w io.Writer) (int, error) {
 	return w.Write(out)
 }
 
-func (cfgs ConfigInfos) AddDependency() error {
+// AddDependency adds the dependencies of the configs, which are from the parent child relationship, the references of the TF resource ids, and the optional ARM template dependencies.
+func (cfgs ConfigInfos) AddDependency(armDeps armDependencies) error {
 	cfgs.addParentChildDependency()
 	if err := cfgs.addReferenceDependency(); err != nil {
 		return err
 	}
+	cfgs.addARMDependency(armDeps)
 
 	// Disduplicate then sort the dependencies
 	for i, cfg := range cfgs {
@@ -142,6 +144,28 @@ func (cfgs ConfigInfos) addParentChildDependency() {
 	}
 }
 
+// addARMDependency adds the dependencies declared in the ARM template, whose resources are also exported.
+func (cfgs ConfigInfos) addARMDependency(armDeps armDependencies) {
+	if len(armDeps) == 0 {
+		return
+	}
+	// Upper cased Azure resource id to the Azure resource id of the configs.
+	m := map[string]string{}
+	for _, cfg := range cfgs {
+		m[strings.ToUpper(cfg.AzureResourceID.String())] = cfg.AzureResourceID.String()
+	}
+	for i, cfg := range cfgs {
+		for _, dep := range armDeps[strings.ToUpper(cfg.AzureResourceID.String())] {
+			id, ok := m[strings.ToUpper(dep)]
+			if !ok || id == cfg.AzureResourceID.String() {
+				continue
+			}
+			cfg.DependsOn = append(cfg.DependsOn, Dependency{Candidates: []string{id}})
+		}
+		cfgs[i] = cfg
+	}
+}
+
 func (cfgs ConfigInfos) addReferenceDependency() error {
 	// TF resource id to Azure resource ids.
 	// Typically, one TF resource id maps to one Azure resource id. However, there are cases that one one TF resource id maps to multiple Azure resource ids.
@@ -193,3 +217,80 @@ func (cfgs ConfigInfos) addReferenceDependency() error {
 	}
 	return nil
 }
+
+// ResolveReferences rewrites the string literals that are the TF resource ids of other configs as references to their "id" attributes (e.g. "azurerm_subnet.res-3.id").
+// The literals matching multiple configs (i.e. their TF resource ids are identical) are kept as is. The dependencies that are established by the references are removed from the DependsOn.
+// This should be called after AddDependency.
+func (cfgs ConfigInfos) ResolveReferences() error {
+	// TF resource id to the configs.
+	m := map[string][]ConfigInfo{}
+	for _, cfg := range cfgs {
+		m[cfg.TFResourceId] = append(m[cfg.TFResourceId], cfg)
+	}
+
+	for i, cfg := range cfgs {
+		referenced := map[string]bool{}
+		tokens := cfg.hcl.BuildTokens(nil)
+		var out hclwrite.Tokens
+		for j := 0; j < len(tokens); j++ {
+			if j+2 >= len(tokens) ||
+				tokens[j].Type != hclsyntax.TokenOQuote ||
+				tokens[j+1].Type != hclsyntax.TokenQuotedLit ||
+				tokens[j+2].Type != hclsyntax.TokenCQuote {
+				out = append(out, tokens[j])
+				continue
+			}
+			// Skip the object keys
+			if j+3 < len(tokens) && (tokens[j+3].Type == hclsyntax.TokenEqual || tokens[j+3].Type == hclsyntax.TokenColon) {
+				out = append(out, tokens[j])
+				continue
+			}
+
+			var candidates []ConfigInfo
+			for _, ocfg := range m[string(tokens[j+1].Bytes)] {
+				if ocfg.AzureResourceID.String() == cfg.AzureResourceID.String() {
+					continue
+				}
+				// Skip the sub resources to avoid circular dependency, the same as addReferenceDependency
+				if cfg.AzureResourceID.Equal(ocfg.AzureResourceID.Parent()) {
+					continue
+				}
+				candidates = append(candidates, ocfg)
+			}
+			if len(candidates) != 1 {
+				out = append(out, tokens[j])
+				continue
+			}
+
+			ref := hclwrite.TokensForTraversal(hcl.Traversal{
+				hcl.TraverseRoot{Name: candidates[0].TFAddr.Type},
+				hcl.TraverseAttr{Name: candidates[0].TFAddr.Name},
+				hcl.TraverseAttr{Name: "id"},
+			})
+			ref[0].SpacesBefore = tokens[j].SpacesBefore
+			out = append(out, ref...)
+			referenced[candidates[0].AzureResourceID.String()] = true
+			j += 2
+		}
+		if len(referenced) == 0 {
+			continue
+		}
+
+		f, diags := hclwrite.ParseConfig(out.Bytes(), "main.tf", hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing the hcl with references for %s: %v", cfg.AzureResourceID, diags.Error())
+		}
+		cfg.hcl = f
+
+		var deps []Dependency
+		for _, dep := range cfg.DependsOn {
+			if len(dep.Candidates) == 1 && referenced[dep.Candidates[0]] {
+				continue
+			}
+			deps = append(deps, dep)
+		}
+		cfg.DependsOn = deps
+		cfgs[i] = cfg
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package meta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReferences(t *testing.T) {
+	const (
+		rgId     = "/subscriptions/123/resourceGroups/rg"
+		vnetId   = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnetId = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet"
+		nicId    = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/nic"
+		assocId  = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/nic/associations/assoc"
+	)
+	newConfig := func(azureId, tfId, tfType, tfName, src string) ConfigInfo {
+		id, err := armid.ParseResourceId(azureId)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return ConfigInfo{
+			ImportItem: ImportItem{
+				AzureResourceID: id,
+				TFResourceId:    tfId,
+				TFAddr:          tfaddr.TFAddr{Type: tfType, Name: tfName},
+			},
+			hcl: f,
+		}
+	}
+
+	cfgs := ConfigInfos{
+		newConfig(rgId, rgId, "azurerm_resource_group", "rg", `resource "azurerm_resource_group" "rg" {
+  name = "rg"
+}
+`),
+		newConfig(vnetId, vnetId, "azurerm_virtual_network", "vnet", `resource "azurerm_virtual_network" "vnet" {
+  name                = "vnet"
+  resource_group_name = "rg"
+}
+`),
+		newConfig(subnetId, subnetId, "azurerm_subnet", "subnet", `resource "azurerm_subnet" "subnet" {
+  name                 = "subnet"
+  virtual_network_name = "vnet"
+}
+`),
+		newConfig(nicId, nicId, "azurerm_network_interface", "nic", `resource "azurerm_network_interface" "nic" {
+  name = "nic"
+  ip_configuration {
+    subnet_id = "`+subnetId+`"
+  }
+  tags = {
+    "`+subnetId+`" = "`+vnetId+`"
+  }
+}
+`),
+		// The association resource has the same TF id as the nic
+		newConfig(assocId, nicId, "azurerm_network_interface_security_group_association", "assoc", `resource "azurerm_network_interface_security_group_association" "assoc" {
+  network_interface_id = "`+nicId+`"
+}
+`),
+	}
+	require.NoError(t, cfgs.AddDependency(nil))
+	require.NoError(t, cfgs.ResolveReferences())
+
+	require.Equal(t, `resource "azurerm_network_interface" "nic" {
+  name = "nic"
+  ip_configuration {
+    subnet_id = azurerm_subnet.subnet.id
+  }
+  tags = {
+    "`+subnetId+`" = azurerm_virtual_network.vnet.id
+  }
+}
+`, string(cfgs[3].hcl.Bytes()))
+	// The dependencies are established by the references
+	require.Empty(t, cfgs[3].DependsOn)
+
+	// The parent is referenced, as the sub resources are skipped
+	require.Equal(t, `resource "azurerm_network_interface_security_group_association" "assoc" {
+  network_interface_id = azurerm_network_interface.nic.id
+}
+`, string(cfgs[4].hcl.Bytes()))
+	require.Empty(t, cfgs[4].DependsOn)
+
+	// The dependencies without references are kept
+	require.Equal(t, []Dependency{{Candidates: []string{vnetId}}}, cfgs[2].DependsOn)
+}
+
+func TestAddARMDependency(t *testing.T) {
+	const (
+		kvId  = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"
+		desId = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"
+		vmId  = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"
+	)
+	newConfig := func(azureId, tfType, tfName, src string) ConfigInfo {
+		id, err := armid.ParseResourceId(azureId)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return ConfigInfo{
+			ImportItem: ImportItem{
+				AzureResourceID: id,
+				TFResourceId:    azureId,
+				TFAddr:          tfaddr.TFAddr{Type: tfType, Name: tfName},
+			},
+			hcl: f,
+		}
+	}
+	cfgs := ConfigInfos{
+		newConfig(kvId, "azurerm_key_vault", "kv", `resource "azurerm_key_vault" "kv" {
+}
+`),
+		newConfig(desId, "azurerm_disk_encryption_set", "des", `resource "azurerm_disk_encryption_set" "des" {
+  key_vault_id = "`+kvId+`"
+}
+`),
+		newConfig(vmId, "azurerm_linux_virtual_machine", "vm", `resource "azurerm_linux_virtual_machine" "vm" {
+}
+`),
+	}
+	armDeps := armDependencies{
+		// The resource id is matched case insensitively, and the ones not exported are ignored
+		strings.ToUpper(vmId):  {strings.ToLower(desId), kvId + "/keys/key"},
+		strings.ToUpper(desId): {kvId},
+	}
+	require.NoError(t, cfgs.AddDependency(armDeps))
+	require.NoError(t, cfgs.ResolveReferences())
+
+	// The dependency is established by the reference
+	require.Empty(t, cfgs[1].DependsOn)
+	require.Equal(t, []Dependency{{Candidates: []string{desId}}}, cfgs[2].DependsOn)
+}
@@ -0,0 +1,137 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CoverageReportFileName is the report of the discovered resources that are not imported, which is written to the output directory.
+const CoverageReportFileName = "aztfexportCoverageReport.json"
+
+// The reasons of the resources not being imported, as are recorded in the coverage report.
+const (
+	// The resource has no TF resource type mapped
+	coverageReasonNoMapping = "no_mapping"
+	// The TF resource type of the resource isn't supported by the provider in use
+	coverageReasonUnsupported = "unsupported"
+	// The resource is skipped by the user, either interactively or by the filters (e.g. the type filter, the name filter and the skip file)
+	coverageReasonUserSkipped = "user_skipped"
+	// The resource is already managed in the state of the output directory
+	coverageReasonManaged = "managed"
+	// The resource failed to import
+	coverageReasonImportError = "import_error"
+)
+
+// coverageSummary is the content of the coverage report.
+type coverageSummary struct {
+	// The number of the discovered resources, including the filtered out ones
+	Discovered int `json:"discovered"`
+	// The number of the imported resources
+	Imported int `json:"imported"`
+	// The discovered resources that are not imported, in the order of the Azure resource ids
+	NotImported []coverageItem `json:"not_imported"`
+}
+
+// coverageItem is a discovered resource that is not imported.
+type coverageItem struct {
+	AzureResourceId string `json:"azure_resource_id"`
+	// The TF resource type that is mapped (if any)
+	TFResourceType string `json:"tf_resource_type,omitempty"`
+	// One of the coverageReason* constants
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// exportCoverageReport writes the coverage report of the import list, together with the resources filtered out during listing, to the output directory.
+func (meta baseMeta) exportCoverageReport(l ImportList) error {
+	report, err := meta.buildCoverageReport(l)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the coverage report: %v", err)
+	}
+	output := filepath.Join(meta.outdir, CoverageReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(output, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the coverage report to %s: %v", output, err)
+	}
+	return nil
+}
+
+func (meta baseMeta) buildCoverageReport(l ImportList) (*coverageSummary, error) {
+	managedIds, err := meta.managedResourceIds()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &coverageSummary{
+		Discovered:  len(l) + len(meta.filteredResources),
+		Imported:    len(l.Imported()),
+		NotImported: []coverageItem{},
+	}
+	for _, item := range l {
+		if item.Imported {
+			continue
+		}
+		citem := coverageItem{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceType:  item.TFAddr.Type,
+		}
+		switch {
+		case item.Skip():
+			citem.TFResourceType = item.TFAddrCache.Type
+			switch {
+			case managedIds[strings.ToUpper(item.TFResourceId)]:
+				citem.Reason = coverageReasonManaged
+			case len(item.Recommendations) == 0 && item.TFAddrCache.Type == "":
+				citem.Reason = coverageReasonNoMapping
+			default:
+				citem.Reason = coverageReasonUserSkipped
+				citem.Detail = "skipped interactively"
+			}
+		case !meta.isResourceTypeSupported(item.TFAddr.Type):
+			citem.Reason = coverageReasonUnsupported
+			citem.Detail = fmt.Sprintf("the resource type %q is not supported by the provider", item.TFAddr.Type)
+		case item.ImportError != nil:
+			citem.Reason = coverageReasonImportError
+			citem.Detail = item.ImportError.Error()
+		default:
+			// The resource is not attempted to import (e.g. the import is aborted), which is not regarded as not covered.
+			continue
+		}
+		report.NotImported = append(report.NotImported, citem)
+	}
+	for _, res := range meta.filteredResources {
+		citem := coverageItem{
+			AzureResourceId: res.AzureId.String(),
+			TFResourceType:  res.TFType,
+			Reason:          coverageReasonUserSkipped,
+		}
+		switch {
+		case meta.skipFilter.Match(res.AzureId):
+			citem.Detail = "skipped by the skip file"
+		case meta.nameFilter != nil && meta.typeFilter.Match(res.AzureId, res.TFType):
+			// The resource might be excluded by the name of its parent resource or scope, rather than its own name.
+			citem.Detail = "excluded by the name filter"
+		default:
+			citem.Detail = "excluded by the type filter"
+		}
+		report.NotImported = append(report.NotImported, citem)
+	}
+	sort.SliceStable(report.NotImported, func(i, j int) bool {
+		return report.NotImported[i].AzureResourceId < report.NotImported[j].AzureResourceId
+	})
+	return report, nil
+}
+
+// isResourceTypeSupported tells whether the TF resource type is supported by the provider in use (or the azapi provider if it is used together).
+func (meta baseMeta) isResourceTypeSupported(tfType string) bool {
+	_, ok := meta.resourceSchema(tfType)
+	return ok
+}
@@ -0,0 +1,91 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCoverageReport(t *testing.T) {
+	parseId := func(id string) armid.ResourceId {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return azureId
+	}
+	const prefix = "/subscriptions/123/resourceGroups/rg/providers"
+	l := ImportList{
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Network/virtualNetworks/imported"),
+			TFResourceId:    prefix + "/Microsoft.Network/virtualNetworks/imported",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "imported"},
+			Imported:        true,
+		},
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Foo/foos/nomapping"),
+			TFResourceId:    prefix + "/Microsoft.Foo/foos/nomapping",
+		},
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Network/virtualNetworks/skipped"),
+			TFResourceId:    prefix + "/Microsoft.Network/virtualNetworks/skipped",
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "skipped"},
+			Recommendations: []string{"azurerm_virtual_network"},
+		},
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Network/virtualNetworks/managed"),
+			TFResourceId:    prefix + "/Microsoft.Network/virtualNetworks/managed",
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "managed"},
+			Recommendations: []string{"azurerm_virtual_network"},
+		},
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Foo/foos/unsupported"),
+			TFResourceId:    prefix + "/Microsoft.Foo/foos/unsupported",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_foo", Name: "unsupported"},
+			ImportError:     errors.New("unknown resource type"),
+		},
+		{
+			AzureResourceID: parseId(prefix + "/Microsoft.Network/virtualNetworks/errored"),
+			TFResourceId:    prefix + "/Microsoft.Network/virtualNetworks/errored",
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "errored"},
+			ImportError:     errors.New("resource not found"),
+		},
+	}
+
+	dir := t.TempDir()
+	meta := baseMeta{
+		providerName: "azurerm",
+		outdir:       dir,
+		baseState:    []byte(`{"resources": [{"mode": "managed", "type": "azurerm_virtual_network", "name": "managed", "instances": [{"attributes": {"id": "` + prefix + `/Microsoft.Network/virtualNetworks/managed"}}]}]}`),
+		skipFilter:   skipFilter{regexp.MustCompile(`/skipfile$`)},
+		filteredResources: []resourceset.TFResource{
+			{AzureId: parseId(prefix + "/Microsoft.Network/virtualNetworks/skipfile"), TFType: "azurerm_virtual_network"},
+			{AzureId: parseId(prefix + "/Microsoft.Network/publicIPAddresses/excluded"), TFType: "azurerm_public_ip"},
+		},
+	}
+	require.NoError(t, meta.exportCoverageReport(l))
+
+	b, err := os.ReadFile(filepath.Join(dir, CoverageReportFileName))
+	require.NoError(t, err)
+	var report coverageSummary
+	require.NoError(t, json.Unmarshal(b, &report))
+	require.Equal(t, coverageSummary{
+		Discovered: 8,
+		Imported:   1,
+		NotImported: []coverageItem{
+			{AzureResourceId: prefix + "/Microsoft.Foo/foos/nomapping", Reason: coverageReasonNoMapping},
+			{AzureResourceId: prefix + "/Microsoft.Foo/foos/unsupported", TFResourceType: "azurerm_foo", Reason: coverageReasonUnsupported, Detail: `the resource type "azurerm_foo" is not supported by the provider`},
+			{AzureResourceId: prefix + "/Microsoft.Network/publicIPAddresses/excluded", TFResourceType: "azurerm_public_ip", Reason: coverageReasonUserSkipped, Detail: "excluded by the type filter"},
+			{AzureResourceId: prefix + "/Microsoft.Network/virtualNetworks/errored", TFResourceType: "azurerm_virtual_network", Reason: coverageReasonImportError, Detail: "resource not found"},
+			{AzureResourceId: prefix + "/Microsoft.Network/virtualNetworks/managed", TFResourceType: "azurerm_virtual_network", Reason: coverageReasonManaged},
+			{AzureResourceId: prefix + "/Microsoft.Network/virtualNetworks/skipfile", TFResourceType: "azurerm_virtual_network", Reason: coverageReasonUserSkipped, Detail: "skipped by the skip file"},
+			{AzureResourceId: prefix + "/Microsoft.Network/virtualNetworks/skipped", TFResourceType: "azurerm_virtual_network", Reason: coverageReasonUserSkipped, Detail: "skipped interactively"},
+		},
+	}, report)
+}
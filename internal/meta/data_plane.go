@@ -0,0 +1,175 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/client"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+)
+
+// listDataPlaneResources lists the data plane resources of the given resources, which are not listed by the control plane.
+// Currently, the key-values of the App Configuration stores and the certificates of the Key Vaults are supported. Other data plane resources, e.g. the Key Vault secrets/keys,
+// or the Storage containers/queues/shares, are already listed as the child resources of the control plane.
+func (meta baseMeta) listDataPlaneResources(ctx context.Context, rl []resourceset.AzureResource) ([]resourceset.AzureResource, error) {
+	b := client.ClientBuilder{
+		Credential: meta.azureSDKCred,
+		Opt:        meta.azureSDKClientOpt,
+	}
+
+	var out []resourceset.AzureResource
+	for _, res := range rl {
+		props, _ := res.Properties["properties"].(map[string]interface{})
+		switch {
+		case strings.EqualFold(res.Id.RouteScopeString(), "/Microsoft.AppConfiguration/configurationStores"):
+			endpoint, _ := props["endpoint"].(string)
+			if endpoint == "" {
+				meta.Logger().Warn("Skip listing key-values for App Configuration store without endpoint", "id", res.Id.String())
+				continue
+			}
+			l, err := listAppConfigurationKeys(ctx, meta.Logger(), b.NewDataPlanePipeline(endpoint), endpoint, res.Id)
+			if err != nil {
+				// The data plane access might not be granted to the user (e.g. missing the data reader role, or the store disables the AAD auth), which shouldn't fail the whole process.
+				meta.Logger().Warn("Failed to list key-values for App Configuration store", "id", res.Id.String(), "error", err)
+				continue
+			}
+			out = append(out, l...)
+		case strings.EqualFold(res.Id.RouteScopeString(), "/Microsoft.KeyVault/vaults"):
+			vaultUri, _ := props["vaultUri"].(string)
+			audience, err := keyVaultAudience(vaultUri)
+			if err != nil {
+				meta.Logger().Warn("Skip listing certificates for Key Vault with invalid vault uri", "id", res.Id.String(), "vault_uri", vaultUri, "error", err)
+				continue
+			}
+			l, err := listKeyVaultCertificates(ctx, meta.Logger(), b.NewDataPlanePipeline(audience), vaultUri, res.Id)
+			if err != nil {
+				// The data plane access might not be granted to the user (e.g. missing the certificate list permission, or the vault denies the public network access), which shouldn't fail the whole process.
+				meta.Logger().Warn("Failed to list certificates for Key Vault", "id", res.Id.String(), "error", err)
+				continue
+			}
+			out = append(out, l...)
+		}
+	}
+	return out, nil
+}
+
+// listAppConfigurationKeys lists the key-values of the App Configuration store, and returns them as resources identified by the
+// "<store id>/AppConfigurationKey/<key>/Label/<label>" pseudo resource ids.
+// The feature flags and the keys that contain "/" are skipped, as they can't be represented by the pseudo resource id. So are the keys whose pseudo resource id fails to parse, with a warning.
+func listAppConfigurationKeys(ctx context.Context, logger *slog.Logger, pl runtime.Pipeline, endpoint string, storeId armid.ResourceId) ([]resourceset.AzureResource, error) {
+	type kvList struct {
+		Items []struct {
+			Key   string  `json:"key"`
+			Label *string `json:"label"`
+		} `json:"items"`
+		NextLink string `json:"@nextLink"`
+	}
+
+	var out []resourceset.AzureResource
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	link := "/kv?api-version=1.0"
+	for link != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, endpoint+link)
+		if err != nil {
+			return nil, err
+		}
+		req.Raw().Header.Set("Accept", "application/vnd.microsoft.appconfig.kvset+json")
+		resp, err := pl.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var result kvList
+		if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.Items {
+			if strings.Contains(item.Key, "/") {
+				continue
+			}
+			// The null label is represented as "%00" in the id.
+			label := "%00"
+			if item.Label != nil && *item.Label != "" {
+				label = *item.Label
+			}
+			if strings.Contains(label, "/") {
+				continue
+			}
+			id, err := armid.ParseResourceId(fmt.Sprintf("%s/AppConfigurationKey/%s/Label/%s", storeId.String(), item.Key, label))
+			if err != nil {
+				logger.Warn("Skip the App Configuration key whose id fails to parse", "store", storeId.String(), "key", item.Key, "label", label, "error", err)
+				continue
+			}
+			out = append(out, resourceset.AzureResource{Id: id})
+		}
+		link = result.NextLink
+	}
+	return out, nil
+}
+
+// keyVaultAudience returns the token audience of the Key Vault data plane, which is the vault uri (e.g. https://foo.vault.azure.net) without the vault name (i.e. https://vault.azure.net).
+func keyVaultAudience(vaultUri string) (string, error) {
+	u, err := url.Parse(vaultUri)
+	if err != nil {
+		return "", err
+	}
+	_, domain, ok := strings.Cut(u.Host, ".")
+	if u.Scheme == "" || !ok || domain == "" {
+		return "", fmt.Errorf("unexpected vault uri %q", vaultUri)
+	}
+	return u.Scheme + "://" + domain, nil
+}
+
+// listKeyVaultCertificates lists the certificates of the Key Vault, and returns them as resources identified by the "<vault id>/certificates/<name>" resource ids,
+// which are mapped to azurerm_key_vault_certificate. The certificates whose resource id fails to parse are skipped, with a warning.
+func listKeyVaultCertificates(ctx context.Context, logger *slog.Logger, pl runtime.Pipeline, vaultUri string, vaultId armid.ResourceId) ([]resourceset.AzureResource, error) {
+	type certList struct {
+		Value []struct {
+			Id string `json:"id"`
+		} `json:"value"`
+		NextLink *string `json:"nextLink"`
+	}
+
+	var out []resourceset.AzureResource
+	link := strings.TrimSuffix(vaultUri, "/") + "/certificates?api-version=7.4"
+	for link != "" {
+		req, err := runtime.NewRequest(ctx, http.MethodGet, link)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := pl.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return nil, runtime.NewResponseError(resp)
+		}
+		var result certList
+		if err := runtime.UnmarshalAsJSON(resp, &result); err != nil {
+			return nil, err
+		}
+		for _, item := range result.Value {
+			// The item id is in the form of "https://foo.vault.azure.net/certificates/<name>".
+			name := item.Id[strings.LastIndex(item.Id, "/")+1:]
+			id, err := armid.ParseResourceId(fmt.Sprintf("%s/certificates/%s", vaultId.String(), name))
+			if err != nil {
+				logger.Warn("Skip the Key Vault certificate whose id fails to parse", "vault", vaultId.String(), "certificate", item.Id, "error", err)
+				continue
+			}
+			out = append(out, resourceset.AzureResource{Id: id})
+		}
+		link = ""
+		if result.NextLink != nil {
+			link = *result.NextLink
+		}
+	}
+	return out, nil
+}
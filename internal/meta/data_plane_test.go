@@ -0,0 +1,84 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAppConfigurationKeys(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("after") == "" {
+			fmt.Fprintf(w, `{"items": [{"key": "foo", "label": null}, {"key": ".appconfig.featureflag/bar", "label": null}, {"key": "", "label": null}], "@nextLink": "/kv?api-version=1.0&after=foo"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"items": [{"key": "baz", "label": "prod"}]}`)
+	}))
+	defer srv.Close()
+
+	storeId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.AppConfiguration/configurationStores/store")
+	require.NoError(t, err)
+
+	rl, err := listAppConfigurationKeys(context.Background(), slog.New(slog.NewTextHandler(os.Stderr, nil)), runtime.NewPipeline("test", "", runtime.PipelineOptions{}, nil), srv.URL, storeId)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, res := range rl {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.AppConfiguration/configurationStores/store/AppConfigurationKey/foo/Label/%00",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.AppConfiguration/configurationStores/store/AppConfigurationKey/baz/Label/prod",
+	}, ids)
+}
+
+func TestListKeyVaultCertificates(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/certificates", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("skiptoken") == "" {
+			fmt.Fprintf(w, `{"value": [{"id": "%[1]s/certificates/foo"}], "nextLink": "%[1]s/certificates?api-version=7.4&skiptoken=next"}`, srv.URL)
+			return
+		}
+		fmt.Fprintf(w, `{"value": [{"id": "%s/certificates/bar"}], "nextLink": null}`, srv.URL)
+	}))
+	defer srv.Close()
+
+	vaultId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/vault")
+	require.NoError(t, err)
+
+	rl, err := listKeyVaultCertificates(context.Background(), slog.New(slog.NewTextHandler(os.Stderr, nil)), runtime.NewPipeline("test", "", runtime.PipelineOptions{}, nil), srv.URL+"/", vaultId)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, res := range rl {
+		ids = append(ids, res.Id.String())
+	}
+	require.Equal(t, []string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/vault/certificates/foo",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/vault/certificates/bar",
+	}, ids)
+}
+
+func TestKeyVaultAudience(t *testing.T) {
+	audience, err := keyVaultAudience("https://foo.vault.azure.net/")
+	require.NoError(t, err)
+	require.Equal(t, "https://vault.azure.net", audience)
+
+	audience, err = keyVaultAudience("https://foo.vault.azure.cn/")
+	require.NoError(t, err)
+	require.Equal(t, "https://vault.azure.cn", audience)
+
+	_, err = keyVaultAudience("")
+	require.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/magodo/armid"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EnvironmentResourcesFileName is the file that records the identities of the resources declared by the environments, which is written to the output directory.
+const EnvironmentResourcesFileName = "aztfexportEnvironmentResources.json"
+
+// environmentNamePattern is the allowed environment name, which is used as both the Terraform workspace name and the variable definitions file name.
+var environmentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// environmentIdentity returns the identity of the resource across the environments, which is the TF resource type and the upper cased Azure resource id,
+// with the environment specific segments normalised: the subscription id is replaced with "{SUBSCRIPTION}", and the environment name within the resource names
+// (e.g. "rg-prod") is replaced with "{ENVIRONMENT}". The provider namespaces and the resource types are kept as is.
+func (meta baseMeta) environmentIdentity(tfType string, id armid.ResourceId) string {
+	env := strings.ToUpper(meta.environment)
+	segs := strings.Split(strings.TrimPrefix(strings.ToUpper(id.String()), "/"), "/")
+	// The segments of the resource id are key value pairs, e.g. "subscriptions/<id>", "providers/<namespace>", "<type>/<name>".
+	for i := 1; i < len(segs); i += 2 {
+		switch segs[i-1] {
+		case "SUBSCRIPTIONS":
+			segs[i] = "{SUBSCRIPTION}"
+		case "PROVIDERS":
+		default:
+			segs[i] = strings.ReplaceAll(segs[i], env, "{ENVIRONMENT}")
+		}
+	}
+	return tfType + ":/" + strings.Join(segs, "/")
+}
+
+// readEnvironmentResources returns the TF addresses of the resources declared by the environments, keyed by their identities (see environmentIdentity).
+// An empty map is returned if the output directory has no record yet.
+func (meta baseMeta) readEnvironmentResources() (map[string]string, error) {
+	path := filepath.Join(meta.outdir, EnvironmentResourcesFileName)
+	m := map[string]string{}
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("reading %s: %v", path, err)
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %v", path, err)
+	}
+	return m, nil
+}
+
+// exportEnvironmentResources records the identities of the imported items to the output directory, so that the other environments exported later are able to tell the shared resources.
+func (meta baseMeta) exportEnvironmentResources(l ImportList) error {
+	m, err := meta.readEnvironmentResources()
+	if err != nil {
+		return err
+	}
+	for _, item := range l {
+		if !item.Imported {
+			continue
+		}
+		m[meta.environmentIdentity(item.TFAddr.Type, item.AzureResourceID)] = item.TFAddr.String()
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the environment resources: %v", err)
+	}
+	path := filepath.Join(meta.outdir, EnvironmentResourcesFileName)
+	// #nosec G306
+	if err := os.WriteFile(path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the environment resources to %s: %v", path, err)
+	}
+	return nil
+}
+
+// alignSharedResources renames the import items that are declared by the other environments (i.e. have the same identity, see environmentIdentity) to the declared addresses,
+// so that the shared config applies to the state of this environment's workspace.
+func (meta baseMeta) alignSharedResources(l ImportList) (ImportList, error) {
+	identities, err := meta.readEnvironmentResources()
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range l {
+		if item.Skip() {
+			continue
+		}
+		addr, ok := identities[meta.environmentIdentity(item.TFAddr.Type, item.AzureResourceID)]
+		if !ok || addr == item.TFAddr.String() {
+			continue
+		}
+		_, name, _ := strings.Cut(addr, ".")
+		meta.Logger().Info("Rename the resource that is shared with the other environments", "id", item.AzureResourceID.String(), "from", item.TFAddr.String(), "to", addr)
+		l[i].TFAddr.Name = name
+		l[i].TFAddrCache.Name = name
+	}
+	return l, nil
+}
+
+// declaredVariableRefs returns the variables that are referenced by the top level attributes of the resources declared in the module directory (e.g. `location = var.location`),
+// keyed by the resource address and then the attribute name. Only the native syntax files (i.e. "*.tf") are inspected.
+func (meta baseMeta) declaredVariableRefs() (map[string]map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join(meta.moduleDir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]map[string]string{}
+	for _, file := range files {
+		// #nosec G304
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %v", file, err)
+		}
+		f, diags := hclsyntax.ParseConfig(b, file, hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing %s: %v", file, diags.Error())
+		}
+		for _, block := range f.Body.(*hclsyntax.Body).Blocks {
+			if block.Type != "resource" || len(block.Labels) != 2 {
+				continue
+			}
+			for name, attr := range block.Body.Attributes {
+				expr, ok := attr.Expr.(*hclsyntax.ScopeTraversalExpr)
+				if !ok || len(expr.Traversal) != 2 || expr.Traversal.RootName() != "var" {
+					continue
+				}
+				step, ok := expr.Traversal[1].(hcl.TraverseAttr)
+				if !ok {
+					continue
+				}
+				addr := block.Labels[0] + "." + block.Labels[1]
+				if out[addr] == nil {
+					out[addr] = map[string]string{}
+				}
+				out[addr][name] = step.Name
+			}
+		}
+	}
+	return out, nil
+}
+
+// environmentVarFile returns the variable definitions file of the environment, which is written to the output directory.
+func (meta baseMeta) environmentVarFile() string {
+	return filepath.Join(meta.outdir, meta.environment+".tfvars")
+}
+
+// selectWorkspace selects the Terraform workspace of the environment in the output directory, the workspace is created if not exists.
+func (meta baseMeta) selectWorkspace(ctx context.Context) error {
+	workspaces, current, err := meta.tf.WorkspaceList(ctx)
+	if err != nil {
+		return fmt.Errorf("listing the workspaces: %v", err)
+	}
+	if current == meta.environment {
+		return nil
+	}
+	for _, ws := range workspaces {
+		if ws == meta.environment {
+			meta.Logger().Info("Select the workspace", "workspace", meta.environment)
+			if err := meta.tf.WorkspaceSelect(ctx, meta.environment); err != nil {
+				return fmt.Errorf("selecting the workspace %q: %v", meta.environment, err)
+			}
+			return nil
+		}
+	}
+	meta.Logger().Info("Create the workspace", "workspace", meta.environment)
+	if err := meta.tf.WorkspaceNew(ctx, meta.environment); err != nil {
+		return fmt.Errorf("creating the workspace %q: %v", meta.environment, err)
+	}
+	return nil
+}
+
+// writeEnvironmentVariables sets the values of the variables to the variable definitions file of the environment, the other variables in the file are kept as is.
+func (meta baseMeta) writeEnvironmentVariables(names []string, values map[string]cty.Value) error {
+	path := meta.environmentVarFile()
+	f := hclwrite.NewEmptyFile()
+	// #nosec G304
+	if b, err := os.ReadFile(path); err == nil {
+		var diags hcl.Diagnostics
+		f, diags = hclwrite.ParseConfig(b, path, hcl.InitialPos)
+		if diags.HasErrors() {
+			return fmt.Errorf("parsing %s: %v", path, diags.Error())
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %v", path, err)
+	}
+	for _, name := range names {
+		f.Body().SetAttributeValue(name, values[name])
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// removeSharedResources removes the resources that are already declared in the module directory, which are exported for the other environments before and are shared by this environment.
+// A resource is shared if it is declared at the same address, and has the same identity (see environmentIdentity) as the declared one, if the identity is recorded.
+// The resources are still imported to the state of this environment's workspace.
+func (meta baseMeta) removeSharedResources(configs ConfigInfos) (ConfigInfos, error) {
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	identities, err := meta.readEnvironmentResources()
+	if err != nil {
+		return nil, err
+	}
+	addrIdentities := map[string]string{}
+	for identity, addr := range identities {
+		addrIdentities[addr] = identity
+	}
+	var out ConfigInfos
+	for _, cfg := range configs {
+		addr := cfg.TFAddr.Type + "." + cfg.TFAddr.Name
+		if identity, ok := addrIdentities[addr]; ok && identity != meta.environmentIdentity(cfg.TFAddr.Type, cfg.AzureResourceID) {
+			out = append(out, cfg)
+			continue
+		}
+		if _, ok := module.ManagedResources[addr]; ok {
+			meta.Logger().Info("The resource is declared already, which is shared with the other environments", "addr", cfg.TFAddr)
+			continue
+		}
+		out = append(out, cfg)
+	}
+	return out, nil
+}
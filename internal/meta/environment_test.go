@@ -0,0 +1,219 @@
+package meta
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractVariablesForEnvironment(t *testing.T) {
+	var cfgs ConfigInfos
+	for i, src := range []string{
+		`resource "azurerm_resource_group" "res-0" {
+  location = "westeurope"
+  name     = "rg-prod"
+}
+`,
+		`resource "azurerm_virtual_network" "res-1" {
+  location            = "westeurope"
+  name                = "vnet"
+  resource_group_name = "rg-prod"
+}
+`,
+		`resource "azurerm_public_ip" "res-2" {
+  location            = "westeurope"
+  name                = "pip"
+  resource_group_name = "rg-prod"
+  sku                 = "Basic"
+}
+`,
+	} {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		tfType := []string{"azurerm_resource_group", "azurerm_virtual_network", "azurerm_public_ip"}[i]
+		cfgs = append(cfgs, ConfigInfo{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: tfType, Name: fmt.Sprintf("res-%d", i)}}, hcl: f})
+	}
+
+	dir := t.TempDir()
+	// The variables and the resources that are already declared by the other environment
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "location" {
+  type = string
+}
+variable "sku" {
+  type = string
+}
+variable "sku_2" {
+  type = string
+}
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azurerm_resource_group" "res-0" {
+  location = var.location
+  name     = "rg-dev"
+}
+resource "azurerm_public_ip" "res-2" {
+  location = var.location
+  name     = "pip"
+  sku      = var.sku_2
+}
+`), 0600))
+	// The variable definitions file of this environment from a former run
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "prod.tfvars"), []byte(`location = "eastus"
+other    = "foo"
+`), 0600))
+
+	meta := baseMeta{
+		logger:          slog.Default(),
+		outdir:          dir,
+		moduleDir:       dir,
+		environment:     "prod",
+		outputFileNames: config.OutputFileNames{VariableFileName: "variables.tf"},
+	}
+	cfgs, err := meta.extractVariables(cfgs)
+	require.NoError(t, err)
+
+	// The variables referenced by the shared resources are reused (even for a value not shared by this environment), while the new variable has no default
+	b, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `variable "location" {
+  type = string
+}
+variable "sku" {
+  type = string
+}
+variable "sku_2" {
+  type = string
+}
+variable "resource_group_name" {
+  type = string
+}
+
+`, string(b))
+
+	b, err = os.ReadFile(filepath.Join(dir, "prod.tfvars"))
+	require.NoError(t, err)
+	require.Equal(t, `location            = "westeurope"
+other               = "foo"
+resource_group_name = "rg-prod"
+sku_2               = "Basic"
+`, string(b))
+
+	require.Equal(t, `resource "azurerm_virtual_network" "res-1" {
+  location            = var.location
+  name                = "vnet"
+  resource_group_name = var.resource_group_name
+}
+`, string(hclwrite.Format(cfgs[1].hcl.Bytes())))
+}
+
+func TestEnvironmentIdentity(t *testing.T) {
+	meta := baseMeta{environment: "prod"}
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet-prod/subnets/subnet")
+	require.NoError(t, err)
+	require.Equal(t,
+		"azurerm_subnet:/SUBSCRIPTIONS/{SUBSCRIPTION}/RESOURCEGROUPS/RG-{ENVIRONMENT}/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET-{ENVIRONMENT}/SUBNETS/SUBNET",
+		meta.environmentIdentity("azurerm_subnet", id),
+	)
+
+	// The same resource of the other environment has the same identity
+	otherId, err := armid.ParseResourceId("/subscriptions/456/resourceGroups/rg-dev/providers/Microsoft.Network/virtualNetworks/vnet-dev/subnets/subnet")
+	require.NoError(t, err)
+	require.Equal(t, meta.environmentIdentity("azurerm_subnet", id), baseMeta{environment: "dev"}.environmentIdentity("azurerm_subnet", otherId))
+}
+
+func TestRemoveSharedResources(t *testing.T) {
+	newConfig := func(tfType, tfName, id string) ConfigInfo {
+		f := hclwrite.NewEmptyFile()
+		f.Body().AppendNewBlock("resource", []string{tfType, tfName})
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ConfigInfo{ImportItem: ImportItem{AzureResourceID: azureId, TFAddr: tfaddr.TFAddr{Type: tfType, Name: tfName}}, hcl: f}
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`resource "azurerm_resource_group" "res-0" {
+}
+resource "azurerm_virtual_network" "res-1" {
+}
+resource "azurerm_public_ip" "res-2" {
+}
+`), 0600))
+	// The identities recorded by the other environment, the public ip isn't recorded (e.g. exported by a former version)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, EnvironmentResourcesFileName), []byte(`{
+  "azurerm_resource_group:/SUBSCRIPTIONS/{SUBSCRIPTION}/RESOURCEGROUPS/RG-{ENVIRONMENT}": "azurerm_resource_group.res-0",
+  "azurerm_virtual_network:/SUBSCRIPTIONS/{SUBSCRIPTION}/RESOURCEGROUPS/RG-{ENVIRONMENT}/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET1": "azurerm_virtual_network.res-1"
+}
+`), 0600))
+
+	meta := baseMeta{
+		logger:      slog.Default(),
+		outdir:      dir,
+		moduleDir:   dir,
+		environment: "prod",
+	}
+	cfgs, err := meta.removeSharedResources(ConfigInfos{
+		newConfig("azurerm_resource_group", "res-0", "/subscriptions/123/resourceGroups/rg-prod"),
+		// The same address is declared for a different virtual network
+		newConfig("azurerm_virtual_network", "res-1", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet2"),
+		newConfig("azurerm_public_ip", "res-2", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/publicIPAddresses/pip"),
+		newConfig("azurerm_network_security_group", "res-3", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/networkSecurityGroups/nsg"),
+	})
+	require.NoError(t, err)
+	var addrs []string
+	for _, cfg := range cfgs {
+		addrs = append(addrs, cfg.TFAddr.String())
+	}
+	require.Equal(t, []string{"azurerm_virtual_network.res-1", "azurerm_network_security_group.res-3"}, addrs)
+}
+
+func TestAlignSharedResources(t *testing.T) {
+	newItem := func(tfType, tfName, id string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		addr := tfaddr.TFAddr{Type: tfType, Name: tfName}
+		return ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: addr, TFAddrCache: addr}
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, EnvironmentResourcesFileName), []byte(`{
+  "azurerm_resource_group:/SUBSCRIPTIONS/{SUBSCRIPTION}/RESOURCEGROUPS/RG-{ENVIRONMENT}": "azurerm_resource_group.res-0",
+  "azurerm_virtual_network:/SUBSCRIPTIONS/{SUBSCRIPTION}/RESOURCEGROUPS/RG-{ENVIRONMENT}/PROVIDERS/MICROSOFT.NETWORK/VIRTUALNETWORKS/VNET": "azurerm_virtual_network.res-1"
+}
+`), 0600))
+
+	meta := baseMeta{
+		logger:      slog.Default(),
+		outdir:      dir,
+		moduleDir:   dir,
+		environment: "prod",
+	}
+	// This environment lists an extra virtual network ahead of the shared one, which takes the address of the shared one in the other environment.
+	l, err := meta.reconcileManagedResources(ImportList{
+		newItem("azurerm_resource_group", "res-0", "/subscriptions/123/resourceGroups/rg-prod"),
+		newItem("azurerm_virtual_network", "res-1", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet2"),
+		newItem("azurerm_virtual_network", "res-2", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet"),
+	})
+	require.Error(t, err)
+	require.ErrorContains(t, err, "the other environments")
+
+	meta.resolveAddressConflict = true
+	l, err = meta.reconcileManagedResources(ImportList{
+		newItem("azurerm_resource_group", "res-0", "/subscriptions/123/resourceGroups/rg-prod"),
+		newItem("azurerm_virtual_network", "res-1", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet2"),
+		newItem("azurerm_virtual_network", "res-2", "/subscriptions/123/resourceGroups/rg-prod/providers/Microsoft.Network/virtualNetworks/vnet"),
+	})
+	require.NoError(t, err)
+	var addrs []string
+	for _, item := range l {
+		addrs = append(addrs, item.TFAddr.String())
+	}
+	require.Equal(t, []string{"azurerm_resource_group.res-0", "azurerm_virtual_network.res-1_2", "azurerm_virtual_network.res-1"}, addrs)
+}
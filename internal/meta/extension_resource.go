@@ -8,6 +8,7 @@ import (
 
 type extBuilder struct {
 	includeRoleAssignment bool
+	includeLock           bool
 }
 
 func (b extBuilder) Build() []azlist.ExtensionResource {
@@ -36,6 +37,31 @@ func (b extBuilder) Build() []azlist.ExtensionResource {
 			},
 		})
 	}
+	if b.includeLock {
+		el = append(el, azlist.ExtensionResource{
+			Type: "Microsoft.Authorization/locks",
+			// Only the locks that are directly scoped to the resource are included, e.g. the inherited locks from the resource group are excluded.
+			Filter: func(res, extensionRes map[string]interface{}) bool {
+				idRaw, ok := res["id"]
+				if !ok {
+					return false
+				}
+				id := idRaw.(string)
+
+				extIdRaw, ok := extensionRes["id"]
+				if !ok {
+					return false
+				}
+				extId := extIdRaw.(string)
+
+				scope, _, ok := strings.Cut(strings.ToUpper(extId), "/PROVIDERS/MICROSOFT.AUTHORIZATION/LOCKS/")
+				if !ok {
+					return false
+				}
+				return strings.EqualFold(id, scope)
+			},
+		})
+	}
 
 	return el
 }
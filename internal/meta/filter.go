@@ -0,0 +1,364 @@
+package meta
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/magodo/armid"
+)
+
+// tagFilter filters the Azure resources by their tags.
+// The key of the include/exclude map is the tag name (case insensitive), the value is the tag value, where a nil value matches any value (while an empty value only matches the empty value).
+type tagFilter struct {
+	include map[string]*string
+	exclude map[string]*string
+}
+
+// Filter returns the resource set that only contains resources which have all the include tags and none of the exclude tags.
+// Only the resources that can be tagged are judged on their own tags, while the others inherit the decision of the resource they belong to (see ownerId),
+// e.g. a subnet follows its virtual network, and a role assignment follows the resource (or the resource group) that it is assigned to.
+// A resource group is kept if it matches by itself, or any resource within it is kept, so that the kept resources don't refer to a missing resource group.
+func (f tagFilter) Filter(rset *resourceset.AzureResourceSet) *resourceset.AzureResourceSet {
+	if len(f.include) == 0 && len(f.exclude) == 0 {
+		return rset
+	}
+	var ids []armid.ResourceId
+	props := map[string]map[string]interface{}{}
+	for _, res := range rset.Resources {
+		ids = append(ids, res.Id)
+		props[strings.ToUpper(res.Id.String())] = res.Properties
+	}
+	keep := filterByOwner(ids, func(id armid.ResourceId) bool {
+		return f.match(props[strings.ToUpper(id.String())])
+	})
+	var rl []resourceset.AzureResource
+	for _, res := range rset.Resources {
+		if keep[strings.ToUpper(res.Id.String())] {
+			rl = append(rl, res)
+		}
+	}
+	return &resourceset.AzureResourceSet{Resources: rl}
+}
+
+func (f tagFilter) match(props map[string]interface{}) bool {
+	tags := map[string]string{}
+	if m, ok := props["tags"].(map[string]interface{}); ok {
+		for k, v := range m {
+			v, _ := v.(string)
+			tags[strings.ToLower(k)] = v
+		}
+	}
+
+	hasTag := func(k string, v *string) bool {
+		tv, ok := tags[strings.ToLower(k)]
+		if !ok {
+			return false
+		}
+		return v == nil || *v == tv
+	}
+
+	for k, v := range f.include {
+		if !hasTag(k, v) {
+			return false
+		}
+	}
+	for k, v := range f.exclude {
+		if hasTag(k, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// extensionResourceTypes are the (upper cased) Azure resource types of the extension resources, which can be scoped to a resource group (or subscription) directly.
+// The extension resources scoped to another resource are identified by their resource ids.
+var extensionResourceTypes = map[string]bool{
+	"MICROSOFT.AUTHORIZATION/ROLEASSIGNMENTS":   true,
+	"MICROSOFT.AUTHORIZATION/LOCKS":             true,
+	"MICROSOFT.AUTHORIZATION/POLICYASSIGNMENTS": true,
+	"MICROSOFT.INSIGHTS/DIAGNOSTICSETTINGS":     true,
+}
+
+// ownerId returns the id of the resource that the resource belongs to when filtering, i.e. the parent resource of a child resource (e.g. the virtual network of a subnet),
+// or the scope of an extension resource (e.g. the resource or resource group that a role assignment is assigned to).
+// Nil is returned for the other resources (e.g. a resource directly within a resource group), which are judged by themselves.
+func ownerId(id armid.ResourceId) armid.ResourceId {
+	sid, ok := id.(*armid.ScopedResourceId)
+	if !ok {
+		return nil
+	}
+	if len(sid.Types()) > 1 {
+		return sid.Parent()
+	}
+	switch scope := sid.ParentScope().(type) {
+	case *armid.ScopedResourceId:
+		return scope
+	case *armid.ResourceGroup, *armid.SubscriptionId:
+		if extensionResourceTypes[strings.ToUpper(sid.TypeString())] {
+			return scope
+		}
+	}
+	return nil
+}
+
+// resourceGroupOf returns the resource group that the resource resides in, or nil if the resource isn't within a resource group.
+func resourceGroupOf(id armid.ResourceId) *armid.ResourceGroup {
+	for ; id != nil; id = id.ParentScope() {
+		if rg, ok := id.(*armid.ResourceGroup); ok {
+			return rg
+		}
+	}
+	return nil
+}
+
+// filterByOwner decides which of the resources are kept by the match function, and returns the upper cased ids of the kept ones.
+// The resources whose owner (see ownerId) is also in the list inherit the decision of the owner, rather than being matched by themselves.
+// A resource group is kept if it matches by itself, or any resource within it is kept.
+func filterByOwner(ids []armid.ResourceId, match func(id armid.ResourceId) bool) map[string]bool {
+	listed := map[string]bool{}
+	for _, id := range ids {
+		listed[strings.ToUpper(id.String())] = true
+	}
+	// top returns the upper cased id of the outermost listed owner of the resource, or the resource itself.
+	top := func(id armid.ResourceId) string {
+		for owner := ownerId(id); owner != nil && listed[strings.ToUpper(owner.String())]; owner = ownerId(owner) {
+			id = owner
+		}
+		return strings.ToUpper(id.String())
+	}
+
+	keep := map[string]bool{}
+	for _, id := range ids {
+		if _, ok := id.(*armid.ResourceGroup); ok {
+			continue
+		}
+		if top(id) == strings.ToUpper(id.String()) && match(id) {
+			keep[strings.ToUpper(id.String())] = true
+			if rg := resourceGroupOf(id); rg != nil {
+				keep[strings.ToUpper(rg.String())] = true
+			}
+		}
+	}
+	for _, id := range ids {
+		if _, ok := id.(*armid.ResourceGroup); ok && match(id) {
+			keep[strings.ToUpper(id.String())] = true
+		}
+	}
+
+	out := map[string]bool{}
+	for _, id := range ids {
+		if keep[strings.ToUpper(id.String())] || keep[top(id)] {
+			out[strings.ToUpper(id.String())] = true
+		}
+	}
+	return out
+}
+
+// typeFilter filters the resources by their types. Each pattern is either an Azure resource type (e.g. "Microsoft.Network/virtualNetworks"),
+// or a Terraform resource type (e.g. "azurerm_subnet"). A pattern that contains "/" is regarded as an Azure resource type.
+// The "*" in the pattern matches any sequence of characters (including "/"). The match is case insensitive.
+type typeFilter struct {
+	include []typePattern
+	exclude []typePattern
+}
+
+type typePattern struct {
+	isAzureType bool
+	re          *regexp.Regexp
+}
+
+func newTypeFilter(include, exclude []string) typeFilter {
+	build := func(patterns []string) []typePattern {
+		var out []typePattern
+		for _, p := range patterns {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			out = append(out, typePattern{
+				isAzureType: strings.Contains(p, "/"),
+				re:          utils.GlobToRegexp(p),
+			})
+		}
+		return out
+	}
+	return typeFilter{
+		include: build(include),
+		exclude: build(exclude),
+	}
+}
+
+func (f typeFilter) isEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Match tells whether a resource, with the Azure resource id and the Terraform resource type (empty if unknown), passes the filter.
+func (f typeFilter) Match(azureId armid.ResourceId, tfType string) bool {
+	azureType := azureId.TypeString()
+	matchAny := func(l []typePattern) bool {
+		for _, p := range l {
+			if p.isAzureType {
+				if p.re.MatchString(azureType) {
+					return true
+				}
+				continue
+			}
+			if tfType != "" && p.re.MatchString(tfType) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(f.include) != 0 && !matchAny(f.include) {
+		return false
+	}
+	return !matchAny(f.exclude)
+}
+
+// matchName tells whether the Azure resource name (i.e. the last segment of the resource id) matches the name filter.
+// A nil name filter matches any name.
+func matchName(re *regexp.Regexp, azureId armid.ResourceId) bool {
+	if re == nil {
+		return true
+	}
+	names := azureId.Names()
+	if len(names) == 0 {
+		return false
+	}
+	return re.MatchString(names[len(names)-1])
+}
+
+// skipFilter matches the resources that are always excluded by their Azure resource ids. Each pattern is either an Azure resource id,
+// where "*" matches any sequence of characters (including "/"), or a regular expression prefixed by "regex:". The match is case insensitive.
+type skipFilter []*regexp.Regexp
+
+func newSkipFilter(patterns []string) (skipFilter, error) {
+	var out skipFilter
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if expr, ok := strings.CutPrefix(p, resmap.RegexpRulePrefix); ok {
+			re, err := regexp.Compile("(?i)" + expr)
+			if err != nil {
+				return nil, fmt.Errorf("compiling the skip pattern %q: %v", p, err)
+			}
+			out = append(out, re)
+			continue
+		}
+		out = append(out, utils.GlobToRegexp(p))
+	}
+	return out, nil
+}
+
+// Match tells whether the Azure resource id matches any of the skip patterns.
+func (f skipFilter) Match(azureId armid.ResourceId) bool {
+	for _, re := range f {
+		if re.MatchString(azureId.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// nameIncludedIds returns the upper cased ids of the resources that pass the name filter, or nil if there is no name filter.
+// The child resources and the extension resources (e.g. role assignments) follow the name of their parent resource or scope (see filterByOwner),
+// and a resource group is kept if any resource within it is kept, so that the kept resources don't refer to the filtered out ones.
+func (meta baseMeta) nameIncludedIds(ids []armid.ResourceId) map[string]bool {
+	if meta.nameFilter == nil {
+		return nil
+	}
+	return filterByOwner(ids, func(id armid.ResourceId) bool {
+		return matchName(meta.nameFilter, id)
+	})
+}
+
+// isResourceIncluded tells whether a resource, with the Azure resource id and the Terraform resource type (empty if unknown), passes the type filter and the name filter, and is not skipped.
+// The nameIncluded is returned by nameIncludedIds for all the resources.
+func (meta baseMeta) isResourceIncluded(azureId armid.ResourceId, tfType string, nameIncluded map[string]bool) bool {
+	if meta.nameFilter != nil && !nameIncluded[strings.ToUpper(azureId.String())] {
+		return false
+	}
+	return meta.typeFilter.Match(azureId, tfType) && !meta.skipFilter.Match(azureId)
+}
+
+// filterTFResources returns the TF resources that pass the type filter and the name filter, and are not skipped.
+// The filtered out resources are recorded for the coverage report.
+func (meta *baseMeta) filterTFResources(rl []resourceset.TFResource) []resourceset.TFResource {
+	if meta.typeFilter.isEmpty() && meta.nameFilter == nil && len(meta.skipFilter) == 0 {
+		return rl
+	}
+	var ids []armid.ResourceId
+	for _, res := range rl {
+		ids = append(ids, res.AzureId)
+	}
+	nameIncluded := meta.nameIncludedIds(ids)
+	var out []resourceset.TFResource
+	for _, res := range rl {
+		if meta.isResourceIncluded(res.AzureId, res.TFType, nameIncluded) {
+			out = append(out, res)
+			continue
+		}
+		meta.filteredResources = append(meta.filteredResources, res)
+	}
+	return out
+}
+
+// embeddedParent is a parent TF resource type that embeds its child resources in the config, by the attribute (or nested block).
+type embeddedParent struct {
+	tfType    string
+	attribute string
+}
+
+// embeddedChildResources is the allowlist of the child TF resource types that are embedded in their parent's schema, keyed by the child TF resource type.
+// The other child resources (e.g. azurerm_mssql_database, azurerm_storage_container) are managed separately, which are never excluded.
+var embeddedChildResources = map[string][]embeddedParent{
+	"azurerm_subnet":                {{tfType: "azurerm_virtual_network", attribute: "subnet"}},
+	"azurerm_network_security_rule": {{tfType: "azurerm_network_security_group", attribute: "security_rule"}},
+	"azurerm_route":                 {{tfType: "azurerm_route_table", attribute: "route"}},
+	"azurerm_virtual_machine_scale_set_extension": {
+		{tfType: "azurerm_linux_virtual_machine_scale_set", attribute: "extension"},
+		{tfType: "azurerm_windows_virtual_machine_scale_set", attribute: "extension"},
+	},
+}
+
+// excludeChildResources removes the TF resources that are embedded in their parent TF resource (e.g. the subnets of a virtual network), when the parent is also in the list.
+// Only the child TF resource types in embeddedChildResources are regarded as embedded, the others (e.g. the databases of a SQL server) are kept as separate resources.
+// Extension resources (e.g. role assignments) scoped to a resource in the list are not regarded as child resources.
+func excludeChildResources(logger *slog.Logger, rl []resourceset.TFResource) []resourceset.TFResource {
+	// The key is the upper cased Azure resource id, the value is the TF resource type.
+	tfTypes := map[string]string{}
+	for _, res := range rl {
+		tfTypes[strings.ToUpper(res.AzureId.String())] = res.TFType
+	}
+	embeddedBy := func(res resourceset.TFResource) (armid.ResourceId, *embeddedParent) {
+		if len(res.AzureId.Types()) < 2 {
+			return nil, nil
+		}
+		pid := res.AzureId.Parent()
+		if pid == nil {
+			return nil, nil
+		}
+		for _, parent := range embeddedChildResources[res.TFType] {
+			if parent.tfType == tfTypes[strings.ToUpper(pid.String())] {
+				return pid, &parent
+			}
+		}
+		return nil, nil
+	}
+	var out []resourceset.TFResource
+	for _, res := range rl {
+		if pid, parent := embeddedBy(res); parent != nil {
+			logger.Info("Exclude the child resource that is embedded in its parent", "id", res.AzureId.String(), "tf_type", res.TFType, "parent", pid.String(), "attribute", parent.attribute)
+			continue
+		}
+		out = append(out, res)
+	}
+	return out
+}
@@ -0,0 +1,329 @@
+package meta
+
+import (
+	"log/slog"
+	"regexp"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagFilterMatch(t *testing.T) {
+	props := map[string]interface{}{
+		"tags": map[string]interface{}{
+			"Env":  "prod",
+			"team": "network",
+		},
+	}
+
+	cases := []struct {
+		name    string
+		filter  tagFilter
+		props   map[string]interface{}
+		isMatch bool
+	}{
+		{
+			name:    "no filter",
+			filter:  tagFilter{},
+			props:   props,
+			isMatch: true,
+		},
+		{
+			name:    "include tag matches (tag name is case insensitive)",
+			filter:  tagFilter{include: map[string]*string{"env": ptr("prod")}},
+			props:   props,
+			isMatch: true,
+		},
+		{
+			name:    "include tag value mismatches",
+			filter:  tagFilter{include: map[string]*string{"env": ptr("dev")}},
+			props:   props,
+			isMatch: false,
+		},
+		{
+			name:    "include tag with nil value matches any value",
+			filter:  tagFilter{include: map[string]*string{"team": nil}},
+			props:   props,
+			isMatch: true,
+		},
+		{
+			name:    "include tag with empty value only matches the empty value",
+			filter:  tagFilter{include: map[string]*string{"team": ptr("")}},
+			props:   props,
+			isMatch: false,
+		},
+		{
+			name:    "include tag with empty value matches the empty value",
+			filter:  tagFilter{include: map[string]*string{"team": ptr("")}},
+			props:   map[string]interface{}{"tags": map[string]interface{}{"team": ""}},
+			isMatch: true,
+		},
+		{
+			name:    "all include tags are required",
+			filter:  tagFilter{include: map[string]*string{"env": ptr("prod"), "owner": nil}},
+			props:   props,
+			isMatch: false,
+		},
+		{
+			name:    "exclude tag matches",
+			filter:  tagFilter{exclude: map[string]*string{"team": ptr("network")}},
+			props:   props,
+			isMatch: false,
+		},
+		{
+			name:    "exclude tag mismatches",
+			filter:  tagFilter{exclude: map[string]*string{"team": ptr("compute")}},
+			props:   props,
+			isMatch: true,
+		},
+		{
+			name:    "resource without tags",
+			filter:  tagFilter{include: map[string]*string{"env": nil}},
+			props:   nil,
+			isMatch: false,
+		},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.isMatch, c.filter.match(c.props), c.name)
+	}
+}
+
+func TestTagFilterFilter(t *testing.T) {
+	newRes := func(id string, tags map[string]interface{}) resourceset.AzureResource {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		var props map[string]interface{}
+		if tags != nil {
+			props = map[string]interface{}{"tags": tags}
+		}
+		return resourceset.AzureResource{Id: azureId, Properties: props}
+	}
+	const (
+		rg          = "/subscriptions/123/resourceGroups/rg"
+		vnet        = rg + "/providers/Microsoft.Network/virtualNetworks/vnet"
+		subnet      = vnet + "/subnets/subnet"
+		vnetLock    = vnet + "/providers/Microsoft.Authorization/locks/lock"
+		rgRole      = rg + "/providers/Microsoft.Authorization/roleAssignments/00000000-0000-0000-0000-000000000000"
+		nsg         = rg + "/providers/Microsoft.Network/networkSecurityGroups/nsg"
+		nsgRule     = nsg + "/securityRules/rule"
+		otherRg     = "/subscriptions/123/resourceGroups/other"
+		otherRgRole = otherRg + "/providers/Microsoft.Authorization/roleAssignments/11111111-1111-1111-1111-111111111111"
+		otherRgVnet = otherRg + "/providers/Microsoft.Network/virtualNetworks/vnet"
+		taggedRg    = "/subscriptions/123/resourceGroups/tagged"
+	)
+	rset := &resourceset.AzureResourceSet{
+		Resources: []resourceset.AzureResource{
+			newRes(rg, nil),
+			newRes(vnet, map[string]interface{}{"env": "prod"}),
+			newRes(subnet, nil),
+			newRes(vnetLock, nil),
+			newRes(rgRole, nil),
+			newRes(nsg, map[string]interface{}{"env": "dev"}),
+			newRes(nsgRule, nil),
+			newRes(otherRg, nil),
+			newRes(otherRgRole, nil),
+			newRes(otherRgVnet, nil),
+			newRes(taggedRg, map[string]interface{}{"env": "prod"}),
+		},
+	}
+
+	ids := func(rset *resourceset.AzureResourceSet) []string {
+		var out []string
+		for _, res := range rset.Resources {
+			out = append(out, res.Id.String())
+		}
+		return out
+	}
+
+	// The children and extension resources follow their parent or scope, the resource group is kept as it contains kept resources.
+	require.Equal(t,
+		[]string{rg, vnet, subnet, vnetLock, rgRole, taggedRg},
+		ids(tagFilter{include: map[string]*string{"env": ptr("prod")}}.Filter(rset)),
+	)
+	require.Equal(t,
+		[]string{rg, vnet, subnet, vnetLock, rgRole, otherRg, otherRgRole, otherRgVnet, taggedRg},
+		ids(tagFilter{exclude: map[string]*string{"env": ptr("dev")}}.Filter(rset)),
+	)
+}
+
+func TestTypeFilterMatch(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name    string
+		include []string
+		exclude []string
+		tfType  string
+		isMatch bool
+	}{
+		{
+			name:    "no filter",
+			isMatch: true,
+		},
+		{
+			name:    "include Azure type (case insensitive)",
+			include: []string{"microsoft.network/virtualnetworks/subnets"},
+			isMatch: true,
+		},
+		{
+			name:    "include Azure type with wildcard",
+			include: []string{"Microsoft.Network/*"},
+			isMatch: true,
+		},
+		{
+			name:    "include Azure type mismatches",
+			include: []string{"Microsoft.Network/virtualNetworks"},
+			isMatch: false,
+		},
+		{
+			name:    "include TF type",
+			include: []string{"azurerm_subnet"},
+			tfType:  "azurerm_subnet",
+			isMatch: true,
+		},
+		{
+			name:    "include TF type of unknown TF type",
+			include: []string{"azurerm_*"},
+			isMatch: false,
+		},
+		{
+			name:    "exclude TF type with wildcard",
+			exclude: []string{"azurerm_sub*"},
+			tfType:  "azurerm_subnet",
+			isMatch: false,
+		},
+		{
+			name:    "include and exclude",
+			include: []string{"Microsoft.Network/*"},
+			exclude: []string{"*/subnets"},
+			isMatch: false,
+		},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.isMatch, newTypeFilter(c.include, c.exclude).Match(id, c.tfType), c.name)
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/app1-vnet/subnets/app1-subnet")
+	require.NoError(t, err)
+
+	require.True(t, matchName(nil, id))
+	require.True(t, matchName(regexp.MustCompile("^app1-"), id))
+	require.True(t, matchName(regexp.MustCompile("subnet$"), id))
+	require.False(t, matchName(regexp.MustCompile("vnet$"), id))
+	require.False(t, matchName(regexp.MustCompile("^app2-"), id))
+}
+
+func TestFilterTFResourcesByName(t *testing.T) {
+	const (
+		rg         = "/subscriptions/123/resourceGroups/rg"
+		rgRole     = rg + "/providers/Microsoft.Authorization/roleAssignments/00000000-0000-0000-0000-000000000000"
+		vnet       = rg + "/providers/Microsoft.Network/virtualNetworks/app1-vnet"
+		subnet     = vnet + "/subnets/default"
+		vnetRole   = vnet + "/providers/Microsoft.Authorization/roleAssignments/11111111-1111-1111-1111-111111111111"
+		otherVnet  = rg + "/providers/Microsoft.Network/virtualNetworks/app2-vnet"
+		otherRg    = "/subscriptions/123/resourceGroups/other"
+		otherRgPip = otherRg + "/providers/Microsoft.Network/publicIPAddresses/app2-pip"
+	)
+	var rl []resourceset.TFResource
+	for _, id := range []string{rg, rgRole, vnet, subnet, vnetRole, otherVnet, otherRg, otherRgPip} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		rl = append(rl, resourceset.TFResource{AzureId: azureId, TFId: id})
+	}
+
+	meta := baseMeta{nameFilter: regexp.MustCompile("^app1-")}
+	var ids []string
+	for _, res := range meta.filterTFResources(rl) {
+		ids = append(ids, res.AzureId.String())
+	}
+	// The resource group and its role assignment are kept for the kept virtual network, so are the subnet and the role assignment of the virtual network.
+	require.Equal(t, []string{rg, rgRole, vnet, subnet, vnetRole}, ids)
+	require.Len(t, meta.filteredResources, 3)
+}
+
+func TestSkipFilterMatch(t *testing.T) {
+	f, err := newSkipFilter([]string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+		"/subscriptions/*/resourceGroups/MC_*",
+		"regex:/providers/Microsoft\\.Insights/diagnosticSettings/[^/]+$",
+		"",
+	})
+	require.NoError(t, err)
+	require.Len(t, f, 3)
+
+	for id, isMatch := range map[string]bool{
+		"/subscriptions/123/resourceGroups/RG/providers/Microsoft.Network/virtualNetworks/VNET":                                       true,
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet":                        false,
+		"/subscriptions/123/resourceGroups/mc_rg_aks_eastus":                                                                          true,
+		"/subscriptions/123/resourceGroups/MC_rg_aks_eastus/providers/Microsoft.Network/loadBalancers/kubernetes":                     true,
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Web/sites/app/providers/Microsoft.Insights/diagnosticSettings/diag": true,
+		"/subscriptions/123/resourceGroups/rg":                                                                                        false,
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		require.Equal(t, isMatch, f.Match(azureId), id)
+	}
+
+	_, err = newSkipFilter([]string{"regex:("})
+	require.Error(t, err)
+}
+
+func TestExcludeChildResources(t *testing.T) {
+	const rgId = "/subscriptions/123/resourceGroups/rg"
+	var rl []resourceset.TFResource
+	for _, res := range []struct {
+		id     string
+		tfType string
+	}{
+		{rgId, "azurerm_resource_group"},
+		{rgId + "/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network"},
+		{rgId + "/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet", "azurerm_subnet"},
+		{rgId + "/providers/Microsoft.Network/virtualNetworks/vnet/providers/Microsoft.Authorization/locks/lock", "azurerm_management_lock"},
+		{rgId + "/providers/Microsoft.Network/networkSecurityGroups/nsg/securityRules/rule", "azurerm_network_security_rule"},
+		{rgId + "/providers/Microsoft.Sql/servers/server", "azurerm_mssql_server"},
+		{rgId + "/providers/Microsoft.Sql/servers/server/databases/db", "azurerm_mssql_database"},
+		{rgId + "/providers/Microsoft.Storage/storageAccounts/sa", "azurerm_storage_account"},
+		{rgId + "/providers/Microsoft.Storage/storageAccounts/sa/blobServices/default/containers/container", "azurerm_storage_container"},
+	} {
+		azureId, err := armid.ParseResourceId(res.id)
+		require.NoError(t, err)
+		rl = append(rl, resourceset.TFResource{AzureId: azureId, TFType: res.tfType})
+	}
+
+	var ids []string
+	for _, res := range excludeChildResources(slog.Default(), rl) {
+		ids = append(ids, res.AzureId.String())
+	}
+	// Only the subnet is excluded, as it is embedded in the virtual network. The NSG isn't in the list, and the other children aren't embedded in their parents.
+	require.Equal(t, []string{
+		rgId,
+		rgId + "/providers/Microsoft.Network/virtualNetworks/vnet",
+		rgId + "/providers/Microsoft.Network/virtualNetworks/vnet/providers/Microsoft.Authorization/locks/lock",
+		rgId + "/providers/Microsoft.Network/networkSecurityGroups/nsg/securityRules/rule",
+		rgId + "/providers/Microsoft.Sql/servers/server",
+		rgId + "/providers/Microsoft.Sql/servers/server/databases/db",
+		rgId + "/providers/Microsoft.Storage/storageAccounts/sa",
+		rgId + "/providers/Microsoft.Storage/storageAccounts/sa/blobServices/default/containers/container",
+	}, ids)
+}
+
+func TestEmbeddedChildResourcesSchema(t *testing.T) {
+	for child, parents := range embeddedChildResources {
+		require.Contains(t, azurerm.ProviderSchemaInfo.ResourceSchemas, child)
+		for _, parent := range parents {
+			sch, ok := azurerm.ProviderSchemaInfo.ResourceSchemas[parent.tfType]
+			require.True(t, ok, parent.tfType)
+			_, isAttr := sch.Block.Attributes.Map()[parent.attribute]
+			_, isBlock := sch.Block.BlockTypes.Map()[parent.attribute]
+			require.True(t, isAttr || isBlock, "%s.%s embedding %s", parent.tfType, parent.attribute, child)
+		}
+	}
+}
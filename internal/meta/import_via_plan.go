@@ -0,0 +1,146 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/magodo/tfmerge/tfmerge"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	importViaPlanImportFileName    = "import.aztfexport.tf"
+	importViaPlanGeneratedFileName = "generated.aztfexport.tf"
+	importViaPlanPlanFileName      = "aztfexport.tfplan"
+)
+
+// importViaPlanMinTerraformVersion is the minimum terraform version that supports the import blocks, and generating their config during the plan.
+var importViaPlanMinTerraformVersion = version.Must(version.NewVersion("1.5.0"))
+
+// buildImportBlocksConfig builds the import blocks of the items, together with the aliased providers for the items that reside in other subscriptions.
+func (meta baseMeta) buildImportBlocksConfig(items []*ImportItem) string {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	aliases := map[string]bool{}
+	for _, item := range items {
+		blk := body.AppendNewBlock("import", nil)
+		blk.Body().SetAttributeValue("id", cty.StringVal(item.TFResourceId))
+		blk.Body().SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: item.TFAddr.Type}, hcl.TraverseAttr{Name: item.TFAddr.Name}})
+		if alias, subscriptionId := meta.providerAlias(item.AzureResourceID, item.TFAddr.Type); alias != "" {
+			blk.Body().SetAttributeTraversal("provider", meta.providerAliasTraversal(alias))
+			if !aliases[alias] {
+				aliases[alias] = true
+				body.AppendBlock(meta.buildAliasProviderBlock(alias, subscriptionId, true))
+			}
+		}
+	}
+	return string(f.Bytes())
+}
+
+// importItemsViaPlan imports the items in a single "terraform plan" (with the import blocks, whose config is generated by the plan) and "terraform apply" round-trip
+// in the first import directory, instead of running "terraform import" per item. The plan is only applied if it does nothing but importing, so that no remote object is changed.
+// Nothing is imported if an error is returned.
+func (meta *baseMeta) importItemsViaPlan(ctx context.Context, items []*ImportItem) error {
+	var importItems []*ImportItem
+	for _, item := range items {
+		if !item.Skip() {
+			importItems = append(importItems, item)
+		}
+	}
+	if len(importItems) == 0 {
+		return nil
+	}
+
+	dir := meta.importBaseDirs[0]
+	tf := meta.importTFs[0]
+
+	tfVersion, _, err := tf.Version(ctx, false)
+	if err != nil {
+		return fmt.Errorf("retrieving the Terraform version: %v", err)
+	}
+	if tfVersion.LessThan(importViaPlanMinTerraformVersion) {
+		return fmt.Errorf("importing via plan requires Terraform %s or later, got %s", importViaPlanMinTerraformVersion, tfVersion)
+	}
+
+	importFile := filepath.Join(dir, importViaPlanImportFileName)
+	generatedFile := filepath.Join(dir, importViaPlanGeneratedFileName)
+	planFile := filepath.Join(dir, importViaPlanPlanFileName)
+	stateFile := filepath.Join(dir, "terraform.tfstate")
+	for _, path := range []string{importFile, generatedFile, planFile} {
+		// #nosec G104
+		defer os.Remove(path)
+	}
+
+	// #nosec G306
+	if err := os.WriteFile(importFile, []byte(meta.buildImportBlocksConfig(importItems)), 0644); err != nil {
+		return fmt.Errorf("writing the import blocks: %v", err)
+	}
+
+	startTime := time.Now()
+	meta.Logger().Info("Importing resources via plan", "count", len(importItems))
+	// The "-generate-config-out" option is not supported by terraform-exec.
+	var stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, tf.ExecPath(), "plan", "-input=false", "-no-color", "-lock=false", "-generate-config-out="+importViaPlanGeneratedFileName, "-out="+importViaPlanPlanFileName)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running terraform plan: %v: %s", err, stderr.String())
+	}
+
+	plan, err := tf.ShowPlanFile(ctx, planFile)
+	if err != nil {
+		return fmt.Errorf("showing the plan: %v", err)
+	}
+	for _, rc := range plan.ResourceChanges {
+		if rc.Change == nil || rc.Change.Importing == nil || !rc.Change.Actions.NoOp() {
+			return fmt.Errorf("the plan does more than importing %s, which is not applied", rc.Address)
+		}
+	}
+
+	if err := tf.Apply(ctx, tfexec.DirOrPlan(planFile)); err != nil {
+		// #nosec G104
+		os.Remove(stateFile)
+		return fmt.Errorf("applying the plan: %v", err)
+	}
+
+	meta.Logger().Debug("Merging terraform state file (tfmerge)", "file", stateFile)
+	newState, err := tfmerge.Merge(ctx, meta.tf, meta.baseState, stateFile)
+	// #nosec G104
+	os.Remove(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to merge state file: %v", err)
+	}
+	meta.baseState = newState
+
+	// The hooks are only called for the imported items, as the items are imported one by one instead if failed.
+	importedAt := time.Now()
+	for _, item := range importItems {
+		iitem := config.ImportItem{
+			AzureResourceID: item.AzureResourceID,
+			TFResourceId:    item.TFResourceId,
+			TFAddr:          item.TFAddr,
+		}
+		if meta.preImportHook != nil {
+			meta.preImportHook(startTime, iitem)
+		}
+		item.ImportError = nil
+		item.Imported = true
+		item.ImportedAt = importedAt
+		if meta.postImportHook != nil {
+			meta.postImportHook(startTime, iitem)
+		}
+	}
+	return nil
+}
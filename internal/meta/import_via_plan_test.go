@@ -0,0 +1,51 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildImportBlocksConfig(t *testing.T) {
+	meta := baseMeta{
+		providerName:   "azurerm",
+		subscriptionId: "00000000-0000-0000-0000-000000000000",
+	}
+	newItem := func(id, name string) *ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return &ImportItem{
+			AzureResourceID: azureId,
+			TFResourceId:    id,
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: name},
+		}
+	}
+	items := []*ImportItem{
+		newItem("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1", "rg1"),
+		newItem("/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg2", "rg2"),
+		newItem("/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg3", "rg3"),
+	}
+	require.Equal(t, `import {
+  id = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg1"
+  to = azurerm_resource_group.rg1
+}
+import {
+  id       = "/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg2"
+  to       = azurerm_resource_group.rg2
+  provider = azurerm.subscription_11111111_1111_1111_1111_111111111111
+}
+provider "azurerm" {
+  alias = "subscription_11111111_1111_1111_1111_111111111111"
+  features {
+  }
+  subscription_id = "11111111-1111-1111-1111-111111111111"
+}
+import {
+  id       = "/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg3"
+  to       = azurerm_resource_group.rg3
+  provider = azurerm.subscription_11111111_1111_1111_1111_111111111111
+}
+`, meta.buildImportBlocksConfig(items))
+}
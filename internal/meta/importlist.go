@@ -1,6 +1,9 @@
 package meta
 
 import (
+	"strings"
+	"time"
+
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/magodo/armid"
 	"github.com/zclconf/go-cty/cty"
@@ -19,6 +22,9 @@ type ImportItem struct {
 	// Whether this azure resource has been successfully imported
 	Imported bool
 
+	// The time when this azure resource is imported, which is zero if not imported (or is imported by an old session)
+	ImportedAt time.Time
+
 	// Whether this azure resource failed to validate into terraform (tbh, this should reside in UI layer only)
 	ValidateError error
 
@@ -33,8 +39,14 @@ type ImportItem struct {
 
 	Recommendations []string
 
+	// The detail of the Azure resource, which is only used by the interactive mode. It is nil if unknown (e.g. restored from a session).
+	Detail *ResourceDetail
+
 	// State is what is being imported&read by terraform-plugin-go client. It is nil when importing via terraform binary.
 	State cty.Value
+
+	// StatePrivate is the provider's private data of the State. It is nil when importing via terraform binary.
+	StatePrivate []byte
 }
 
 func (item ImportItem) Skip() bool {
@@ -84,3 +96,24 @@ func (l ImportList) Imported() ImportList {
 	}
 	return out
 }
+
+// Remap overrides the TF resource id and address of the items with the ones of the same azure resource in the other list (e.g. read from a resource mapping file).
+// The items that don't exist in the other list are kept as is.
+func (l ImportList) Remap(other ImportList) ImportList {
+	m := map[string]ImportItem{}
+	for _, item := range other {
+		m[strings.ToUpper(item.AzureResourceID.String())] = item
+	}
+	var out ImportList
+	for _, item := range l {
+		if oitem, ok := m[strings.ToUpper(item.AzureResourceID.String())]; ok {
+			item.TFResourceId = oitem.TFResourceId
+			item.TFAddr = oitem.TFAddr
+			item.TFAddrCache = oitem.TFAddrCache
+			item.IsRecommended = oitem.IsRecommended
+			item.Recommendations = oitem.Recommendations
+		}
+		out = append(out, item)
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package meta
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	lockFileName     = ".terraform.lock.hcl"
+	providerRegistry = "registry.terraform.io"
+)
+
+// lockedProviderVersions returns the provider versions locked in the dependency lock file of the directory, which are keyed by the fully qualified provider addresses
+// (e.g. "registry.terraform.io/hashicorp/azurerm"). A nil map is returned if the lock file doesn't exist.
+func lockedProviderVersions(dir string) (map[string]*version.Version, error) {
+	path := filepath.Join(dir, lockFileName)
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading the lock file %s: %v", path, err)
+	}
+	f, diags := hclsyntax.ParseConfig(b, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing the lock file %s: %v", path, diags.Error())
+	}
+	out := map[string]*version.Version{}
+	for _, blk := range f.Body.(*hclsyntax.Body).Blocks {
+		if blk.Type != "provider" || len(blk.Labels) != 1 {
+			continue
+		}
+		attr, ok := blk.Body.Attributes["version"]
+		if !ok {
+			continue
+		}
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() || val.Type() != cty.String {
+			return nil, fmt.Errorf("invalid version of the provider %q in the lock file %s", blk.Labels[0], path)
+		}
+		v, err := version.NewVersion(val.AsString())
+		if err != nil {
+			return nil, fmt.Errorf("parsing the version of the provider %q in the lock file %s: %v", blk.Labels[0], path, err)
+		}
+		out[strings.ToLower(blk.Labels[0])] = v
+	}
+	return out, nil
+}
+
+// providerInstalled tells whether the provider (of the fully qualified address) of the version is installed in the ".terraform" directory of the directory, for the current platform.
+func providerInstalled(dir, addr string, v *version.Version) bool {
+	_, err := os.Stat(filepath.Join(dir, ".terraform", "providers", filepath.FromSlash(addr), v.String(), runtime.GOOS+"_"+runtime.GOARCH))
+	return err == nil
+}
+
+// outputDirInitialized tells whether the output directory is already initialized by a former run, so that the "terraform init" can be skipped:
+//   - No backend config is specified, which requires reinitializing the backend.
+//   - The backend recorded in the ".terraform" directory is of the backend type (unless it is the local backend).
+//   - The required providers are locked (and the provider satisfies the provider version) and installed.
+func (meta baseMeta) outputDirInitialized() (bool, error) {
+	if len(meta.backendConfig) != 0 {
+		return false, nil
+	}
+	if meta.backendType != "" && meta.backendType != "local" {
+		// #nosec G304
+		b, err := os.ReadFile(filepath.Join(meta.outdir, ".terraform", "terraform.tfstate"))
+		if err != nil {
+			return false, nil
+		}
+		var backendState struct {
+			Backend struct {
+				Type string `json:"type"`
+			} `json:"backend"`
+		}
+		if err := json.Unmarshal(b, &backendState); err != nil || backendState.Backend.Type != meta.backendType {
+			return false, nil
+		}
+	}
+
+	versions, err := lockedProviderVersions(meta.outdir)
+	if err != nil {
+		return false, err
+	}
+	addr := providerRegistry + "/" + meta.providerSource()
+	v, ok := versions[addr]
+	if !ok || !providerInstalled(meta.outdir, addr, v) {
+		return false, nil
+	}
+	if meta.providerVersion != "" {
+		constraints, err := version.NewConstraint(meta.providerVersion)
+		if err != nil {
+			return false, fmt.Errorf("parsing the provider version %q: %v", meta.providerVersion, err)
+		}
+		if !constraints.Check(v) {
+			return false, nil
+		}
+	}
+	if meta.withAzAPI {
+		addr := providerRegistry + "/azure/azapi"
+		v, ok := versions[addr]
+		if !ok || !providerInstalled(meta.outdir, addr, v) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// sharedProviderDir returns the directory in the user cache directory that holds the providers initialized for the terraform config (of the import directories),
+// which is shared by the import directories across runs.
+func sharedProviderDir(tfConfig string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("retrieving the user's cache directory: %v", err)
+	}
+	sum := sha256.Sum256([]byte(tfConfig))
+	return filepath.Join(cacheDir, "aztfexport", "providers", hex.EncodeToString(sum[:8])), nil
+}
+
+// ensureSharedProviderDir returns the shared provider directory for the terraform config of the import directories, which is initialized if not yet.
+// The directory is initialized in a temporary directory aside, which is then renamed, so that the concurrent runs never see a partially initialized one.
+func (meta baseMeta) ensureSharedProviderDir(ctx context.Context) (string, error) {
+	tfConfig := meta.buildTerraformConfig("")
+	dir, err := sharedProviderDir(tfConfig)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filepath.Join(dir, lockFileName)); err == nil {
+		return dir, nil
+	}
+
+	// #nosec G301
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", fmt.Errorf("creating the shared provider directory: %v", err)
+	}
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+"-")
+	if err != nil {
+		return "", fmt.Errorf("creating the shared provider directory: %v", err)
+	}
+	// #nosec G104
+	defer os.RemoveAll(tmpDir)
+
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(tmpDir, "terraform.tf"), []byte(tfConfig), 0644); err != nil {
+		return "", fmt.Errorf("error creating terraform config: %w", err)
+	}
+	tf, err := tfexec.NewTerraform(tmpDir, meta.tf.ExecPath())
+	if err != nil {
+		return "", fmt.Errorf("error running NewTerraform: %w", err)
+	}
+	meta.Logger().Debug(`Run "terraform init" for the shared provider directory`, "dir", dir)
+	if err := tf.Init(ctx); err != nil {
+		return "", fmt.Errorf("error running terraform init: %s", err)
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		// Another run might have initialized it in the meanwhile.
+		if _, serr := os.Stat(filepath.Join(dir, lockFileName)); serr == nil {
+			return dir, nil
+		}
+		return "", fmt.Errorf("renaming the shared provider directory: %v", err)
+	}
+	return dir, nil
+}
+
+// linkSharedProviderDir makes the directory initialized by the shared provider directory, by copying its lock file and linking its installed providers.
+func linkSharedProviderDir(sharedDir, dir string) error {
+	// #nosec G304
+	b, err := os.ReadFile(filepath.Join(sharedDir, lockFileName))
+	if err != nil {
+		return err
+	}
+	// #nosec G306
+	if err := os.WriteFile(filepath.Join(dir, lockFileName), b, 0644); err != nil {
+		return err
+	}
+	// #nosec G301
+	if err := os.MkdirAll(filepath.Join(dir, ".terraform"), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(filepath.Join(sharedDir, ".terraform", "providers"), filepath.Join(dir, ".terraform", "providers"))
+}
@@ -0,0 +1,100 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+const testLockFile = `provider "registry.terraform.io/hashicorp/azurerm" {
+  version     = "3.99.0"
+  constraints = "3.99.0"
+  hashes = [
+    "h1:foo=",
+  ]
+}
+`
+
+func writeInitializedDir(t *testing.T, dir string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, lockFileName), []byte(testLockFile), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".terraform", "providers", "registry.terraform.io", "hashicorp", "azurerm", "3.99.0", runtime.GOOS+"_"+runtime.GOARCH), 0755))
+}
+
+func TestLockedProviderVersions(t *testing.T) {
+	dir := t.TempDir()
+	versions, err := lockedProviderVersions(dir)
+	require.NoError(t, err)
+	require.Nil(t, versions)
+
+	writeInitializedDir(t, dir)
+	versions, err = lockedProviderVersions(dir)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	require.Equal(t, "3.99.0", versions["registry.terraform.io/hashicorp/azurerm"].String())
+}
+
+func TestOutputDirInitialized(t *testing.T) {
+	dir := t.TempDir()
+	writeInitializedDir(t, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".terraform", "terraform.tfstate"), []byte(`{"backend": {"type": "azurerm"}}`), 0644))
+
+	cases := []struct {
+		name   string
+		meta   baseMeta
+		expect bool
+	}{
+		{
+			name:   "matching provider version",
+			meta:   baseMeta{outdir: dir, providerName: "azurerm", providerVersion: "3.99.0"},
+			expect: true,
+		},
+		{
+			name: "mismatching provider version",
+			meta: baseMeta{outdir: dir, providerName: "azurerm", providerVersion: "3.100.0"},
+		},
+		{
+			name: "provider not locked",
+			meta: baseMeta{outdir: dir, providerName: "azapi"},
+		},
+		{
+			name:   "matching backend",
+			meta:   baseMeta{outdir: dir, providerName: "azurerm", backendType: "azurerm"},
+			expect: true,
+		},
+		{
+			name: "mismatching backend",
+			meta: baseMeta{outdir: dir, providerName: "azurerm", backendType: "remote"},
+		},
+		{
+			name: "backend config",
+			meta: baseMeta{outdir: dir, providerName: "azurerm", backendType: "azurerm", backendConfig: []string{"key=foo"}},
+		},
+		{
+			name: "not initialized",
+			meta: baseMeta{outdir: t.TempDir(), providerName: "azurerm"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			initialized, err := tt.meta.outputDirInitialized()
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, initialized)
+		})
+	}
+}
+
+func TestLinkSharedProviderDir(t *testing.T) {
+	sharedDir := t.TempDir()
+	writeInitializedDir(t, sharedDir)
+
+	dir := t.TempDir()
+	require.NoError(t, linkSharedProviderDir(sharedDir, dir))
+	b, err := os.ReadFile(filepath.Join(dir, lockFileName))
+	require.NoError(t, err)
+	require.Equal(t, testLockFile, string(b))
+	require.True(t, providerInstalled(dir, "registry.terraform.io/hashicorp/azurerm", version.Must(version.NewVersion("3.99.0"))))
+}
@@ -0,0 +1,91 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InventoryFileName is the inventory of the resources imported by the former delta runs, which is written to the output directory.
+const InventoryFileName = "aztfexportInventory.json"
+
+// inventory records the Azure resources that are imported by the former delta runs, so that the next delta run only processes the resources created or left unimported since then.
+type inventory struct {
+	// The RFC3339 formatted time of the last delta run
+	UpdatedAt string `json:"updated_at"`
+	// The upper cased Azure resource ids in alphabetical order
+	ResourceIds []string `json:"resource_ids"`
+}
+
+// readInventory returns the set of the Azure resource ids (in upper case) recorded in the inventory of the output directory, which is empty if there is no inventory yet.
+func (meta baseMeta) readInventory() (map[string]bool, error) {
+	path := filepath.Join(meta.outdir, InventoryFileName)
+	ids := map[string]bool{}
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ids, nil
+		}
+		return nil, fmt.Errorf("reading the inventory %s: %v", path, err)
+	}
+	var inv inventory
+	if err := json.Unmarshal(b, &inv); err != nil {
+		return nil, fmt.Errorf("unmarshalling the inventory %s: %v", path, err)
+	}
+	for _, id := range inv.ResourceIds {
+		ids[strings.ToUpper(id)] = true
+	}
+	return ids, nil
+}
+
+// filterDelta removes the import items that are either recorded in the inventory, or managed in the state (by their TF resource ids),
+// so that only the resources created since the last delta run are processed.
+func (meta baseMeta) filterDelta(l ImportList, managedIds map[string]bool) (ImportList, error) {
+	ids, err := meta.readInventory()
+	if err != nil {
+		return nil, err
+	}
+	var out ImportList
+	for _, item := range l {
+		if ids[strings.ToUpper(item.AzureResourceID.String())] || managedIds[strings.ToUpper(item.TFResourceId)] {
+			continue
+		}
+		out = append(out, item)
+	}
+	meta.Logger().Info("Only process the resources created since the last delta run", "total", len(l), "new", len(out))
+	return out, nil
+}
+
+// exportInventory adds the imported items to the inventory of the output directory. The skipped and failed ones are not recorded, so that they are processed again by the next delta run.
+func (meta baseMeta) exportInventory(l ImportList) error {
+	ids, err := meta.readInventory()
+	if err != nil {
+		return err
+	}
+	inv := inventory{UpdatedAt: time.Now().UTC().Format(time.RFC3339)}
+	for _, item := range l {
+		if !item.Imported {
+			continue
+		}
+		ids[strings.ToUpper(item.AzureResourceID.String())] = true
+	}
+	for id := range ids {
+		inv.ResourceIds = append(inv.ResourceIds, id)
+	}
+	sort.Strings(inv.ResourceIds)
+	b, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the inventory: %v", err)
+	}
+	output := filepath.Join(meta.outdir, InventoryFileName)
+	// #nosec G306
+	if err := os.WriteFile(output, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing the inventory to %s: %v", output, err)
+	}
+	return nil
+}
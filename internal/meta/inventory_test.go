@@ -0,0 +1,62 @@
+package meta
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelta(t *testing.T) {
+	newItem := func(id, tfType string) ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return ImportItem{
+			AzureResourceID: azureId,
+			TFResourceId:    id,
+			TFAddr:          tfaddr.TFAddr{Type: tfType, Name: "res"},
+		}
+	}
+	const (
+		rgId      = "/subscriptions/123/resourceGroups/rg"
+		vnetId    = rgId + "/providers/Microsoft.Network/virtualNetworks/vnet"
+		nsgId     = rgId + "/providers/Microsoft.Network/networkSecurityGroups/nsg"
+		unknownId = rgId + "/providers/Microsoft.Foo/bars/bar"
+		pipId     = rgId + "/providers/Microsoft.Network/publicIPAddresses/pip"
+	)
+
+	meta := baseMeta{
+		logger:    slog.Default(),
+		outdir:    t.TempDir(),
+		delta:     true,
+		baseState: []byte(`{"version": 4, "resources": [{"mode": "managed", "type": "azurerm_resource_group", "name": "rg", "instances": [{"attributes": {"id": "` + rgId + `"}}]}]}`),
+	}
+
+	// The first delta run processes all the resources that are not managed
+	l := ImportList{newItem(rgId, "azurerm_resource_group"), newItem(vnetId, "azurerm_virtual_network"), newItem(nsgId, "azurerm_network_security_group"), newItem(unknownId, "")}
+	l, err := meta.reconcileManagedResources(l)
+	require.NoError(t, err)
+	require.Len(t, l, 3)
+	require.Equal(t, vnetId, l[0].TFResourceId)
+
+	// Only the imported resources are recorded, while the failed and skipped ones are not
+	l[0].Imported = true
+	l[1].ImportError = errors.New("import failed")
+	require.NoError(t, meta.exportInventory(l))
+	ids, err := meta.readInventory()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{strings.ToUpper(vnetId): true}, ids)
+
+	// The next delta run only processes the new resources, together with the failed and skipped ones
+	l = ImportList{newItem(rgId, "azurerm_resource_group"), newItem(vnetId, "azurerm_virtual_network"), newItem(nsgId, "azurerm_network_security_group"), newItem(unknownId, ""), newItem(pipId, "azurerm_public_ip")}
+	l, err = meta.reconcileManagedResources(l)
+	require.NoError(t, err)
+	require.Len(t, l, 3)
+	require.Equal(t, nsgId, l[0].TFResourceId)
+	require.Equal(t, unknownId, l[1].TFResourceId)
+	require.Equal(t, pipId, l[2].TFResourceId)
+}
@@ -0,0 +1,303 @@
+package meta
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// jsonObject is a JSON object that keeps the order of its keys as is inserted.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]interface{}{}}
+}
+
+func (obj *jsonObject) Get(key string) (interface{}, bool) {
+	v, ok := obj.values[key]
+	return v, ok
+}
+
+func (obj *jsonObject) Set(key string, value interface{}) {
+	if _, ok := obj.values[key]; !ok {
+		obj.keys = append(obj.keys, key)
+	}
+	obj.values[key] = value
+}
+
+func (obj *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, k := range obj.keys {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(obj.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteString(":")
+		buf.Write(vb)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// convertToJSONSyntax converts the generated config files (i.e. the ".tf" files) in the output directory and the module directory to the Terraform JSON syntax,
+// which are written to the ".tf.json" files of the same names, and the ".tf" files are removed. The comments are not preserved.
+func (meta baseMeta) convertToJSONSyntax() error {
+	dirs := []string{meta.outdir}
+	if meta.moduleDir != meta.outdir {
+		dirs = append(dirs, meta.moduleDir)
+	}
+	for _, dir := range dirs {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			// #nosec G304
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s: %v", path, err)
+			}
+			out, err := hclToJSONSyntax(b, path)
+			if err != nil {
+				return err
+			}
+			// #nosec G306
+			if err := os.WriteFile(path+".json", out, 0644); err != nil {
+				return fmt.Errorf("writing %s: %v", path+".json", err)
+			}
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("removing %s: %v", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// hclToJSONSyntax converts the HCL of a Terraform config file to the Terraform JSON syntax (https://developer.hashicorp.com/terraform/language/syntax/json).
+func hclToJSONSyntax(src []byte, filename string) ([]byte, error) {
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %v", filename, diags.Error())
+	}
+	body := f.Body.(*hclsyntax.Body)
+
+	root := newJSONObject()
+	if len(body.Attributes) != 0 {
+		return nil, fmt.Errorf("%s: top level attributes are not supported", filename)
+	}
+	for _, blk := range body.Blocks {
+		if err := appendJSONBlock(root, append([]string{blk.Type}, blk.Labels...), convertJSONBody(src, blk.Type, blk.Body)); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	b, err := json.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling %s: %v", filename, err)
+	}
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return nil, fmt.Errorf("indenting %s: %v", filename, err)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// appendJSONBlock sets the block body to the object, nested by the block type and the labels. The block body becomes an array of the block bodies if the block is repeated.
+func appendJSONBlock(obj *jsonObject, keys []string, body *jsonObject) error {
+	for _, k := range keys[:len(keys)-1] {
+		v, ok := obj.Get(k)
+		if !ok {
+			v = newJSONObject()
+			obj.Set(k, v)
+		}
+		next, ok := v.(*jsonObject)
+		if !ok {
+			return fmt.Errorf("conflicting block %q", strings.Join(keys, "."))
+		}
+		obj = next
+	}
+	key := keys[len(keys)-1]
+	v, ok := obj.Get(key)
+	if !ok {
+		obj.Set(key, body)
+		return nil
+	}
+	if l, ok := v.([]interface{}); ok {
+		obj.Set(key, append(l, body))
+		return nil
+	}
+	obj.Set(key, []interface{}{v, body})
+	return nil
+}
+
+func convertJSONBody(src []byte, blockType string, body *hclsyntax.Body) *jsonObject {
+	obj := newJSONObject()
+	for _, attr := range sortedSyntaxAttributes(body) {
+		obj.Set(attr.Name, convertJSONAttribute(src, blockType, attr))
+	}
+	for _, blk := range body.Blocks {
+		// The nested blocks are defined by the provider schema, which won't conflict with each other
+		// #nosec G104
+		appendJSONBlock(obj, append([]string{blk.Type}, blk.Labels...), convertJSONBody(src, blk.Type, blk.Body))
+	}
+	return obj
+}
+
+// convertJSONAttribute converts the attribute expression. The meta arguments that expect references (e.g. "depends_on") are converted to the bare reference strings,
+// the others are converted to the JSON values if they are literal, or the "${...}" template strings otherwise.
+func convertJSONAttribute(src []byte, blockType string, attr *hclsyntax.Attribute) interface{} {
+	switch {
+	case attr.Name == "depends_on",
+		blockType == "lifecycle" && (attr.Name == "ignore_changes" || attr.Name == "replace_triggered_by"),
+		blockType == "import" && attr.Name == "to",
+		blockType == "moved" && (attr.Name == "from" || attr.Name == "to"),
+		blockType == "variable" && attr.Name == "type",
+		(blockType == "resource" || blockType == "data" || blockType == "import") && attr.Name == "provider":
+		return convertJSONReference(src, attr.Expr)
+	case blockType == "module" && attr.Name == "providers":
+		if expr, ok := attr.Expr.(*hclsyntax.ObjectConsExpr); ok {
+			obj := newJSONObject()
+			for _, item := range expr.Items {
+				obj.Set(expressionSource(src, item.KeyExpr), expressionSource(src, item.ValueExpr))
+			}
+			return obj
+		}
+	}
+	return convertJSONExpression(src, attr.Expr)
+}
+
+func convertJSONReference(src []byte, expr hclsyntax.Expression) interface{} {
+	if expr, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+		l := []interface{}{}
+		for _, e := range expr.Exprs {
+			l = append(l, convertJSONReference(src, e))
+		}
+		return l
+	}
+	return expressionSource(src, expr)
+}
+
+func convertJSONExpression(src []byte, expr hclsyntax.Expression) interface{} {
+	if len(expr.Variables()) == 0 && !hasFunctionCall(expr) {
+		if v, diags := expr.Value(nil); !diags.HasErrors() {
+			return convertJSONValue(v)
+		}
+	}
+	switch expr := expr.(type) {
+	case *hclsyntax.TupleConsExpr:
+		l := []interface{}{}
+		for _, e := range expr.Exprs {
+			l = append(l, convertJSONExpression(src, e))
+		}
+		return l
+	case *hclsyntax.ObjectConsExpr:
+		obj := newJSONObject()
+		for _, item := range expr.Items {
+			key := expressionSource(src, item.KeyExpr)
+			if v, diags := item.KeyExpr.Value(nil); !diags.HasErrors() && v.Type() == cty.String {
+				key = escapeJSONTemplate(v.AsString())
+			} else if len(item.KeyExpr.Variables()) != 0 {
+				key = "${" + key + "}"
+			}
+			obj.Set(key, convertJSONExpression(src, item.ValueExpr))
+		}
+		return obj
+	case *hclsyntax.TemplateExpr:
+		if !expr.IsStringLiteral() {
+			if s := expressionSource(src, expr); strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+				var out string
+				if err := json.Unmarshal([]byte(s), &out); err == nil {
+					return out
+				}
+			}
+		}
+	}
+	return "${" + expressionSource(src, expr) + "}"
+}
+
+// convertJSONValue converts the literal value to the JSON value, where the strings are escaped as they are regarded as templates in the JSON syntax.
+func convertJSONValue(v cty.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return escapeJSONTemplate(v.AsString())
+	case ty == cty.Number:
+		return json.Number(v.AsBigFloat().Text('f', -1))
+	case ty == cty.Bool:
+		return v.True()
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		l := []interface{}{}
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			l = append(l, convertJSONValue(ev))
+		}
+		return l
+	case ty.IsMapType() || ty.IsObjectType():
+		obj := newJSONObject()
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			obj.Set(escapeJSONTemplate(k.AsString()), convertJSONValue(ev))
+		}
+		return obj
+	}
+	return nil
+}
+
+// escapeJSONTemplate escapes the template sequences of the literal string.
+func escapeJSONTemplate(s string) string {
+	return strings.NewReplacer("${", "$${", "%{", "%%{").Replace(s)
+}
+
+func expressionSource(src []byte, expr hclsyntax.Expression) string {
+	rng := expr.Range()
+	return string(src[rng.Start.Byte:rng.End.Byte])
+}
+
+// hasFunctionCall tells whether the expression calls any function, which can't be evaluated without the function table.
+func hasFunctionCall(expr hclsyntax.Expression) bool {
+	found := false
+	// #nosec G104
+	hclsyntax.VisitAll(expr, func(node hclsyntax.Node) hcl.Diagnostics {
+		if _, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// sortedSyntaxAttributes returns the attributes of the body in the order of their positions in the source.
+func sortedSyntaxAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	var attrs []*hclsyntax.Attribute
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	return attrs
+}
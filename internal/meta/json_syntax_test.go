@@ -0,0 +1,147 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHCLToJSONSyntax(t *testing.T) {
+	src := `# The comments are dropped
+resource "azurerm_resource_group" "rg" {
+  name     = "rg"
+  location = "westus"
+  tags = {
+    "env"   = "dev"
+    "price" = "$${cost}"
+  }
+}
+
+resource "azurerm_network_interface" "nic" {
+  name     = "nic-${var.suffix}"
+  location = azurerm_resource_group.rg.location
+  dns_servers = ["10.0.0.4", var.dns_server]
+  ip_configuration {
+    name      = "first"
+    subnet_id = azurerm_subnet.subnet.id
+  }
+  ip_configuration {
+    name                 = "second"
+    private_ip_address   = cidrhost("10.0.0.0/24", 5)
+    primary              = false
+    private_ip_prefix    = 24
+  }
+  provider   = azurerm.other
+  depends_on = [azurerm_resource_group.rg]
+  lifecycle {
+    ignore_changes = [tags, ip_configuration[0].name]
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+import {
+  id = "/subscriptions/123/resourceGroups/rg"
+  to = module.resources.azurerm_resource_group.rg
+}
+`
+	out, err := hclToJSONSyntax([]byte(src), "main.tf")
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "resource": {
+    "azurerm_resource_group": {
+      "rg": {
+        "name": "rg",
+        "location": "westus",
+        "tags": {
+          "env": "dev",
+          "price": "$${cost}"
+        }
+      }
+    },
+    "azurerm_network_interface": {
+      "nic": {
+        "name": "nic-${var.suffix}",
+        "location": "${azurerm_resource_group.rg.location}",
+        "dns_servers": [
+          "10.0.0.4",
+          "${var.dns_server}"
+        ],
+        "provider": "azurerm.other",
+        "depends_on": [
+          "azurerm_resource_group.rg"
+        ],
+        "ip_configuration": [
+          {
+            "name": "first",
+            "subnet_id": "${azurerm_subnet.subnet.id}"
+          },
+          {
+            "name": "second",
+            "private_ip_address": "${cidrhost(\"10.0.0.0/24\", 5)}",
+            "primary": false,
+            "private_ip_prefix": 24
+          }
+        ],
+        "lifecycle": {
+          "ignore_changes": [
+            "tags",
+            "ip_configuration[0].name"
+          ]
+        }
+      }
+    }
+  },
+  "variable": {
+    "password": {
+      "type": "string",
+      "sensitive": true
+    }
+  },
+  "import": {
+    "id": "/subscriptions/123/resourceGroups/rg",
+    "to": "module.resources.azurerm_resource_group.rg"
+  }
+}
+`, string(out))
+}
+
+func TestConvertToJSONSyntax(t *testing.T) {
+	dir := t.TempDir()
+	moduleDir := filepath.Join(dir, ChildModuleSource)
+	require.NoError(t, os.MkdirAll(moduleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(`module "resources" {
+  source = "./modules/resources"
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(`resource "azurerm_resource_group" "rg" {
+  name = "rg"
+}
+`), 0644))
+	// Other files are not converted
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SensitiveVariableExampleFileName), []byte(`foo = ""`), 0644))
+
+	meta := baseMeta{outdir: dir, moduleDir: moduleDir}
+	require.NoError(t, meta.convertToJSONSyntax())
+
+	for _, d := range []string{dir, moduleDir} {
+		require.NoFileExists(t, filepath.Join(d, "main.tf"))
+		require.FileExists(t, filepath.Join(d, "main.tf.json"))
+	}
+	require.FileExists(t, filepath.Join(dir, SensitiveVariableExampleFileName))
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf.json"))
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "module": {
+    "resources": {
+      "source": "./modules/resources"
+    }
+  }
+}
+`, string(b))
+}
@@ -0,0 +1,73 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/utils"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// lifecycleRules are the compiled config.LifecycleRule, whose resource type patterns are matched case insensitively.
+type lifecycleRules []lifecycleRule
+
+type lifecycleRule struct {
+	re            *regexp.Regexp
+	ignoreChanges []string
+}
+
+func newLifecycleRules(rules []config.LifecycleRule) (lifecycleRules, error) {
+	var out lifecycleRules
+	for i, rule := range rules {
+		pattern := strings.TrimSpace(rule.ResourceType)
+		if pattern == "" {
+			return nil, fmt.Errorf("the lifecycle rule %d has no resource type", i)
+		}
+		if len(rule.IgnoreChanges) == 0 {
+			return nil, fmt.Errorf("the lifecycle rule for %q has no attribute to ignore changes", pattern)
+		}
+		for _, attr := range rule.IgnoreChanges {
+			if _, diags := hclsyntax.ParseTraversalAbs([]byte(attr), "", hcl.InitialPos); diags.HasErrors() {
+				return nil, fmt.Errorf("invalid attribute %q to ignore changes in the lifecycle rule for %q: %v", attr, pattern, diags.Error())
+			}
+		}
+		out = append(out, lifecycleRule{
+			re:            utils.GlobToRegexp(pattern),
+			ignoreChanges: rule.IgnoreChanges,
+		})
+	}
+	return out, nil
+}
+
+// IgnoreChanges returns the attributes to ignore changes of all the rules matching the TF resource type, in the order of the rules, without duplicates.
+func (rules lifecycleRules) IgnoreChanges(tfType string) []string {
+	var out []string
+	for _, rule := range rules {
+		if !rule.re.MatchString(tfType) {
+			continue
+		}
+		out = appendIgnoreChanges(out, rule.ignoreChanges...)
+	}
+	return out
+}
+
+// appendIgnoreChanges appends the attributes to ignore changes that don't exist yet.
+func appendIgnoreChanges(l []string, attrs ...string) []string {
+	for _, attr := range attrs {
+		attr = strings.TrimSpace(attr)
+		exists := false
+		for _, v := range l {
+			if v == attr {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			l = append(l, attr)
+		}
+	}
+	return l
+}
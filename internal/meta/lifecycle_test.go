@@ -0,0 +1,86 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLifecycleRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []config.LifecycleRule
+		err   string
+	}{
+		{
+			name:  "valid rules",
+			rules: []config.LifecycleRule{{ResourceType: "azurerm_*", IgnoreChanges: []string{"tags", "default_node_pool[0].node_count"}}},
+		},
+		{
+			name:  "no resource type",
+			rules: []config.LifecycleRule{{IgnoreChanges: []string{"tags"}}},
+			err:   "has no resource type",
+		},
+		{
+			name:  "no attribute",
+			rules: []config.LifecycleRule{{ResourceType: "azurerm_*"}},
+			err:   "has no attribute to ignore changes",
+		},
+		{
+			name:  "invalid attribute",
+			rules: []config.LifecycleRule{{ResourceType: "azurerm_*", IgnoreChanges: []string{"tags["}}},
+			err:   `invalid attribute "tags["`,
+		},
+	}
+	for _, c := range cases {
+		_, err := newLifecycleRules(c.rules)
+		if c.err == "" {
+			require.NoError(t, err, c.name)
+			continue
+		}
+		require.ErrorContains(t, err, c.err, c.name)
+	}
+}
+
+func TestLifecycleAddon(t *testing.T) {
+	rules, err := newLifecycleRules([]config.LifecycleRule{
+		{ResourceType: "azurerm_kubernetes_cluster", IgnoreChanges: []string{"default_node_pool[0].node_count"}},
+		{ResourceType: "*", IgnoreChanges: []string{"tags"}},
+	})
+	require.NoError(t, err)
+	meta := baseMeta{lifecycleRules: rules}
+
+	var cfgs ConfigInfos
+	for _, tfType := range []string{"azurerm_kubernetes_cluster", "azurerm_application_insights_web_test"} {
+		f, diags := hclwrite.ParseConfig([]byte(`resource "`+tfType+`" "test" {
+}
+`), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		cfgs = append(cfgs, ConfigInfo{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: tfType, Name: "test"}}, hcl: f})
+	}
+	cfgs, err = meta.lifecycleAddon(cfgs)
+	require.NoError(t, err)
+
+	require.Equal(t, `resource "azurerm_kubernetes_cluster" "test" {
+  lifecycle {
+    ignore_changes = [
+      default_node_pool[0].node_count,
+      tags,
+    ]
+  }
+}
+`, string(hclwrite.Format(cfgs[0].hcl.Bytes())))
+	// The builtin ignored attribute is not duplicated
+	require.Equal(t, `resource "azurerm_application_insights_web_test" "test" {
+  lifecycle {
+    ignore_changes = [
+      tags,
+    ]
+  }
+}
+`, string(hclwrite.Format(cfgs[1].hcl.Bytes())))
+}
@@ -0,0 +1,176 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/go-multierror"
+)
+
+type stateResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Instances []struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	} `json:"instances"`
+}
+
+// managedResources returns the managed resources in the base state.
+func (meta baseMeta) managedResources() ([]stateResource, error) {
+	if len(meta.baseState) == 0 {
+		return nil, nil
+	}
+	var state struct {
+		Resources []stateResource `json:"resources"`
+	}
+	if err := json.Unmarshal(meta.baseState, &state); err != nil {
+		return nil, fmt.Errorf("unmarshalling the base state: %v", err)
+	}
+	var out []stateResource
+	for _, res := range state.Resources {
+		if res.Mode != "managed" {
+			continue
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+// managedResourceIds returns the set of the TF resource ids (in upper case) of the managed resources in the base state.
+func (meta baseMeta) managedResourceIds() (map[string]bool, error) {
+	resources, err := meta.managedResources()
+	if err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for _, res := range resources {
+		for _, ins := range res.Instances {
+			if id, ok := ins.Attributes["id"].(string); ok && id != "" {
+				ids[strings.ToUpper(id)] = true
+			}
+		}
+	}
+	return ids, nil
+}
+
+// managedResourceAddrs returns the set of the addresses (including the module address) of the managed resources in the base state.
+func (meta baseMeta) managedResourceAddrs() (map[string]bool, error) {
+	resources, err := meta.managedResources()
+	if err != nil {
+		return nil, err
+	}
+	addrs := map[string]bool{}
+	for _, res := range resources {
+		addr := res.Type + "." + res.Name
+		if res.Module != "" {
+			addr = res.Module + "." + addr
+		}
+		addrs[addr] = true
+	}
+	return addrs, nil
+}
+
+// reconcileManagedResources reconciles the import items with the managed resources in the base state (e.g. the output directory is an existing workspace):
+//   - The import items whose TF resource ids are already managed are marked as skipped, so that only the resources not managed yet are imported.
+//   - The import items whose TF addresses conflict with the managed resources, or with each other, are either renamed or reported as an error (see resolveAddressConflicts).
+//
+// In the delta mode, the import items that are managed or recorded in the inventory are removed instead (see filterDelta).
+// For an environment, the import items that are shared with the other environments are renamed to the declared addresses beforehand (see alignSharedResources).
+func (meta baseMeta) reconcileManagedResources(l ImportList) (ImportList, error) {
+	ids, err := meta.managedResourceIds()
+	if err != nil {
+		return nil, err
+	}
+	if meta.delta {
+		if l, err = meta.filterDelta(l, ids); err != nil {
+			return nil, err
+		}
+	}
+	if meta.environment != "" {
+		if l, err = meta.alignSharedResources(l); err != nil {
+			return nil, err
+		}
+	}
+	for i, item := range l {
+		if item.Skip() || !ids[strings.ToUpper(item.TFResourceId)] {
+			continue
+		}
+		meta.Logger().Info("Skip the resource that is already managed in the state", "tf_id", item.TFResourceId)
+		l[i].TFAddr.Type = ""
+	}
+	return meta.resolveAddressConflicts(l)
+}
+
+// resolveAddressConflicts detects the import items whose TF addresses conflict with the managed resources in the base state, or with the former import items.
+// For an environment, the addresses declared by the other environments are also regarded as used, except by the items shared with them.
+// If resolveAddressConflict is set, the conflicting items are renamed by appending a counter to the resource name. Otherwise, all the conflicts are reported as an error,
+// rather than failing halfway through the import.
+func (meta baseMeta) resolveAddressConflicts(l ImportList) (ImportList, error) {
+	addrs, err := meta.managedResourceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	fullAddr := func(addr tfaddr.TFAddr) string {
+		if meta.moduleAddr != "" {
+			return meta.moduleAddr + "." + addr.String()
+		}
+		return addr.String()
+	}
+	used := map[string]string{}
+	for addr := range addrs {
+		used[addr] = "the state"
+	}
+	const otherEnvironments = "the other environments"
+	sharedAddrs := map[string]bool{}
+	if meta.environment != "" {
+		identities, err := meta.readEnvironmentResources()
+		if err != nil {
+			return nil, err
+		}
+		for identity, addr := range identities {
+			tfType, tfName, _ := strings.Cut(addr, ".")
+			addr := fullAddr(tfaddr.TFAddr{Type: tfType, Name: tfName})
+			used[addr] = otherEnvironments
+			sharedAddrs[identity+"\x00"+addr] = true
+		}
+	}
+
+	var result error
+	for i, item := range l {
+		if item.Skip() {
+			continue
+		}
+		addr := fullAddr(item.TFAddr)
+		owner, ok := used[addr]
+		if ok && owner == otherEnvironments && sharedAddrs[meta.environmentIdentity(item.TFAddr.Type, item.AzureResourceID)+"\x00"+addr] {
+			ok = false
+		}
+		if !ok {
+			used[addr] = item.TFResourceId
+			continue
+		}
+		if !meta.resolveAddressConflict {
+			result = multierror.Append(result, fmt.Errorf("%s of %s conflicts with the one of %s", addr, item.TFResourceId, owner))
+			continue
+		}
+		newAddr := item.TFAddr
+		for cnt := 2; ; cnt++ {
+			newAddr.Name = fmt.Sprintf("%s_%d", item.TFAddr.Name, cnt)
+			if _, ok := used[fullAddr(newAddr)]; !ok {
+				break
+			}
+		}
+		meta.Logger().Info("Rename the resource whose address conflicts", "tf_id", item.TFResourceId, "from", addr, "to", fullAddr(newAddr))
+		l[i].TFAddr.Name = newAddr.Name
+		l[i].TFAddrCache.Name = newAddr.Name
+		used[fullAddr(newAddr)] = item.TFResourceId
+	}
+	if result != nil {
+		return nil, fmt.Errorf("resource address conflicts found: %v", result)
+	}
+	return l, nil
+}
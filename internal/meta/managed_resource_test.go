@@ -0,0 +1,101 @@
+package meta
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipManagedResources(t *testing.T) {
+	meta := baseMeta{
+		logger: slog.Default(),
+		baseState: []byte(`{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "rg",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg"}}]
+    },
+    {
+      "mode": "data",
+      "type": "azurerm_virtual_network",
+      "name": "vnet",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"}}]
+    }
+  ]
+}`),
+	}
+
+	l := ImportList{
+		{
+			TFResourceId: "/subscriptions/123/resourcegroups/RG",
+			TFAddr:       tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+		},
+		{
+			TFResourceId: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			TFAddr:       tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+	}
+	l, err := meta.reconcileManagedResources(l)
+	require.NoError(t, err)
+	require.True(t, l[0].Skip())
+	require.False(t, l[1].Skip())
+}
+
+func TestResolveAddressConflicts(t *testing.T) {
+	state := []byte(`{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "azurerm_resource_group",
+      "name": "res-0",
+      "instances": [{"attributes": {"id": "/subscriptions/123/resourceGroups/rg1"}}]
+    }
+  ]
+}`)
+	rg2, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg2")
+	require.NoError(t, err)
+	rg3, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg3")
+	require.NoError(t, err)
+	newList := func() ImportList {
+		return ImportList{
+			{
+				AzureResourceID: rg2,
+				TFResourceId:    rg2.String(),
+				TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+				TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			},
+			{
+				AzureResourceID: rg3,
+				TFResourceId:    rg3.String(),
+				TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+				TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			},
+		}
+	}
+
+	meta := baseMeta{logger: slog.Default(), baseState: state}
+	_, err = meta.reconcileManagedResources(newList())
+	require.ErrorContains(t, err, "azurerm_resource_group.res-0 of /subscriptions/123/resourceGroups/rg2 conflicts with the one of the state")
+	require.ErrorContains(t, err, "azurerm_resource_group.res-0 of /subscriptions/123/resourceGroups/rg3 conflicts with the one of the state")
+
+	meta = baseMeta{logger: slog.Default(), baseState: state, resolveAddressConflict: true}
+	l, err := meta.reconcileManagedResources(newList())
+	require.NoError(t, err)
+	require.Equal(t, "res-0_2", l[0].TFAddr.Name)
+	require.Equal(t, "res-0_2", l[0].TFAddrCache.Name)
+	require.Equal(t, "res-0_3", l[1].TFAddr.Name)
+
+	// The addresses in the module are not conflicting with the ones in the root module
+	meta = baseMeta{logger: slog.Default(), baseState: state, moduleAddr: "module.foo"}
+	l = newList()
+	l[1].TFAddr.Name = "res-1"
+	_, err = meta.reconcileManagedResources(l)
+	require.NoError(t, err)
+}
@@ -2,6 +2,7 @@ package meta
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
 	"time"
@@ -65,6 +66,14 @@ func (m MetaGroupDummy) ListResource(_ context.Context) (ImportList, error) {
 	}, nil
 }
 
+func (m MetaGroupDummy) StateBackupFile() string {
+	return ""
+}
+
+func (m MetaGroupDummy) RollbackState(_ context.Context) error {
+	return nil
+}
+
 func (m MetaGroupDummy) CleanTFState(_ context.Context, _ string) {
 	return
 }
@@ -84,6 +93,15 @@ func (m MetaGroupDummy) GenerateCfg(_ context.Context, l ImportList) error {
 	return nil
 }
 
+func (m MetaGroupDummy) ReadState(_ context.Context) (bool, error) {
+	return true, nil
+}
+
+func (m MetaGroupDummy) PreviewCfg(_ context.Context, item ImportItem) (string, error) {
+	time.Sleep(500 * time.Millisecond)
+	return fmt.Sprintf("resource %q %q {\n}\n", item.TFAddr.Type, item.TFAddr.Name), nil
+}
+
 func (m MetaGroupDummy) ExportResourceMapping(_ context.Context, l ImportList) error {
 	time.Sleep(500 * time.Millisecond)
 	return nil
@@ -94,6 +112,18 @@ func (m MetaGroupDummy) ExportSkippedResources(_ context.Context, l ImportList)
 	return nil
 }
 
+func (m MetaGroupDummy) SaveSession(_ context.Context, l ImportList) error {
+	return nil
+}
+
+func (m MetaGroupDummy) LoadSession(ctx context.Context) (ImportList, error) {
+	return m.ListResource(ctx)
+}
+
+func (m MetaGroupDummy) LoadFailedImports(ctx context.Context) (ImportList, error) {
+	return m.ListResource(ctx)
+}
+
 func (m MetaGroupDummy) CleanUpWorkspace(_ context.Context) error {
 	time.Sleep(500 * time.Millisecond)
 	return nil
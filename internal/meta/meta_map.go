@@ -2,21 +2,27 @@ package meta
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"sort"
+	"strings"
 
 	"github.com/Azure/aztfexport/pkg/config"
 
 	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/internal/resourceset"
 	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/magodo/armid"
+	"github.com/magodo/tfadd/providers/azapi"
+	"github.com/magodo/tfadd/providers/azurerm"
 )
 
 type MetaMap struct {
 	baseMeta
-	mappingFile string
+	mappingFile            string
+	additionalMappingFiles []string
+	mapping                resmap.ResourceMapping
 }
 
 func NewMetaMap(cfg config.Config) (*MetaMap, error) {
@@ -27,42 +33,127 @@ func NewMetaMap(cfg config.Config) (*MetaMap, error) {
 	}
 
 	meta := &MetaMap{
-		baseMeta:    *baseMeta,
-		mappingFile: cfg.MappingFile,
+		baseMeta:               *baseMeta,
+		mappingFile:            cfg.MappingFile,
+		additionalMappingFiles: cfg.AdditionalMappingFiles,
+	}
+
+	// Validate the mapping file before starting, rather than failing in the middle of the import.
+	m, err := resmap.Load(append([]string{meta.mappingFile}, meta.additionalMappingFiles...)...)
+	if err != nil {
+		return nil, err
+	}
+	if err := meta.validateMapping(m); err != nil {
+		return nil, fmt.Errorf("validating the mapping file: %v", err)
+	}
+	meta.mapping = m
+
+	// The azapi resources in the mapping file are exported by the azapi provider, which is used together with the azurerm provider.
+	if !meta.useAzAPI() {
+		for _, res := range m {
+			if isAzAPIResourceType(res.ResourceType) {
+				meta.withAzAPI = true
+				break
+			}
+		}
 	}
 
 	return meta, nil
 }
 
+// validateMapping validates each entry of the mapping, and returns the errors of all the invalid entries.
+// The resource types are validated against the embedded provider schema, which only warns for the unknown ones if the provider used differs from the embedded schema
+// (i.e. a different provider version, or the development provider), as the resource types might be added or removed since then.
+func (meta MetaMap) validateMapping(m resmap.ResourceMapping) error {
+	resourceSchemas := azurerm.ProviderSchemaInfo.ResourceSchemas
+	schemaVersion := azurerm.ProviderSchemaInfo.Version
+	if meta.useAzAPI() {
+		resourceSchemas = azapi.ProviderSchemaInfo.ResourceSchemas
+		schemaVersion = azapi.ProviderSchemaInfo.Version
+	}
+	schemaApplies := !meta.devProvider && (meta.providerVersion == "" || strings.TrimPrefix(meta.providerVersion, "v") == schemaVersion)
+
+	var ids []string
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var result error
+	addrs := map[string]string{}
+	for _, id := range ids {
+		res := m[id]
+		if _, err := armid.ParseResourceId(id); err != nil {
+			result = multierror.Append(result, fmt.Errorf("%q: malformed Azure resource id: %v", id, err))
+		}
+		if res.ResourceId == "" {
+			result = multierror.Append(result, fmt.Errorf("%q: missing resource_id", id))
+		}
+		if isAzAPIResourceType(res.ResourceType) && !meta.useAzAPI() {
+			// The azapi resources are exported by the azapi provider, besides the azurerm provider.
+			if _, ok := azapi.ProviderSchemaInfo.ResourceSchemas[res.ResourceType]; !ok {
+				result = multierror.Append(result, fmt.Errorf("%q: unknown resource_type %q for provider azapi", id, res.ResourceType))
+			}
+			if meta.tfclient != nil {
+				result = multierror.Append(result, fmt.Errorf("%q: resource_type %q can't be used together with the azurerm provider via the TFClient", id, res.ResourceType))
+			}
+		} else if _, ok := resourceSchemas[res.ResourceType]; !ok {
+			if schemaApplies {
+				result = multierror.Append(result, fmt.Errorf("%q: unknown resource_type %q for provider %s", id, res.ResourceType, meta.providerName))
+			} else {
+				meta.Logger().Warn("Unknown resource_type for the embedded provider schema, which is expected to be supported by the provider used", "id", id, "resource_type", res.ResourceType, "schema_version", schemaVersion)
+			}
+		}
+		if res.APIVersion != "" && res.ResourceType != AzAPIResourceType {
+			result = multierror.Append(result, fmt.Errorf("%q: api_version only applies to resource_type %q", id, AzAPIResourceType))
+		}
+		if !hclsyntax.ValidIdentifier(res.ResourceName) {
+			result = multierror.Append(result, fmt.Errorf("%q: invalid resource_name %q", id, res.ResourceName))
+		}
+		addr := res.ResourceType + "." + res.ResourceName
+		if dupId, ok := addrs[addr]; ok {
+			result = multierror.Append(result, fmt.Errorf("%q: duplicated address %s with %q", id, addr, dupId))
+		}
+		addrs[addr] = id
+	}
+	return result
+}
+
 func (meta MetaMap) ScopeName() string {
 	return meta.mappingFile
 }
 
 func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
-	var m resmap.ResourceMapping
-
-	meta.Logger().Debug("Read resource set from mapping file")
-	b, err := os.ReadFile(meta.mappingFile)
-	if err != nil {
-		return nil, fmt.Errorf("reading mapping file %s: %v", meta.mappingFile, err)
-	}
-	if err := json.Unmarshal(b, &m); err != nil {
-		return nil, fmt.Errorf("unmarshalling the mapping file: %v", err)
-	}
-
-	var l ImportList
-	for id, res := range m {
+	azureIds := map[string]armid.ResourceId{}
+	var ids []armid.ResourceId
+	for id := range meta.mapping {
 		azureId, err := armid.ParseResourceId(id)
 		if err != nil {
 			return nil, fmt.Errorf("parsing resource id %q: %v", id, err)
 		}
+		azureIds[id] = azureId
+		ids = append(ids, azureId)
+	}
+	nameIncluded := meta.nameIncludedIds(ids)
+
+	var l ImportList
+	for id, res := range meta.mapping {
+		azureId := azureIds[id]
+		if !meta.isResourceIncluded(azureId, res.ResourceType, nameIncluded) {
+			meta.filteredResources = append(meta.filteredResources, resourceset.TFResource{AzureId: azureId, TFId: res.ResourceId, TFType: res.ResourceType})
+			continue
+		}
 		tfAddr := tfaddr.TFAddr{
 			Type: res.ResourceType,
 			Name: res.ResourceName,
 		}
+		tfResourceId := res.ResourceId
+		if res.ResourceType == AzAPIResourceType {
+			tfResourceId = azapiImportId(res.ResourceId, res.APIVersion)
+		}
 		item := ImportItem{
 			AzureResourceID: azureId,
-			TFResourceId:    res.ResourceId,
+			TFResourceId:    tfResourceId,
 			TFAddrCache:     tfAddr,
 			TFAddr:          tfAddr,
 			Recommendations: []string{res.ResourceType},
@@ -74,5 +165,5 @@ func (meta *MetaMap) ListResource(_ context.Context) (ImportList, error) {
 		return l[i].AzureResourceID.String() < l[j].AzureResourceID.String()
 	})
 
-	return l, nil
+	return meta.reconcileManagedResources(l)
 }
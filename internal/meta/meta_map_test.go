@@ -0,0 +1,148 @@
+package meta
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMapping(t *testing.T) {
+	cases := []struct {
+		name            string
+		providerVersion string
+		devProvider     bool
+		input           resmap.ResourceMapping
+		errs            []string
+	}{
+		{
+			name: "valid",
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "rg",
+				},
+			},
+		},
+		{
+			name: "valid azapi resource",
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo",
+					ResourceType: "azapi_resource",
+					ResourceName: "foo",
+					APIVersion:   "2024-01-01",
+				},
+			},
+		},
+		{
+			name: "api_version of non azapi resource",
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "rg",
+					APIVersion:   "2024-01-01",
+				},
+			},
+			errs: []string{
+				`"/subscriptions/123/resourceGroups/rg": api_version only applies to resource_type "azapi_resource"`,
+			},
+		},
+		{
+			name:            "unknown resource_type of the embedded provider version",
+			providerVersion: azurerm.ProviderSchemaInfo.Version,
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_foo",
+					ResourceName: "rg",
+				},
+			},
+			errs: []string{
+				`"/subscriptions/123/resourceGroups/rg": unknown resource_type "azurerm_foo" for provider azurerm`,
+			},
+		},
+		{
+			name:            "unknown resource_type of a different provider version",
+			providerVersion: "99.0.0",
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_foo",
+					ResourceName: "rg",
+				},
+			},
+		},
+		{
+			name:        "unknown resource_type of the development provider",
+			devProvider: true,
+			input: resmap.ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_foo",
+					ResourceName: "rg",
+				},
+			},
+		},
+		{
+			name:            "invalid entries of a different provider version",
+			providerVersion: "99.0.0",
+			input: resmap.ResourceMapping{
+				"foo": {
+					ResourceId:   "foo",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "1rg",
+				},
+			},
+			errs: []string{
+				`"foo": malformed Azure resource id`,
+				`"foo": invalid resource_name "1rg"`,
+			},
+		},
+		{
+			name: "invalid entries",
+			input: resmap.ResourceMapping{
+				"foo": {
+					ResourceId:   "foo",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "rg1",
+				},
+				"/subscriptions/123/resourceGroups/rg1": {
+					ResourceType: "azurerm_foo",
+					ResourceName: "1rg",
+				},
+				"/subscriptions/123/resourceGroups/rg2": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg2",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "rg1",
+				},
+			},
+			errs: []string{
+				`"/subscriptions/123/resourceGroups/rg1": missing resource_id`,
+				`"/subscriptions/123/resourceGroups/rg1": unknown resource_type "azurerm_foo" for provider azurerm`,
+				`"/subscriptions/123/resourceGroups/rg1": invalid resource_name "1rg"`,
+				`"foo": malformed Azure resource id`,
+				`"foo": duplicated address azurerm_resource_group.rg1 with "/subscriptions/123/resourceGroups/rg2"`,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := MetaMap{baseMeta: baseMeta{logger: slog.Default(), providerName: "azurerm", providerVersion: tt.providerVersion, devProvider: tt.devProvider}}
+			err := meta.validateMapping(tt.input)
+			if len(tt.errs) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, e := range tt.errs {
+				require.ErrorContains(t, err, e)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+)
+
+type MetaManagementGroup struct {
+	baseMeta
+	managementGroupName     string
+	onlySubscriptionId      string
+	resourceNamePattern     string
+	includeRoleAssignment   bool
+	includeLock             bool
+	includeDataPlane        bool
+	tagFilter               tagFilter
+	excludeChildResources   bool
+	expandEmbeddedResources bool
+}
+
+func NewMetaManagementGroup(cfg config.Config) (*MetaManagementGroup, error) {
+	cfg.Logger.Info("New management group meta")
+	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &MetaManagementGroup{
+		baseMeta:                *baseMeta,
+		resourceNamePattern:     cfg.ResourceNamePattern,
+		managementGroupName:     cfg.ManagementGroupName,
+		onlySubscriptionId:      cfg.ManagementGroupSubscriptionId,
+		includeRoleAssignment:   cfg.IncludeRoleAssignment,
+		includeLock:             cfg.IncludeLock,
+		includeDataPlane:        cfg.IncludeDataPlane,
+		tagFilter:               tagFilter{include: cfg.IncludeTags, exclude: cfg.ExcludeTags},
+		excludeChildResources:   cfg.ExcludeChildResources,
+		expandEmbeddedResources: cfg.ExpandEmbeddedResources,
+	}
+
+	return meta, nil
+}
+
+func (meta MetaManagementGroup) ScopeName() string {
+	return "/providers/Microsoft.Management/managementGroups/" + meta.managementGroupName
+}
+
+func (meta *MetaManagementGroup) ListResource(ctx context.Context) (ImportList, error) {
+	meta.Logger().Debug("Query resource set")
+	rset, err := meta.queryResourceSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Logger().Debug("Filter resource set by tags")
+	rset = meta.tagFilter.Filter(rset)
+
+	if meta.includeDataPlane {
+		meta.Logger().Debug("List data plane resources")
+		l, err := meta.listDataPlaneResources(ctx, rset.Resources)
+		if err != nil {
+			return nil, err
+		}
+		rset.Resources = append(rset.Resources, l...)
+	}
+
+	var rl []resourceset.TFResource
+	if meta.useAzAPI() {
+		rl = rset.ToTFAzAPIResources()
+	} else {
+		meta.Logger().Debug("Populate resource set")
+		if err := rset.PopulateResource(); err != nil {
+			return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
+		}
+		if meta.expandEmbeddedResources {
+			meta.Logger().Debug("Populate embedded resources")
+			if err := rset.PopulateEmbeddedResource(meta.Logger()); err != nil {
+				return nil, fmt.Errorf("populating embedded resources in the azure resource set: %v", err)
+			}
+		}
+		meta.Logger().Debug("Reduce resource set")
+		if err := rset.ReduceResource(); err != nil {
+			return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
+		}
+
+		meta.Logger().Debug("Azure Resource set map to TF resource set")
+		rl = meta.toTFAzureRMResources(ctx, rset)
+		if meta.azapiFallback {
+			rl = meta.fallbackToAzAPI(rl)
+		}
+	}
+
+	meta.Logger().Debug("Filter TF resource set by types and names")
+	rl = meta.filterTFResources(rl)
+
+	if meta.excludeChildResources {
+		meta.Logger().Debug("Exclude child resources")
+		rl = excludeChildResources(meta.Logger(), rl)
+	}
+
+	var l ImportList
+	namer := newResourceNamer(meta.resourceNamePattern)
+	for i, res := range rl {
+		tfAddr := tfaddr.TFAddr{
+			Type: "",
+			Name: namer.Name(i, res.AzureId, res.TFType),
+		}
+		item := ImportItem{
+			AzureResourceID: res.AzureId,
+			TFResourceId:    res.TFId,
+			TFAddr:          tfAddr,
+			TFAddrCache:     tfAddr,
+		}
+		if res.TFType != "" {
+			item.Recommendations = []string{res.TFType}
+			item.TFAddr.Type = res.TFType
+			item.TFAddrCache.Type = res.TFType
+			item.IsRecommended = true
+		}
+
+		l = append(l, item)
+	}
+	meta.setResourceDetails(l, rset.Resources)
+	return meta.reconcileManagedResources(l)
+}
+
+// subscriptionIds returns the subscriptions to export, which is either the only subscription specified, or all the subscriptions under the management group.
+func (meta MetaManagementGroup) subscriptionIds(ctx context.Context) ([]string, error) {
+	if meta.onlySubscriptionId != "" {
+		return []string{meta.onlySubscriptionId}, nil
+	}
+	subIds, err := listManagementGroupSubscriptions(ctx, meta.azureSDKCred, meta.azureSDKClientOpt, meta.managementGroupName)
+	if err != nil {
+		return nil, err
+	}
+	meta.Logger().Info("Subscriptions found under the management group", "management_group", meta.managementGroupName, "subscriptions", subIds)
+	return subIds, nil
+}
+
+func (meta MetaManagementGroup) queryResourceSet(ctx context.Context) (*resourceset.AzureResourceSet, error) {
+	subIds, err := meta.subscriptionIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rl []resourceset.AzureResource
+	for _, subId := range subIds {
+		l, err := meta.listSubscriptionResources(ctx, subId, extBuilder{includeRoleAssignment: meta.includeRoleAssignment, includeLock: meta.includeLock})
+		if err != nil {
+			return nil, fmt.Errorf("listing resources of subscription %s: %v", subId, err)
+		}
+		rl = append(rl, l...)
+	}
+	return &resourceset.AzureResourceSet{Resources: rl}, nil
+}
+
+// ListManagementGroupSubscriptions lists the ids of all the subscriptions under the management group specified in the config, including those under its descendant management groups.
+func ListManagementGroupSubscriptions(ctx context.Context, cfg config.Config) ([]string, error) {
+	return listManagementGroupSubscriptions(ctx, cfg.AzureSDKCredential, cfg.AzureSDKClientOption, cfg.ManagementGroupName)
+}
+
+func listManagementGroupSubscriptions(ctx context.Context, cred azcore.TokenCredential, clientOpt arm.ClientOptions, managementGroupName string) ([]string, error) {
+	client, err := armresourcegraph.NewClient(cred, &clientOpt)
+	if err != nil {
+		return nil, fmt.Errorf("building resource graph client: %v", err)
+	}
+
+	query := `ResourceContainers | where type =~ "microsoft.resources/subscriptions" | project subscriptionId`
+	req := armresourcegraph.QueryRequest{
+		Query: &query,
+		Options: &armresourcegraph.QueryRequestOptions{
+			ResultFormat: ptr(armresourcegraph.ResultFormatObjectArray),
+		},
+		ManagementGroups: []*string{&managementGroupName},
+	}
+
+	var subIds []string
+	for {
+		resp, err := client.Resources(ctx, req, nil)
+		if err != nil {
+			return nil, fmt.Errorf("executing ARG query %q: %v", query, err)
+		}
+		data, _ := resp.Data.([]interface{})
+		for _, item := range data {
+			item, _ := item.(map[string]interface{})
+			if id, ok := item["subscriptionId"].(string); ok && id != "" {
+				subIds = append(subIds, id)
+			}
+		}
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			break
+		}
+		req.Options.SkipToken = resp.SkipToken
+	}
+
+	if len(subIds) == 0 {
+		return nil, fmt.Errorf("no subscription found under the management group %q", managementGroupName)
+	}
+
+	sort.Strings(subIds)
+	return subIds, nil
+}
@@ -0,0 +1,21 @@
+package meta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManagementGroupSubscriptionIds(t *testing.T) {
+	meta := MetaManagementGroup{
+		baseMeta:            baseMeta{subscriptionId: "111"},
+		managementGroupName: "mg",
+		onlySubscriptionId:  "222",
+	}
+	subIds, err := meta.subscriptionIds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"222"}, subIds)
+	// The subscription of the provider config is kept as is
+	require.Equal(t, "111", meta.subscriptionId)
+}
@@ -15,12 +15,16 @@ type MetaQuery struct {
 	baseMeta
 	argPredicate                 string
 	recursiveQuery               bool
-	resourceNamePrefix           string
-	resourceNameSuffix           string
+	resourceNamePattern          string
 	includeRoleAssignment        bool
+	includeLock                  bool
+	includeDataPlane             bool
 	includeResourceGroup         bool
 	argTable                     string
 	argAuthenticationScopeFilter armresourcegraph.AuthorizationScopeFilter
+	tagFilter                    tagFilter
+	excludeChildResources        bool
+	expandEmbeddedResources      bool
 }
 
 func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
@@ -32,14 +36,19 @@ func NewMetaQuery(cfg config.Config) (*MetaQuery, error) {
 
 	meta := &MetaQuery{
 		baseMeta:                     *baseMeta,
+		resourceNamePattern:          cfg.ResourceNamePattern,
 		argPredicate:                 cfg.ARGPredicate,
 		recursiveQuery:               cfg.RecursiveQuery,
 		includeRoleAssignment:        cfg.IncludeRoleAssignment,
+		includeLock:                  cfg.IncludeLock,
+		includeDataPlane:             cfg.IncludeDataPlane,
 		includeResourceGroup:         cfg.IncludeResourceGroup,
 		argTable:                     cfg.ARGTable,
 		argAuthenticationScopeFilter: armresourcegraph.AuthorizationScopeFilter(cfg.ARGAuthorizationScopeFilter),
+		tagFilter:                    tagFilter{include: cfg.IncludeTags, exclude: cfg.ExcludeTags},
+		excludeChildResources:        cfg.ExcludeChildResources,
+		expandEmbeddedResources:      cfg.ExpandEmbeddedResources,
 	}
-	meta.resourceNamePrefix, meta.resourceNameSuffix = resourceNamePattern(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
@@ -58,6 +67,18 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	meta.Logger().Debug("Filter resource set by tags")
+	rset = meta.tagFilter.Filter(rset)
+
+	if meta.includeDataPlane {
+		meta.Logger().Debug("List data plane resources")
+		l, err := meta.listDataPlaneResources(ctx, rset.Resources)
+		if err != nil {
+			return nil, err
+		}
+		rset.Resources = append(rset.Resources, l...)
+	}
 	var rl []resourceset.TFResource
 	if meta.useAzAPI() {
 		meta.Logger().Debug("Azure Resource set map to TF resource set")
@@ -67,27 +88,46 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 		if err := rset.PopulateResource(); err != nil {
 			return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
 		}
+		if meta.expandEmbeddedResources {
+			meta.Logger().Debug("Populate embedded resources")
+			if err := rset.PopulateEmbeddedResource(meta.Logger()); err != nil {
+				return nil, fmt.Errorf("populating embedded resources in the azure resource set: %v", err)
+			}
+		}
 		meta.Logger().Debug("Reduce resource set")
 		if err := rset.ReduceResource(); err != nil {
 			return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
 		}
 
 		meta.Logger().Debug("Azure Resource set map to TF resource set")
-		rl = rset.ToTFAzureRMResources(meta.Logger(), meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+		rl = meta.toTFAzureRMResources(ctx, rset)
+		if meta.azapiFallback {
+			rl = meta.fallbackToAzAPI(rl)
+		}
+	}
+
+	meta.Logger().Debug("Filter TF resource set by types and names")
+	rl = meta.filterTFResources(rl)
+
+	if meta.excludeChildResources {
+		meta.Logger().Debug("Exclude child resources")
+		rl = excludeChildResources(meta.Logger(), rl)
 	}
 
 	var l ImportList
+	namer := newResourceNamer(meta.resourceNamePattern)
 	for i, res := range rl {
+		name := namer.Name(i, res.AzureId, res.TFType)
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
 			TFResourceId:    res.TFId,
 			TFAddr: tfaddr.TFAddr{
 				Type: "",
-				Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+				Name: name,
 			},
 			TFAddrCache: tfaddr.TFAddr{
 				Type: "",
-				Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+				Name: name,
 			},
 		}
 		if res.TFType != "" {
@@ -99,7 +139,8 @@ func (meta *MetaQuery) ListResource(ctx context.Context) (ImportList, error) {
 
 		l = append(l, item)
 	}
-	return l, nil
+	meta.setResourceDetails(l, rset.Resources)
+	return meta.reconcileManagedResources(l)
 }
 
 func (meta MetaQuery) queryResourceSet(ctx context.Context, predicate string, recursive bool) (*resourceset.AzureResourceSet, error) {
@@ -111,7 +152,7 @@ func (meta MetaQuery) queryResourceSet(ctx context.Context, predicate string, re
 		Parallelism:                 meta.parallelism,
 		Recursive:                   recursive,
 		IncludeResourceGroup:        meta.includeResourceGroup,
-		ExtensionResourceTypes:      extBuilder{includeRoleAssignment: meta.includeRoleAssignment}.Build(),
+		ExtensionResourceTypes:      extBuilder{includeRoleAssignment: meta.includeRoleAssignment, includeLock: meta.includeLock}.Build(),
 		ARGTable:                    meta.argTable,
 		ARGAuthorizationScopeFilter: meta.argAuthenticationScopeFilter,
 	}
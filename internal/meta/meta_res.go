@@ -8,16 +8,18 @@ import (
 	"github.com/Azure/aztfexport/internal/tfaddr"
 	"github.com/Azure/aztfexport/pkg/config"
 	"github.com/magodo/armid"
+	"github.com/magodo/azlist/azlist"
 	"github.com/magodo/aztft/aztft"
 )
 
 type MetaResource struct {
 	baseMeta
-	AzureIds           []armid.ResourceId
-	ResourceName       string
-	ResourceType       string
-	resourceNamePrefix string
-	resourceNameSuffix string
+	AzureIds              []armid.ResourceId
+	ResourceName          string
+	ResourceType          string
+	resourceNamePattern   string
+	recursive             bool
+	excludeChildResources bool
 }
 
 func NewMetaResource(cfg config.Config) (*MetaResource, error) {
@@ -38,14 +40,15 @@ func NewMetaResource(cfg config.Config) (*MetaResource, error) {
 	}
 
 	meta := &MetaResource{
-		baseMeta:     *baseMeta,
-		AzureIds:     ids,
-		ResourceName: cfg.TFResourceName,
-		ResourceType: cfg.TFResourceType,
+		baseMeta:              *baseMeta,
+		resourceNamePattern:   cfg.ResourceNamePattern,
+		AzureIds:              ids,
+		ResourceName:          cfg.TFResourceName,
+		ResourceType:          cfg.TFResourceType,
+		recursive:             cfg.RecursiveQuery,
+		excludeChildResources: cfg.ExcludeChildResources,
 	}
 
-	meta.resourceNamePrefix, meta.resourceNameSuffix = resourceNamePattern(cfg.ResourceNamePattern)
-
 	return meta, nil
 }
 
@@ -63,6 +66,15 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 		resources = append(resources, resourceset.AzureResource{Id: id})
 	}
 
+	if meta.recursive {
+		meta.Logger().Debug("List child resources")
+		var err error
+		resources, err = meta.listChildResource(ctx, resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	rset := &resourceset.AzureResourceSet{
 		Resources: resources,
 	}
@@ -73,10 +85,22 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 	if meta.useAzAPI() {
 		rl = rset.ToTFAzAPIResources()
 	} else {
-		rl = rset.ToTFAzureRMResources(meta.Logger(), meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+		rl = meta.toTFAzureRMResources(ctx, rset)
+		if meta.azapiFallback {
+			rl = meta.fallbackToAzAPI(rl)
+		}
+	}
+
+	meta.Logger().Debug("Filter TF resource set by types and names")
+	rl = meta.filterTFResources(rl)
+
+	if meta.excludeChildResources {
+		meta.Logger().Debug("Exclude child resources")
+		rl = excludeChildResources(meta.Logger(), rl)
 	}
 
 	var l ImportList
+	namer := newResourceNamer(meta.resourceNamePattern)
 
 	// The ResourceName and ResourceType are only honored for single resource
 	if len(rl) == 1 {
@@ -85,7 +109,7 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 		// Honor the ResourceName
 		name := meta.ResourceName
 		if name == "" {
-			name = fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, 0, meta.resourceNameSuffix)
+			name = namer.Name(0, res.AzureId, res.TFType)
 		}
 
 		// Honor the ResourceType
@@ -120,14 +144,15 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 			TFAddrCache:     tfAddr,
 		}
 		l = append(l, item)
-		return l, nil
+		meta.setResourceDetails(l, rset.Resources)
+		return meta.reconcileManagedResources(l)
 	}
 
 	// Multi-resource mode only honors the resourceName[Pre|Suf]fix
 	for i, res := range rl {
 		tfAddr := tfaddr.TFAddr{
 			Type: "",
-			Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+			Name: namer.Name(i, res.AzureId, res.TFType),
 		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
@@ -145,5 +170,42 @@ func (meta *MetaResource) ListResource(ctx context.Context) (ImportList, error)
 		l = append(l, item)
 	}
 
-	return l, nil
+	meta.setResourceDetails(l, rset.Resources)
+	return meta.reconcileManagedResources(l)
+}
+
+// listChildResource lists the child resources of the specified resources recursively, returns the specified resources with their child resources appended.
+func (meta MetaResource) listChildResource(ctx context.Context, resources []resourceset.AzureResource) ([]resourceset.AzureResource, error) {
+	opt := azlist.Option{
+		Logger:         meta.logger.WithGroup("azlist"),
+		SubscriptionId: meta.subscriptionId,
+		Cred:           meta.azureSDKCred,
+		ClientOpt:      meta.azureSDKClientOpt,
+		Parallelism:    meta.parallelism,
+	}
+	lister, err := azlist.NewLister(opt)
+	if err != nil {
+		return nil, fmt.Errorf("building azlister: %v", err)
+	}
+
+	var rl []azlist.AzureResource
+	for _, res := range resources {
+		rl = append(rl, azlist.AzureResource{Id: res.Id})
+	}
+	rl, el, err := lister.ListChildResource(ctx, rl)
+	if err != nil {
+		return nil, fmt.Errorf("listing child resources: %w", err)
+	}
+	for _, e := range el {
+		meta.Logger().Warn("Failed to list child resource", "error", e.Error())
+	}
+
+	var out []resourceset.AzureResource
+	for _, res := range rl {
+		out = append(out, resourceset.AzureResource{
+			Id:         res.Id,
+			Properties: res.Properties,
+		})
+	}
+	return out, nil
 }
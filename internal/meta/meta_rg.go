@@ -12,10 +12,14 @@ import (
 
 type MetaResourceGroup struct {
 	baseMeta
-	resourceGroup         string
-	resourceNamePrefix    string
-	resourceNameSuffix    string
-	includeRoleAssignment bool
+	resourceGroup           string
+	resourceNamePattern     string
+	includeRoleAssignment   bool
+	includeLock             bool
+	includeDataPlane        bool
+	tagFilter               tagFilter
+	excludeChildResources   bool
+	expandEmbeddedResources bool
 }
 
 func NewMetaResourceGroup(cfg config.Config) (*MetaResourceGroup, error) {
@@ -26,11 +30,16 @@ func NewMetaResourceGroup(cfg config.Config) (*MetaResourceGroup, error) {
 	}
 
 	meta := &MetaResourceGroup{
-		baseMeta:              *baseMeta,
-		resourceGroup:         cfg.ResourceGroupName,
-		includeRoleAssignment: cfg.IncludeRoleAssignment,
+		baseMeta:                *baseMeta,
+		resourceNamePattern:     cfg.ResourceNamePattern,
+		resourceGroup:           cfg.ResourceGroupName,
+		includeRoleAssignment:   cfg.IncludeRoleAssignment,
+		includeLock:             cfg.IncludeLock,
+		includeDataPlane:        cfg.IncludeDataPlane,
+		tagFilter:               tagFilter{include: cfg.IncludeTags, exclude: cfg.ExcludeTags},
+		excludeChildResources:   cfg.ExcludeChildResources,
+		expandEmbeddedResources: cfg.ExpandEmbeddedResources,
 	}
-	meta.resourceNamePrefix, meta.resourceNameSuffix = resourceNamePattern(cfg.ResourceNamePattern)
 
 	return meta, nil
 }
@@ -46,6 +55,18 @@ func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, er
 		return nil, err
 	}
 
+	meta.Logger().Debug("Filter resource set by tags")
+	rset = meta.tagFilter.Filter(rset)
+
+	if meta.includeDataPlane {
+		meta.Logger().Debug("List data plane resources")
+		l, err := meta.listDataPlaneResources(ctx, rset.Resources)
+		if err != nil {
+			return nil, err
+		}
+		rset.Resources = append(rset.Resources, l...)
+	}
+
 	var rl []resourceset.TFResource
 	if meta.useAzAPI() {
 		rl = rset.ToTFAzAPIResources()
@@ -54,20 +75,38 @@ func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, er
 		if err := rset.PopulateResource(); err != nil {
 			return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
 		}
+		if meta.expandEmbeddedResources {
+			meta.Logger().Debug("Populate embedded resources")
+			if err := rset.PopulateEmbeddedResource(meta.Logger()); err != nil {
+				return nil, fmt.Errorf("populating embedded resources in the azure resource set: %v", err)
+			}
+		}
 		meta.Logger().Debug("Reduce resource set")
 		if err := rset.ReduceResource(); err != nil {
 			return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
 		}
 
 		meta.Logger().Debug("Azure Resource set map to TF resource set")
-		rl = rset.ToTFAzureRMResources(meta.Logger(), meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt)
+		rl = meta.toTFAzureRMResources(ctx, rset)
+		if meta.azapiFallback {
+			rl = meta.fallbackToAzAPI(rl)
+		}
+	}
+
+	meta.Logger().Debug("Filter TF resource set by types and names")
+	rl = meta.filterTFResources(rl)
+
+	if meta.excludeChildResources {
+		meta.Logger().Debug("Exclude child resources")
+		rl = excludeChildResources(meta.Logger(), rl)
 	}
 
 	var l ImportList
+	namer := newResourceNamer(meta.resourceNamePattern)
 	for i, res := range rl {
 		tfAddr := tfaddr.TFAddr{
 			Type: "",
-			Name: fmt.Sprintf("%s%d%s", meta.resourceNamePrefix, i, meta.resourceNameSuffix),
+			Name: namer.Name(i, res.AzureId, res.TFType),
 		}
 		item := ImportItem{
 			AzureResourceID: res.AzureId,
@@ -84,7 +123,8 @@ func (meta *MetaResourceGroup) ListResource(ctx context.Context) (ImportList, er
 
 		l = append(l, item)
 	}
-	return l, nil
+	meta.setResourceDetails(l, rset.Resources)
+	return meta.reconcileManagedResources(l)
 }
 
 func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (*resourceset.AzureResourceSet, error) {
@@ -97,14 +137,14 @@ func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (
 		Cred:                   meta.azureSDKCred,
 		ClientOpt:              meta.azureSDKClientOpt,
 		Parallelism:            meta.parallelism,
-		ExtensionResourceTypes: extBuilder{includeRoleAssignment: meta.includeRoleAssignment}.Build(),
+		ExtensionResourceTypes: extBuilder{includeRoleAssignment: meta.includeRoleAssignment, includeLock: meta.includeLock}.Build(),
 		ARGTable:               "ResourceContainers",
 	}
 	lister, err := azlist.NewLister(opt)
 	if err != nil {
 		return nil, fmt.Errorf("building azlister for listing resource group only: %v", err)
 	}
-	result, err := lister.List(ctx, fmt.Sprintf("name == %q", rg))
+	result, err := meta.listResources(ctx, lister, fmt.Sprintf("name == %q", rg), meta.armListResourceGroups(meta.subscriptionId, rg))
 	if err != nil {
 		return nil, fmt.Errorf("listing resource group only: %w", err)
 	}
@@ -128,14 +168,14 @@ func (meta MetaResourceGroup) queryResourceSet(ctx context.Context, rg string) (
 		Cred:                   meta.azureSDKCred,
 		ClientOpt:              meta.azureSDKClientOpt,
 		Parallelism:            meta.parallelism,
-		ExtensionResourceTypes: extBuilder{includeRoleAssignment: meta.includeRoleAssignment}.Build(),
+		ExtensionResourceTypes: extBuilder{includeRoleAssignment: meta.includeRoleAssignment, includeLock: meta.includeLock}.Build(),
 		Recursive:              true,
 	}
 	lister, err = azlist.NewLister(opt)
 	if err != nil {
 		return nil, fmt.Errorf("building azlister for listing resource group: %v", err)
 	}
-	result, err = lister.List(ctx, fmt.Sprintf("resourceGroup =~ %q", rg))
+	result, err = meta.listResources(ctx, lister, fmt.Sprintf("resourceGroup =~ %q", rg), meta.armListResources(meta.subscriptionId, rg))
 	if err != nil {
 		return nil, fmt.Errorf("listing resource group: %w", err)
 	}
@@ -0,0 +1,194 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/azlist/azlist"
+)
+
+type MetaSubscription struct {
+	baseMeta
+	resourceNamePattern     string
+	includeRoleAssignment   bool
+	includeLock             bool
+	includeDataPlane        bool
+	tagFilter               tagFilter
+	excludeChildResources   bool
+	expandEmbeddedResources bool
+}
+
+func NewMetaSubscription(cfg config.Config) (*MetaSubscription, error) {
+	cfg.Logger.Info("New subscription meta")
+	baseMeta, err := NewBaseMeta(cfg.CommonConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &MetaSubscription{
+		baseMeta:                *baseMeta,
+		resourceNamePattern:     cfg.ResourceNamePattern,
+		includeRoleAssignment:   cfg.IncludeRoleAssignment,
+		includeLock:             cfg.IncludeLock,
+		includeDataPlane:        cfg.IncludeDataPlane,
+		tagFilter:               tagFilter{include: cfg.IncludeTags, exclude: cfg.ExcludeTags},
+		excludeChildResources:   cfg.ExcludeChildResources,
+		expandEmbeddedResources: cfg.ExpandEmbeddedResources,
+	}
+
+	return meta, nil
+}
+
+func (meta MetaSubscription) ScopeName() string {
+	return "/subscriptions/" + meta.subscriptionId
+}
+
+func (meta *MetaSubscription) ListResource(ctx context.Context) (ImportList, error) {
+	meta.Logger().Debug("Query resource set")
+	rset, err := meta.queryResourceSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Logger().Debug("Filter resource set by tags")
+	rset = meta.tagFilter.Filter(rset)
+
+	if meta.includeDataPlane {
+		meta.Logger().Debug("List data plane resources")
+		l, err := meta.listDataPlaneResources(ctx, rset.Resources)
+		if err != nil {
+			return nil, err
+		}
+		rset.Resources = append(rset.Resources, l...)
+	}
+
+	var rl []resourceset.TFResource
+	if meta.useAzAPI() {
+		rl = rset.ToTFAzAPIResources()
+	} else {
+		meta.Logger().Debug("Populate resource set")
+		if err := rset.PopulateResource(); err != nil {
+			return nil, fmt.Errorf("tweaking single resources in the azure resource set: %v", err)
+		}
+		if meta.expandEmbeddedResources {
+			meta.Logger().Debug("Populate embedded resources")
+			if err := rset.PopulateEmbeddedResource(meta.Logger()); err != nil {
+				return nil, fmt.Errorf("populating embedded resources in the azure resource set: %v", err)
+			}
+		}
+		meta.Logger().Debug("Reduce resource set")
+		if err := rset.ReduceResource(); err != nil {
+			return nil, fmt.Errorf("tweaking across resources in the azure resource set: %v", err)
+		}
+
+		meta.Logger().Debug("Azure Resource set map to TF resource set")
+		rl = meta.toTFAzureRMResources(ctx, rset)
+		if meta.azapiFallback {
+			rl = meta.fallbackToAzAPI(rl)
+		}
+	}
+
+	meta.Logger().Debug("Filter TF resource set by types and names")
+	rl = meta.filterTFResources(rl)
+
+	if meta.excludeChildResources {
+		meta.Logger().Debug("Exclude child resources")
+		rl = excludeChildResources(meta.Logger(), rl)
+	}
+
+	var l ImportList
+	namer := newResourceNamer(meta.resourceNamePattern)
+	for i, res := range rl {
+		tfAddr := tfaddr.TFAddr{
+			Type: "",
+			Name: namer.Name(i, res.AzureId, res.TFType),
+		}
+		item := ImportItem{
+			AzureResourceID: res.AzureId,
+			TFResourceId:    res.TFId,
+			TFAddr:          tfAddr,
+			TFAddrCache:     tfAddr,
+		}
+		if res.TFType != "" {
+			item.Recommendations = []string{res.TFType}
+			item.TFAddr.Type = res.TFType
+			item.TFAddrCache.Type = res.TFType
+			item.IsRecommended = true
+		}
+
+		l = append(l, item)
+	}
+	meta.setResourceDetails(l, rset.Resources)
+	return meta.reconcileManagedResources(l)
+}
+
+func (meta MetaSubscription) queryResourceSet(ctx context.Context) (*resourceset.AzureResourceSet, error) {
+	rl, err := meta.listSubscriptionResources(ctx, meta.subscriptionId, extBuilder{includeRoleAssignment: meta.includeRoleAssignment, includeLock: meta.includeLock})
+	if err != nil {
+		return nil, err
+	}
+	return &resourceset.AzureResourceSet{Resources: rl}, nil
+}
+
+// listSubscriptionResources lists all the resource groups (with any extension resources) of the specified subscription, including the empty ones,
+// together with the resources (recursively) within them.
+func (meta baseMeta) listSubscriptionResources(ctx context.Context, subscriptionId string, ext extBuilder) ([]resourceset.AzureResource, error) {
+	var rl []resourceset.AzureResource
+
+	// List all the resource groups (with any extension resources) of the subscription first, including the empty ones.
+	opt := azlist.Option{
+		Logger:                 meta.logger.WithGroup("azlist"),
+		SubscriptionId:         subscriptionId,
+		Cred:                   meta.azureSDKCred,
+		ClientOpt:              meta.azureSDKClientOpt,
+		Parallelism:            meta.parallelism,
+		ExtensionResourceTypes: ext.Build(),
+		ARGTable:               "ResourceContainers",
+	}
+	lister, err := azlist.NewLister(opt)
+	if err != nil {
+		return nil, fmt.Errorf("building azlister for listing resource groups: %v", err)
+	}
+	result, err := meta.listResources(ctx, lister, `type =~ "microsoft.resources/subscriptions/resourcegroups"`, meta.armListResourceGroups(subscriptionId, ""))
+	if err != nil {
+		return nil, fmt.Errorf("listing resource groups: %w", err)
+	}
+	for _, res := range result.Resources {
+		res := resourceset.AzureResource{
+			Id:         res.Id,
+			Properties: res.Properties,
+		}
+		rl = append(rl, res)
+	}
+
+	// List the resources within all the resource groups.
+	opt = azlist.Option{
+		Logger:                 meta.logger.WithGroup("azlist"),
+		SubscriptionId:         subscriptionId,
+		Cred:                   meta.azureSDKCred,
+		ClientOpt:              meta.azureSDKClientOpt,
+		Parallelism:            meta.parallelism,
+		ExtensionResourceTypes: ext.Build(),
+		Recursive:              true,
+	}
+	lister, err = azlist.NewLister(opt)
+	if err != nil {
+		return nil, fmt.Errorf("building azlister for listing resources: %v", err)
+	}
+	result, err = meta.listResources(ctx, lister, "true", meta.armListResources(subscriptionId, ""))
+	if err != nil {
+		return nil, fmt.Errorf("listing resources: %w", err)
+	}
+	for _, res := range result.Resources {
+		res := resourceset.AzureResource{
+			Id:         res.Id,
+			Properties: res.Properties,
+		}
+		rl = append(rl, res)
+	}
+
+	return rl, nil
+}
@@ -0,0 +1,58 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// ChildModuleName is the name of the module call in the root module, when the resources are exported as a child module.
+	ChildModuleName = "resources"
+	// ChildModuleSource is the source (relative to the output directory) of the child module, when the resources are exported as a child module.
+	ChildModuleSource = "./modules/resources"
+)
+
+// initChildModule scaffolds the child module that holds the generated resources, together with the root module that instantiates it.
+// The root module (i.e. the output directory) keeps the terraform, provider and import blocks, while the child module only requires the providers.
+// The module call and the terraform block are only added when not defined yet (e.g. in the append mode).
+func (meta *baseMeta) initChildModule() error {
+	// #nosec G301
+	if err := os.MkdirAll(meta.moduleDir, 0755); err != nil {
+		return fmt.Errorf("creating the child module directory: %v", err)
+	}
+
+	rootModule, diags := tfconfig.LoadModule(meta.outdir)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+	if rootModule.ModuleCalls[ChildModuleName] == nil {
+		meta.Logger().Info("Output directory doesn't contain the child module call, create one then", "module", ChildModuleName)
+		if err := appendToFile(filepath.Join(meta.outdir, meta.outputFileNames.MainFileName), meta.buildChildModuleCall()); err != nil {
+			return fmt.Errorf("error creating the child module call: %w", err)
+		}
+	}
+
+	childModule, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+	if !meta.hasRequiredProviders(childModule) {
+		meta.Logger().Info("Child module doesn't contain the required provider setting, create one then", "module", ChildModuleName)
+		if err := appendToFile(filepath.Join(meta.moduleDir, meta.outputFileNames.TerraformFileName), meta.buildTerraformConfig("")); err != nil {
+			return fmt.Errorf("error creating the child module terraform config: %w", err)
+		}
+	}
+	return nil
+}
+
+// buildChildModuleCall builds the module call of the child module, which is written to the root module.
+func (meta *baseMeta) buildChildModuleCall() string {
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendNewBlock("module", []string{ChildModuleName}).Body().SetAttributeValue("source", cty.StringVal(ChildModuleSource))
+	return string(f.Bytes())
+}
@@ -0,0 +1,44 @@
+package meta
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitChildModule(t *testing.T) {
+	dir := t.TempDir()
+	meta := baseMeta{
+		logger:          slog.Default(),
+		providerName:    "azurerm",
+		outdir:          dir,
+		moduleDir:       filepath.Join(dir, ChildModuleSource),
+		outputFileNames: config.OutputFileNames{MainFileName: "main.tf", TerraformFileName: "terraform.tf"},
+	}
+	require.NoError(t, meta.initChildModule())
+
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `module "resources" {
+  source = "./modules/resources"
+}
+`, string(b))
+
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	require.False(t, diags.HasErrors())
+	require.Contains(t, module.RequiredProviders, "azurerm")
+
+	// The scaffolding is idempotent (e.g. in the append mode)
+	require.NoError(t, meta.initChildModule())
+	b2, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	require.Equal(t, string(b), string(b2))
+	b, err = os.ReadFile(filepath.Join(meta.moduleDir, "terraform.tf"))
+	require.NoError(t, err)
+	require.Equal(t, meta.buildTerraformConfig(""), string(b))
+}
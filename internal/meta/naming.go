@@ -0,0 +1,90 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/magodo/armid"
+)
+
+// namingTokenRegexp matches the tokens in a templated resource name pattern.
+var namingTokenRegexp = regexp.MustCompile(`\{(name|type|rg|index)\}`)
+
+// resourceNamer generates the Terraform resource names from the resource name pattern, which is either:
+//   - A templated pattern that contains any of the tokens: "{name}" (the Azure resource name), "{type}" (the Terraform resource type without the provider prefix),
+//     "{rg}" (the resource group name) and "{index}" (the index of the resource). The generated names are sanitized to valid HCL identifiers, and de-duplicated by appending a counter.
+//   - Otherwise, the same semantic as Go's os.CreateTemp(), where the last "*" (or the end) is replaced by the index of the resource.
+type resourceNamer struct {
+	pattern  string
+	template bool
+	prefix   string
+	suffix   string
+	used     map[string]bool
+}
+
+func newResourceNamer(pattern string) *resourceNamer {
+	namer := &resourceNamer{
+		pattern:  pattern,
+		template: namingTokenRegexp.MatchString(pattern),
+		used:     map[string]bool{},
+	}
+	if !namer.template {
+		namer.prefix, namer.suffix = resourceNamePattern(pattern)
+	}
+	return namer
+}
+
+// Name returns the resource name for the i-th resource, with its Azure resource id and Terraform resource type (empty if unknown).
+func (n *resourceNamer) Name(i int, azureId armid.ResourceId, tfType string) string {
+	if !n.template {
+		return fmt.Sprintf("%s%d%s", n.prefix, i, n.suffix)
+	}
+
+	name := namingTokenRegexp.ReplaceAllStringFunc(n.pattern, func(token string) string {
+		switch token {
+		case "{name}":
+			if names := azureId.Names(); len(names) != 0 {
+				return names[len(names)-1]
+			}
+			return ""
+		case "{type}":
+			if tfType != "" {
+				_, t, _ := strings.Cut(tfType, "_")
+				return t
+			}
+			// Fallback to the Azure resource type when the Terraform resource type is unknown
+			if types := azureId.Types(); len(types) != 0 {
+				return types[len(types)-1]
+			}
+			return ""
+		case "{rg}":
+			if rg, ok := azureId.RootScope().(*armid.ResourceGroup); ok {
+				return rg.Name
+			}
+			return ""
+		case "{index}":
+			return fmt.Sprint(i)
+		}
+		return token
+	})
+	name = sanitizeHCLIdentifier(name)
+
+	candidate := name
+	for cnt := 2; n.used[candidate]; cnt++ {
+		candidate = fmt.Sprintf("%s_%d", name, cnt)
+	}
+	n.used[candidate] = true
+	return candidate
+}
+
+var invalidHCLIdentifierCharRegexp = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// sanitizeHCLIdentifier converts the string to a valid HCL identifier, by replacing the invalid characters with "_", and prefixing a "_" if it doesn't start with a letter or "_".
+func sanitizeHCLIdentifier(s string) string {
+	s = invalidHCLIdentifierCharRegexp.ReplaceAllString(s, "_")
+	if s == "" || !(s[0] == '_' || (s[0] >= 'a' && s[0] <= 'z') || (s[0] >= 'A' && s[0] <= 'Z')) {
+		s = "_" + s
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceNamer(t *testing.T) {
+	type res struct {
+		id     string
+		tfType string
+	}
+	resources := []res{
+		{id: "/subscriptions/123/resourceGroups/my-rg", tfType: "azurerm_resource_group"},
+		{id: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/vnet.1", tfType: "azurerm_virtual_network"},
+		{id: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Network/virtualNetworks/vnet.1/subnets/default", tfType: ""},
+		{id: "/subscriptions/123/resourceGroups/other-rg/providers/Microsoft.Network/virtualNetworks/vnet-1", tfType: "azurerm_virtual_network"},
+		{id: "/subscriptions/123/resourceGroups/my-rg/providers/Microsoft.Storage/storageAccounts/1logs", tfType: "azurerm_storage_account"},
+	}
+
+	cases := []struct {
+		pattern string
+		expect  []string
+	}{
+		{
+			pattern: "res-",
+			expect:  []string{"res-0", "res-1", "res-2", "res-3", "res-4"},
+		},
+		{
+			pattern: "res-*-x",
+			expect:  []string{"res-0-x", "res-1-x", "res-2-x", "res-3-x", "res-4-x"},
+		},
+		{
+			pattern: "{type}_{name}",
+			expect:  []string{"resource_group_my-rg", "virtual_network_vnet_1", "subnets_default", "virtual_network_vnet-1", "storage_account_1logs"},
+		},
+		{
+			pattern: "{name}",
+			expect:  []string{"my-rg", "vnet_1", "default", "vnet-1", "_1logs"},
+		},
+		{
+			pattern: "{rg}",
+			expect:  []string{"my-rg", "my-rg_2", "my-rg_3", "other-rg", "my-rg_4"},
+		},
+		{
+			pattern: "r{index}",
+			expect:  []string{"r0", "r1", "r2", "r3", "r4"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.pattern, func(t *testing.T) {
+			namer := newResourceNamer(tt.pattern)
+			var names []string
+			for i, res := range resources {
+				id, err := armid.ParseResourceId(res.id)
+				require.NoError(t, err)
+				names = append(names, namer.Name(i, id, res.tfType))
+			}
+			require.Equal(t, tt.expect, names)
+		})
+	}
+}
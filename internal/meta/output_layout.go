@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/magodo/armid"
+)
+
+// azureServices maps the Azure resource provider namespaces (in lower case) to the service names, which follow the service packages of the azurerm provider.
+// Namespaces that are not listed here are named after the namespace itself, without the "Microsoft." prefix (e.g. "Microsoft.Network" -> "network").
+var azureServices = map[string]string{
+	"microsoft.resources":            "resource",
+	"microsoft.authorization":        "authorization",
+	"microsoft.managedidentity":      "managedidentity",
+	"microsoft.containerservice":     "containers",
+	"microsoft.containerregistry":    "containers",
+	"microsoft.containerinstance":    "containers",
+	"microsoft.app":                  "containerapps",
+	"microsoft.web":                  "appservice",
+	"microsoft.insights":             "monitor",
+	"microsoft.alertsmanagement":     "monitor",
+	"microsoft.operationalinsights":  "loganalytics",
+	"microsoft.operationsmanagement": "loganalytics",
+	"microsoft.sql":                  "mssql",
+	"microsoft.documentdb":           "cosmos",
+	"microsoft.dbforpostgresql":      "postgres",
+	"microsoft.dbformysql":           "mysql",
+	"microsoft.cache":                "redis",
+	"microsoft.keyvault":             "keyvault",
+	"microsoft.eventhub":             "eventhub",
+	"microsoft.servicebus":           "servicebus",
+	"microsoft.apimanagement":        "apimanagement",
+	"microsoft.recoveryservices":     "recoveryservices",
+	"microsoft.dataprotection":       "dataprotection",
+}
+
+// azureServiceName returns the service name of the Azure resource, which is used as the file name in the service layout.
+func azureServiceName(azureId armid.ResourceId) string {
+	namespace := strings.ToLower(azureId.Provider())
+	if name, ok := azureServices[namespace]; ok {
+		return name
+	}
+	name := strings.TrimPrefix(namespace, "microsoft.")
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// configFileName returns the name of the file that the config of the import item is written to, according to the output layout.
+func (meta baseMeta) configFileName(item ImportItem) string {
+	switch meta.outputLayout {
+	case config.OutputLayoutResource:
+		return item.TFAddr.Type + "." + item.TFAddr.Name + ".tf"
+	case config.OutputLayoutType:
+		return item.TFAddr.Type + ".tf"
+	case config.OutputLayoutService:
+		if item.AzureResourceID == nil {
+			return meta.outputFileNames.MainFileName
+		}
+		return azureServiceName(item.AzureResourceID) + ".tf"
+	default:
+		return meta.outputFileNames.MainFileName
+	}
+}
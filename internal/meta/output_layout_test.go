@@ -0,0 +1,109 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateConfigLayout(t *testing.T) {
+	var cfgs ConfigInfos
+	for _, res := range []struct {
+		id   string
+		addr tfaddr.TFAddr
+	}{
+		{
+			id:   "/subscriptions/123/resourceGroups/rg",
+			addr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"},
+		},
+		{
+			id:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet1",
+			addr: tfaddr.TFAddr{Type: "azurerm_subnet", Name: "subnet1"},
+		},
+		{
+			id:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet2",
+			addr: tfaddr.TFAddr{Type: "azurerm_subnet", Name: "subnet2"},
+		},
+	} {
+		azureId, err := armid.ParseResourceId(res.id)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(fmt.Sprintf("resource %q %q {}\n", res.addr.Type, res.addr.Name)), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		cfgs = append(cfgs, ConfigInfo{ImportItem: ImportItem{AzureResourceID: azureId, TFAddr: res.addr}, hcl: f})
+	}
+
+	cases := []struct {
+		layout string
+		files  []string
+	}{
+		{
+			layout: "",
+			files:  []string{"main.tf"},
+		},
+		{
+			layout: config.OutputLayoutResource,
+			files:  []string{"azurerm_resource_group.rg.tf", "azurerm_subnet.subnet1.tf", "azurerm_subnet.subnet2.tf"},
+		},
+		{
+			layout: config.OutputLayoutType,
+			files:  []string{"azurerm_resource_group.tf", "azurerm_subnet.tf"},
+		},
+		{
+			layout: config.OutputLayoutService,
+			files:  []string{"network.tf", "resource.tf"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.layout, func(t *testing.T) {
+			dir := t.TempDir()
+			meta := baseMeta{
+				moduleDir:       dir,
+				outputFileNames: config.OutputFileNames{MainFileName: "main.tf"},
+				outputLayout:    tt.layout,
+			}
+			require.NoError(t, meta.generateConfig(cfgs))
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			var files []string
+			for _, entry := range entries {
+				files = append(files, entry.Name())
+			}
+			sort.Strings(files)
+			require.Equal(t, tt.files, files)
+
+			if tt.layout == config.OutputLayoutType {
+				b, err := os.ReadFile(filepath.Join(dir, "azurerm_subnet.tf"))
+				require.NoError(t, err)
+				require.Equal(t, `resource "azurerm_subnet" "subnet1" {}
+
+resource "azurerm_subnet" "subnet2" {}
+
+`, string(b))
+			}
+		})
+	}
+}
+
+func TestAzureServiceName(t *testing.T) {
+	for id, name := range map[string]string{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm":                                                "compute",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa":                                                "storage",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks":                                      "containers",
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/providers/Microsoft.Authorization/locks/lock": "authorization",
+		"/subscriptions/123/resourceGroups/rg/providers/Contoso.Foo/foos/foo":                                                                "contoso_foo",
+	} {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		require.Equal(t, name, azureServiceName(azureId), id)
+	}
+}
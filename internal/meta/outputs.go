@@ -0,0 +1,107 @@
+package meta
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/magodo/tfadd/providers/azapi"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/magodo/tfadd/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// outputAttributes are the attributes exposed as outputs for all the resources, if supported by the resource type.
+var outputAttributes = []string{"id", "name"}
+
+// connectionOutputAttributes are the connection relevant attributes exposed as outputs per resource type, besides the outputAttributes.
+var connectionOutputAttributes = map[string][]string{
+	"azurerm_application_insights":       {"instrumentation_key", "connection_string"},
+	"azurerm_container_registry":         {"login_server"},
+	"azurerm_cosmosdb_account":           {"endpoint"},
+	"azurerm_key_vault":                  {"vault_uri"},
+	"azurerm_kubernetes_cluster":         {"fqdn", "kube_config_raw"},
+	"azurerm_linux_function_app":         {"default_hostname"},
+	"azurerm_linux_web_app":              {"default_hostname"},
+	"azurerm_log_analytics_workspace":    {"workspace_id"},
+	"azurerm_mssql_server":               {"fully_qualified_domain_name"},
+	"azurerm_mysql_flexible_server":      {"fqdn"},
+	"azurerm_postgresql_flexible_server": {"fqdn"},
+	"azurerm_public_ip":                  {"ip_address", "fqdn"},
+	"azurerm_redis_cache":                {"hostname", "ssl_port"},
+	"azurerm_servicebus_namespace":       {"endpoint"},
+	"azurerm_storage_account":            {"primary_blob_endpoint", "primary_connection_string"},
+	"azurerm_user_assigned_identity":     {"client_id", "principal_id"},
+	"azurerm_windows_function_app":       {"default_hostname"},
+	"azurerm_windows_web_app":            {"default_hostname"},
+}
+
+// generateOutputs writes the outputs of the key attributes of the resources to the output file, so that they can be consumed by other configurations.
+// The outputs are named as "<resource type>_<resource name>_<attribute>". The ones whose names are already defined in the module directory (e.g. in the append mode) are skipped.
+func (meta baseMeta) generateOutputs(configs ConfigInfos) (ConfigInfos, error) {
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+
+	f := hclwrite.NewEmptyFile()
+	var cnt int
+	for _, cfg := range configs {
+		tfType := cfg.TFAddr.Type
+		sch, ok := meta.resourceSchema(tfType)
+		if !ok {
+			continue
+		}
+		schAttrs := sch.Block.Attributes.Map()
+		attrs := append(append([]string{}, outputAttributes...), connectionOutputAttributes[tfType]...)
+		for _, attr := range attrs {
+			// The "id" attribute is not defined in the resource schemas, but available in all resources.
+			schAttr, ok := schAttrs[attr]
+			if !ok && attr != "id" {
+				continue
+			}
+			name := fmt.Sprintf("%s_%s_%s", tfType, cfg.TFAddr.Name, attr)
+			if _, ok := module.Outputs[name]; ok {
+				continue
+			}
+			body := f.Body().AppendNewBlock("output", []string{name}).Body()
+			body.SetAttributeTraversal("value", hcl.Traversal{
+				hcl.TraverseRoot{Name: tfType},
+				hcl.TraverseAttr{Name: cfg.TFAddr.Name},
+				hcl.TraverseAttr{Name: attr},
+			})
+			if schAttr != nil && schAttr.Sensitive {
+				body.SetAttributeValue("sensitive", cty.True)
+			}
+			f.Body().AppendNewline()
+			cnt++
+		}
+	}
+	if cnt == 0 {
+		return configs, nil
+	}
+
+	outputFile := filepath.Join(meta.moduleDir, meta.outputFileNames.OutputFileName)
+	if err := appendToFile(outputFile, string(hclwrite.Format(f.Bytes()))); err != nil {
+		return nil, fmt.Errorf("generating the output file: %w", err)
+	}
+	return configs, nil
+}
+
+// resourceSchema returns the schema of the TF resource type from the provider in use, or from the azapi provider if it is used together.
+func (meta baseMeta) resourceSchema(tfType string) (*schema.Schema, bool) {
+	resourceSchemas := azurerm.ProviderSchemaInfo.ResourceSchemas
+	if meta.useAzAPI() {
+		resourceSchemas = azapi.ProviderSchemaInfo.ResourceSchemas
+	}
+	sch, ok := resourceSchemas[tfType]
+	if !ok && meta.withAzAPI {
+		sch, ok = azapi.ProviderSchemaInfo.ResourceSchemas[tfType]
+	}
+	if !ok || sch.Block == nil {
+		return nil, false
+	}
+	return sch, true
+}
@@ -0,0 +1,73 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateOutputs(t *testing.T) {
+	cfgs := ConfigInfos{
+		{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "rg"}}},
+		{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_container_registry", Name: "acr"}}},
+		{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_application_insights", Name: "ai"}}},
+		{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_foo", Name: "unknown"}}},
+	}
+
+	dir := t.TempDir()
+	// The output that is already defined in the module directory
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.tf"), []byte(`output "azurerm_resource_group_rg_name" {
+  value = "rg"
+}
+`), 0600))
+
+	meta := baseMeta{
+		providerName:    "azurerm",
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{OutputFileName: "outputs.tf"},
+	}
+	_, err := meta.generateOutputs(cfgs)
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(filepath.Join(dir, "outputs.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `output "azurerm_resource_group_rg_id" {
+  value = azurerm_resource_group.rg.id
+}
+
+output "azurerm_container_registry_acr_id" {
+  value = azurerm_container_registry.acr.id
+}
+
+output "azurerm_container_registry_acr_name" {
+  value = azurerm_container_registry.acr.name
+}
+
+output "azurerm_container_registry_acr_login_server" {
+  value = azurerm_container_registry.acr.login_server
+}
+
+output "azurerm_application_insights_ai_id" {
+  value = azurerm_application_insights.ai.id
+}
+
+output "azurerm_application_insights_ai_name" {
+  value = azurerm_application_insights.ai.name
+}
+
+output "azurerm_application_insights_ai_instrumentation_key" {
+  value     = azurerm_application_insights.ai.instrumentation_key
+  sensitive = true
+}
+
+output "azurerm_application_insights_ai_connection_string" {
+  value     = azurerm_application_insights.ai.connection_string
+  sensitive = true
+}
+
+`, string(b))
+}
@@ -0,0 +1,74 @@
+package meta
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// overrideTemplates are the parsed override templates, keyed by the TF resource type.
+type overrideTemplates map[string]*template.Template
+
+// overrideTemplateData is the data that the override templates are executed with.
+type overrideTemplateData struct {
+	// The TF resource type and name
+	Type string
+	Name string
+	// The Azure resource id and the TF resource id
+	AzureResourceId string
+	TFResourceId    string
+	// The HCL of the generated resource block
+	Block string
+	// The HCL of the body of the generated resource block, i.e. the content inside the braces
+	Body string
+}
+
+func newOverrideTemplates(tpls map[string]string) (overrideTemplates, error) {
+	out := overrideTemplates{}
+	for tfType, tpl := range tpls {
+		t, err := template.New(tfType).Option("missingkey=error").Parse(tpl)
+		if err != nil {
+			return nil, fmt.Errorf("parsing the override template of %q: %v", tfType, err)
+		}
+		out[tfType] = t
+	}
+	return out, nil
+}
+
+// applyOverrideTemplates replaces the generated config of the resources whose TF resource types have override templates by the rendered templates,
+// which can either replace the generated resource block completely, or wrap it (e.g. adding more attributes) by referring to the Block or the Body.
+func (meta baseMeta) applyOverrideTemplates(configs ConfigInfos) (ConfigInfos, error) {
+	out := make(ConfigInfos, len(configs))
+	copy(out, configs)
+	for i, cfg := range out {
+		tpl, ok := meta.overrideTemplates[cfg.TFAddr.Type]
+		if !ok {
+			continue
+		}
+		data := overrideTemplateData{
+			Type:            cfg.TFAddr.Type,
+			Name:            cfg.TFAddr.Name,
+			AzureResourceId: cfg.AzureResourceID.String(),
+			TFResourceId:    cfg.TFResourceId,
+			Block:           strings.TrimSuffix(string(hclwrite.Format(cfg.hcl.Bytes())), "\n"),
+		}
+		if blocks := cfg.hcl.Body().Blocks(); len(blocks) != 0 {
+			data.Body = strings.Trim(string(blocks[0].Body().BuildTokens(nil).Bytes()), "\n")
+		}
+
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("executing the override template for %s: %v", cfg.TFAddr, err)
+		}
+		f, diags := hclwrite.ParseConfig(buf.Bytes(), cfg.TFAddr.String(), hcl.InitialPos)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("parsing the rendered override template for %s: %v", cfg.TFAddr, diags.Error())
+		}
+		out[i].hcl = f
+	}
+	return out, nil
+}
@@ -0,0 +1,100 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyOverrideTemplates(t *testing.T) {
+	newConfig := func(azureId, tfType, tfName, src string) ConfigInfo {
+		id, err := armid.ParseResourceId(azureId)
+		require.NoError(t, err)
+		f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return ConfigInfo{
+			ImportItem: ImportItem{
+				AzureResourceID: id,
+				TFResourceId:    azureId,
+				TFAddr:          tfaddr.TFAddr{Type: tfType, Name: tfName},
+			},
+			hcl: f,
+		}
+	}
+	cfgs := ConfigInfos{
+		newConfig("/subscriptions/123/resourceGroups/rg", "azurerm_resource_group", "rg", `resource "azurerm_resource_group" "rg" {
+  name     = "rg"
+  location = "westus"
+}
+`),
+		newConfig("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network", "vnet", `resource "azurerm_virtual_network" "vnet" {
+  name = "vnet"
+}
+`),
+		newConfig("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/sa", "azurerm_storage_account", "sa", `resource "azurerm_storage_account" "sa" {
+  name = "sa"
+}
+`),
+	}
+
+	tpls, err := newOverrideTemplates(map[string]string{
+		// Wrap the generated block by adding the standard tags
+		"azurerm_resource_group": `resource "{{ .Type }}" "{{ .Name }}" {
+{{ .Body }}
+  tags = {
+    owner = "platform"
+  }
+}
+`,
+		// Replace the generated block by a module call
+		"azurerm_virtual_network": `module "{{ .Name }}" {
+  source = "./modules/network"
+  id     = "{{ .AzureResourceId }}"
+}
+`,
+	})
+	require.NoError(t, err)
+
+	meta := baseMeta{overrideTemplates: tpls}
+	cfgs, err = meta.applyOverrideTemplates(cfgs)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_resource_group" "rg" {
+  name     = "rg"
+  location = "westus"
+  tags = {
+    owner = "platform"
+  }
+}
+`, string(hclwrite.Format(cfgs[0].hcl.Bytes())))
+	require.Equal(t, `module "vnet" {
+  source = "./modules/network"
+  id     = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+}
+`, string(hclwrite.Format(cfgs[1].hcl.Bytes())))
+	// The resources without override template are kept as is
+	require.Equal(t, `resource "azurerm_storage_account" "sa" {
+  name = "sa"
+}
+`, string(hclwrite.Format(cfgs[2].hcl.Bytes())))
+
+	// The rendered output must be valid HCL
+	tpls, err = newOverrideTemplates(map[string]string{"azurerm_storage_account": `resource "{{ .Type }}" {`})
+	require.NoError(t, err)
+	meta = baseMeta{overrideTemplates: tpls}
+	_, err = meta.applyOverrideTemplates(cfgs)
+	require.ErrorContains(t, err, "parsing the rendered override template for azurerm_storage_account.sa")
+
+	// The unknown fields are not allowed
+	tpls, err = newOverrideTemplates(map[string]string{"azurerm_storage_account": `{{ .Foo }}`})
+	require.NoError(t, err)
+	meta = baseMeta{overrideTemplates: tpls}
+	_, err = meta.applyOverrideTemplates(cfgs)
+	require.ErrorContains(t, err, "executing the override template for azurerm_storage_account.sa")
+
+	_, err = newOverrideTemplates(map[string]string{"azurerm_storage_account": `{{ .Block `})
+	require.ErrorContains(t, err, `parsing the override template of "azurerm_storage_account"`)
+}
@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// pinTerraformConfig regenerates the terraform block of the output directory with the versions that are installed by "terraform init",
+// so that the provider version is pinned exactly even if only a version constraint is specified, and the Terraform version is required as the minimum.
+func (meta *baseMeta) pinTerraformConfig(ctx context.Context) error {
+	tfVersion, providerVersions, err := meta.tf.Version(ctx, true)
+	if err != nil {
+		return fmt.Errorf("retrieving the Terraform and provider versions: %v", err)
+	}
+	providerVersion, ok := installedProviderVersion(providerVersions, meta.providerSource())
+	if !ok {
+		return fmt.Errorf("the installed version of the provider %q is not found", meta.providerSource())
+	}
+
+	meta.requiredVersion = ">= " + tfVersion.String()
+	meta.providerVersion = providerVersion.String()
+	meta.Logger().Info("Pin the versions in the terraform block", "terraform", meta.requiredVersion, "provider", meta.providerVersion)
+
+	cfgFile := filepath.Join(meta.outdir, meta.outputFileNames.TerraformFileName)
+	// #nosec G306
+	if err := os.WriteFile(cfgFile, []byte(meta.buildTerraformConfig(meta.backendType)), 0644); err != nil {
+		return fmt.Errorf("error pinning the versions of the terraform config: %w", err)
+	}
+	return nil
+}
+
+// installedProviderVersion returns the version of the provider of the source (e.g. "hashicorp/azurerm") from the provider versions reported by "terraform version",
+// which are keyed by the fully qualified provider addresses (e.g. "registry.terraform.io/hashicorp/azurerm").
+func installedProviderVersion(providerVersions map[string]*version.Version, source string) (*version.Version, bool) {
+	for addr, v := range providerVersions {
+		if v != nil && strings.HasSuffix(strings.ToLower(addr), "/"+source) {
+			return v, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,43 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstalledProviderVersion(t *testing.T) {
+	providerVersions := map[string]*version.Version{
+		"registry.terraform.io/hashicorp/azurerm": version.Must(version.NewVersion("3.99.0")),
+		"registry.terraform.io/azure/azapi":       version.Must(version.NewVersion("1.12.1")),
+	}
+	v, ok := installedProviderVersion(providerVersions, "hashicorp/azurerm")
+	require.True(t, ok)
+	require.Equal(t, "3.99.0", v.String())
+	v, ok = installedProviderVersion(providerVersions, "azure/azapi")
+	require.True(t, ok)
+	require.Equal(t, "1.12.1", v.String())
+	_, ok = installedProviderVersion(providerVersions, "hashicorp/random")
+	require.False(t, ok)
+}
+
+func TestBuildTerraformConfigWithPinnedVersions(t *testing.T) {
+	meta := baseMeta{
+		providerName:    "azurerm",
+		providerVersion: "3.99.0",
+		requiredVersion: ">= 1.5.7",
+	}
+	require.Equal(t, `terraform {
+  required_version = ">= 1.5.7"
+
+  required_providers {
+    azurerm = {
+      source = "hashicorp/azurerm"
+      version = "3.99.0"
+
+    }
+  }
+}
+`, meta.buildTerraformConfig(""))
+}
@@ -0,0 +1,52 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/magodo/tfadd/tfadd"
+)
+
+// PreviewCfg implements BaseMeta. The resource is imported into the first import directory (or held in memory when importing via the tfclient),
+// whose state is discarded afterwards, so that the state of the workspace is not changed.
+func (meta *baseMeta) PreviewCfg(ctx context.Context, item ImportItem) (string, error) {
+	if item.Skip() {
+		return "", fmt.Errorf("%s is skipped, nothing to preview", item.TFResourceId)
+	}
+	item.ImportError = nil
+	item.Imported = false
+
+	if meta.tfclient == nil {
+		// #nosec G104
+		defer os.Remove(filepath.Join(meta.importBaseDirs[0], "terraform.tfstate"))
+	}
+
+	meta.Logger().Info("Previewing the config of a resource", "tf_id", item.TFResourceId, "tf_addr", item.TFAddr)
+	meta.importItem(ctx, &item, 0)
+	if item.ImportError != nil {
+		return "", fmt.Errorf("reading %s as %s: %w", item.TFResourceId, item.TFAddr, item.ImportError)
+	}
+
+	if meta.tfclient != nil {
+		cfgs, err := meta.stateToConfig(ctx, ImportList{item})
+		if err != nil {
+			return "", err
+		}
+		return string(cfgs[0].hcl.Bytes()), nil
+	}
+
+	addr := item.TFAddr.String()
+	if meta.moduleAddr != "" {
+		addr = meta.moduleAddr + "." + addr
+	}
+	bs, err := tfadd.StateForTargets(ctx, meta.importTFs[0], []string{addr}, tfadd.Full(meta.fullConfig), tfadd.MaskSenstitive(meta.maskSensitive))
+	if err != nil {
+		return "", fmt.Errorf("converting terraform state to config: %w", err)
+	}
+	if len(bs) != 1 {
+		return "", fmt.Errorf("expect 1 config generated for %s, got=%d", addr, len(bs))
+	}
+	return meta.cleanupTerraformAdd(string(bs[0])), nil
+}
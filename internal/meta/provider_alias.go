@@ -0,0 +1,112 @@
+package meta
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/magodo/armid"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// subscriptionOf returns the subscription id that the resource resides in, or an empty string if the resource is not within a subscription (e.g. a tenant level resource).
+func subscriptionOf(id armid.ResourceId) string {
+	switch root := id.RootScope().(type) {
+	case *armid.SubscriptionId:
+		return root.Id
+	case *armid.ResourceGroup:
+		return root.SubscriptionId
+	}
+	return ""
+}
+
+// subscriptionProviderAlias returns the provider alias name used for the resources residing in the given subscription.
+func subscriptionProviderAlias(subscriptionId string) string {
+	return "subscription_" + strings.ReplaceAll(strings.ToLower(subscriptionId), "-", "_")
+}
+
+// providerAlias returns the alias of the provider that the resource, with the Azure resource id and the TF resource type, should be managed by, and the subscription id that the aliased provider targets.
+// It returns empty strings if the resource should be managed by the default provider, i.e. it resides in the same subscription as the one specified for the tool.
+// Only the azurerm provider needs the alias, as the azapi provider manages resources across subscriptions by their ids.
+func (meta baseMeta) providerAlias(id armid.ResourceId, tfType string) (alias, subscriptionId string) {
+	if meta.useAzAPI() || isAzAPIResourceType(tfType) || meta.subscriptionId == "" {
+		return "", ""
+	}
+	subscriptionId = subscriptionOf(id)
+	if subscriptionId == "" || strings.EqualFold(subscriptionId, meta.subscriptionId) {
+		return "", ""
+	}
+	return subscriptionProviderAlias(subscriptionId), subscriptionId
+}
+
+// buildAliasProviderBlock builds the aliased provider block, which targets to the given subscription.
+// The secrets from the auth config are only included when withAuthSecrets is true.
+func (meta baseMeta) buildAliasProviderBlock(alias, subscriptionId string, withAuthSecrets bool) *hclwrite.Block {
+	blk := hclwrite.NewBlock("provider", []string{meta.providerName})
+	body := blk.Body()
+	body.SetAttributeValue("alias", cty.StringVal(alias))
+	body.AppendNewBlock("features", nil)
+	for _, k := range meta.providerConfigKeys() {
+		if !withAuthSecrets && meta.authSecretKeys[k] {
+			continue
+		}
+		body.SetAttributeValue(k, meta.providerConfig[k])
+	}
+	body.SetAttributeValue("subscription_id", cty.StringVal(subscriptionId))
+	return blk
+}
+
+// providerAliasTraversal returns the traversal that refers to the aliased provider, e.g. azurerm.subscription_xxx.
+func (meta baseMeta) providerAliasTraversal(alias string) hcl.Traversal {
+	return hcl.Traversal{hcl.TraverseRoot{Name: meta.providerName}, hcl.TraverseAttr{Name: alias}}
+}
+
+// addProviderAlias sets the "provider" meta argument for the resources that reside in a subscription other than the one specified for the tool.
+func (meta baseMeta) addProviderAlias(configs ConfigInfos) (ConfigInfos, error) {
+	out := make(ConfigInfos, len(configs))
+	for i, cfg := range configs {
+		if alias, _ := meta.providerAlias(cfg.AzureResourceID, cfg.TFAddr.Type); alias != "" {
+			cfg.hcl.Body().Blocks()[0].Body().SetAttributeTraversal("provider", meta.providerAliasTraversal(alias))
+		}
+		out[i] = cfg
+	}
+	return out, nil
+}
+
+// buildAliasProviderConfig builds the aliased provider blocks needed by the resources, skipping the aliases that are already defined in the module directory.
+func (meta baseMeta) buildAliasProviderConfig(cfgs ConfigInfos) (string, error) {
+	subs := map[string]string{}
+	for _, cfg := range cfgs {
+		if alias, subscriptionId := meta.providerAlias(cfg.AzureResourceID, cfg.TFAddr.Type); alias != "" {
+			subs[alias] = subscriptionId
+		}
+	}
+	if len(subs) == 0 {
+		return "", nil
+	}
+
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return "", diags.Err()
+	}
+	for _, pcfg := range module.ProviderConfigs {
+		if pcfg.Name == meta.providerName && pcfg.Alias != "" {
+			delete(subs, pcfg.Alias)
+		}
+	}
+
+	var aliases []string
+	for alias := range subs {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	f := hclwrite.NewEmptyFile()
+	for _, alias := range aliases {
+		f.Body().AppendBlock(meta.buildAliasProviderBlock(alias, subs[alias], false))
+		f.Body().AppendNewline()
+	}
+	return string(f.Bytes()), nil
+}
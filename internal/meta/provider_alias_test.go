@@ -0,0 +1,87 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestProviderAlias(t *testing.T) {
+	meta := baseMeta{
+		providerName:   "azurerm",
+		subscriptionId: "00000000-0000-0000-0000-000000000000",
+	}
+
+	cases := []struct {
+		name  string
+		id    string
+		alias string
+	}{
+		{
+			name:  "resource in the same subscription",
+			id:    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg",
+			alias: "",
+		},
+		{
+			name:  "resource in another subscription",
+			id:    "/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			alias: "subscription_11111111_1111_1111_1111_111111111111",
+		},
+		{
+			name:  "tenant level resource",
+			id:    "/providers/Microsoft.Management/managementGroups/mg",
+			alias: "",
+		},
+	}
+
+	for _, c := range cases {
+		id, err := armid.ParseResourceId(c.id)
+		require.NoError(t, err, c.name)
+		alias, _ := meta.providerAlias(id, "")
+		require.Equal(t, c.alias, alias, c.name)
+	}
+
+	id, err := armid.ParseResourceId("/subscriptions/11111111-1111-1111-1111-111111111111/resourceGroups/rg")
+	require.NoError(t, err)
+	alias, _ := meta.providerAlias(id, AzAPIResourceType)
+	require.Empty(t, alias, "azapi resource needs no alias")
+
+	meta.providerName = "azapi"
+	alias, _ = meta.providerAlias(id, "")
+	require.Empty(t, alias, "azapi provider needs no alias")
+}
+
+func TestBuildAliasProviderBlock(t *testing.T) {
+	meta := baseMeta{
+		providerName: "azurerm",
+		providerConfig: map[string]cty.Value{
+			"client_secret": cty.StringVal("secret"),
+		},
+		authSecretKeys: map[string]bool{
+			"client_secret": true,
+		},
+	}
+	f := hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(meta.buildAliasProviderBlock("subscription_foo", "foo", false))
+	require.Equal(t, `provider "azurerm" {
+  alias = "subscription_foo"
+  features {
+  }
+  subscription_id = "foo"
+}
+`, string(hclwrite.Format(f.Bytes())))
+
+	f = hclwrite.NewEmptyFile()
+	f.Body().AppendBlock(meta.buildAliasProviderBlock("subscription_foo", "foo", true))
+	require.Equal(t, `provider "azurerm" {
+  alias = "subscription_foo"
+  features {
+  }
+  client_secret   = "secret"
+  subscription_id = "foo"
+}
+`, string(hclwrite.Format(f.Bytes())))
+}
@@ -0,0 +1,63 @@
+package meta
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// validateProviderBlocks validates the user supplied provider blocks, which can only contain the "provider" blocks of the given providers,
+// where each provider has at most one default (i.e. non-aliased) block.
+func validateProviderBlocks(src string, providerNames ...string) error {
+	f, diags := hclsyntax.ParseConfig([]byte(src), "provider", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("parsing the provider blocks: %v", diags.Error())
+	}
+	body := f.Body.(*hclsyntax.Body)
+	if len(body.Attributes) != 0 {
+		return fmt.Errorf("the provider blocks can't contain top level attributes")
+	}
+	known := map[string]bool{}
+	for _, name := range providerNames {
+		known[name] = true
+	}
+	defaults := map[string]bool{}
+	for _, blk := range body.Blocks {
+		if blk.Type != "provider" || len(blk.Labels) != 1 {
+			return fmt.Errorf(`the provider blocks can only contain "provider" blocks, got %q`, blk.Type)
+		}
+		name := blk.Labels[0]
+		if !known[name] {
+			return fmt.Errorf("unexpected provider %q in the provider blocks", name)
+		}
+		if _, ok := blk.Body.Attributes["alias"]; ok {
+			continue
+		}
+		if defaults[name] {
+			return fmt.Errorf("duplicate default provider %q in the provider blocks", name)
+		}
+		defaults[name] = true
+	}
+	return nil
+}
+
+// userProviderBlocks parses the user supplied provider blocks, and returns the default (i.e. non-aliased) block of each provider, together with the other blocks in order.
+// The blocks are newly parsed on each call, so that they can be appended to another file.
+func (meta baseMeta) userProviderBlocks() (defaults map[string]*hclwrite.Block, others []*hclwrite.Block) {
+	defaults = map[string]*hclwrite.Block{}
+	if meta.providerBlocks == "" {
+		return defaults, nil
+	}
+	// The blocks have been validated in NewBaseMeta.
+	f, _ := hclwrite.ParseConfig([]byte(meta.providerBlocks), "provider", hcl.InitialPos)
+	for _, blk := range f.Body().Blocks() {
+		if blk.Body().GetAttribute("alias") == nil {
+			defaults[blk.Labels()[0]] = blk
+			continue
+		}
+		others = append(others, blk)
+	}
+	return defaults, others
+}
@@ -0,0 +1,110 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestValidateProviderBlocks(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "valid blocks",
+			src: `provider "azurerm" {
+  features {}
+}
+provider "azurerm" {
+  alias = "other"
+  features {}
+}
+provider "azapi" {}
+`,
+		},
+		{
+			name: "invalid HCL",
+			src:  `provider "azurerm" {`,
+			err:  "parsing the provider blocks",
+		},
+		{
+			name: "top level attribute",
+			src:  `foo = "bar"`,
+			err:  "can't contain top level attributes",
+		},
+		{
+			name: "non provider block",
+			src:  `terraform {}`,
+			err:  `can only contain "provider" blocks`,
+		},
+		{
+			name: "unknown provider",
+			src:  `provider "aws" {}`,
+			err:  `unexpected provider "aws"`,
+		},
+		{
+			name: "duplicate default provider",
+			src: `provider "azurerm" {}
+provider "azurerm" {}
+`,
+			err: `duplicate default provider "azurerm"`,
+		},
+	}
+	for _, c := range cases {
+		err := validateProviderBlocks(c.src, "azurerm", "azapi")
+		if c.err == "" {
+			require.NoError(t, err, c.name)
+			continue
+		}
+		require.ErrorContains(t, err, c.err, c.name)
+	}
+}
+
+func TestBuildProviderConfigWithProviderBlocks(t *testing.T) {
+	meta := baseMeta{
+		providerName: "azurerm",
+		providerConfig: map[string]cty.Value{
+			"subscription_id": cty.StringVal("123"),
+			"partner_id":      cty.StringVal("default"),
+			"client_secret":   cty.StringVal("secret"),
+		},
+		authSecretKeys: map[string]bool{"client_secret": true},
+		providerBlocks: `provider "azurerm" {
+  partner_id                 = "00000000-0000-0000-0000-000000000000"
+  skip_provider_registration = true
+  features {
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+}
+
+provider "azurerm" {
+  alias           = "other"
+  subscription_id = "456"
+  features {}
+}
+`,
+	}
+	require.Equal(t, `provider "azurerm" {
+  partner_id                 = "00000000-0000-0000-0000-000000000000"
+  skip_provider_registration = true
+  features {
+    key_vault {
+      purge_soft_delete_on_destroy = false
+    }
+  }
+  subscription_id = "123"
+}
+
+provider "azurerm" {
+  alias           = "other"
+  subscription_id = "456"
+  features {}
+}
+`, string(hclwrite.Format([]byte(meta.buildProviderConfig(false)))))
+}
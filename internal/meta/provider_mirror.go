@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// buildProviderMirrorCLIConfig builds the terraform CLI config that installs the providers only from the mirror, which is either a network mirror (an https URL),
+// or a filesystem mirror (a directory), so that no registry access is needed.
+func buildProviderMirrorCLIConfig(mirror string) (string, error) {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body().AppendNewBlock("provider_installation", nil).Body()
+	// The single letter scheme is the drive letter of a Windows path.
+	if u, err := url.Parse(mirror); err == nil && len(u.Scheme) > 1 {
+		if u.Scheme != "https" {
+			return "", fmt.Errorf("the network mirror %q must use https", mirror)
+		}
+		// The network mirror URL must end with a slash, which is the base URL of the provider mirror protocol.
+		if !strings.HasSuffix(mirror, "/") {
+			mirror += "/"
+		}
+		body.AppendNewBlock("network_mirror", nil).Body().SetAttributeValue("url", cty.StringVal(mirror))
+	} else {
+		path, err := filepath.Abs(mirror)
+		if err != nil {
+			return "", fmt.Errorf("resolving the absolute path of the filesystem mirror %q: %v", mirror, err)
+		}
+		body.AppendNewBlock("filesystem_mirror", nil).Body().SetAttributeValue("path", cty.StringVal(filepath.ToSlash(path)))
+	}
+	return string(f.Bytes()), nil
+}
+
+// initProviderMirror writes the terraform CLI config that uses the provider mirror to a temporary file, which is then used by the terraform commands via the "TF_CLI_CONFIG_FILE".
+func (meta *baseMeta) initProviderMirror() error {
+	config, err := buildProviderMirrorCLIConfig(meta.providerMirror)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp("", "aztfexport-*.tfrc")
+	if err != nil {
+		return fmt.Errorf("creating the terraform CLI config file: %v", err)
+	}
+	// #nosec G307
+	defer f.Close()
+	if _, err := f.WriteString(config); err != nil {
+		return fmt.Errorf("writing the terraform CLI config file %s: %v", f.Name(), err)
+	}
+	meta.cliConfigFile = f.Name()
+
+	if v, ok := os.LookupEnv("TF_CLI_CONFIG_FILE"); ok {
+		meta.Logger().Warn("The terraform CLI config file is overridden to use the provider mirror", "file", v)
+	}
+	meta.Logger().Info("Install the providers from the mirror", "mirror", meta.providerMirror)
+	// #nosec G104
+	os.Setenv("TF_CLI_CONFIG_FILE", meta.cliConfigFile)
+	return nil
+}
+
+// pluginCacheDir returns the provider plugin cache directory specified by the "TF_PLUGIN_CACHE_DIR", which is shared by the terraform init of all the directories.
+// The directory is created if not exists, as terraform requires it to exist.
+func pluginCacheDir() (string, error) {
+	dir := os.Getenv("TF_PLUGIN_CACHE_DIR")
+	if dir == "" {
+		return "", nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("creating the plugin cache directory %s: %v", dir, err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,55 @@
+package meta
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProviderMirrorCLIConfig(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name   string
+		mirror string
+		expect string
+		err    string
+	}{
+		{
+			name:   "network mirror",
+			mirror: "https://mirror.example.com/providers",
+			expect: `provider_installation {
+  network_mirror {
+    url = "https://mirror.example.com/providers/"
+  }
+}
+`,
+		},
+		{
+			name:   "network mirror without https",
+			mirror: "http://mirror.example.com/providers/",
+			err:    "must use https",
+		},
+		{
+			name:   "filesystem mirror",
+			mirror: dir,
+			expect: `provider_installation {
+  filesystem_mirror {
+    path = "` + filepath.ToSlash(dir) + `"
+  }
+}
+`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := buildProviderMirrorCLIConfig(tt.mirror)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, config)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package meta
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+)
+
+const (
+	resourceCacheKindType        = "types"
+	resourceCacheKindARMTemplate = "armtemplates"
+)
+
+// resourceCache caches the results of the Azure calls between runs in the user cache directory, i.e. the TF resource types resolved by aztft (which might GET the resources),
+// and the exported ARM templates. The results are keyed by the etags of the resources involved, so that a result is invalidated once any of the resources changes.
+type resourceCache struct {
+	dir string
+
+	mu sync.Mutex
+	// The upper cased Azure resource ids to the etags of the listed resources.
+	etags map[string]string
+}
+
+// newResourceCache returns the resource cache in the user cache directory. The cache is separated by the aztft version, as the type resolution might change across versions.
+func newResourceCache() (*resourceCache, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("retrieving the user's cache directory: %v", err)
+	}
+	return &resourceCache{
+		dir:   filepath.Join(cacheDir, "aztfexport", "cache", aztftVersion()),
+		etags: map[string]string{},
+	}, nil
+}
+
+// aztftVersion returns the version of the aztft module built into the binary.
+func aztftVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/magodo/aztft" {
+				return dep.Version
+			}
+		}
+	}
+	return "devel"
+}
+
+// resourceETag returns the etag of the listed resource, which is the "etag" property if any, otherwise the digest of the properties.
+// No etag is returned for the resources without properties (e.g. the pseudo resources), which are never cached.
+func resourceETag(res resourceset.AzureResource) (string, bool) {
+	if res.Properties == nil {
+		return "", false
+	}
+	if etag, ok := res.Properties["etag"].(string); ok && etag != "" {
+		return etag, true
+	}
+	b, err := json.Marshal(res.Properties)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// record records the etags of the listed resources, which are used to key the cached results.
+func (c *resourceCache) record(rl []resourceset.AzureResource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, res := range rl {
+		if etag, ok := resourceETag(res); ok {
+			c.etags[strings.ToUpper(res.Id.String())] = etag
+		}
+	}
+}
+
+func (c *resourceCache) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, kind, hex.EncodeToString(sum[:])+".json")
+}
+
+// read reads the cached result of the key into v, which tells whether the result is cached.
+func (c *resourceCache) read(kind, key string, v interface{}) bool {
+	// #nosec G304
+	b, err := os.ReadFile(c.path(kind, key))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+// write caches the result v of the key. The result is written to a temporary file aside first, so that the concurrent runs never read a partially written one.
+func (c *resourceCache) write(kind, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	path := c.path(kind, key)
+	// #nosec G301
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	// #nosec G104
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		// #nosec G104
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+type cachedType struct {
+	AzureId string `json:"azure_id"`
+	TFType  string `json:"tf_type"`
+	TFId    string `json:"tf_id"`
+}
+
+func cachedTypeKey(res resourceset.AzureResource, etag string) string {
+	return strings.ToUpper(res.Id.String()) + "\n" + etag
+}
+
+// Get implements resourceset.TypeCache.
+func (c *resourceCache) Get(res resourceset.AzureResource) ([]aztft.Type, []string, bool) {
+	etag, ok := resourceETag(res)
+	if !ok {
+		return nil, nil, false
+	}
+	var l []cachedType
+	if !c.read(resourceCacheKindType, cachedTypeKey(res, etag), &l) || len(l) == 0 {
+		return nil, nil, false
+	}
+	var (
+		tftypes []aztft.Type
+		tfids   []string
+	)
+	for _, t := range l {
+		id, err := armid.ParseResourceId(t.AzureId)
+		if err != nil {
+			return nil, nil, false
+		}
+		tftypes = append(tftypes, aztft.Type{AzureId: id, TFType: t.TFType})
+		tfids = append(tfids, t.TFId)
+	}
+	return tftypes, tfids, true
+}
+
+// Put implements resourceset.TypeCache. Failing to cache is not regarded as an error.
+func (c *resourceCache) Put(res resourceset.AzureResource, tftypes []aztft.Type, tfids []string) {
+	etag, ok := resourceETag(res)
+	if !ok || len(tftypes) == 0 || len(tftypes) != len(tfids) {
+		return
+	}
+	var l []cachedType
+	for i, t := range tftypes {
+		l = append(l, cachedType{AzureId: t.AzureId.String(), TFType: t.TFType, TFId: tfids[i]})
+	}
+	// #nosec G104
+	c.write(resourceCacheKindType, cachedTypeKey(res, etag), l)
+}
+
+// armTemplateKey returns the cache key of the ARM template exported for the resources of the resource group, which requires the etag of the resource group to be recorded.
+// The resources without etags (e.g. the pseudo resources) are keyed by their ids only, as they are derived from the properties of the other resources.
+func (c *resourceCache) armTemplateKey(rg *armid.ResourceGroup, resources []*string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rgId := strings.ToUpper(rg.String())
+	etag, ok := c.etags[rgId]
+	if !ok {
+		return "", false
+	}
+	var ids []string
+	for _, res := range resources {
+		ids = append(ids, strings.ToUpper(*res))
+	}
+	sort.Strings(ids)
+	var sb strings.Builder
+	sb.WriteString(rgId + "\n" + etag + "\n")
+	for _, id := range ids {
+		sb.WriteString(id + "\n" + c.etags[id] + "\n")
+	}
+	return sb.String(), true
+}
+
+// toTFAzureRMResources resolves the azurerm resource types and ids of the resource set, which are cached (together with the etags of the resources) if the cache is enabled.
+func (meta baseMeta) toTFAzureRMResources(ctx context.Context, rset *resourceset.AzureResourceSet) []resourceset.TFResource {
+	var cache resourceset.TypeCache
+	if meta.cache != nil {
+		meta.cache.record(rset.Resources)
+		cache = meta.cache
+	}
+	return rset.ToTFAzureRMResources(ctx, meta.Logger(), meta.parallelism, meta.azureSDKCred, meta.azureSDKClientOpt, meta.typeResolver, cache)
+}
@@ -0,0 +1,93 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/magodo/armid"
+	"github.com/magodo/aztft/aztft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceCacheType(t *testing.T) {
+	c := &resourceCache{dir: t.TempDir(), etags: map[string]string{}}
+
+	id, err := armid.ParseResourceId("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+	res := resourceset.AzureResource{Id: id, Properties: map[string]interface{}{"location": "westus"}}
+
+	_, _, ok := c.Get(res)
+	require.False(t, ok)
+
+	c.Put(res, []aztft.Type{{AzureId: id, TFType: "azurerm_virtual_network"}}, []string{id.String()})
+	tftypes, tfids, ok := c.Get(res)
+	require.True(t, ok)
+	require.Equal(t, []aztft.Type{{AzureId: id, TFType: "azurerm_virtual_network"}}, tftypes)
+	require.Equal(t, []string{id.String()}, tfids)
+
+	// The cached type is invalidated once the resource changes
+	res.Properties["location"] = "eastus"
+	_, _, ok = c.Get(res)
+	require.False(t, ok)
+
+	// The resources without properties are never cached
+	pseudo := resourceset.AzureResource{Id: id}
+	c.Put(pseudo, []aztft.Type{{AzureId: id, TFType: "azurerm_virtual_network"}}, []string{id.String()})
+	_, _, ok = c.Get(pseudo)
+	require.False(t, ok)
+}
+
+func TestResourceETag(t *testing.T) {
+	id, err := armid.ParseResourceId("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg")
+	require.NoError(t, err)
+
+	etag, ok := resourceETag(resourceset.AzureResource{Id: id, Properties: map[string]interface{}{"etag": "foo"}})
+	require.True(t, ok)
+	require.Equal(t, "foo", etag)
+
+	etag1, ok := resourceETag(resourceset.AzureResource{Id: id, Properties: map[string]interface{}{"a": 1, "b": 2}})
+	require.True(t, ok)
+	etag2, ok := resourceETag(resourceset.AzureResource{Id: id, Properties: map[string]interface{}{"b": 2, "a": 1}})
+	require.True(t, ok)
+	require.Equal(t, etag1, etag2)
+
+	_, ok = resourceETag(resourceset.AzureResource{Id: id})
+	require.False(t, ok)
+}
+
+func TestResourceCacheARMTemplateKey(t *testing.T) {
+	c := &resourceCache{dir: t.TempDir(), etags: map[string]string{}}
+
+	rg := &armid.ResourceGroup{SubscriptionId: "00000000-0000-0000-0000-000000000000", Name: "rg"}
+	vnet := rg.String() + "/providers/Microsoft.Network/virtualNetworks/vnet"
+	subnet := vnet + "/subnets/subnet"
+
+	_, ok := c.armTemplateKey(rg, []*string{&vnet})
+	require.False(t, ok, "no etag of the resource group is recorded")
+
+	rgId, err := armid.ParseResourceId(rg.String())
+	require.NoError(t, err)
+	vnetId, err := armid.ParseResourceId(vnet)
+	require.NoError(t, err)
+	c.record([]resourceset.AzureResource{
+		{Id: rgId, Properties: map[string]interface{}{"etag": "rg1"}},
+		{Id: vnetId, Properties: map[string]interface{}{"etag": "vnet1"}},
+	})
+
+	key1, ok := c.armTemplateKey(rg, []*string{&vnet, &subnet})
+	require.True(t, ok)
+	key2, ok := c.armTemplateKey(rg, []*string{&subnet, &vnet})
+	require.True(t, ok)
+	require.Equal(t, key1, key2)
+
+	c.record([]resourceset.AzureResource{{Id: vnetId, Properties: map[string]interface{}{"etag": "vnet2"}}})
+	key3, ok := c.armTemplateKey(rg, []*string{&vnet, &subnet})
+	require.True(t, ok)
+	require.NotEqual(t, key1, key3)
+
+	require.NoError(t, c.write(resourceCacheKindARMTemplate, key3, armTemplate{Resources: []armTemplateResource{{Type: "Microsoft.Network/virtualNetworks", Name: "vnet"}}}))
+	var tpl armTemplate
+	require.True(t, c.read(resourceCacheKindARMTemplate, key3, &tpl))
+	require.Equal(t, "vnet", tpl.Resources[0].Name)
+	require.False(t, c.read(resourceCacheKindARMTemplate, key1, &tpl))
+}
@@ -0,0 +1,109 @@
+package meta
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/magodo/azlist/azlist"
+)
+
+// ResourceDetail is the detail of the Azure resource of an import item, which helps users to disambiguate the similarly named resources (e.g. in the interactive mode).
+type ResourceDetail struct {
+	// The location of the resource, which is empty if unknown (e.g. the resource is not listed, or is not a tracked resource)
+	Location string
+	// The tags of the resource, which is nil if unknown
+	Tags map[string]string
+	// The latest stable API version of the resource type known by the ARM schema, which is empty if unknown
+	APIVersion string
+	// The URL of the resource in the Azure portal, which is empty for the custom clouds
+	PortalURL string
+}
+
+var (
+	armAPIVersionsOnce sync.Once
+	armAPIVersions     map[string]string
+)
+
+// latestAPIVersion returns the latest API version of the ARM resource type known by the ARM schema, where the stable versions take precedence over the preview ones.
+func latestAPIVersion(armType string) string {
+	armAPIVersionsOnce.Do(func() {
+		armAPIVersions = map[string]string{}
+		var schemas map[string][]string
+		if err := json.Unmarshal(azlist.ARMSchemaFile, &schemas); err != nil {
+			return
+		}
+		for rt, versions := range schemas {
+			versions := append([]string{}, versions...)
+			sort.Strings(versions)
+			latest := versions[len(versions)-1]
+			for i := len(versions) - 1; i >= 0; i-- {
+				if !strings.Contains(versions[i], "preview") {
+					latest = versions[i]
+					break
+				}
+			}
+			armAPIVersions[strings.ToUpper(strings.TrimSuffix(rt, "/"))] = latest
+		}
+	})
+	return armAPIVersions[strings.ToUpper(armType)]
+}
+
+// portalHosts maps the resource manager endpoints of the well known clouds to the hosts of the Azure portal.
+var portalHosts = map[string]string{
+	cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint:     "portal.azure.com",
+	cloud.AzureGovernment.Services[cloud.ResourceManager].Endpoint: "portal.azure.us",
+	cloud.AzureChina.Services[cloud.ResourceManager].Endpoint:      "portal.azure.cn",
+}
+
+// portalURL returns the URL of the resource in the Azure portal, which is empty for the custom clouds.
+func (meta baseMeta) portalURL(id string) string {
+	endpoint := cloud.AzurePublic.Services[cloud.ResourceManager].Endpoint
+	if svc, ok := meta.azureSDKClientOpt.Cloud.Services[cloud.ResourceManager]; ok {
+		endpoint = svc.Endpoint
+	}
+	host, ok := portalHosts[strings.TrimSuffix(endpoint, "/")]
+	if !ok {
+		host, ok = portalHosts[strings.TrimSuffix(endpoint, "/")+"/"]
+	}
+	if !ok {
+		return ""
+	}
+	return "https://" + host + "/#resource" + id
+}
+
+// setResourceDetails sets the details of the import items, whose location and tags are read from the properties of the listed resources (if any).
+func (meta baseMeta) setResourceDetails(l ImportList, rl []resourceset.AzureResource) {
+	props := map[string]map[string]interface{}{}
+	for _, res := range rl {
+		if res.Properties != nil {
+			props[strings.ToUpper(res.Id.String())] = res.Properties
+		}
+	}
+	for i := range l {
+		item := &l[i]
+		if item.AzureResourceID == nil {
+			continue
+		}
+		id := item.AzureResourceID.String()
+		detail := &ResourceDetail{
+			APIVersion: latestAPIVersion(item.AzureResourceID.TypeString()),
+			PortalURL:  meta.portalURL(id),
+		}
+		if p, ok := props[strings.ToUpper(id)]; ok {
+			detail.Location, _ = p["location"].(string)
+			if tags, ok := p["tags"].(map[string]interface{}); ok {
+				detail.Tags = map[string]string{}
+				for k, v := range tags {
+					if v, ok := v.(string); ok {
+						detail.Tags[k] = v
+					}
+				}
+			}
+		}
+		item.Detail = detail
+	}
+}
@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/resourceset"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestAPIVersion(t *testing.T) {
+	// The stable version takes precedence over the newer preview ones
+	v := latestAPIVersion("microsoft.compute/virtualMachines")
+	require.NotEmpty(t, v)
+	require.NotContains(t, v, "preview")
+	require.Empty(t, latestAPIVersion("Microsoft.Foo/bars"))
+}
+
+func TestSetResourceDetails(t *testing.T) {
+	vnetId, err := armid.ParseResourceId("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+	subnetId, err := armid.ParseResourceId("/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet")
+	require.NoError(t, err)
+
+	l := ImportList{{AzureResourceID: vnetId}, {AzureResourceID: subnetId}}
+	meta := baseMeta{}
+	meta.setResourceDetails(l, []resourceset.AzureResource{
+		{Id: vnetId, Properties: map[string]interface{}{"location": "westus", "tags": map[string]interface{}{"env": "test"}}},
+		{Id: subnetId},
+	})
+	require.Equal(t, "westus", l[0].Detail.Location)
+	require.Equal(t, map[string]string{"env": "test"}, l[0].Detail.Tags)
+	require.NotEmpty(t, l[0].Detail.APIVersion)
+	require.Equal(t, "https://portal.azure.com/#resource"+vnetId.String(), l[0].Detail.PortalURL)
+	require.Empty(t, l[1].Detail.Location)
+	require.Nil(t, l[1].Detail.Tags)
+
+	meta.azureSDKClientOpt = arm.ClientOptions{ClientOptions: policy.ClientOptions{Cloud: cloud.AzureChina}}
+	require.Equal(t, "https://portal.azure.cn/#resource"+vnetId.String(), meta.portalURL(vnetId.String()))
+	meta.azureSDKClientOpt = arm.ClientOptions{ClientOptions: policy.ClientOptions{Cloud: cloud.Configuration{
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{cloud.ResourceManager: {Endpoint: "https://management.local.azurestack.external"}},
+	}}}
+	require.Empty(t, meta.portalURL(vnetId.String()))
+}
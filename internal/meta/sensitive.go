@@ -0,0 +1,195 @@
+package meta
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfpluginschema "github.com/magodo/tfpluginschema/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SensitiveVariableExampleFileName is the example variable definitions file of the extracted sensitive variables, which is written to the output directory.
+const SensitiveVariableExampleFileName = "terraform.tfvars.example"
+
+// sensitiveVariable is a variable extracted from a sensitive attribute.
+type sensitiveVariable struct {
+	name string
+	typ  cty.Type
+	addr string
+}
+
+// extractSensitiveVariables replaces the literal values of the sensitive attributes (as is marked in the schema) of the resources with references to the sensitive variables,
+// which are written to the variable file without defaults. The variables are named as "<resource type>_<resource name>_<attribute path>".
+// An example variable definitions file is written to the output directory with placeholder values, instead of the actual secrets.
+func (meta baseMeta) extractSensitiveVariables(configs ConfigInfos) (ConfigInfos, error) {
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	used := map[string]bool{}
+	for name := range module.Variables {
+		used[name] = true
+	}
+
+	var vars []sensitiveVariable
+	for _, cfg := range configs {
+		sch, ok := meta.resourceSchema(cfg.TFAddr.Type)
+		if !ok {
+			continue
+		}
+		blocks := cfg.hcl.Body().Blocks()
+		if len(blocks) == 0 {
+			continue
+		}
+		vars = append(vars, extractSensitiveBlock(blocks[0].Body(), sch.Block, cfg.TFAddr.Type+"_"+cfg.TFAddr.Name, cfg.TFAddr.String(), used)...)
+	}
+	if len(vars) == 0 {
+		return configs, nil
+	}
+
+	varFile := filepath.Join(meta.moduleDir, meta.outputFileNames.VariableFileName)
+	if err := appendToFile(varFile, buildSensitiveVariables(vars)); err != nil {
+		return nil, fmt.Errorf("generating the sensitive variables: %w", err)
+	}
+	if meta.asModule {
+		if err := meta.passChildModuleVariables(vars); err != nil {
+			return nil, err
+		}
+	}
+
+	f := hclwrite.NewEmptyFile()
+	for _, v := range vars {
+		f.Body().AppendUnstructuredTokens(hclwrite.Tokens{{Type: hclsyntax.TokenComment, Bytes: []byte("# The sensitive value of " + v.addr + "\n")}})
+		placeholder := cty.NullVal(v.typ)
+		if v.typ == cty.String {
+			placeholder = cty.StringVal("")
+		}
+		f.Body().SetAttributeValue(v.name, placeholder)
+	}
+	exampleFile := filepath.Join(meta.outdir, SensitiveVariableExampleFileName)
+	if err := appendToFile(exampleFile, string(hclwrite.Format(f.Bytes()))); err != nil {
+		return nil, fmt.Errorf("generating the sensitive variable example file: %w", err)
+	}
+	return configs, nil
+}
+
+// extractSensitiveBlock extracts the sensitive attributes of the block (recursively) as variables, where the prefix is the variable name prefix, and the addr is the attribute address prefix.
+func extractSensitiveBlock(body *hclwrite.Body, sch *tfpluginschema.SchemaBlock, prefix, addr string, used map[string]bool) []sensitiveVariable {
+	if sch == nil {
+		return nil
+	}
+	var vars []sensitiveVariable
+	attrSchs := sch.Attributes.Map()
+	for _, name := range sortedAttributeNames(body) {
+		attrSch, ok := attrSchs[name]
+		if !ok || !attrSch.Sensitive || attrSch.Type == nil {
+			continue
+		}
+		if v, ok := literalValue(body.GetAttribute(name).Expr()); !ok || v.IsNull() {
+			continue
+		}
+		varName := sanitizeHCLIdentifier(prefix + "_" + name)
+		candidate := varName
+		for cnt := 2; used[candidate]; cnt++ {
+			candidate = fmt.Sprintf("%s_%d", varName, cnt)
+		}
+		used[candidate] = true
+		body.SetAttributeTraversal(name, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: candidate}})
+		vars = append(vars, sensitiveVariable{name: candidate, typ: *attrSch.Type, addr: addr + "." + name})
+	}
+
+	blockSchs := sch.BlockTypes.Map()
+	counts := map[string]int{}
+	for _, blk := range body.Blocks() {
+		counts[blk.Type()]++
+	}
+	indexes := map[string]int{}
+	for _, blk := range body.Blocks() {
+		blkSch, ok := blockSchs[blk.Type()]
+		if !ok {
+			continue
+		}
+		blkPrefix, blkAddr := prefix+"_"+blk.Type(), addr+"."+blk.Type()
+		if counts[blk.Type()] > 1 {
+			blkPrefix = fmt.Sprintf("%s_%d", blkPrefix, indexes[blk.Type()])
+			blkAddr = fmt.Sprintf("%s[%d]", blkAddr, indexes[blk.Type()])
+		}
+		indexes[blk.Type()]++
+		vars = append(vars, extractSensitiveBlock(blk.Body(), blkSch.Block, blkPrefix, blkAddr, used)...)
+	}
+	return vars
+}
+
+// buildSensitiveVariables builds the variable blocks of the sensitive variables, which have no default value.
+func buildSensitiveVariables(vars []sensitiveVariable) string {
+	f := hclwrite.NewEmptyFile()
+	for _, v := range vars {
+		body := f.Body().AppendNewBlock("variable", []string{v.name}).Body()
+		body.SetAttributeRaw("type", hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(typeexpr.TypeString(v.typ))}})
+		body.SetAttributeValue("sensitive", cty.True)
+		f.Body().AppendNewline()
+	}
+	return string(hclwrite.Format(f.Bytes()))
+}
+
+// passChildModuleVariables defines the sensitive variables in the root module as well, and passes them to the child module call.
+func (meta baseMeta) passChildModuleVariables(vars []sensitiveVariable) error {
+	mainFile := filepath.Join(meta.outdir, meta.outputFileNames.MainFileName)
+	// #nosec G304
+	b, err := os.ReadFile(mainFile)
+	if err != nil {
+		return fmt.Errorf("reading the root module file: %v", err)
+	}
+	f, diags := hclwrite.ParseConfig(b, mainFile, hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("parsing the root module file: %v", diags.Error())
+	}
+	blk := f.Body().FirstMatchingBlock("module", []string{ChildModuleName})
+	if blk == nil {
+		meta.Logger().Warn("The child module call is not found in the root module file, the sensitive variables need to be passed manually", "file", mainFile)
+		return nil
+	}
+	for _, v := range vars {
+		blk.Body().SetAttributeTraversal(v.name, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: v.name}})
+	}
+	// #nosec G306
+	if err := os.WriteFile(mainFile, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the root module file: %v", err)
+	}
+
+	varFile := filepath.Join(meta.outdir, meta.outputFileNames.VariableFileName)
+	if err := appendToFile(varFile, buildSensitiveVariables(vars)); err != nil {
+		return fmt.Errorf("generating the sensitive variables in the root module: %w", err)
+	}
+	return nil
+}
+
+// sortedAttributeNames returns the attribute names of the body in alphabetical order.
+func sortedAttributeNames(body *hclwrite.Body) []string {
+	var names []string
+	for name := range body.Attributes() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// literalValue returns the value of the expression if it doesn't reference anything.
+func literalValue(expr *hclwrite.Expression) (cty.Value, bool) {
+	e, diags := hclsyntax.ParseExpression(expr.BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() || len(e.Variables()) != 0 {
+		return cty.NilVal, false
+	}
+	v, diags := e.Value(nil)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+	return v, true
+}
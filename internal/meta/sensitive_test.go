@@ -0,0 +1,127 @@
+package meta
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractSensitiveVariables(t *testing.T) {
+	newConfig := func(tfType, tfName, src string) ConfigInfo {
+		f, diags := hclwrite.ParseConfig([]byte(src), "main.tf", hcl.InitialPos)
+		require.False(t, diags.HasErrors(), diags.Error())
+		return ConfigInfo{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: tfType, Name: tfName}}, hcl: f}
+	}
+	cfgs := ConfigInfos{
+		newConfig("azurerm_mssql_server", "sql", `resource "azurerm_mssql_server" "sql" {
+  administrator_login          = "admin"
+  administrator_login_password = "secret"
+}
+`),
+		newConfig("azurerm_kubernetes_cluster", "aks", `resource "azurerm_kubernetes_cluster" "aks" {
+  service_principal {
+    client_id     = "foo"
+    client_secret = "secret"
+  }
+}
+`),
+		// The masked sensitive attribute is skipped
+		newConfig("azurerm_mssql_server", "masked", `resource "azurerm_mssql_server" "masked" {
+  administrator_login_password = null # Masked sensitive attribute
+}
+`),
+	}
+
+	dir := t.TempDir()
+	// The variable that is already defined in the module directory
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.tf"), []byte(`variable "azurerm_mssql_server_sql_administrator_login_password" {
+}
+`), 0600))
+
+	meta := baseMeta{
+		logger:          slog.Default(),
+		providerName:    "azurerm",
+		outdir:          dir,
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{VariableFileName: "variables.tf"},
+	}
+	cfgs, err := meta.extractSensitiveVariables(cfgs)
+	require.NoError(t, err)
+
+	require.Equal(t, `resource "azurerm_mssql_server" "sql" {
+  administrator_login          = "admin"
+  administrator_login_password = var.azurerm_mssql_server_sql_administrator_login_password_2
+}
+`, string(hclwrite.Format(cfgs[0].hcl.Bytes())))
+	require.Equal(t, `resource "azurerm_kubernetes_cluster" "aks" {
+  service_principal {
+    client_id     = "foo"
+    client_secret = var.azurerm_kubernetes_cluster_aks_service_principal_client_secret
+  }
+}
+`, string(hclwrite.Format(cfgs[1].hcl.Bytes())))
+
+	b, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `variable "azurerm_mssql_server_sql_administrator_login_password_2" {
+  type      = string
+  sensitive = true
+}
+
+variable "azurerm_kubernetes_cluster_aks_service_principal_client_secret" {
+  type      = string
+  sensitive = true
+}
+
+`, string(b))
+
+	b, err = os.ReadFile(filepath.Join(dir, SensitiveVariableExampleFileName))
+	require.NoError(t, err)
+	require.Equal(t, `# The sensitive value of azurerm_mssql_server.sql.administrator_login_password
+azurerm_mssql_server_sql_administrator_login_password_2 = ""
+# The sensitive value of azurerm_kubernetes_cluster.aks.service_principal.client_secret
+azurerm_kubernetes_cluster_aks_service_principal_client_secret = ""
+`, string(b))
+}
+
+func TestExtractSensitiveVariablesAsModule(t *testing.T) {
+	dir := t.TempDir()
+	meta := baseMeta{
+		logger:          slog.Default(),
+		providerName:    "azurerm",
+		outdir:          dir,
+		moduleDir:       filepath.Join(dir, ChildModuleSource),
+		asModule:        true,
+		outputFileNames: config.OutputFileNames{MainFileName: "main.tf", TerraformFileName: "terraform.tf", VariableFileName: "variables.tf"},
+	}
+	require.NoError(t, meta.initChildModule())
+
+	f, diags := hclwrite.ParseConfig([]byte(`resource "azurerm_mssql_server" "sql" {
+  administrator_login_password = "secret"
+}
+`), "main.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+	_, err := meta.extractSensitiveVariables(ConfigInfos{{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_mssql_server", Name: "sql"}}, hcl: f}})
+	require.NoError(t, err)
+
+	// The sensitive variables are passed from the root module to the child module
+	b, err := os.ReadFile(filepath.Join(dir, "main.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `module "resources" {
+  source                                                = "./modules/resources"
+  azurerm_mssql_server_sql_administrator_login_password = var.azurerm_mssql_server_sql_administrator_login_password
+}
+`, string(b))
+	for _, d := range []string{dir, meta.moduleDir} {
+		b, err = os.ReadFile(filepath.Join(d, "variables.tf"))
+		require.NoError(t, err)
+		require.Contains(t, string(b), `variable "azurerm_mssql_server_sql_administrator_login_password" {`)
+	}
+}
@@ -0,0 +1,204 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+)
+
+const SessionFileName = "aztfexportSession.json"
+
+// session records the progress of a non-interactive run, or the import list curated in the interactive mode (saved on quit), so that it can be resumed.
+type session struct {
+	// The state pulled from the output directory prior to the importing, which is used to detect out of band changes when resuming.
+	OriginBaseState string `json:"origin_base_state"`
+	// The merged state of the resources that have been imported, which has not been pushed to the output directory yet.
+	BaseState string        `json:"base_state"`
+	Items     []sessionItem `json:"items"`
+}
+
+type sessionItem struct {
+	AzureResourceId string   `json:"azure_resource_id"`
+	TFResourceId    string   `json:"tf_resource_id"`
+	TFType          string   `json:"tf_type,omitempty"`
+	TFName          string   `json:"tf_name"`
+	IsRecommended   bool     `json:"is_recommended,omitempty"`
+	Recommendations []string `json:"recommendations,omitempty"`
+	Imported        bool     `json:"imported,omitempty"`
+	// The RFC3339 formatted import time
+	ImportedAt string `json:"imported_at,omitempty"`
+	// The error of the failed import, which can be retried later
+	ImportError string `json:"import_error,omitempty"`
+}
+
+func (meta baseMeta) SaveSession(_ context.Context, l ImportList) error {
+	// Noop if tfclient is set, as the imported states are only held in memory by the import items.
+	if meta.tfclient != nil {
+		return nil
+	}
+
+	sess := session{
+		// The state pushed incrementally is what the state of the output directory is expected to be when resuming.
+		OriginBaseState: string(meta.lastPushedState()),
+		BaseState:       string(meta.baseState),
+	}
+	for _, item := range l {
+		var importedAt string
+		if !item.ImportedAt.IsZero() {
+			importedAt = item.ImportedAt.UTC().Format(time.RFC3339)
+		}
+		var importError string
+		if item.ImportError != nil {
+			importError = item.ImportError.Error()
+		}
+		sess.Items = append(sess.Items, sessionItem{
+			AzureResourceId: item.AzureResourceID.String(),
+			TFResourceId:    item.TFResourceId,
+			TFType:          item.TFAddr.Type,
+			TFName:          item.TFAddr.Name,
+			IsRecommended:   item.IsRecommended,
+			Recommendations: item.Recommendations,
+			Imported:        item.Imported,
+			ImportedAt:      importedAt,
+			ImportError:     importError,
+		})
+	}
+	b, err := json.MarshalIndent(sess, "", "\t")
+	if err != nil {
+		return fmt.Errorf("JSON marshalling the session: %v", err)
+	}
+
+	// Write to a temporary file first, then rename it, to avoid leaving a corrupted session file when being interrupted.
+	output := filepath.Join(meta.outdir, SessionFileName)
+	tmpOutput := output + ".tmp"
+	if err := os.WriteFile(tmpOutput, b, 0600); err != nil {
+		return fmt.Errorf("writing the session to %s: %v", tmpOutput, err)
+	}
+	if err := os.Rename(tmpOutput, output); err != nil {
+		return fmt.Errorf("renaming %s to %s: %v", tmpOutput, output, err)
+	}
+	return nil
+}
+
+func (meta *baseMeta) LoadSession(_ context.Context) (ImportList, error) {
+	sess, err := meta.readSession()
+	if err != nil {
+		return nil, err
+	}
+
+	// The state of the output directory shouldn't change since the session started, otherwise the state to be pushed will overwrite the changes.
+	if sess.OriginBaseState != string(meta.originBaseState) {
+		return nil, fmt.Errorf("the state of the output directory has changed since the session was saved, the session can't be resumed")
+	}
+
+	l, err := sess.importList()
+	if err != nil {
+		return nil, err
+	}
+	meta.baseState = []byte(sess.BaseState)
+	return l, nil
+}
+
+func (meta *baseMeta) LoadFailedImports(_ context.Context) (ImportList, error) {
+	sess, err := meta.readSession()
+	if err != nil {
+		return nil, err
+	}
+	l, err := sess.importList()
+	if err != nil {
+		return nil, err
+	}
+
+	var fl ImportList
+	for _, item := range l {
+		if item.ImportError == nil {
+			continue
+		}
+		item.ImportError = nil
+		fl = append(fl, item)
+	}
+	if len(fl) == 0 {
+		return nil, fmt.Errorf("no failed import is recorded in the session file %s", filepath.Join(meta.outdir, SessionFileName))
+	}
+	// The failed imports might have been imported by others since then.
+	return meta.reconcileManagedResources(fl)
+}
+
+// hasFailedImports tells whether the session file (if any) records failed imports.
+func (meta baseMeta) hasFailedImports() (bool, error) {
+	if _, err := os.Stat(filepath.Join(meta.outdir, SessionFileName)); os.IsNotExist(err) {
+		return false, nil
+	}
+	sess, err := meta.readSession()
+	if err != nil {
+		return false, err
+	}
+	for _, sitem := range sess.Items {
+		if sitem.ImportError != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (meta baseMeta) readSession() (*session, error) {
+	input := filepath.Join(meta.outdir, SessionFileName)
+	// #nosec G304
+	b, err := os.ReadFile(input)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no session file %s found to resume from", input)
+		}
+		return nil, fmt.Errorf("reading the session file %s: %v", input, err)
+	}
+	var sess session
+	if err := json.Unmarshal(b, &sess); err != nil {
+		return nil, fmt.Errorf("unmarshalling the session file %s: %v", input, err)
+	}
+	return &sess, nil
+}
+
+// importList returns the import list recorded in the session.
+func (sess session) importList() (ImportList, error) {
+	var l ImportList
+	for _, sitem := range sess.Items {
+		azureId, err := armid.ParseResourceId(sitem.AzureResourceId)
+		if err != nil {
+			return nil, fmt.Errorf("parsing resource id %q in the session file: %v", sitem.AzureResourceId, err)
+		}
+		var importedAt time.Time
+		if sitem.ImportedAt != "" {
+			importedAt, err = time.Parse(time.RFC3339, sitem.ImportedAt)
+			if err != nil {
+				return nil, fmt.Errorf("parsing the import time %q of %q in the session file: %v", sitem.ImportedAt, sitem.AzureResourceId, err)
+			}
+		}
+		var importError error
+		if sitem.ImportError != "" {
+			importError = errors.New(sitem.ImportError)
+		}
+		tfAddr := tfaddr.TFAddr{
+			Type: sitem.TFType,
+			Name: sitem.TFName,
+		}
+		l = append(l, ImportItem{
+			AzureResourceID: azureId,
+			TFResourceId:    sitem.TFResourceId,
+			TFAddr:          tfAddr,
+			TFAddrCache:     tfAddr,
+			IsRecommended:   sitem.IsRecommended,
+			Recommendations: sitem.Recommendations,
+			Imported:        sitem.Imported,
+			ImportedAt:      importedAt,
+			ImportError:     importError,
+		})
+	}
+	return l, nil
+}
@@ -0,0 +1,164 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionSaveLoad(t *testing.T) {
+	rgId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg")
+	require.NoError(t, err)
+	vnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+
+	l := ImportList{
+		{
+			AzureResourceID: rgId,
+			TFResourceId:    rgId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			IsRecommended:   true,
+			Recommendations: []string{"azurerm_resource_group"},
+			Imported:        true,
+			ImportedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		{
+			AzureResourceID: vnetId,
+			TFResourceId:    vnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Name: "res-1"},
+			TFAddrCache:     tfaddr.TFAddr{Name: "res-1"},
+		},
+	}
+
+	dir := t.TempDir()
+	meta := baseMeta{
+		outdir:          dir,
+		originBaseState: []byte("origin"),
+		baseState:       []byte("current"),
+	}
+	require.NoError(t, meta.SaveSession(context.Background(), l))
+
+	// Resume succeeds when the state is not changed
+	newMeta := baseMeta{
+		outdir:          dir,
+		originBaseState: []byte("origin"),
+		baseState:       []byte("origin"),
+	}
+	nl, err := newMeta.LoadSession(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, l, nl)
+	require.Equal(t, "current", string(newMeta.baseState))
+
+	// Resume fails when the state has out of band changes
+	newMeta = baseMeta{
+		outdir:          dir,
+		originBaseState: []byte("changed"),
+		baseState:       []byte("changed"),
+	}
+	_, err = newMeta.LoadSession(context.Background())
+	require.Error(t, err)
+}
+
+func TestSessionLoadFailedImports(t *testing.T) {
+	rgId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg")
+	require.NoError(t, err)
+	vnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+
+	l := ImportList{
+		{
+			AzureResourceID: rgId,
+			TFResourceId:    rgId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			Imported:        true,
+		},
+		{
+			AzureResourceID: vnetId,
+			TFResourceId:    vnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+			ImportError:     errors.New("import failed"),
+		},
+	}
+
+	dir := t.TempDir()
+	meta := baseMeta{
+		outdir:          dir,
+		originBaseState: []byte("origin"),
+	}
+	require.NoError(t, meta.SaveSession(context.Background(), l))
+
+	// The session is kept for the failed imports
+	require.NoError(t, meta.CleanUpWorkspace(context.Background()))
+	require.FileExists(t, filepath.Join(dir, SessionFileName))
+
+	// The failed imports can be loaded regardless of the state change
+	newMeta := baseMeta{
+		outdir:          dir,
+		originBaseState: []byte(`{"version": 4, "resources": []}`),
+		baseState:       []byte(`{"version": 4, "resources": []}`),
+	}
+	fl, err := newMeta.LoadFailedImports(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ImportList{
+		{
+			AzureResourceID: vnetId,
+			TFResourceId:    vnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-1"},
+		},
+	}, fl)
+
+	// The session is removed once there is no failed import
+	l[1].ImportError = nil
+	l[1].Imported = true
+	require.NoError(t, meta.SaveSession(context.Background(), l))
+	_, err = newMeta.LoadFailedImports(context.Background())
+	require.ErrorContains(t, err, "no failed import is recorded")
+	require.NoError(t, meta.CleanUpWorkspace(context.Background()))
+	require.NoFileExists(t, filepath.Join(dir, SessionFileName))
+}
+
+func TestImportListRemap(t *testing.T) {
+	vnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet")
+	require.NoError(t, err)
+	subnetId, err := armid.ParseResourceId("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet")
+	require.NoError(t, err)
+	upperVnetId, err := armid.ParseResourceId("/SUBSCRIPTIONS/123/RESOURCEGROUPS/RG/providers/Microsoft.Network/virtualNetworks/VNET")
+	require.NoError(t, err)
+
+	l := ImportList{
+		{
+			AzureResourceID: vnetId,
+			TFResourceId:    vnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-0"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-0"},
+		},
+		{
+			AzureResourceID: subnetId,
+			TFResourceId:    subnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-1"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-1"},
+		},
+	}
+	ml := ImportList{
+		{
+			AzureResourceID: upperVnetId,
+			TFResourceId:    vnetId.String(),
+			TFAddr:          tfaddr.TFAddr{Type: "azapi_resource", Name: "vnet"},
+			TFAddrCache:     tfaddr.TFAddr{Type: "azapi_resource", Name: "vnet"},
+		},
+	}
+	rl := l.Remap(ml)
+	require.Equal(t, tfaddr.TFAddr{Type: "azapi_resource", Name: "vnet"}, rl[0].TFAddr)
+	require.Equal(t, vnetId, rl[0].AzureResourceID)
+	require.Equal(t, l[1], rl[1])
+}
@@ -0,0 +1,21 @@
+package meta
+
+import (
+	"strings"
+	"time"
+)
+
+// sourceMetadataComment builds the comment header of the generated resource, which records the Azure resource it is exported from, so that the config can be traced back to the Azure resource.
+// The API version is only known for the azapi resources, and the import time is unknown for the resources imported by an old session.
+func sourceMetadataComment(item ImportItem) string {
+	lines := []string{"# Azure resource: " + item.AzureResourceID.String()}
+	armType := item.AzureResourceID.TypeString()
+	if _, apiVersion := splitAzAPIImportId(item.TFResourceId); apiVersion != "" {
+		armType += " (API version: " + apiVersion + ")"
+	}
+	lines = append(lines, "# ARM type: "+armType)
+	if !item.ImportedAt.IsZero() {
+		lines = append(lines, "# Imported at: "+item.ImportedAt.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
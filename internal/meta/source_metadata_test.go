@@ -0,0 +1,32 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceMetadataComment(t *testing.T) {
+	const vnetId = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	id, err := armid.ParseResourceId(vnetId)
+	require.NoError(t, err)
+
+	require.Equal(t, `# Azure resource: `+vnetId+`
+# ARM type: Microsoft.Network/virtualNetworks
+# Imported at: 2024-01-02T03:04:05Z
+`, sourceMetadataComment(ImportItem{
+		AzureResourceID: id,
+		TFResourceId:    vnetId,
+		ImportedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}))
+
+	// The API version is recorded for the azapi resources, and the unknown import time is omitted
+	require.Equal(t, `# Azure resource: `+vnetId+`
+# ARM type: Microsoft.Network/virtualNetworks (API version: 2023-04-01)
+`, sourceMetadataComment(ImportItem{
+		AzureResourceID: id,
+		TFResourceId:    vnetId + "?api-version=2023-04-01",
+	}))
+}
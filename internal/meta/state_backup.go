@@ -0,0 +1,76 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+)
+
+// StateBackupFilePrefix is the file name prefix of the state backup, which is written to the output directory prior to the import, and is removed once the export succeeds.
+const StateBackupFilePrefix = "aztfexportStateBackup-"
+
+// backupState writes the current state of the output directory (i.e. the origin base state) to a timestamped backup file. Nothing is written if the state is empty.
+func (meta *baseMeta) backupState() error {
+	if len(meta.originBaseState) == 0 {
+		return nil
+	}
+	return meta.writeStateBackup(meta.originBaseState)
+}
+
+// backupEmptyState backs up the empty origin base state once the state is pushed incrementally, so that the state can still be rolled back to empty.
+// As an empty state has no lineage, the backup is the pushed state with the resources and the outputs removed, which has the same lineage as the pushed one.
+func (meta *baseMeta) backupEmptyState(pushed []byte) error {
+	if meta.stateBackupFile != "" || len(meta.originBaseState) != 0 {
+		return nil
+	}
+	var state map[string]interface{}
+	if err := json.Unmarshal(pushed, &state); err != nil {
+		return fmt.Errorf("unmarshalling the pushed state: %v", err)
+	}
+	state["resources"] = []interface{}{}
+	state["outputs"] = map[string]interface{}{}
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling the empty state: %v", err)
+	}
+	return meta.writeStateBackup(b)
+}
+
+func (meta *baseMeta) writeStateBackup(b []byte) error {
+	path := filepath.Join(meta.outdir, StateBackupFilePrefix+time.Now().Format("20060102150405")+".tfstate")
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("writing the state backup to %s: %v", path, err)
+	}
+	meta.stateBackupFile = path
+	return nil
+}
+
+func (meta baseMeta) StateBackupFile() string {
+	return meta.stateBackupFile
+}
+
+func (meta baseMeta) RollbackState(ctx context.Context) error {
+	if (meta.tfclient != nil && meta.hclOnly) || meta.stateBackupFile == "" {
+		return nil
+	}
+	current, err := meta.tf.StatePull(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to pull state: %v", err)
+	}
+	if current == string(meta.originBaseState) {
+		meta.Logger().Info("The state is not changed, skip rolling back")
+		return nil
+	}
+	meta.Logger().Info("Roll back the state", "backup", meta.stateBackupFile)
+	// The backup has a lower serial than the current state, hence it has to be forced.
+	if err := meta.tf.StatePush(ctx, meta.stateBackupFile, tfexec.Lock(true), tfexec.Force(true)); err != nil {
+		return fmt.Errorf("failed to push the state backup: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package meta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupState(t *testing.T) {
+	dir := t.TempDir()
+
+	// Nothing is backed up for the empty state
+	meta := baseMeta{outdir: dir}
+	require.NoError(t, meta.backupState())
+	require.Empty(t, meta.StateBackupFile())
+	// There is nothing to roll back to
+	require.NoError(t, meta.RollbackState(context.Background()))
+
+	meta.originBaseState = []byte(`{"version": 4, "lineage": "foo", "serial": 1}`)
+	require.NoError(t, meta.backupState())
+	require.Equal(t, dir, filepath.Dir(meta.StateBackupFile()))
+	require.True(t, strings.HasPrefix(filepath.Base(meta.StateBackupFile()), StateBackupFilePrefix))
+	b, err := os.ReadFile(meta.StateBackupFile())
+	require.NoError(t, err)
+	require.Equal(t, string(meta.originBaseState), string(b))
+
+	// The backup is removed once the export succeeds
+	require.NoError(t, meta.CleanUpWorkspace(context.Background()))
+	_, err = os.Stat(meta.StateBackupFile())
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestBackupEmptyState(t *testing.T) {
+	dir := t.TempDir()
+	meta := baseMeta{outdir: dir}
+	pushed := []byte(`{"version": 4, "lineage": "foo", "serial": 2, "outputs": {"foo": {}}, "resources": [{"type": "azurerm_resource_group"}]}`)
+	require.NoError(t, meta.backupEmptyState(pushed))
+	require.NotEmpty(t, meta.StateBackupFile())
+	b, err := os.ReadFile(meta.StateBackupFile())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"version": 4, "lineage": "foo", "serial": 2, "outputs": {}, "resources": []}`, string(b))
+
+	// The origin base state is backed up already
+	meta = baseMeta{outdir: dir, originBaseState: []byte(`{"version": 4, "lineage": "foo", "serial": 1}`)}
+	require.NoError(t, meta.backupEmptyState(pushed))
+	require.Empty(t, meta.StateBackupFile())
+}
+
+func TestLastPushedState(t *testing.T) {
+	meta := baseMeta{originBaseState: []byte("origin")}
+	require.Equal(t, "origin", string(meta.lastPushedState()))
+	meta.pushedState = []byte("pushed")
+	require.Equal(t, "pushed", string(meta.lastPushedState()))
+	// The origin base state is kept for rolling back
+	require.Equal(t, "origin", string(meta.originBaseState))
+}
@@ -0,0 +1,47 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// stateFileMeta is the metadata of a state file, which identifies the state (lineage) and its version (serial).
+type stateFileMeta struct {
+	Version int    `json:"version"`
+	Lineage string `json:"lineage"`
+	Serial  uint64 `json:"serial"`
+}
+
+// baseStateFromFile returns the content of the state file, which is used as the base state instead of the current state of the output directory, so that the resources are imported next to
+// the ones managed in the state file. The state file itself is not modified, its copy (with the imported resources added) is pushed to the output directory.
+// If the current state is not empty, the state file must have the same lineage as it, and must not be older than it.
+func baseStateFromFile(path string, current []byte) ([]byte, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the state file %s: %v", path, err)
+	}
+	var m stateFileMeta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling the state file %s: %v", path, err)
+	}
+	if m.Version == 0 || m.Lineage == "" {
+		return nil, fmt.Errorf("the state file %s has no version or lineage", path)
+	}
+	if len(current) == 0 {
+		return b, nil
+	}
+
+	var cm stateFileMeta
+	if err := json.Unmarshal(current, &cm); err != nil {
+		return nil, fmt.Errorf("unmarshalling the current state: %v", err)
+	}
+	if cm.Lineage != m.Lineage {
+		return nil, fmt.Errorf("the state file %s (lineage %s) is not the same state as the one of the output directory (lineage %s)", path, m.Lineage, cm.Lineage)
+	}
+	if m.Serial < cm.Serial {
+		return nil, fmt.Errorf("the state file %s (serial %d) is older than the state of the output directory (serial %d)", path, m.Serial, cm.Serial)
+	}
+	return b, nil
+}
@@ -0,0 +1,55 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseStateFromFile(t *testing.T) {
+	const state = `{"version": 4, "lineage": "foo", "serial": 3, "resources": []}`
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(path, []byte(state), 0600))
+
+	cases := []struct {
+		name    string
+		current string
+		err     string
+	}{
+		{
+			name: "no current state",
+		},
+		{
+			name:    "same lineage with an older current state",
+			current: `{"version": 4, "lineage": "foo", "serial": 2}`,
+		},
+		{
+			name:    "different lineage",
+			current: `{"version": 4, "lineage": "bar", "serial": 1}`,
+			err:     "is not the same state as the one of the output directory (lineage bar)",
+		},
+		{
+			name:    "older than the current state",
+			current: `{"version": 4, "lineage": "foo", "serial": 4}`,
+			err:     "(serial 3) is older than the state of the output directory (serial 4)",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := baseStateFromFile(path, []byte(tt.current))
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, state, string(b))
+		})
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.tfstate")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{}`), 0600))
+	_, err := baseStateFromFile(invalid, nil)
+	require.ErrorContains(t, err, "has no version or lineage")
+}
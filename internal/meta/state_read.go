@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ReadState reads the state of the output directory as the base state without initializing the output directory (e.g. in dry-run mode),
+// so that the resources already managed in the state are skipped when listing the resources.
+// It returns false if the state can't be read without initializing the output directory, i.e. the state is stored in a non local backend.
+func (meta *baseMeta) ReadState(_ context.Context) (bool, error) {
+	if meta.stateFile != "" {
+		b, err := baseStateFromFile(meta.stateFile, nil)
+		if err != nil {
+			return false, err
+		}
+		meta.baseState = b
+		return true, nil
+	}
+	if meta.backendType != "" && meta.backendType != "local" {
+		return false, nil
+	}
+
+	path := filepath.Join(meta.outdir, "terraform.tfstate")
+	for _, opt := range meta.backendConfig {
+		if k, v, ok := strings.Cut(opt, "="); ok && strings.TrimSpace(k) == "path" {
+			path = strings.Trim(strings.TrimSpace(v), `"`)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(meta.outdir, path)
+			}
+		}
+	}
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("reading the state %s: %v", path, err)
+	}
+	meta.baseState = b
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package meta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadState(t *testing.T) {
+	state := `{"version": 4, "lineage": "foo", "serial": 1, "resources": []}`
+
+	// There is no state yet
+	dir := t.TempDir()
+	meta := baseMeta{outdir: dir, backendType: "local"}
+	ok, err := meta.ReadState(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, meta.baseState)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "terraform.tfstate"), []byte(state), 0644))
+	ok, err = meta.ReadState(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state, string(meta.baseState))
+
+	// The path of the local backend
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.tfstate"), []byte(state), 0644))
+	meta = baseMeta{outdir: dir, backendType: "local", backendConfig: []string{"path=custom.tfstate"}}
+	ok, err = meta.ReadState(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state, string(meta.baseState))
+
+	// The state file
+	meta = baseMeta{outdir: t.TempDir(), backendType: "azurerm", stateFile: filepath.Join(dir, "custom.tfstate")}
+	ok, err = meta.ReadState(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state, string(meta.baseState))
+
+	// The remote state can't be read without initializing
+	meta = baseMeta{outdir: dir, backendType: "azurerm"}
+	ok, err = meta.ReadState(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, meta.baseState)
+}
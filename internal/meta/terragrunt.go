@@ -0,0 +1,98 @@
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// TerragruntFileName is the terragrunt config written to the output directory, which makes the output directory a terragrunt unit.
+const TerragruntFileName = "terragrunt.hcl"
+
+// generateTerragruntConfig writes the terragrunt config to the output directory, so that the output directory can be placed as a unit of a terragrunt live repo.
+// Terraform runs in place (i.e. without the "terraform" block), as the state of the imported resources is addressed by the output directory as the root module.
+// The "remote_state" is wired with the backend block config (the backend config from the CLI is not included as it might contain secrets), and the "inputs" with the defaults of the variables.
+func (meta baseMeta) generateTerragruntConfig() error {
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return diags.Err()
+	}
+
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	if meta.backendType != "" && meta.backendType != "local" {
+		blk := body.AppendNewBlock("remote_state", nil).Body()
+		blk.SetAttributeValue("backend", cty.StringVal(meta.backendType))
+		var keys []string
+		for k := range meta.backendBlock {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		config := map[string]cty.Value{}
+		for _, k := range keys {
+			config[k] = cty.StringVal(meta.backendBlock[k])
+		}
+		if len(config) == 0 {
+			blk.SetAttributeRaw("config", hclwrite.Tokens{{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")}, {Type: hclsyntax.TokenCBrace, Bytes: []byte("}")}})
+		} else {
+			blk.SetAttributeValue("config", cty.ObjectVal(config))
+		}
+		body.AppendNewline()
+	}
+
+	var names []string
+	for name := range module.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	inputs := hclwrite.Tokens{{Type: hclsyntax.TokenOBrace, Bytes: []byte("{")}, {Type: hclsyntax.TokenNewline, Bytes: []byte("\n")}}
+	var noDefaults []string
+	for _, name := range names {
+		v := module.Variables[name]
+		if v.Default == nil {
+			noDefaults = append(noDefaults, name)
+			continue
+		}
+		val, err := terragruntInputValue(v.Default)
+		if err != nil {
+			return fmt.Errorf("converting the default value of the variable %q: %v", name, err)
+		}
+		inputs = append(inputs, hclwrite.TokensForIdentifier(name)...)
+		inputs = append(inputs, &hclwrite.Token{Type: hclsyntax.TokenEqual, Bytes: []byte("=")})
+		inputs = append(inputs, hclwrite.TokensForValue(val)...)
+		inputs = append(inputs, &hclwrite.Token{Type: hclsyntax.TokenNewline, Bytes: []byte("\n")})
+	}
+	inputs = append(inputs, &hclwrite.Token{Type: hclsyntax.TokenCBrace, Bytes: []byte("}")})
+	if len(noDefaults) != 0 {
+		body.AppendUnstructuredTokens(hclwrite.Tokens{{Type: hclsyntax.TokenComment, Bytes: []byte(fmt.Sprintf("# The variables without default values are expected to be set via the TF_VAR_<name> environment variables: %v\n", noDefaults))}})
+	}
+	body.SetAttributeRaw("inputs", inputs)
+
+	output := filepath.Join(meta.outdir, TerragruntFileName)
+	// #nosec G306
+	if err := os.WriteFile(output, hclwrite.Format(f.Bytes()), 0644); err != nil {
+		return fmt.Errorf("writing the terragrunt config to %s: %v", output, err)
+	}
+	return nil
+}
+
+// terragruntInputValue converts the approximate default value of a variable loaded by tfconfig to the cty value.
+func terragruntInputValue(v interface{}) (cty.Value, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, ty)
+}
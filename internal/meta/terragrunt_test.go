@@ -0,0 +1,67 @@
+package meta
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTerragruntConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "variables.tf"), []byte(`variable "location" {
+  default = "westus"
+}
+
+variable "tags" {
+  default = {
+    env = "dev"
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+`), 0644))
+
+	meta := baseMeta{
+		outdir:      dir,
+		moduleDir:   dir,
+		backendType: "azurerm",
+		backendBlock: map[string]string{
+			"storage_account_name": "sa",
+			"container_name":       "tfstate",
+		},
+	}
+	require.NoError(t, meta.generateTerragruntConfig())
+	b, err := os.ReadFile(filepath.Join(dir, TerragruntFileName))
+	require.NoError(t, err)
+	require.Equal(t, `remote_state {
+  backend = "azurerm"
+  config = {
+    container_name       = "tfstate"
+    storage_account_name = "sa"
+  }
+}
+
+# The variables without default values are expected to be set via the TF_VAR_<name> environment variables: [password]
+inputs = {
+  location = "westus"
+  tags = {
+    env = "dev"
+  }
+}
+`, string(b))
+
+	// The remote state is omitted for the local backend
+	meta = baseMeta{outdir: dir, moduleDir: dir, backendType: "local"}
+	require.NoError(t, os.Remove(filepath.Join(dir, "variables.tf")))
+	require.NoError(t, meta.generateTerragruntConfig())
+	b, err = os.ReadFile(filepath.Join(dir, TerragruntFileName))
+	require.NoError(t, err)
+	require.Equal(t, `inputs = {
+}
+`, string(b))
+}
@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gofrs/uuid"
+	"github.com/magodo/tfmerge/tfmerge"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// stateV4 is the (subset of the) version 4 state file format, which holds the states imported via the TFClient.
+type stateV4 struct {
+	Version          int               `json:"version"`
+	TerraformVersion string            `json:"terraform_version"`
+	Serial           uint64            `json:"serial"`
+	Lineage          string            `json:"lineage"`
+	Outputs          map[string]any    `json:"outputs"`
+	Resources        []stateV4Resource `json:"resources"`
+}
+
+type stateV4Resource struct {
+	Module    string            `json:"module,omitempty"`
+	Mode      string            `json:"mode"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Provider  string            `json:"provider"`
+	Instances []stateV4Instance `json:"instances"`
+}
+
+type stateV4Instance struct {
+	SchemaVersion       uint64          `json:"schema_version"`
+	Attributes          json.RawMessage `json:"attributes"`
+	SensitiveAttributes []any           `json:"sensitive_attributes"`
+	Private             []byte          `json:"private,omitempty"`
+}
+
+// buildImportedState builds a state file of the items imported via the TFClient, which is then merged into the base state. Nil is returned if nothing is imported.
+func (meta baseMeta) buildImportedState(items []*ImportItem, tfVersion string) ([]byte, error) {
+	schResp, diags := meta.tfclient.GetProviderSchema()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("get provider schema: %v", diags)
+	}
+
+	var resources []stateV4Resource
+	for _, item := range items {
+		if !item.Imported || item.State.IsNull() {
+			continue
+		}
+		rsch, ok := schResp.ResourceTypes[item.TFAddr.Type]
+		if !ok {
+			return nil, fmt.Errorf("no resource schema for %s found in the provider schema", item.TFAddr.Type)
+		}
+		attrs, err := ctyjson.Marshal(item.State, schResp.ResourceTypesCty[item.TFAddr.Type])
+		if err != nil {
+			return nil, fmt.Errorf("marshalling the state of %s: %v", item.TFAddr, err)
+		}
+		resources = append(resources, stateV4Resource{
+			Module:   meta.moduleAddr,
+			Mode:     "managed",
+			Type:     item.TFAddr.Type,
+			Name:     item.TFAddr.Name,
+			Provider: meta.stateProviderAddr(item),
+			Instances: []stateV4Instance{
+				{
+					SchemaVersion:       rsch.Version,
+					Attributes:          attrs,
+					SensitiveAttributes: []any{},
+					Private:             item.StatePrivate,
+				},
+			},
+		})
+	}
+	if len(resources) == 0 {
+		return nil, nil
+	}
+
+	lineage, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("generating the state lineage: %v", err)
+	}
+	return json.MarshalIndent(stateV4{
+		Version:          4,
+		TerraformVersion: tfVersion,
+		Serial:           1,
+		Lineage:          lineage.String(),
+		Outputs:          map[string]any{},
+		Resources:        resources,
+	}, "", "  ")
+}
+
+// stateProviderAddr returns the address of the provider config that manages the item in the state.
+// The resources residing in other subscriptions are managed by the aliased providers, which are defined in the module directory.
+func (meta baseMeta) stateProviderAddr(item *ImportItem) string {
+	addr := fmt.Sprintf("provider[%q]", providerRegistry+"/"+meta.providerSource())
+	alias, _ := meta.providerAlias(item.AzureResourceID, item.TFAddr.Type)
+	if alias == "" {
+		return addr
+	}
+	addr += "." + alias
+	if meta.moduleAddr != "" {
+		addr = meta.moduleAddr + "." + addr
+	}
+	return addr
+}
+
+// mergeImportedState merges the states of the items imported via the TFClient into the base state, via the terraform binary of the output directory.
+func (meta *baseMeta) mergeImportedState(ctx context.Context, items []*ImportItem) error {
+	tfVersion, _, err := meta.tf.Version(ctx, true)
+	if err != nil {
+		return fmt.Errorf("retrieving the Terraform version: %v", err)
+	}
+	b, err := meta.buildImportedState(items, tfVersion.String())
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return nil
+	}
+
+	f, err := os.CreateTemp("", "aztfexport-*.tfstate")
+	if err != nil {
+		return fmt.Errorf("creating a temporary state file: %v", err)
+	}
+	// #nosec G104
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		// #nosec G104
+		f.Close()
+		return fmt.Errorf("writing to the temporary state file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing the temporary state file %s: %v", f.Name(), err)
+	}
+
+	meta.Logger().Debug("Merging the imported states (tfmerge)", "file", f.Name())
+	newState, err := tfmerge.Merge(ctx, meta.tf, meta.baseState, f.Name())
+	if err != nil {
+		return fmt.Errorf("failed to merge state file: %v", err)
+	}
+	meta.baseState = newState
+	return nil
+}
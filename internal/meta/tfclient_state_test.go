@@ -0,0 +1,109 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/magodo/armid"
+	"github.com/magodo/terraform-client-go/tfclient"
+	"github.com/magodo/terraform-client-go/tfclient/typ"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type schemaOnlyClient struct {
+	tfclient.Client
+	schema *typ.GetProviderSchemaResponse
+}
+
+func (c schemaOnlyClient) GetProviderSchema() (*typ.GetProviderSchemaResponse, typ.Diagnostics) {
+	return c.schema, nil
+}
+
+func TestBuildImportedState(t *testing.T) {
+	rgType := cty.Object(map[string]cty.Type{
+		"id":       cty.String,
+		"name":     cty.String,
+		"location": cty.String,
+	})
+	meta := baseMeta{
+		providerName: "azurerm",
+		moduleAddr:   "module.foo",
+		tfclient: schemaOnlyClient{
+			schema: &typ.GetProviderSchemaResponse{
+				ResourceTypes: map[string]tfjson.Schema{
+					"azurerm_resource_group": {Version: 1},
+				},
+				ResourceTypesCty: map[string]cty.Type{
+					"azurerm_resource_group": rgType,
+				},
+			},
+		},
+	}
+
+	b, err := meta.buildImportedState([]*ImportItem{
+		{
+			TFAddr:   tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"},
+			Imported: true,
+			State: cty.ObjectVal(map[string]cty.Value{
+				"id":       cty.StringVal("/subscriptions/123/resourceGroups/rg"),
+				"name":     cty.StringVal("rg"),
+				"location": cty.StringVal("westus"),
+			}),
+			StatePrivate: []byte("private"),
+		},
+		{
+			TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-1"},
+		},
+	}, "1.5.0")
+	require.NoError(t, err)
+
+	var state stateV4
+	require.NoError(t, json.Unmarshal(b, &state))
+	require.Equal(t, 4, state.Version)
+	require.Equal(t, "1.5.0", state.TerraformVersion)
+	require.NotEmpty(t, state.Lineage)
+	require.Len(t, state.Resources, 1)
+	res := state.Resources[0]
+	require.Equal(t, "module.foo", res.Module)
+	require.Equal(t, "managed", res.Mode)
+	require.Equal(t, "azurerm_resource_group", res.Type)
+	require.Equal(t, "res-0", res.Name)
+	require.Equal(t, `provider["registry.terraform.io/hashicorp/azurerm"]`, res.Provider)
+	require.Len(t, res.Instances, 1)
+	require.Equal(t, uint64(1), res.Instances[0].SchemaVersion)
+	require.JSONEq(t, `{"id": "/subscriptions/123/resourceGroups/rg", "name": "rg", "location": "westus"}`, string(res.Instances[0].Attributes))
+	require.Equal(t, []byte("private"), res.Instances[0].Private)
+
+	// The resources residing in other subscriptions are managed by the aliased provider defined in the module
+	meta.subscriptionId = "123"
+	otherRgId, err := armid.ParseResourceId("/subscriptions/456/resourceGroups/rg")
+	require.NoError(t, err)
+	b, err = meta.buildImportedState([]*ImportItem{
+		{
+			AzureResourceID: otherRgId,
+			TFAddr:          tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-2"},
+			Imported:        true,
+			State: cty.ObjectVal(map[string]cty.Value{
+				"id":       cty.StringVal("/subscriptions/456/resourceGroups/rg"),
+				"name":     cty.StringVal("rg"),
+				"location": cty.StringVal("westus"),
+			}),
+		},
+	}, "1.5.0")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &state))
+	require.Len(t, state.Resources, 1)
+	require.Equal(t, `module.foo.provider["registry.terraform.io/hashicorp/azurerm"].subscription_456`, state.Resources[0].Provider)
+
+	// Nothing is built if nothing is imported
+	b, err = meta.buildImportedState([]*ImportItem{
+		{
+			TFAddr: tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-1"},
+		},
+	}, "1.5.0")
+	require.NoError(t, err)
+	require.Nil(t, b)
+}
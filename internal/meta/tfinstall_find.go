@@ -2,10 +2,15 @@ package meta
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 
+	"github.com/hashicorp/go-version"
 	install "github.com/hashicorp/hc-install"
 	"github.com/hashicorp/hc-install/fs"
 	"github.com/hashicorp/hc-install/product"
+	"github.com/hashicorp/hc-install/releases"
 	"github.com/hashicorp/hc-install/src"
 )
 
@@ -18,3 +23,66 @@ func FindTerraform(ctx context.Context) (string, error) {
 		},
 	})
 }
+
+// EnsureTerraform returns the path to the terraform executable:
+//   - If the version is not specified, the binary (if specified) is used as is, otherwise it is the same as FindTerraform.
+//   - If the version is specified, the binary (if specified) is used only when it is of that version. Otherwise, the executable of that version is looked up from the PATH and
+//     the install directory, which is downloaded into the install directory if not found, unless offline is set.
+func EnsureTerraform(ctx context.Context, execPath, tfVersion, installDir string, offline bool) (string, error) {
+	if tfVersion == "" {
+		if execPath == "" {
+			return FindTerraform(ctx)
+		}
+		return (&fs.AnyVersion{ExactBinPath: execPath}).Find(ctx)
+	}
+
+	v, err := version.NewVersion(tfVersion)
+	if err != nil {
+		return "", fmt.Errorf("parsing the terraform version %q: %v", tfVersion, err)
+	}
+
+	if execPath != "" {
+		bv, err := product.Terraform.GetVersion(ctx, execPath)
+		if err != nil {
+			return "", fmt.Errorf("reading the version of the terraform binary %s: %v", execPath, err)
+		}
+		if bv.Equal(v) {
+			return execPath, nil
+		}
+	}
+
+	sources := []src.Source{
+		&fs.ExactVersion{
+			Product:    product.Terraform,
+			Version:    v,
+			ExtraPaths: []string{installDir},
+		},
+	}
+	if !offline {
+		if err := os.MkdirAll(installDir, 0755); err != nil {
+			return "", fmt.Errorf("creating the install directory %s: %v", installDir, err)
+		}
+		sources = append(sources, &releases.ExactVersion{
+			Product:    product.Terraform,
+			Version:    v,
+			InstallDir: installDir,
+		})
+	}
+	execPath, err = install.NewInstaller().Ensure(ctx, sources)
+	if err != nil {
+		if offline {
+			return "", fmt.Errorf("no terraform %s found in neither the PATH nor %s (downloading is disabled in offline mode): %v", v, installDir, err)
+		}
+		return "", err
+	}
+	return execPath, nil
+}
+
+// terraformInstallDir returns the directory in the user cache directory, where the terraform of the version is installed.
+func terraformInstallDir(tfVersion string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("retrieving the user's cache directory: %v", err)
+	}
+	return filepath.Join(cacheDir, "aztfexport", "terraform", tfVersion), nil
+}
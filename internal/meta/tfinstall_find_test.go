@@ -0,0 +1,48 @@
+package meta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureTerraform(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform binary is a shell script")
+	}
+
+	// Isolate from the terraform executables in the PATH
+	t.Setenv("PATH", t.TempDir())
+
+	execPath := filepath.Join(t.TempDir(), "terraform")
+	// #nosec G306
+	require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/sh\necho 'Terraform v1.5.0'\n"), 0755))
+
+	// The binary is used as is without the version
+	p, err := EnsureTerraform(context.Background(), execPath, "", "", false)
+	require.NoError(t, err)
+	require.Equal(t, execPath, p)
+
+	// The binary is used when it is of the version
+	p, err = EnsureTerraform(context.Background(), execPath, "1.5.0", t.TempDir(), true)
+	require.NoError(t, err)
+	require.Equal(t, execPath, p)
+
+	// The cached one in the install directory is used when the binary isn't of the version
+	installDir := filepath.Join(t.TempDir(), "1.6.0")
+	require.NoError(t, os.MkdirAll(installDir, 0755))
+	cachedPath := filepath.Join(installDir, "terraform")
+	// #nosec G306
+	require.NoError(t, os.WriteFile(cachedPath, []byte("#!/bin/sh\necho 'Terraform v1.6.0'\n"), 0755))
+	p, err = EnsureTerraform(context.Background(), execPath, "1.6.0", installDir, true)
+	require.NoError(t, err)
+	require.Equal(t, cachedPath, p)
+
+	// Nothing is downloaded in offline mode
+	_, err = EnsureTerraform(context.Background(), execPath, "1.7.0", t.TempDir(), true)
+	require.ErrorContains(t, err, "downloading is disabled in offline mode")
+}
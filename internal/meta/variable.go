@@ -0,0 +1,218 @@
+package meta
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// variableAttributes are the top level attributes whose recurring literal values are extracted as variables, in the order of the variables defined.
+var variableAttributes = []string{"location", "resource_group_name", "tags", "sku", "sku_name"}
+
+// variableCandidate is a literal value of an attribute that is shared by multiple resources.
+type variableCandidate struct {
+	attr   string
+	value  cty.Value
+	key    string
+	bodies []*hclwrite.Body
+	// The addresses of the resources, in the order of the bodies
+	addrs []string
+	// The variables that are referenced by the shared resources for this attribute, which are declared by the other environments (only for an environment)
+	sharedNames []string
+}
+
+// extractVariables lifts the literal values of the variableAttributes that are shared by more than one resources into variables, which are written to the variable file.
+// The resources are rewritten to reference the variables. The variables are named after the attribute, appended with a counter if the name is already used (e.g. "location_2").
+// For an environment, the values are written to the variable definitions file of the environment, instead of as the defaults. The variables referenced by the resources
+// that are declared by the other environments (i.e. the shared resources) are reused for the values of the same attribute paths, so that the shared config gets the values of this environment.
+// The other values are named in the order of the resource addresses, rather than how many resources share them, which varies between the environments.
+func (meta baseMeta) extractVariables(configs ConfigInfos) (ConfigInfos, error) {
+	var sharedRefs map[string]map[string]string
+	if meta.environment != "" {
+		var err error
+		if sharedRefs, err = meta.declaredVariableRefs(); err != nil {
+			return nil, fmt.Errorf("reading the variables referenced by the declared resources: %v", err)
+		}
+	}
+
+	candidates := map[string]*variableCandidate{}
+	for _, cfg := range configs {
+		blocks := cfg.hcl.Body().Blocks()
+		if len(blocks) == 0 {
+			continue
+		}
+		body := blocks[0].Body()
+		for _, attr := range variableAttributes {
+			a := body.GetAttribute(attr)
+			if a == nil {
+				continue
+			}
+			v, ok := literalVariableValue(a.Expr())
+			if !ok {
+				continue
+			}
+			b, err := ctyjson.Marshal(v, v.Type())
+			if err != nil {
+				return nil, fmt.Errorf("marshalling the value of %s.%s: %v", cfg.TFAddr, attr, err)
+			}
+			key := attr + "\x00" + string(b)
+			c, ok := candidates[key]
+			if !ok {
+				c = &variableCandidate{attr: attr, value: v, key: key}
+				candidates[key] = c
+			}
+			c.bodies = append(c.bodies, body)
+			c.addrs = append(c.addrs, cfg.TFAddr.String())
+			if name, ok := sharedRefs[cfg.TFAddr.String()][attr]; ok && !slices.Contains(c.sharedNames, name) {
+				c.sharedNames = append(c.sharedNames, name)
+			}
+		}
+	}
+
+	attrOrder := map[string]int{}
+	for i, attr := range variableAttributes {
+		attrOrder[attr] = i
+	}
+	var list []*variableCandidate
+	for _, c := range candidates {
+		// The value referenced by a shared resource is always needed, as the variable declared by the other environments has no default.
+		if len(c.bodies) > 1 || len(c.sharedNames) != 0 {
+			sort.Strings(c.sharedNames)
+			list = append(list, c)
+		}
+	}
+	if len(list) == 0 {
+		return configs, nil
+	}
+	if meta.environment != "" {
+		// The values are ordered by the first resource address, which is stable among the environments exported with the same resource name pattern.
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].attr != list[j].attr {
+				return attrOrder[list[i].attr] < attrOrder[list[j].attr]
+			}
+			if list[i].addrs[0] != list[j].addrs[0] {
+				return list[i].addrs[0] < list[j].addrs[0]
+			}
+			return list[i].key < list[j].key
+		})
+	} else {
+		// The most shared value of an attribute takes the attribute name.
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].attr != list[j].attr {
+				return attrOrder[list[i].attr] < attrOrder[list[j].attr]
+			}
+			if len(list[i].bodies) != len(list[j].bodies) {
+				return len(list[i].bodies) > len(list[j].bodies)
+			}
+			return list[i].key < list[j].key
+		})
+	}
+
+	// Avoid conflicting with the variables that are already defined in the module directory (e.g. in the append mode), unless they are referenced by the shared resources of the environment.
+	used := map[string]bool{}
+	module, diags := tfconfig.LoadModule(meta.moduleDir)
+	if diags.HasErrors() {
+		return nil, diags.Err()
+	}
+	for name := range module.Variables {
+		used[name] = true
+	}
+	// The variables referenced by the shared resources that have been assigned with a value of this environment.
+	assigned := map[string]bool{}
+
+	f := hclwrite.NewEmptyFile()
+	var envVarNames []string
+	envVarValues := map[string]cty.Value{}
+	for _, c := range list {
+		var name string
+		for _, sharedName := range c.sharedNames {
+			if assigned[sharedName] {
+				meta.Logger().Warn("The variable referenced by the shared resources has different values in this environment, only the first one is used", "variable", sharedName, "attribute", c.attr)
+				continue
+			}
+			assigned[sharedName] = true
+			envVarNames = append(envVarNames, sharedName)
+			envVarValues[sharedName] = c.value
+			if name == "" {
+				name = sharedName
+			}
+		}
+		if name == "" {
+			name = c.attr
+			for cnt := 2; used[name]; cnt++ {
+				name = fmt.Sprintf("%s_%d", c.attr, cnt)
+			}
+			used[name] = true
+			if meta.environment != "" {
+				envVarNames = append(envVarNames, name)
+				envVarValues[name] = c.value
+			}
+		}
+		if _, ok := module.Variables[name]; !ok || meta.environment == "" {
+			typ := "string"
+			if c.value.Type().IsMapType() {
+				typ = "map(string)"
+			}
+			body := f.Body().AppendNewBlock("variable", []string{name}).Body()
+			body.SetAttributeRaw("type", hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(typ)}})
+			if meta.environment == "" {
+				body.SetAttributeValue("default", c.value)
+			}
+			f.Body().AppendNewline()
+		}
+
+		for _, b := range c.bodies {
+			b.SetAttributeTraversal(c.attr, hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: name}})
+		}
+	}
+
+	varFile := filepath.Join(meta.moduleDir, meta.outputFileNames.VariableFileName)
+	if err := appendToFile(varFile, string(hclwrite.Format(f.Bytes()))); err != nil {
+		return nil, fmt.Errorf("generating the variable file: %w", err)
+	}
+	if meta.environment != "" {
+		if err := meta.writeEnvironmentVariables(envVarNames, envVarValues); err != nil {
+			return nil, fmt.Errorf("generating the variable definitions file of the environment: %w", err)
+		}
+	}
+	return configs, nil
+}
+
+// literalVariableValue returns the value of the expression if it is a string literal, or a map of string literals.
+func literalVariableValue(expr *hclwrite.Expression) (cty.Value, bool) {
+	e, diags := hclsyntax.ParseExpression(expr.BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() || len(e.Variables()) != 0 {
+		return cty.NilVal, false
+	}
+	v, diags := e.Value(nil)
+	if diags.HasErrors() || !v.IsWhollyKnown() || v.IsNull() {
+		return cty.NilVal, false
+	}
+	ty := v.Type()
+	switch {
+	case ty == cty.String:
+		return v, true
+	case ty.IsObjectType() || ty.IsMapType():
+		if v.LengthInt() == 0 {
+			return cty.NilVal, false
+		}
+		m := map[string]cty.Value{}
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			if ev.IsNull() || ev.Type() != cty.String {
+				return cty.NilVal, false
+			}
+			m[k.AsString()] = ev
+		}
+		return cty.MapVal(m), true
+	}
+	return cty.NilVal, false
+}
@@ -0,0 +1,119 @@
+package meta
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractVariables(t *testing.T) {
+	var cfgs ConfigInfos
+	for _, src := range []string{
+		`resource "azurerm_resource_group" "rg" {
+  location = "westeurope"
+  name     = "rg"
+  tags = {
+    env = "prod"
+  }
+}
+`,
+		`resource "azurerm_virtual_network" "vnet" {
+  location            = "westeurope"
+  name                = "vnet"
+  resource_group_name = "rg"
+  tags = {
+    env = "prod"
+  }
+}
+`,
+		`resource "azurerm_public_ip" "pip" {
+  location            = "eastus"
+  name                = "pip"
+  resource_group_name = azurerm_resource_group.rg.name
+  sku                 = "Standard"
+}
+`,
+		`resource "azurerm_public_ip" "pip2" {
+  location            = "eastus"
+  name                = "pip2"
+  resource_group_name = "rg"
+  sku                 = "Standard"
+}
+`,
+	} {
+		f, diags := hclwrite.ParseConfig([]byte(src), "", hcl.InitialPos)
+		require.False(t, diags.HasErrors())
+		cfgs = append(cfgs, ConfigInfo{ImportItem: ImportItem{TFAddr: tfaddr.TFAddr{Type: "azurerm_foo", Name: "foo"}}, hcl: f})
+	}
+
+	dir := t.TempDir()
+	// The variable that is already defined in the module directory
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "existing.tf"), []byte(`variable "sku" {}`), 0600))
+
+	meta := baseMeta{
+		moduleDir:       dir,
+		outputFileNames: config.OutputFileNames{VariableFileName: "variables.tf"},
+	}
+	cfgs, err := meta.extractVariables(cfgs)
+	require.NoError(t, err)
+
+	b, err := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	require.NoError(t, err)
+	require.Equal(t, `variable "location" {
+  type    = string
+  default = "eastus"
+}
+
+variable "location_2" {
+  type    = string
+  default = "westeurope"
+}
+
+variable "resource_group_name" {
+  type    = string
+  default = "rg"
+}
+
+variable "tags" {
+  type = map(string)
+  default = {
+    env = "prod"
+  }
+}
+
+variable "sku_2" {
+  type    = string
+  default = "Standard"
+}
+
+`, string(b))
+
+	var buf bytes.Buffer
+	_, err = cfgs[1].DumpHCL(&buf)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_virtual_network" "vnet" {
+  location            = var.location_2
+  name                = "vnet"
+  resource_group_name = var.resource_group_name
+  tags                = var.tags
+}
+`, buf.String())
+
+	buf.Reset()
+	_, err = cfgs[2].DumpHCL(&buf)
+	require.NoError(t, err)
+	require.Equal(t, `resource "azurerm_public_ip" "pip" {
+  location            = var.location
+  name                = "pip"
+  resource_group_name = azurerm_resource_group.rg.name
+  sku                 = var.sku_2
+}
+`, buf.String())
+}
@@ -0,0 +1,251 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// VerifyReportFileName is the report of verifying the generated config, which is written to the output directory.
+const VerifyReportFileName = "aztfexportVerifyReport.txt"
+
+// attributeDiff is the difference of an attribute between the state and the planned value.
+type attributeDiff struct {
+	path   string
+	before string
+	after  string
+}
+
+// verifyCfg runs "terraform validate" and "terraform plan" against the output directory, and writes a report of the validation diagnostics
+// and the non-empty resource changes (per attribute) to the verify report file. The failure of the plan is recorded in the report, rather than returned as an error,
+// as the config is generated already.
+func (meta baseMeta) verifyCfg(ctx context.Context) error {
+	var sb strings.Builder
+
+	meta.Logger().Info("Verify the generated config", "dir", meta.outdir)
+	validateOutput, err := meta.tf.Validate(ctx)
+	if err != nil {
+		return fmt.Errorf("running terraform validate: %v", err)
+	}
+	if len(validateOutput.Diagnostics) != 0 {
+		sb.WriteString("# terraform validate\n\n")
+		sb.WriteString(formatDiagnostics(validateOutput.Diagnostics))
+		sb.WriteString("\n")
+	}
+
+	nChanges := 0
+	if !validateOutput.Valid {
+		meta.Logger().Warn("The generated config is invalid, skip planning")
+		sb.WriteString("The generated config is invalid, terraform plan is skipped.\n")
+	} else {
+		sb.WriteString("# terraform plan\n\n")
+		changes, err := meta.planResourceChanges(ctx)
+		if err != nil {
+			meta.Logger().Warn("Failed to plan the generated config", "error", err)
+			sb.WriteString(fmt.Sprintf("terraform plan failed: %v\n", err))
+		} else if len(changes) == 0 {
+			sb.WriteString("No changes. The generated config matches the imported resources.\n")
+		} else {
+			nChanges = len(changes)
+			sb.WriteString(formatResourceChanges(changes))
+		}
+	}
+
+	reportFile := filepath.Join(meta.outdir, VerifyReportFileName)
+	// #nosec G306
+	if err := os.WriteFile(reportFile, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("writing the verify report to %s: %v", reportFile, err)
+	}
+	if nChanges != 0 {
+		meta.Logger().Warn("The generated config has diff against the imported resources", "count", nChanges, "report", reportFile)
+	}
+	return nil
+}
+
+// planResourceChanges runs "terraform plan" in the output directory, and returns the resource changes that are neither no-op nor read.
+func (meta baseMeta) planResourceChanges(ctx context.Context) ([]*tfjson.ResourceChange, error) {
+	dir, err := os.MkdirTemp("", "aztfexport-plan-")
+	if err != nil {
+		return nil, fmt.Errorf("creating a temp directory for the plan file: %v", err)
+	}
+	// #nosec G104
+	defer os.RemoveAll(dir)
+
+	planFile := filepath.Join(dir, "plan")
+	opts := []tfexec.PlanOption{tfexec.Out(planFile)}
+	if meta.environment != "" {
+		if _, err := os.Stat(meta.environmentVarFile()); err == nil {
+			opts = append(opts, tfexec.VarFile(meta.environmentVarFile()))
+		}
+	}
+	diff, err := meta.tf.Plan(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !diff {
+		return nil, nil
+	}
+	plan, err := meta.tf.ShowPlanFile(ctx, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("showing the plan file: %v", err)
+	}
+	var changes []*tfjson.ResourceChange
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil || change.Change.Actions.NoOp() || change.Change.Actions.Read() {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// formatDiagnostics formats the diagnostics, one per line, together with the location and the detail (if any).
+func formatDiagnostics(diags []tfjson.Diagnostic) string {
+	var sb strings.Builder
+	for _, diag := range diags {
+		location := ""
+		if diag.Range != nil {
+			location = fmt.Sprintf(" (%s:%d)", diag.Range.Filename, diag.Range.Start.Line)
+		}
+		sb.WriteString(fmt.Sprintf("%s: %s%s\n", diag.Severity, diag.Summary, location))
+		if diag.Detail != "" {
+			sb.WriteString("  " + strings.ReplaceAll(diag.Detail, "\n", "\n  ") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatResourceChanges formats the resource changes, each shows its actions and the attribute differences between the state and the planned value (for the in-place updates and replacements).
+func formatResourceChanges(changes []*tfjson.ResourceChange) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		var actions []string
+		for _, action := range change.Change.Actions {
+			actions = append(actions, string(action))
+		}
+		sb.WriteString(fmt.Sprintf("%s (%s)\n", change.Address, strings.Join(actions, ", ")))
+		// The attributes of the resources being created or destroyed are not interesting, as they are not imported (or no config generated)
+		if change.Change.Actions.Update() || change.Change.Actions.Replace() {
+			before := maskSensitiveValue(change.Change.Before, change.Change.After, change.Change.BeforeSensitive, "(sensitive value)")
+			after := maskSensitiveValue(change.Change.After, change.Change.Before, change.Change.AfterSensitive, "(changed sensitive value)")
+			for _, d := range diffAttributes("", before, after, change.Change.AfterUnknown) {
+				sb.WriteString(fmt.Sprintf("  %s: %s => %s\n", d.path, d.before, d.after))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// diffAttributes returns the differences (in the order of the paths) between the JSON values before and after the change, where the afterUnknown
+// marks the values (with the same structure as the after value) that are only known after apply.
+func diffAttributes(path string, before, after, afterUnknown interface{}) []attributeDiff {
+	if unknown, ok := afterUnknown.(bool); ok && unknown {
+		return []attributeDiff{{path: path, before: jsonValueString(before), after: "(known after apply)"}}
+	}
+
+	beforeMap, ok1 := before.(map[string]interface{})
+	afterMap, ok2 := after.(map[string]interface{})
+	if (ok1 || before == nil) && (ok2 || after == nil) && (ok1 || ok2) {
+		unknownMap, _ := afterUnknown.(map[string]interface{})
+		keys := map[string]bool{}
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		for k, v := range unknownMap {
+			if unknown, ok := v.(bool); !ok || unknown {
+				keys[k] = true
+			}
+		}
+		var names []string
+		for k := range keys {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		var diffs []attributeDiff
+		for _, k := range names {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffs = append(diffs, diffAttributes(p, beforeMap[k], afterMap[k], unknownMap[k])...)
+		}
+		return diffs
+	}
+
+	beforeList, ok1 := before.([]interface{})
+	afterList, ok2 := after.([]interface{})
+	if ok1 && ok2 && len(beforeList) == len(afterList) {
+		unknownList, _ := afterUnknown.([]interface{})
+		var diffs []attributeDiff
+		for i := range beforeList {
+			var unknown interface{}
+			if i < len(unknownList) {
+				unknown = unknownList[i]
+			}
+			diffs = append(diffs, diffAttributes(fmt.Sprintf("%s[%d]", path, i), beforeList[i], afterList[i], unknown)...)
+		}
+		return diffs
+	}
+
+	beforeStr, afterStr := jsonValueString(before), jsonValueString(after)
+	if beforeStr == afterStr {
+		return nil
+	}
+	return []attributeDiff{{path: path, before: beforeStr, after: afterStr}}
+}
+
+// maskSensitiveValue replaces the values in the JSON value that are marked as sensitive (with the same structure as the value) by the mask, so that the secrets are not written to the report.
+// The other is the value on the other side of the change, the sensitive values that are not changed are kept as is, as they are not reported anyway.
+func maskSensitiveValue(v, other, sensitive interface{}, mask string) interface{} {
+	switch sensitive := sensitive.(type) {
+	case bool:
+		if sensitive && v != nil && jsonValueString(v) != jsonValueString(other) {
+			return mask
+		}
+	case map[string]interface{}:
+		if m, ok := v.(map[string]interface{}); ok {
+			otherMap, _ := other.(map[string]interface{})
+			out := make(map[string]interface{}, len(m))
+			for k, vv := range m {
+				out[k] = maskSensitiveValue(vv, otherMap[k], sensitive[k], mask)
+			}
+			return out
+		}
+	case []interface{}:
+		if l, ok := v.([]interface{}); ok {
+			otherList, _ := other.([]interface{})
+			out := make([]interface{}, len(l))
+			for i, vv := range l {
+				var o, s interface{}
+				if i < len(otherList) {
+					o = otherList[i]
+				}
+				if i < len(sensitive) {
+					s = sensitive[i]
+				}
+				out[i] = maskSensitiveValue(vv, o, s, mask)
+			}
+			return out
+		}
+	}
+	return v
+}
+
+func jsonValueString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,81 @@
+package meta
+
+import (
+	"encoding/json"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatResourceChanges(t *testing.T) {
+	// The sensitive values are masked
+	var plan tfjson.Plan
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "azurerm_resource_group.rg",
+      "change": {
+        "actions": ["update"],
+        "before": {"name": "rg", "location": "westus", "secret": "foo", "unchanged_secret": "foo", "tags": {"env": "dev", "owner": "foo"}},
+        "after": {"name": "rg", "location": "westus", "secret": "bar", "unchanged_secret": "foo", "tags": {"env": "prod"}},
+        "after_unknown": {"tags": {}},
+        "before_sensitive": {"secret": true, "unchanged_secret": true},
+        "after_sensitive": {"secret": true, "unchanged_secret": true}
+      }
+    },
+    {
+      "address": "azurerm_virtual_network.vnet",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"address_space": ["10.0.0.0/16"], "guid": "123", "subnet": [{"name": "a"}]},
+        "after": {"address_space": ["10.1.0.0/16"], "subnet": [{"name": "a"}]},
+        "after_unknown": {"address_space": [false], "guid": true, "subnet": [{}]}
+      }
+    },
+    {
+      "address": "azurerm_subnet.subnet",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {"name": "subnet"},
+        "after_unknown": {"id": true}
+      }
+    }
+  ]
+}`), &plan))
+
+	require.Equal(t, `azurerm_resource_group.rg (update)
+  secret: "(sensitive value)" => "(changed sensitive value)"
+  tags.env: "dev" => "prod"
+  tags.owner: "foo" => null
+
+azurerm_virtual_network.vnet (delete, create)
+  address_space[0]: "10.0.0.0/16" => "10.1.0.0/16"
+  guid: "123" => (known after apply)
+
+azurerm_subnet.subnet (create)
+
+`, formatResourceChanges(plan.ResourceChanges))
+}
+
+func TestFormatDiagnostics(t *testing.T) {
+	diags := []tfjson.Diagnostic{
+		{
+			Severity: tfjson.DiagnosticSeverityError,
+			Summary:  "Missing required argument",
+			Detail:   "The argument \"location\" is required,\nbut no definition was found.",
+			Range:    &tfjson.Range{Filename: "main.tf", Start: tfjson.Pos{Line: 3}},
+		},
+		{
+			Severity: tfjson.DiagnosticSeverityWarning,
+			Summary:  "Deprecated attribute",
+		},
+	}
+	require.Equal(t, `error: Missing required argument (main.tf:3)
+  The argument "location" is required,
+  but no definition was found.
+warning: Deprecated attribute
+`, formatDiagnostics(diags))
+}
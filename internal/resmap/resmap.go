@@ -1,13 +1,311 @@
 package resmap
 
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
 type ResourceMapEntity struct {
 	// TF resource ID
-	ResourceId string `json:"resource_id"`
+	ResourceId string `json:"resource_id" yaml:"resource_id"`
 	// TF resource type
-	ResourceType string `json:"resource_type"`
+	ResourceType string `json:"resource_type" yaml:"resource_type"`
 	// TF resource name
-	ResourceName string `json:"resource_name"`
+	ResourceName string `json:"resource_name" yaml:"resource_name"`
+	// The API version used to import the resource, which only applies to the "azapi_resource" type.
+	APIVersion string `json:"api_version,omitempty" yaml:"api_version,omitempty"`
 }
 
 // ResourceMapping is the resource mapping file, the key is the Azure resource Id in uppercase.
+// The key can also be a pattern rule, see ResolveRules.
 type ResourceMapping map[string]ResourceMapEntity
+
+// RegexpRulePrefix is the prefix of the pattern rule key that is a regular expression, rather than a glob pattern.
+const RegexpRulePrefix = "regex:"
+
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+	entity  ResourceMapEntity
+}
+
+// IsRule tells whether the key of the resource mapping is a pattern rule, which is either a glob pattern with "*", or a regular expression prefixed by RegexpRulePrefix.
+func IsRule(key string) bool {
+	return strings.HasPrefix(key, RegexpRulePrefix) || strings.Contains(key, "*")
+}
+
+// ResolveRules returns the resource mapping without the pattern rules, where the entries without a resource type are resolved by the rules.
+// For a resolved entry, the resource type is taken from the rule, and the resource id defaults to the Azure resource Id.
+// Note that the rules don't introduce any new entry, use a Resolver to apply them to the resources discovered by other means (e.g. a scope).
+func (m ResourceMapping) ResolveRules() (ResourceMapping, error) {
+	out := ResourceMapping{}
+	for k, v := range m {
+		if !IsRule(k) {
+			out[k] = v
+		}
+	}
+	rules, err := m.rules()
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range out {
+		if v.ResourceType != "" {
+			continue
+		}
+		entity, ok := rules.match(k)
+		if !ok {
+			return nil, fmt.Errorf("%q: missing resource type, and no rule matches", k)
+		}
+		v.ResourceType = entity.ResourceType
+		if v.APIVersion == "" {
+			v.APIVersion = entity.APIVersion
+		}
+		if v.ResourceId == "" {
+			v.ResourceId = k
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+type rules []rule
+
+// rules returns the pattern rules of the resource mapping, sorted by the pattern length in descending order.
+func (m ResourceMapping) rules() (rules, error) {
+	var out rules
+	for k, v := range m {
+		if !IsRule(k) {
+			continue
+		}
+		if v.ResourceType == "" {
+			return nil, fmt.Errorf("rule %q: missing resource type", k)
+		}
+		var re *regexp.Regexp
+		if expr, ok := strings.CutPrefix(k, RegexpRulePrefix); ok {
+			var err error
+			re, err = regexp.Compile("(?i)" + expr)
+			if err != nil {
+				return nil, fmt.Errorf("rule %q: invalid regular expression: %v", k, err)
+			}
+		} else {
+			re = utils.GlobToRegexp(k)
+		}
+		out = append(out, rule{pattern: k, re: re, entity: v})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].pattern) != len(out[j].pattern) {
+			return len(out[i].pattern) > len(out[j].pattern)
+		}
+		return out[i].pattern < out[j].pattern
+	})
+	return out, nil
+}
+
+// match returns the entity of the rule that matches the Azure resource Id case insensitively. When more than one rules match, the one with the longest pattern wins.
+func (rules rules) match(azureId string) (ResourceMapEntity, bool) {
+	for _, rule := range rules {
+		if rule.re.MatchString(azureId) {
+			return rule.entity, true
+		}
+	}
+	return ResourceMapEntity{}, false
+}
+
+// Resolver resolves the TF resource type and id of the Azure resources by a resource mapping, either by the exact entries or by the pattern rules.
+type Resolver struct {
+	// The key is the Azure resource Id in uppercase
+	entries map[string]ResourceMapEntity
+	rules   rules
+}
+
+// NewResolver creates a Resolver from the resource mapping.
+func NewResolver(m ResourceMapping) (*Resolver, error) {
+	rules, err := m.rules()
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]ResourceMapEntity{}
+	for k, v := range m {
+		if IsRule(k) {
+			continue
+		}
+		if v.ResourceType == "" {
+			return nil, fmt.Errorf("%q: missing resource type", k)
+		}
+		entries[strings.ToUpper(k)] = v
+	}
+	return &Resolver{entries: entries, rules: rules}, nil
+}
+
+// Resolve returns the TF resource type and id of the Azure resource, where the id defaults to the Azure resource Id. An empty type is returned if not resolved.
+func (r *Resolver) Resolve(azureId string) (tfType, tfId string) {
+	entity, ok := r.entries[strings.ToUpper(azureId)]
+	if !ok {
+		entity, ok = r.rules.match(azureId)
+		if !ok {
+			return "", ""
+		}
+		entity.ResourceId = ""
+	}
+	if entity.ResourceId == "" {
+		entity.ResourceId = azureId
+	}
+	return entity.ResourceType, entity.ResourceId
+}
+
+// CSV column names of the resource mapping file, where the Azure resource Id is in the extra column.
+// The API version column is optional.
+const (
+	CSVColumnAzureResourceId = "azure_resource_id"
+	CSVColumnResourceId      = "resource_id"
+	CSVColumnResourceType    = "resource_type"
+	CSVColumnResourceName    = "resource_name"
+	CSVColumnAPIVersion      = "api_version"
+)
+
+// Load loads and merges the resource mapping files in order, where the entries (and rules) of the later files override the ones of the former files.
+// A path can also be a directory, in which case the mapping files directly under it are loaded in lexical order.
+// The format of each file is determined by the file extension:
+//   - ".yaml", ".yml": YAML, in the same structure as the JSON format
+//   - ".csv": CSV, with a header row of the column names (in any order)
+//   - Others: JSON
+//
+// The rules are resolved after merging, so that the rules of one file apply to the entries of the others.
+func Load(paths ...string) (ResourceMapping, error) {
+	m, err := loadFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	m, err = m.ResolveRules()
+	if err != nil {
+		return nil, fmt.Errorf("resolving the rules of the mapping file: %v", err)
+	}
+	return m, nil
+}
+
+// LoadResolver loads and merges the resource mapping files in the same way as Load, and creates a Resolver from it.
+func LoadResolver(paths ...string) (*Resolver, error) {
+	m, err := loadFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	return NewResolver(m)
+}
+
+func loadFiles(paths []string) (ResourceMapping, error) {
+	m := ResourceMapping{}
+	for _, path := range paths {
+		files, err := expandPath(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			fm, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range fm {
+				m[k] = v
+			}
+		}
+	}
+	return m, nil
+}
+
+func expandPath(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping file %s: %v", path, err)
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping file directory %s: %v", path, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml", ".csv":
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no mapping file found in directory %s", path)
+	}
+	return files, nil
+}
+
+func loadFile(path string) (ResourceMapping, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mapping file %s: %v", path, err)
+	}
+
+	var m ResourceMapping
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &m)
+	case ".csv":
+		m, err = unmarshalCSV(string(b))
+	default:
+		err = json.Unmarshal(b, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling the mapping file %s: %v", path, err)
+	}
+	return m, nil
+}
+
+func unmarshalCSV(input string) (ResourceMapping, error) {
+	records, err := csv.NewReader(strings.NewReader(input)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing the header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{CSVColumnAzureResourceId, CSVColumnResourceId, CSVColumnResourceType, CSVColumnResourceName} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing the %q column", name)
+		}
+	}
+
+	m := ResourceMapping{}
+	for i, record := range records[1:] {
+		azureId := strings.TrimSpace(record[columns[CSVColumnAzureResourceId]])
+		if _, ok := m[azureId]; ok {
+			return nil, fmt.Errorf("row %d: duplicated Azure resource id %q", i+2, azureId)
+		}
+		entity := ResourceMapEntity{
+			ResourceId:   strings.TrimSpace(record[columns[CSVColumnResourceId]]),
+			ResourceType: strings.TrimSpace(record[columns[CSVColumnResourceType]]),
+			ResourceName: strings.TrimSpace(record[columns[CSVColumnResourceName]]),
+		}
+		if idx, ok := columns[CSVColumnAPIVersion]; ok {
+			entity.APIVersion = strings.TrimSpace(record[idx])
+		}
+		m[azureId] = entity
+	}
+	return m, nil
+}
@@ -0,0 +1,259 @@
+package resmap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	expect := ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg",
+			ResourceType: "azurerm_resource_group",
+			ResourceName: "rg",
+		},
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "vnet",
+		},
+	}
+
+	cases := []struct {
+		name    string
+		file    string
+		content string
+		err     string
+	}{
+		{
+			name: "json",
+			file: "map.json",
+			content: `{
+  "/subscriptions/123/resourceGroups/rg": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg",
+    "resource_type": "azurerm_resource_group",
+    "resource_name": "rg"
+  },
+  "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": {
+    "resource_id": "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+    "resource_type": "azurerm_virtual_network",
+    "resource_name": "vnet"
+  }
+}`,
+		},
+		{
+			name: "yaml",
+			file: "map.yaml",
+			content: `/subscriptions/123/resourceGroups/rg:
+  resource_id: /subscriptions/123/resourceGroups/rg
+  resource_type: azurerm_resource_group
+  resource_name: rg
+/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet:
+  resource_id: /subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet
+  resource_type: azurerm_virtual_network
+  resource_name: vnet
+`,
+		},
+		{
+			name: "csv",
+			file: "map.csv",
+			content: `resource_type,resource_name,azure_resource_id,resource_id
+azurerm_resource_group,rg,/subscriptions/123/resourceGroups/rg,/subscriptions/123/resourceGroups/rg
+azurerm_virtual_network,vnet,/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet,/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet
+`,
+		},
+		{
+			name: "csv with the optional api_version column",
+			file: "map.csv",
+			content: `azure_resource_id,resource_id,resource_type,resource_name,api_version
+/subscriptions/123/resourceGroups/rg,/subscriptions/123/resourceGroups/rg,azurerm_resource_group,rg,
+/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet,/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet,azurerm_virtual_network,vnet,
+`,
+		},
+		{
+			name: "csv missing column",
+			file: "map.csv",
+			content: `resource_type,resource_name,azure_resource_id
+azurerm_resource_group,rg,/subscriptions/123/resourceGroups/rg
+`,
+			err: `missing the "resource_id" column`,
+		},
+		{
+			name: "csv duplicated row",
+			file: "map.csv",
+			content: `resource_type,resource_name,azure_resource_id,resource_id
+azurerm_resource_group,rg,/subscriptions/123/resourceGroups/rg,/subscriptions/123/resourceGroups/rg
+azurerm_resource_group,rg,/subscriptions/123/resourceGroups/rg,/subscriptions/123/resourceGroups/rg
+`,
+			err: "row 3: duplicated Azure resource id",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+			m, err := Load(path)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, expect, m)
+		})
+	}
+}
+
+func TestResolveRules(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  ResourceMapping
+		expect ResourceMapping
+		err    string
+	}{
+		{
+			name: "glob and regexp rules",
+			input: ResourceMapping{
+				"*/providers/Microsoft.Network/networkSecurityGroups/*": {ResourceType: "azurerm_network_security_group"},
+				"*/providers/*": {ResourceType: "azurerm_resource"},
+				`regex:^/subscriptions/[^/]+/resourceGroups/[^/]+$`:                                          {ResourceType: "azurerm_resource_group"},
+				"/subscriptions/123/resourceGroups/rg":                                                       {ResourceName: "rg"},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg": {ResourceName: "nsg"},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet":      {ResourceName: "vnet"},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg2": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg2",
+					ResourceType: "azurerm_network_security_group",
+					ResourceName: "nsg2",
+				},
+			},
+			expect: ResourceMapping{
+				"/subscriptions/123/resourceGroups/rg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg",
+					ResourceType: "azurerm_resource_group",
+					ResourceName: "rg",
+				},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg",
+					ResourceType: "azurerm_network_security_group",
+					ResourceName: "nsg",
+				},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+					ResourceType: "azurerm_resource",
+					ResourceName: "vnet",
+				},
+				"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg2": {
+					ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg2",
+					ResourceType: "azurerm_network_security_group",
+					ResourceName: "nsg2",
+				},
+			},
+		},
+		{
+			name: "no rule matches",
+			input: ResourceMapping{
+				"*/providers/Microsoft.Network/networkSecurityGroups/*": {ResourceType: "azurerm_network_security_group"},
+				"/subscriptions/123/resourceGroups/rg":                  {ResourceName: "rg"},
+			},
+			err: "no rule matches",
+		},
+		{
+			name: "rule without resource type",
+			input: ResourceMapping{
+				"*/providers/Microsoft.Network/networkSecurityGroups/*": {},
+			},
+			err: "missing resource type",
+		},
+		{
+			name: "invalid regexp",
+			input: ResourceMapping{
+				"regex:(": {ResourceType: "azurerm_resource_group"},
+			},
+			err: "invalid regular expression",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := tt.input.ResolveRules()
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, m)
+		})
+	}
+}
+
+func TestLoadMultiple(t *testing.T) {
+	dir := t.TempDir()
+	orgDir := filepath.Join(dir, "org")
+	require.NoError(t, os.Mkdir(orgDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(orgDir, "a.yaml"), []byte(`"*/providers/Microsoft.Network/virtualNetworks/*":
+  resource_type: azurerm_virtual_network
+"*/resourceGroups/*":
+  resource_type: azurerm_resource_group
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(orgDir, "b.json"), []byte(`{"*/resourceGroups/*": {"resource_type": "azurerm_resource_group_override"}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(orgDir, "README.md"), []byte("not a mapping file"), 0600))
+	rgFile := filepath.Join(dir, "rg.csv")
+	require.NoError(t, os.WriteFile(rgFile, []byte(`azure_resource_id,resource_id,resource_type,resource_name
+/subscriptions/123/resourceGroups/rg,,,rg
+/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet,,,vnet
+`), 0600))
+
+	m, err := Load(orgDir, rgFile)
+	require.NoError(t, err)
+	require.Equal(t, ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg",
+			ResourceType: "azurerm_resource_group_override",
+			ResourceName: "rg",
+		},
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet": {
+			ResourceId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet",
+			ResourceType: "azurerm_virtual_network",
+			ResourceName: "vnet",
+		},
+	}, m)
+}
+
+func TestResolver(t *testing.T) {
+	resolver, err := NewResolver(ResourceMapping{
+		"/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1": {
+			ResourceType: "azurerm_foo_special",
+			ResourceId:   "/custom",
+		},
+		"*/providers/Microsoft.Foo/foos/*": {
+			ResourceType: "azurerm_foo",
+		},
+		"regex:/providers/Microsoft\\.Foo/foos/[^/]+/bars/[^/]+$": {
+			ResourceType: "azurerm_foo_bar",
+			// The resource id of a rule is ignored
+			ResourceId: "/ignored",
+		},
+	})
+	require.NoError(t, err)
+
+	tfType, tfId := resolver.Resolve("/SUBSCRIPTIONS/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1")
+	require.Equal(t, "azurerm_foo_special", tfType)
+	require.Equal(t, "/custom", tfId)
+
+	tfType, tfId = resolver.Resolve("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2")
+	require.Equal(t, "azurerm_foo", tfType)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2", tfId)
+
+	tfType, tfId = resolver.Resolve("/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2/bars/bar")
+	require.Equal(t, "azurerm_foo_bar", tfType)
+	require.Equal(t, "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2/bars/bar", tfId)
+
+	tfType, _ = resolver.Resolve("/subscriptions/123/resourceGroups/rg")
+	require.Empty(t, tfType)
+
+	_, err = NewResolver(ResourceMapping{"/subscriptions/123/resourceGroups/rg": {}})
+	require.Error(t, err)
+}
@@ -1,8 +1,9 @@
 package resourceset
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
-	"sort"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
@@ -26,7 +27,20 @@ type PesudoResourceInfo struct {
 	TFId   string
 }
 
-func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, parallelism int, cred azcore.TokenCredential, clientOpt arm.ClientOptions) []TFResource {
+// TypeResolver resolves the TF resource type and id of an Azure resource, which takes precedence over aztft.
+// An empty type is returned if the resource is not resolved by it.
+type TypeResolver interface {
+	ResolveType(ctx context.Context, azureId string) (tfType string, tfId string, err error)
+}
+
+// TypeCache caches the TF resource types and ids of the Azure resources that are exactly resolved by aztft, which might involve the API calls to the resources.
+type TypeCache interface {
+	Get(res AzureResource) (tftypes []aztft.Type, tfids []string, ok bool)
+	Put(res AzureResource, tftypes []aztft.Type, tfids []string)
+}
+
+// ToTFAzureRMResources resolves the azurerm resource type and id of the Azure resources, by the optional resolver first, then by aztft (unless cached by the optional cache).
+func (rset AzureResourceSet) ToTFAzureRMResources(ctx context.Context, logger *slog.Logger, parallelism int, cred azcore.TokenCredential, clientOpt arm.ClientOptions, resolver TypeResolver, cache TypeCache) []TFResource {
 	tfresources := []TFResource{}
 
 	wp := workerpool.NewWorkPool(parallelism)
@@ -75,12 +89,42 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 	for _, res := range rset.Resources {
 		res := res
 		wp.AddTask(func() (interface{}, error) {
+			if resolver != nil {
+				tftype, tfid, err := resolver.ResolveType(ctx, res.Id.String())
+				if err != nil {
+					return result{resid: res.Id, err: fmt.Errorf("resolving by the type resolver: %v", err)}, nil
+				}
+				if tftype != "" {
+					if tfid == "" {
+						tfid = res.Id.String()
+					}
+					return result{
+						resid:   res.Id,
+						tftypes: []aztft.Type{{AzureId: res.Id, TFType: tftype}},
+						tfids:   []string{tfid},
+						exact:   true,
+					}, nil
+				}
+			}
+			if cache != nil {
+				if tftypes, tfids, ok := cache.Get(res); ok {
+					return result{
+						resid:   res.Id,
+						tftypes: tftypes,
+						tfids:   tfids,
+						exact:   true,
+					}, nil
+				}
+			}
 			tftypes, tfids, exact, err := aztft.QueryTypeAndId(res.Id.String(),
 				&aztft.APIOption{
 					Cred:         cred,
 					ClientOption: clientOpt,
 				},
 			)
+			if err == nil && exact && cache != nil {
+				cache.Put(res, tftypes, tfids)
+			}
 			return result{
 				resid:   res.Id,
 				tftypes: tftypes,
@@ -94,10 +138,7 @@ func (rset AzureResourceSet) ToTFAzureRMResources(logger *slog.Logger, paralleli
 	// #nosec G104
 	wp.Done()
 
-	sort.Slice(tfresources, func(i, j int) bool {
-		return tfresources[i].AzureId.String() < tfresources[j].AzureId.String()
-	})
-
+	sortTFResources(tfresources)
 	return tfresources
 }
 
@@ -109,5 +150,6 @@ func (rset AzureResourceSet) ToTFAzAPIResources() (result []TFResource) {
 			TFType:  "azapi_resource",
 		})
 	}
+	sortTFResources(result)
 	return
 }
@@ -3,6 +3,8 @@ package resourceset
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/magodo/armid"
@@ -26,6 +28,16 @@ func (rset *AzureResourceSet) PopulateResource() error {
 	return nil
 }
 
+// PopulateEmbeddedResource populates the child resources that are embedded in the properties of their parent resource, rather than being separate Azure resources.
+// This allows these child resources to be managed as separate TF resources (e.g. azurerm_key_vault_access_policy), instead of being managed as part of their parent resource.
+func (rset *AzureResourceSet) PopulateEmbeddedResource(logger *slog.Logger) error {
+	// Populate the access policies for key vaults.
+	if err := rset.populateForKeyVaultAccessPolicy(logger); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ReduceResource reduce the resource set for certain multiple Azure resources that are known to be mapped to only one TF resource.
 func (rset *AzureResourceSet) ReduceResource() error {
 	// KeyVault certificate is a special resource that its data plane entity is composed of two control plane resources.
@@ -37,6 +49,14 @@ func (rset *AzureResourceSet) ReduceResource() error {
 }
 
 func (rset *AzureResourceSet) reduceForKeyVaultCertificate() error {
+	// The certificates might have been listed from the data plane already, which shouldn't be duplicated.
+	certs := map[string]bool{}
+	for _, res := range rset.Resources {
+		if strings.EqualFold(res.Id.RouteScopeString(), "/Microsoft.KeyVault/vaults/certificates") {
+			certs[strings.ToUpper(res.Id.String())] = true
+		}
+	}
+
 	newResoruces := []AzureResource{}
 	pending := map[string]AzureResource{}
 	for _, res := range rset.Resources {
@@ -53,6 +73,9 @@ func (rset *AzureResourceSet) reduceForKeyVaultCertificate() error {
 		delete(pending, certName)
 		certId := res.Id.Clone().(*armid.ScopedResourceId)
 		certId.AttrTypes[len(certId.AttrTypes)-1] = "certificates"
+		if certs[strings.ToUpper(certId.String())] {
+			continue
+		}
 		newResoruces = append(newResoruces, AzureResource{
 			Id: certId,
 		})
@@ -78,6 +101,38 @@ func (rset *AzureResourceSet) populateForVirtualMachine() error {
 	return nil
 }
 
+func (rset *AzureResourceSet) populateForKeyVaultAccessPolicy(logger *slog.Logger) error {
+	for _, res := range rset.Resources[:] {
+		if strings.ToUpper(res.Id.RouteScopeString()) != "/MICROSOFT.KEYVAULT/VAULTS" {
+			continue
+		}
+		b, err := json.Marshal(res.Properties)
+		if err != nil {
+			return fmt.Errorf("marshaling %v: %v", res.Properties, err)
+		}
+		policies := gjson.GetBytes(b, "properties.accessPolicies").Array()
+		// The access policies that are bound to an application (i.e. compound identity) are not supported by the azurerm_key_vault_access_policy.
+		// In this case, all the access policies of the key vault are kept inline, as the inline ones conflict with the separate ones.
+		if slices.ContainsFunc(policies, func(policy gjson.Result) bool { return policy.Get("applicationId").String() != "" }) {
+			logger.Warn("Key vault access policies are kept inline, as some of them are bound to an application, which is not supported by azurerm_key_vault_access_policy", "id", res.Id)
+			continue
+		}
+		for _, policy := range policies {
+			objectId := policy.Get("objectId").String()
+			if objectId == "" {
+				continue
+			}
+			id := res.Id.Clone().(*armid.ScopedResourceId)
+			id.AttrTypes = append(id.AttrTypes, "objectId")
+			id.AttrNames = append(id.AttrNames, objectId)
+			rset.Resources = append(rset.Resources, AzureResource{
+				Id: id,
+			})
+		}
+	}
+	return nil
+}
+
 // populateManagedResourcesByPath populate the managed resources in the specified paths.
 func populateManagedResourcesByPath(res AzureResource, paths ...string) ([]AzureResource, error) {
 	b, err := json.Marshal(res.Properties)
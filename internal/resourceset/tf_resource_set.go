@@ -1,9 +1,27 @@
 package resourceset
 
-import "github.com/magodo/armid"
+import (
+	"sort"
+
+	"github.com/magodo/armid"
+)
 
 type TFResource struct {
 	AzureId armid.ResourceId
 	TFId    string
 	TFType  string
 }
+
+// sortTFResources sorts the TF resources by the Azure resource id, then by the TF resource type and id (for the Azure resource that maps to multiple TF resources),
+// so that the order (and the generated names) doesn't depend on the order of the API listing or the type querying.
+func sortTFResources(l []TFResource) {
+	sort.Slice(l, func(i, j int) bool {
+		if idi, idj := l[i].AzureId.String(), l[j].AzureId.String(); idi != idj {
+			return idi < idj
+		}
+		if l[i].TFType != l[j].TFType {
+			return l[i].TFType < l[j].TFType
+		}
+		return l[i].TFId < l[j].TFId
+	})
+}
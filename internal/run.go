@@ -2,8 +2,11 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	internalmeta "github.com/Azure/aztfexport/internal/meta"
@@ -28,22 +31,85 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 
 	var errors []string
 
+	// The resources listed in dry-run mode
+	var dryRunList meta.ImportList
+
+	// The deadline applies to the whole run except the deinitialization and the rollback, so that the workspace is still cleaned up once exceeded.
+	cleanupCtx := ctx
+	if cfg.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Deadline)
+		defer cancel()
+	}
+
+	// The first interrupt stops the run once the in-flight imports finish, which are checkpointed in the session file, while the second one aborts the run.
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+	iw := watchInterrupt(os.Stderr, abort)
+	defer iw.Stop()
+
 	f := func(msg Messager) error {
-		msg.SetStatus("Initializing...")
-		if err := c.Init(ctx); err != nil {
-			return err
+		// The output directory is not initialized in dry-run mode or mapping-file-only mode, as nothing is going to be imported.
+		if !cfg.DryRun && !cfg.GenMappingFileOnly {
+			msg.SetStatus("Initializing...")
+			if err := c.Init(ctx); err != nil {
+				return err
+			}
+
+			defer func() {
+				msg.SetStatus("DeInitializing...")
+				// #nosec G104
+				c.DeInit(cleanupCtx)
+			}()
+		} else {
+			// The state is read without initializing the output directory, so that the resources already managed in it are skipped as well.
+			msg.SetStatus("Reading state...")
+			ok, err := c.ReadState(ctx)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Warning: the state stored in the %q backend can't be read without initializing the output directory, the resources already managed in it are not skipped\n", cfg.BackendType)
+			}
 		}
 
-		defer func() {
-			msg.SetStatus("DeInitializing...")
-			// #nosec G104
-			c.DeInit(ctx)
-		}()
+		var list meta.ImportList
+		if cfg.Resume {
+			msg.SetStatus("Loading session...")
+			l, err := c.LoadSession(ctx)
+			if err != nil {
+				return fmt.Errorf("loading session: %v", err)
+			}
+			list = l
+		} else if cfg.RetryFailed {
+			msg.SetStatus("Loading failed imports...")
+			l, err := c.LoadFailedImports(ctx)
+			if err != nil {
+				return fmt.Errorf("loading failed imports: %v", err)
+			}
+			// In map mode, the failed imports are retried with the mappings of the resource mapping file.
+			if cfg.MappingFile != "" {
+				msg.SetStatus("Listing resources...")
+				ml, err := c.ListResource(ctx)
+				if err != nil {
+					return err
+				}
+				l = l.Remap(ml)
+			}
+			list = l
+		} else {
+			msg.SetStatus("Listing resources...")
+			l, err := c.ListResource(ctx)
+			if err != nil {
+				return err
+			}
+			list = l
+		}
 
-		msg.SetStatus("Listing resources...")
-		list, err := c.ListResource(ctx)
-		if err != nil {
-			return err
+		// Return early if only previewing the resources
+		if cfg.DryRun {
+			dryRunList = list
+			return nil
 		}
 
 		msg.SetStatus("Exporting Skipped Resource file...")
@@ -61,19 +127,47 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 			return nil
 		}
 
-		for i := 0; i < len(list); i += cfg.Parallelism {
-			n := cfg.Parallelism
-			if i+cfg.Parallelism > len(list) {
+		if iw.Interrupted() {
+			return fmt.Errorf("interrupted before importing any resource")
+		}
+
+		if err := c.SaveSession(ctx, list); err != nil {
+			return fmt.Errorf("saving session: %v", err)
+		}
+
+		var alreadyImported int
+		for _, item := range list {
+			if item.Imported {
+				alreadyImported++
+			}
+		}
+		progress := common.NewImportProgress(len(list), alreadyImported)
+
+		// All the resources are imported in one round via plan.
+		roundSize := cfg.Parallelism
+		if cfg.ImportViaPlan {
+			roundSize = len(list)
+		}
+		for i := 0; i < len(list); i += roundSize {
+			if iw.Interrupted() {
+				return interruptedError(cfg)
+			}
+			n := roundSize
+			if i+roundSize > len(list) {
 				n = len(list) - i
 			}
 
 			var importList []*meta.ImportItem
-			messages := []string{"Importing resources..."}
+			messages := []string{"Importing resources... " + progress.String()}
 
 			for j := 0; j < n; j++ {
 				idx := i + j
 				if list[idx].Skip() {
 					messages = append(messages, fmt.Sprintf("(%d/%d) Skipping %s", idx+1, len(list), list[idx].TFResourceId))
+				} else if list[idx].Imported {
+					// This only happens when resuming from a session, where the item has been imported in the previous run.
+					messages = append(messages, fmt.Sprintf("(%d/%d) Already imported %s as %s", idx+1, len(list), list[idx].TFResourceId, list[idx].TFAddr))
+					continue
 				} else {
 					messages = append(messages, fmt.Sprintf("(%d/%d) Importing %s as %s", idx+1, len(list), list[idx].TFResourceId, list[idx].TFAddr))
 				}
@@ -84,6 +178,14 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 			if err := c.ParallelImport(ctx, importList); err != nil {
 				return fmt.Errorf("parallel importing: %v", err)
 			}
+			progress.Record(len(importList))
+
+			if err := c.SaveSession(cleanupCtx, list); err != nil {
+				return fmt.Errorf("saving session: %v", err)
+			}
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("the deadline of %s is exceeded, the resources imported so far are recorded in the session file, which can be resumed via `--resume`", cfg.Deadline)
+			}
 
 			var thisErrors []string
 			for j := 0; j < n; j++ {
@@ -102,6 +204,8 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 			}
 		}
 
+		msg.SetStatus("Imported resources " + progress.String())
+
 		if err := c.PushState(ctx); err != nil {
 			return fmt.Errorf("failed to push state: %v", err)
 		}
@@ -120,25 +224,131 @@ func BatchImport(ctx context.Context, cfg config.NonInteractiveModeConfig) error
 	}
 
 	var err error
-	if cfg.PlainUI {
+	if cfg.DryRun && cfg.DryRunFormat == DryRunFormatJSON {
+		// Don't output the progress, to keep the stdout a valid JSON document.
+		err = f(nopMessager{})
+	} else if cfg.PlainUI {
 		err = f(NewStdoutMessager())
 	} else {
 		s := bspinner.NewModel()
 		s.Spinner = common.Spinner
+		// The spinner quits on the Ctrl-C key press, in which case the run keeps finishing the in-flight imports, with the messages written to the stdout instead.
+		sm := &switchMessager{}
+		resultCh := make(chan error, 1)
 		sf := func(msg spinner.Messager) error {
-			return f(&msg)
+			sm.Switch(&msg)
+			err := f(sm)
+			resultCh <- err
+			return err
+		}
+		// #nosec G104
+		spinner.Run(s, sf)
+		select {
+		case err = <-resultCh:
+		default:
+			sm.Switch(NewStdoutMessager())
+			iw.Interrupt()
+			err = <-resultCh
 		}
-		err = spinner.Run(s, sf)
 	}
 
 	if err != nil {
+		if !cfg.DryRun && !cfg.GenMappingFileOnly {
+			return rollbackStateOnFailure(cleanupCtx, c, cfg.RollbackOnFailure, err)
+		}
 		return err
 	}
 
+	if cfg.DryRun {
+		return printDryRunResult(os.Stdout, dryRunList, cfg.DryRunFormat)
+	}
+
 	// Print out the errors, if any
 	if len(errors) != 0 {
 		fmt.Fprintln(os.Stderr, "Errors:\n"+strings.Join(errors, "\n"))
 	}
 
+	if cfg.Verify && !cfg.MockMeta && !cfg.GenMappingFileOnly {
+		fmt.Fprintln(os.Stdout, "The verify report is written to "+filepath.Join(cfg.OutputDir, internalmeta.VerifyReportFileName))
+	}
+
+	if cfg.CoverageReport && !cfg.MockMeta && !cfg.GenMappingFileOnly {
+		fmt.Fprintln(os.Stdout, "The coverage report is written to "+filepath.Join(cfg.OutputDir, internalmeta.CoverageReportFileName))
+	}
+
+	return nil
+}
+
+// interruptedError returns the error of the interrupted run, which tells how to resume it.
+func interruptedError(cfg config.NonInteractiveModeConfig) error {
+	if cfg.TFClient != nil {
+		return fmt.Errorf("interrupted, the resources imported so far are discarded, as no session is recorded when importing via the provider plugin")
+	}
+	return fmt.Errorf("interrupted, the resources imported so far are checkpointed in the session file %s, re-run the same command with `--resume` to continue", filepath.Join(cfg.OutputDir, internalmeta.SessionFileName))
+}
+
+// rollbackStateOnFailure rolls back the state to the backup taken prior to the import when rollback is set, otherwise the backup is pointed out in the error.
+func rollbackStateOnFailure(ctx context.Context, c meta.Meta, rollback bool, err error) error {
+	backup := c.StateBackupFile()
+	if backup == "" {
+		return err
+	}
+	if !rollback {
+		return fmt.Errorf("%v\n\nThe state prior to the import is backed up to %s", err, backup)
+	}
+	if rerr := c.RollbackState(ctx); rerr != nil {
+		return fmt.Errorf("%v\n\nFailed to roll back the state to the backup %s: %v", err, backup, rerr)
+	}
+	return fmt.Errorf("%v\n\nThe state is rolled back to the backup %s", err, backup)
+}
+
+const (
+	DryRunFormatText = "text"
+	DryRunFormatJSON = "json"
+)
+
+type dryRunItem struct {
+	AzureResourceId   string `json:"azure_resource_id"`
+	AzureResourceType string `json:"azure_resource_type"`
+	TFResourceId      string `json:"tf_resource_id"`
+	TFResourceType    string `json:"tf_resource_type,omitempty"`
+	TFResourceAddress string `json:"tf_resource_address,omitempty"`
+	Skipped           bool   `json:"skipped"`
+}
+
+// printDryRunResult prints the resources that would be imported (together with their TF resource addresses), and those would be skipped.
+// The format is either DryRunFormatText (default) or DryRunFormatJSON.
+func printDryRunResult(w io.Writer, l meta.ImportList, format string) error {
+	if format == DryRunFormatJSON {
+		items := []dryRunItem{}
+		for _, item := range l {
+			items = append(items, dryRunItem{
+				AzureResourceId:   item.AzureResourceID.String(),
+				AzureResourceType: item.AzureResourceID.TypeString(),
+				TFResourceId:      item.TFResourceId,
+				TFResourceType:    item.TFAddr.Type,
+				TFResourceAddress: item.TFAddr.String(),
+				Skipped:           item.Skip(),
+			})
+		}
+		b, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON marshalling the resources: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	nonSkipped := l.NonSkipped()
+	fmt.Fprintf(w, "%d resource(s) would be imported:\n", len(nonSkipped))
+	for _, item := range nonSkipped {
+		fmt.Fprintf(w, "  %s => %s\n", item.AzureResourceID, item.TFAddr)
+	}
+	if skipped := l.Skipped(); len(skipped) != 0 {
+		fmt.Fprintf(w, "\n%d resource(s) would be skipped:\n", len(skipped))
+		for _, item := range skipped {
+			fmt.Fprintf(w, "  %s\n", item.AzureResourceID)
+		}
+	}
 	return nil
 }
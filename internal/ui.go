@@ -28,3 +28,10 @@ func (p *stdoutMessager) SetStatus(msg string) {
 func (p *stdoutMessager) SetDetail(msg string) {
 	p.Println(msg)
 }
+
+// nopMessager discards all the messages.
+type nopMessager struct{}
+
+func (nopMessager) SetStatus(string) {}
+
+func (nopMessager) SetDetail(string) {}
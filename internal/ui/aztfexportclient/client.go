@@ -2,6 +2,7 @@ package aztfexportclient
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Azure/aztfexport/pkg/meta"
 
@@ -24,8 +25,25 @@ type ShowImportErrorMsg struct {
 	List  meta.ImportList
 }
 
+type StartPreviewMsg struct {
+	Item  meta.ImportItem
+	Index int
+	List  meta.ImportList
+}
+
+type ShowPreviewMsg struct {
+	Item  meta.ImportItem
+	Index int
+	List  meta.ImportList
+	// The generated config of the item, which is empty if Err is not nil
+	Config string
+	Err    error
+}
+
 type StartImportMsg struct {
 	List meta.ImportList
+	// The indexes of the items in List to import, which are all the items that are not imported yet if empty
+	Selected []int
 }
 
 type ImportItemsDoneMsg struct {
@@ -48,10 +66,14 @@ type ExportSkippedResourcesDoneMsg struct {
 	List meta.ImportList
 }
 
+type SaveSessionDoneMsg struct{}
+
 type GenerateCfgDoneMsg struct{}
 
 type WorkspaceCleanupDoneMsg struct{}
 
+type RollbackStateDoneMsg struct{}
+
 type QuitMsg struct{}
 
 type CleanTFStateMsg struct {
@@ -84,15 +106,49 @@ func ListResource(ctx context.Context, c meta.Meta) tea.Cmd {
 	}
 }
 
+// LoadSession loads the import list from the session file, which is then built as if it is listed.
+func LoadSession(ctx context.Context, c meta.Meta) tea.Cmd {
+	return func() tea.Msg {
+		list, err := c.LoadSession(ctx)
+		if err != nil {
+			return ErrMsg(fmt.Errorf("loading session: %v", err))
+		}
+		return ListResourceDoneMsg{List: list}
+	}
+}
+
+func SaveSession(ctx context.Context, c meta.Meta, l meta.ImportList) tea.Cmd {
+	return func() tea.Msg {
+		if err := c.SaveSession(ctx, l); err != nil {
+			return ErrMsg(fmt.Errorf("saving session: %v", err))
+		}
+		return SaveSessionDoneMsg{}
+	}
+}
+
 func ShowImportError(item meta.ImportItem, idx int, l meta.ImportList) tea.Cmd {
 	return func() tea.Msg {
 		return ShowImportErrorMsg{Item: item, Index: idx, List: l}
 	}
 }
 
-func StartImport(l meta.ImportList) tea.Cmd {
+func StartPreview(item meta.ImportItem, idx int, l meta.ImportList) tea.Cmd {
+	return func() tea.Msg {
+		return StartPreviewMsg{Item: item, Index: idx, List: l}
+	}
+}
+
+// PreviewCfg previews the config of the item, whose error is shown to the user instead of being regarded as an error of the program.
+func PreviewCfg(ctx context.Context, c meta.Meta, item meta.ImportItem, idx int, l meta.ImportList) tea.Cmd {
 	return func() tea.Msg {
-		return StartImportMsg{List: l}
+		cfg, err := c.PreviewCfg(ctx, item)
+		return ShowPreviewMsg{Item: item, Index: idx, List: l, Config: cfg, Err: err}
+	}
+}
+
+func StartImport(l meta.ImportList, selected []int) tea.Cmd {
+	return func() tea.Msg {
+		return StartImportMsg{List: l, Selected: selected}
 	}
 }
 
@@ -169,6 +225,15 @@ func CleanTFState(addr string) tea.Cmd {
 	}
 }
 
+func RollbackState(ctx context.Context, c meta.Meta) tea.Cmd {
+	return func() tea.Msg {
+		if err := c.RollbackState(ctx); err != nil {
+			return ErrMsg(fmt.Errorf("rolling back the state to the backup %s: %v", c.StateBackupFile(), err))
+		}
+		return RollbackStateDoneMsg{}
+	}
+}
+
 func Quit(ctx context.Context, c meta.Meta) tea.Cmd {
 	return func() tea.Msg {
 		if err := c.DeInit(ctx); err != nil {
@@ -20,12 +20,29 @@ var (
 	Red          = lipgloss.AdaptiveColor{Dark: "#ED567A", Light: "#FF4672"}
 	FaintRed     = lipgloss.AdaptiveColor{Dark: "#C74665", Light: "#FF6F91"}
 	NoColor      = lipgloss.AdaptiveColor{Dark: "", Light: ""}
+	Text         = Cream
+	Subdued      = lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#3C3C3C"}
 )
 
 var (
-	TitleStyle    = lipgloss.NewStyle().Foreground(Cream).Background(Indigo)
+	TitleStyle    lipgloss.Style
+	SubtitleStyle lipgloss.Style
+	InfoStyle     lipgloss.Style
+	QuitMsgStyle  lipgloss.Style
+	ErrorMsgStyle lipgloss.Style
+	HintStyle     lipgloss.Style
+)
+
+func init() {
+	setStyles()
+}
+
+// setStyles sets the styles by the colors, which are changed by the theme.
+func setStyles() {
+	TitleStyle = lipgloss.NewStyle().Foreground(Cream).Background(Indigo)
 	SubtitleStyle = lipgloss.NewStyle().Foreground(Cream).Background(SubtleIndigo)
-	InfoStyle     = lipgloss.NewStyle().Foreground(Cream).Background(NoColor)
-	QuitMsgStyle  = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#DDDADA", Dark: "#3C3C3C"})
+	InfoStyle = lipgloss.NewStyle().Foreground(Text).Background(NoColor)
+	QuitMsgStyle = lipgloss.NewStyle().Foreground(Subdued)
 	ErrorMsgStyle = lipgloss.NewStyle().Foreground(Red)
-)
+	HintStyle = lipgloss.NewStyle().Foreground(SubtleIndigo)
+}
@@ -1,11 +1,89 @@
 package common
 
-import "math/rand"
-
-const ProgressShowLastResults = 5
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
 
 func RandomHappyEmoji() string {
 	emojis := []rune("🍦🍡🤠👾😭🦊🐯🦆🥨🎏🍔🍒🍥🎮📦🦁🐶🐸🍕🥐🧲🚒🥇🏆🌽")
 	// #nosec G404 -- This is fine for UI
 	return string(emojis[rand.Intn(len(emojis))])
 }
+
+// ImportProgress tracks the progress of importing a list of resources, i.e. the remaining count, the import rate and the estimated completion time.
+// The rate is measured by the wall time elapsed for the resources processed (imported, failed or skipped) so far, which takes the parallel import into account.
+type ImportProgress struct {
+	total int
+	done  int
+	// The resources that are already done before tracking (e.g. imported in a resumed session), which are not counted into the rate.
+	initDone int
+	start    time.Time
+	now      func() time.Time
+}
+
+// NewImportProgress starts tracking the progress of importing the total resources, among which the done ones are already imported.
+func NewImportProgress(total, done int) *ImportProgress {
+	return newImportProgress(total, done, time.Now)
+}
+
+func newImportProgress(total, done int, now func() time.Time) *ImportProgress {
+	return &ImportProgress{
+		total:    total,
+		done:     done,
+		initDone: done,
+		start:    now(),
+		now:      now,
+	}
+}
+
+// Record records that n more resources are processed.
+func (p *ImportProgress) Record(n int) {
+	p.done += n
+	if p.done > p.total {
+		p.done = p.total
+	}
+}
+
+// Remaining returns the count of the resources that are not processed yet.
+func (p *ImportProgress) Remaining() int {
+	return p.total - p.done
+}
+
+// Rate returns the count of the resources processed per minute, which is zero if nothing is processed yet.
+func (p *ImportProgress) Rate() float64 {
+	elapsed := p.now().Sub(p.start)
+	if p.done == p.initDone || elapsed <= 0 {
+		return 0
+	}
+	return float64(p.done-p.initDone) / elapsed.Minutes()
+}
+
+// ETA returns the estimated duration for the remaining resources to be processed. False is returned if it can't be estimated yet.
+func (p *ImportProgress) ETA() (time.Duration, bool) {
+	rate := p.Rate()
+	if rate == 0 {
+		return 0, p.Remaining() == 0
+	}
+	return time.Duration(float64(p.Remaining()) / rate * float64(time.Minute)), true
+}
+
+// String returns the progress in the form of "(done/total) N remaining, R resources/min, ETA duration (at clock time)",
+// or "(total/total) done in duration, R resources/min" once all the resources are processed.
+func (p *ImportProgress) String() string {
+	if p.Remaining() == 0 {
+		s := fmt.Sprintf("(%d/%d) done in %s", p.done, p.total, p.now().Sub(p.start).Round(time.Second))
+		if rate := p.Rate(); rate != 0 {
+			s += fmt.Sprintf(", %.1f resources/min", rate)
+		}
+		return s
+	}
+	s := fmt.Sprintf("(%d/%d) %d remaining", p.done, p.total, p.Remaining())
+	eta, ok := p.ETA()
+	if !ok {
+		return s + ", estimating..."
+	}
+	eta = eta.Round(time.Second)
+	return s + fmt.Sprintf(", %.1f resources/min, ETA %s (at %s)", p.Rate(), eta, p.now().Add(eta).Format("15:04:05"))
+}
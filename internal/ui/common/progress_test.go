@@ -0,0 +1,30 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportProgress(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	p := newImportProgress(10, 2, func() time.Time { return now })
+	require.Equal(t, 8, p.Remaining())
+	require.Equal(t, "(2/10) 8 remaining, estimating...", p.String())
+
+	// The resources already done are not counted into the rate
+	now = now.Add(2 * time.Minute)
+	p.Record(4)
+	require.Equal(t, 4, p.Remaining())
+	require.Equal(t, 2.0, p.Rate())
+	eta, ok := p.ETA()
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, eta)
+	require.Equal(t, "(6/10) 4 remaining, 2.0 resources/min, ETA 2m0s (at 10:04:00)", p.String())
+
+	now = now.Add(2 * time.Minute)
+	p.Record(5)
+	require.Equal(t, 0, p.Remaining())
+	require.Equal(t, "(10/10) done in 4m0s, 2.0 resources/min", p.String())
+}
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/tfadd/providers/azapi"
+	"github.com/magodo/tfadd/providers/azurerm"
+)
+
+// ImportSummary summarizes the import to be confirmed before it begins.
+type ImportSummary struct {
+	// The count of the resources to import
+	Import int
+	// The count of the resources to skip
+	Skipped int
+	// The count of the resources that are imported already (e.g. in a resumed session), which are not imported again
+	Imported int
+
+	OutputDir       string
+	ProviderName    string
+	ProviderVersion string
+	BackendType     string
+}
+
+// NewImportSummary summarizes the import of the items of the selected indexes in l, or all the items that are not imported yet if nothing is selected.
+func NewImportSummary(cfg config.Config, l meta.ImportList, selected []int) ImportSummary {
+	s := ImportSummary{
+		OutputDir:       cfg.OutputDir,
+		ProviderName:    cfg.ProviderName,
+		ProviderVersion: cfg.ProviderVersion,
+		BackendType:     cfg.BackendType,
+	}
+	if s.ProviderVersion == "" && !cfg.DevProvider {
+		switch cfg.ProviderName {
+		case "azurerm":
+			s.ProviderVersion = azurerm.ProviderSchemaInfo.Version
+		case "azapi":
+			s.ProviderVersion = azapi.ProviderSchemaInfo.Version
+		}
+	}
+	if s.BackendType == "" {
+		s.BackendType = "local"
+	}
+
+	pending := map[int]bool{}
+	for _, idx := range selected {
+		pending[idx] = true
+	}
+	for idx, item := range l {
+		switch {
+		case item.Imported:
+			s.Imported++
+		case len(selected) != 0 && !pending[idx]:
+		case item.Skip():
+			s.Skipped++
+		default:
+			s.Import++
+		}
+	}
+	return s
+}
+
+func (s ImportSummary) String() string {
+	provider := s.ProviderName
+	if s.ProviderVersion != "" {
+		provider += " " + s.ProviderVersion
+	} else {
+		provider += " (development provider)"
+	}
+	out := fmt.Sprintf("Resources to import: %d\nResources to skip:   %d\n", s.Import, s.Skipped)
+	if s.Imported != 0 {
+		out += fmt.Sprintf("Resources imported:  %d (already)\n", s.Imported)
+	}
+	out += fmt.Sprintf("Output directory:    %s\nProvider:            %s\nBackend:             %s", s.OutputDir, provider, s.BackendType)
+	return out
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportSummary(t *testing.T) {
+	addr := tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res"}
+	l := meta.ImportList{
+		{TFAddr: addr, Imported: true},
+		{TFAddr: addr},
+		{},
+		{TFAddr: addr},
+	}
+	cfg := config.Config{
+		CommonConfig: config.CommonConfig{
+			OutputDir:       "/tmp/out",
+			ProviderName:    "azurerm",
+			ProviderVersion: "3.0.0",
+		},
+	}
+
+	s := NewImportSummary(cfg, l, nil)
+	require.Equal(t, ImportSummary{Import: 2, Skipped: 1, Imported: 1, OutputDir: "/tmp/out", ProviderName: "azurerm", ProviderVersion: "3.0.0", BackendType: "local"}, s)
+	require.Equal(t, `Resources to import: 2
+Resources to skip:   1
+Resources imported:  1 (already)
+Output directory:    /tmp/out
+Provider:            azurerm 3.0.0
+Backend:             local`, s.String())
+
+	// Only the selected items are summarized
+	s = NewImportSummary(cfg, l, []int{3})
+	require.Equal(t, 1, s.Import)
+	require.Equal(t, 0, s.Skipped)
+
+	cfg.ProviderVersion = ""
+	cfg.DevProvider = true
+	cfg.BackendType = "azurerm"
+	s = NewImportSummary(cfg, meta.ImportList{{TFAddr: addr}}, nil)
+	require.Equal(t, `Resources to import: 1
+Resources to skip:   0
+Output directory:    /tmp/out
+Provider:            azurerm (development provider)
+Backend:             azurerm`, s.String())
+}
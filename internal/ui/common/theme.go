@@ -0,0 +1,86 @@
+package common
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	prog "github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// The themes of the TUI.
+const (
+	ThemeDefault = "default"
+	// ThemeHighContrast uses the colors that are readable on both the dark and the light terminals, as well as the consoles with limited colors.
+	ThemeHighContrast = "high-contrast"
+)
+
+var Themes = []string{ThemeDefault, ThemeHighContrast}
+
+// Colors of the high contrast theme.
+var (
+	hcAccent     = lipgloss.AdaptiveColor{Dark: "#FFFF00", Light: "#000080"}
+	hcSubtle     = lipgloss.AdaptiveColor{Dark: "#5FD7FF", Light: "#00005F"}
+	hcOnAccent   = lipgloss.AdaptiveColor{Dark: "#000000", Light: "#FFFFFF"}
+	hcText       = lipgloss.AdaptiveColor{Dark: "#FFFFFF", Light: "#000000"}
+	hcSubdued    = lipgloss.AdaptiveColor{Dark: "#BCBCBC", Light: "#4E4E4E"}
+	hcError      = lipgloss.AdaptiveColor{Dark: "#FF8787", Light: "#AF0000"}
+	highContrast bool
+)
+
+// SetTheme sets the colors of the TUI by the theme. The colors are disabled if noColor is true, or by the environment variables (i.e. NO_COLOR, or CLICOLOR=0).
+// The terminal that doesn't support colors (e.g. TERM=dumb) is detected already.
+func SetTheme(theme string, noColor bool) {
+	if noColor || termenv.EnvNoColor() {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	if theme == ThemeHighContrast {
+		highContrast = true
+		Indigo = hcAccent
+		SubtleIndigo = hcSubtle
+		Cream = hcOnAccent
+		Text = hcText
+		Subdued = hcSubdued
+		Red = hcError
+	}
+	setStyles()
+}
+
+// ListStyles returns the list styles for the theme, based on the default ones.
+func ListStyles(s list.Styles) list.Styles {
+	s.Title = SubtitleStyle
+	if !highContrast {
+		return s
+	}
+	s.FilterPrompt = s.FilterPrompt.Copy().Foreground(hcAccent)
+	s.FilterCursor = s.FilterCursor.Copy().Foreground(hcAccent)
+	s.StatusBar = s.StatusBar.Copy().Foreground(hcSubdued)
+	s.HelpStyle = s.HelpStyle.Copy().Foreground(hcSubdued)
+	return s
+}
+
+// ListItemStyles returns the list item styles for the theme, based on the default ones.
+func ListItemStyles(s list.DefaultItemStyles) list.DefaultItemStyles {
+	if !highContrast {
+		return s
+	}
+	s.NormalTitle = s.NormalTitle.Copy().Foreground(hcText)
+	s.NormalDesc = s.NormalDesc.Copy().Foreground(hcSubdued)
+	s.SelectedTitle = s.SelectedTitle.Copy().Foreground(hcAccent).BorderForeground(hcAccent)
+	s.SelectedDesc = s.SelectedDesc.Copy().Foreground(hcAccent).BorderForeground(hcAccent)
+	s.DimmedTitle = s.DimmedTitle.Copy().Foreground(hcSubdued)
+	s.DimmedDesc = s.DimmedDesc.Copy().Foreground(hcSubdued)
+	return s
+}
+
+// ProgressBarOptions returns the options of the progress bar for the theme.
+func ProgressBarOptions() []prog.Option {
+	opts := []prog.Option{prog.WithColorProfile(lipgloss.ColorProfile())}
+	if !highContrast {
+		return append(opts, prog.WithDefaultGradient())
+	}
+	color := hcAccent.Light
+	if lipgloss.HasDarkBackground() {
+		color = hcAccent.Dark
+	}
+	return append(opts, prog.WithSolidFill(color))
+}
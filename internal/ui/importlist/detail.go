@@ -0,0 +1,75 @@
+package importlist
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/internal/ui/common"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// detailMinWidth is the min window width to show the detail pane aside the list.
+const detailMinWidth = 100
+
+func detailStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(common.SubtleIndigo).
+		Padding(0, 1)
+}
+
+// detailWidth returns the width of the detail pane (including the border) for the window width, which is zero if the pane can't be shown.
+func detailWidth(winWidth int) int {
+	if winWidth < detailMinWidth {
+		return 0
+	}
+	return winWidth / 3
+}
+
+// detailView renders the detail of the import item in the pane of the width (including the border).
+func detailView(v meta.ImportItem, width int) string {
+	const unknown = "(unknown)"
+	field := func(name, value string) string {
+		if value == "" {
+			value = unknown
+		}
+		return common.HintStyle.Render(name) + "\n" + value + "\n"
+	}
+
+	var armType, name string
+	if v.AzureResourceID != nil {
+		armType = v.AzureResourceID.TypeString()
+		if names := v.AzureResourceID.Names(); len(names) != 0 {
+			name = names[len(names)-1]
+		}
+	}
+	var location, apiVersion, portalURL string
+	tags := unknown
+	if d := v.Detail; d != nil {
+		location, apiVersion, portalURL = d.Location, d.APIVersion, d.PortalURL
+		if d.Tags != nil {
+			var l []string
+			for k, v := range d.Tags {
+				l = append(l, fmt.Sprintf("%s=%s", k, v))
+			}
+			sort.Strings(l)
+			tags = strings.Join(l, "\n")
+			if tags == "" {
+				tags = "(none)"
+			}
+		}
+	}
+
+	s := strings.Join([]string{
+		field("Name", name),
+		field("ARM type", armType),
+		field("API version", apiVersion),
+		field("Location", location),
+		field("Tags", tags),
+		field("Portal", portalURL),
+	}, "\n")
+	style := detailStyle()
+	return style.Width(width - style.GetHorizontalBorderSize()).Render(strings.TrimSuffix(s, "\n"))
+}
@@ -0,0 +1,108 @@
+package importlist
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+)
+
+// The files that the import list is exported to, which are written to the output directory.
+const (
+	exportCSVFileName  = "aztfexportImportList.csv"
+	exportJSONFileName = "aztfexportImportList.json"
+)
+
+// exportItem is the exported import item, which records the mapping edits and the status of the triage work done in the TUI.
+type exportItem struct {
+	AzureResourceId   string   `json:"azure_resource_id"`
+	AzureResourceType string   `json:"azure_resource_type"`
+	TFResourceAddress string   `json:"tf_resource_address,omitempty"`
+	Status            string   `json:"status"`
+	Error             string   `json:"error,omitempty"`
+	Recommendations   []string `json:"recommendations,omitempty"`
+}
+
+var exportCSVHeader = []string{"azure_resource_id", "azure_resource_type", "tf_resource_address", "status", "error", "recommendations"}
+
+func newExportItems(l meta.ImportList) []exportItem {
+	items := make([]exportItem, 0, len(l))
+	for _, v := range l {
+		item := exportItem{
+			AzureResourceId: v.TFResourceId,
+			Status:          itemStatus(v),
+			Recommendations: v.Recommendations,
+		}
+		if v.AzureResourceID != nil {
+			item.AzureResourceId = v.AzureResourceID.String()
+			item.AzureResourceType = v.AzureResourceID.TypeString()
+		}
+		if !v.Skip() {
+			item.TFResourceAddress = v.TFAddr.String()
+		}
+		switch {
+		case v.ValidateError != nil:
+			item.Error = v.ValidateError.Error()
+		case v.ImportError != nil:
+			item.Error = v.ImportError.Error()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// marshalCSV marshals the import list as CSV, with a header row.
+func marshalCSV(l meta.ImportList) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(exportCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, item := range newExportItems(l) {
+		if err := w.Write([]string{item.AzureResourceId, item.AzureResourceType, item.TFResourceAddress, item.Status, item.Error, strings.Join(item.Recommendations, ";")}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalJSON marshals the import list as a JSON array.
+func marshalJSON(l meta.ImportList) ([]byte, error) {
+	return json.MarshalIndent(newExportItems(l), "", "  ")
+}
+
+// exportList exports the import list (with the statuses) to the file of the format (either "csv" or "json") under the dir, which returns the file path.
+func exportList(dir string, l meta.ImportList, format string) (string, error) {
+	var (
+		b    []byte
+		err  error
+		path string
+	)
+	switch format {
+	case "csv":
+		path = filepath.Join(dir, exportCSVFileName)
+		b, err = marshalCSV(l)
+	case "json":
+		path = filepath.Join(dir, exportJSONFileName)
+		b, err = marshalJSON(l)
+	default:
+		return "", fmt.Errorf("unknown export format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("marshalling the import list as %s: %v", format, err)
+	}
+	// #nosec G306
+	if err := os.WriteFile(path, b, 0644); err != nil {
+		return "", fmt.Errorf("writing the import list to %s: %v", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,55 @@
+package importlist
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportList(t *testing.T) {
+	rg := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	newItem := func(id string, addr tfaddr.TFAddr) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: addr}
+	}
+	vnet := newItem(rg+"/providers/Microsoft.Network/virtualNetworks/vnet", tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-0"})
+	vnet.Imported = true
+	subnet := newItem(rg+"/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet", tfaddr.TFAddr{Type: "azurerm_subnet", Name: "res-1"})
+	subnet.ImportError = errors.New("import failed, \"quoted\"")
+	sa := newItem(rg+"/providers/Microsoft.Storage/storageAccounts/sa", tfaddr.TFAddr{})
+	sa.Recommendations = []string{"azurerm_storage_account", "azapi_resource"}
+	l := meta.ImportList{vnet, subnet, sa}
+
+	dir := t.TempDir()
+	path, err := exportList(dir, l, "csv")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, exportCSVFileName), path)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `azure_resource_id,azure_resource_type,tf_resource_address,status,error,recommendations
+`+rg+`/providers/Microsoft.Network/virtualNetworks/vnet,Microsoft.Network/virtualNetworks,azurerm_virtual_network.res-0,imported,,
+`+rg+`/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet,Microsoft.Network/virtualNetworks/subnets,azurerm_subnet.res-1,failed,"import failed, ""quoted""",
+`+rg+`/providers/Microsoft.Storage/storageAccounts/sa,Microsoft.Storage/storageAccounts,,skipped,,azurerm_storage_account;azapi_resource
+`, string(b))
+
+	path, err = exportList(dir, l, "json")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, exportJSONFileName), path)
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	var items []exportItem
+	require.NoError(t, json.Unmarshal(b, &items))
+	require.Equal(t, newExportItems(l), items)
+	require.Equal(t, "failed", items[1].Status)
+
+	_, err = exportList(dir, l, "yaml")
+	require.Error(t, err)
+}
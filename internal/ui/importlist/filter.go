@@ -0,0 +1,127 @@
+package importlist
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// The fields of the filter value, which are separated by the filterFieldSep.
+// The first field is aligned with the item title, so that the matches on it can be highlighted.
+const (
+	filterFieldTitle = iota
+	filterFieldName
+	filterFieldARMType
+	filterFieldTFType
+	filterFieldStatus
+)
+
+const filterFieldSep = "\t"
+
+// filterQualifiers maps the qualifiers of the filter terms (e.g. "type:") to the fields to match.
+var filterQualifiers = map[string]int{
+	"name":   filterFieldName,
+	"type":   filterFieldARMType,
+	"tf":     filterFieldTFType,
+	"status": filterFieldStatus,
+}
+
+// filterPlaceholder hints the filter syntax in the filter box.
+const filterPlaceholder = "<id regexp> name:<regexp> type:<arm type> tf:<tf type> status:imported|failed|invalid|skipped|recommended|pending"
+
+// itemStatus returns the status of the import item, which can be filtered by the "status:" qualifier.
+func itemStatus(v meta.ImportItem) string {
+	switch {
+	case v.ValidateError != nil:
+		return "invalid"
+	case v.ImportError != nil:
+		return "failed"
+	case v.Imported:
+		return "imported"
+	case v.Skip():
+		return "skipped"
+	case v.IsRecommended:
+		return "recommended"
+	default:
+		return "pending"
+	}
+}
+
+// itemFilterValue returns the filter value of the import item, where the title field is the TF resource id with the prefix aligned with the emoji of the title.
+func itemFilterValue(v meta.ImportItem, prefix string) string {
+	fields := make([]string, filterFieldStatus+1)
+	fields[filterFieldTitle] = prefix + v.TFResourceId
+	if v.AzureResourceID != nil {
+		if names := v.AzureResourceID.Names(); len(names) != 0 {
+			fields[filterFieldName] = names[len(names)-1]
+		}
+		fields[filterFieldARMType] = v.AzureResourceID.TypeString()
+	}
+	fields[filterFieldTFType] = v.TFAddr.Type
+	fields[filterFieldStatus] = itemStatus(v)
+	return strings.Join(fields, filterFieldSep)
+}
+
+type filterTerm struct {
+	field int
+	p     *regexp.Regexp
+}
+
+// parseFilter parses the space separated terms of the filter, which are all required to match. A term is either a regexp matching the TF resource id,
+// or a qualified one (e.g. "type:virtualNetworks") matching the specified field case insensitively.
+func parseFilter(filter string) ([]filterTerm, error) {
+	var terms []filterTerm
+	for _, s := range strings.Fields(filter) {
+		field := filterFieldTitle
+		if k, v, ok := strings.Cut(s, ":"); ok {
+			if f, ok := filterQualifiers[strings.ToLower(k)]; ok {
+				field = f
+				s = "(?i)" + v
+			}
+		}
+		p, err := regexp.Compile(s)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, filterTerm{field: field, p: p})
+	}
+	return terms, nil
+}
+
+// filterItems implements the list.FilterFunc, where the targets are the filter values of the items. Only the matches on the TF resource id are highlighted.
+func filterItems(filter string, targets []string) []list.Rank {
+	terms, err := parseFilter(filter)
+	if err != nil {
+		return nil
+	}
+	result := []list.Rank{}
+	for idx, tgt := range targets {
+		fields := strings.Split(tgt, filterFieldSep)
+		rnk := list.Rank{
+			Index: idx,
+		}
+		matched := true
+		for _, term := range terms {
+			if term.field >= len(fields) {
+				matched = false
+				break
+			}
+			m := term.p.FindStringIndex(fields[term.field])
+			if m == nil {
+				matched = false
+				break
+			}
+			if term.field == filterFieldTitle {
+				for i := m[0]; i < m[1]; i++ {
+					rnk.MatchedIndexes = append(rnk.MatchedIndexes, i)
+				}
+			}
+		}
+		if matched {
+			result = append(result, rnk)
+		}
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package importlist
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterItems(t *testing.T) {
+	newItem := func(id, tftype string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		item := meta.ImportItem{AzureResourceID: azureId, TFResourceId: id}
+		if tftype != "" {
+			item.TFAddr = tfaddr.TFAddr{Type: tftype, Name: "res"}
+		}
+		return item
+	}
+	rg := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	vnet := newItem(rg+"/providers/Microsoft.Network/virtualNetworks/vnet", "azurerm_virtual_network")
+	vnet.Imported = true
+	subnet := newItem(rg+"/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet", "azurerm_subnet")
+	subnet.ImportError = errors.New("failed")
+	skipped := newItem(rg+"/providers/Microsoft.Storage/storageAccounts/sa", "")
+
+	var targets []string
+	for _, item := range []meta.ImportItem{vnet, subnet, skipped} {
+		targets = append(targets, Item{v: item}.FilterValue())
+	}
+
+	cases := []struct {
+		filter string
+		expect []int
+	}{
+		{filter: "vnet", expect: []int{0, 1}},
+		{filter: "name:VNET", expect: []int{0}},
+		{filter: "type:microsoft.network/", expect: []int{0, 1}},
+		{filter: "type:subnets$", expect: []int{1}},
+		{filter: "tf:^azurerm_subnet$", expect: []int{1}},
+		{filter: "status:failed", expect: []int{1}},
+		{filter: "status:skipped", expect: []int{2}},
+		{filter: "type:Microsoft.Network status:imported", expect: []int{0}},
+		{filter: "storage tf:azurerm", expect: []int{}},
+		{filter: "status:(", expect: nil},
+	}
+	for _, c := range cases {
+		t.Run(c.filter, func(t *testing.T) {
+			ranks := filterItems(c.filter, targets)
+			if c.expect == nil {
+				require.Nil(t, ranks)
+				return
+			}
+			idxs := []int{}
+			for _, rnk := range ranks {
+				idxs = append(idxs, rnk.Index)
+			}
+			require.Equal(t, c.expect, idxs)
+		})
+	}
+
+	// The matches on the TF resource id are highlighted, taking the emoji prefix of the title into account
+	ranks := filterItems("vnet status:imported", targets)
+	require.Len(t, ranks, 1)
+	require.NotEmpty(t, ranks[0].MatchedIndexes)
+	title := []rune(Item{v: vnet}.Title())
+	var matched string
+	for _, i := range ranks[0].MatchedIndexes {
+		matched += string(title[i])
+	}
+	require.Equal(t, "vnet", matched)
+}
@@ -0,0 +1,68 @@
+package importlist
+
+import (
+	"github.com/Azure/aztfexport/internal/tfaddr"
+)
+
+// maxHistory is the max count of the mapping edits that can be undone.
+const maxHistory = 100
+
+// mapping is the mapping of an import item that can be edited by the user.
+type mapping struct {
+	addr          tfaddr.TFAddr
+	addrCache     tfaddr.TFAddr
+	isRecommended bool
+	validateErr   error
+}
+
+// mappings are the mappings of all the import items, in the order of their indexes.
+type mappings []mapping
+
+func (ms mappings) equal(oms mappings) bool {
+	if len(ms) != len(oms) {
+		return false
+	}
+	for i := range ms {
+		if ms[i].addr != oms[i].addr || ms[i].addrCache != oms[i].addrCache || ms[i].isRecommended != oms[i].isRecommended || ms[i].validateErr != oms[i].validateErr {
+			return false
+		}
+	}
+	return true
+}
+
+// history records the mappings prior to each edit, so that the edits can be undone and redone.
+type history struct {
+	undos []mappings
+	redos []mappings
+}
+
+// record records the mappings prior to a new edit, which discards the edits that were undone.
+func (h *history) record(ms mappings) {
+	h.undos = append(h.undos, ms)
+	if len(h.undos) > maxHistory {
+		h.undos = h.undos[len(h.undos)-maxHistory:]
+	}
+	h.redos = nil
+}
+
+// undo returns the mappings prior to the last edit, given the current mappings. False is returned if there is nothing to undo.
+func (h *history) undo(cur mappings) (mappings, bool) {
+	if len(h.undos) == 0 {
+		return nil, false
+	}
+	ms := h.undos[len(h.undos)-1]
+	h.undos = h.undos[:len(h.undos)-1]
+	h.redos = append(h.redos, cur)
+	return ms, true
+}
+
+// redo returns the mappings after the last undone edit, given the current mappings. False is returned if there is nothing to redo.
+func (h *history) redo(cur mappings) (mappings, bool) {
+	if len(h.redos) == 0 {
+		return nil, false
+	}
+	ms := h.redos[len(h.redos)-1]
+	h.redos = h.redos[:len(h.redos)-1]
+	h.undos = append(h.undos, cur)
+	return ms, true
+}
@@ -0,0 +1,73 @@
+package importlist
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/magodo/textinput"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	var h history
+	_, ok := h.undo(nil)
+	require.False(t, ok)
+
+	m1 := mappings{{addr: tfaddr.TFAddr{Type: "a", Name: "res"}}}
+	m2 := mappings{{addr: tfaddr.TFAddr{Type: "b", Name: "res"}}}
+	m3 := mappings{{addr: tfaddr.TFAddr{Type: "c", Name: "res"}}}
+	h.record(m1)
+	h.record(m2)
+
+	ms, ok := h.undo(m3)
+	require.True(t, ok)
+	require.Equal(t, m2, ms)
+	ms, ok = h.redo(m2)
+	require.True(t, ok)
+	require.Equal(t, m3, ms)
+	_, ok = h.redo(m3)
+	require.False(t, ok)
+
+	// A new edit discards the undone edits
+	_, ok = h.undo(m3)
+	require.True(t, ok)
+	h.record(m2)
+	_, ok = h.redo(m1)
+	require.False(t, ok)
+
+	for i := 0; i < maxHistory+10; i++ {
+		h.record(m1)
+	}
+	require.Len(t, h.undos, maxHistory)
+}
+
+func TestModelUndoRedo(t *testing.T) {
+	addr := tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res-0"}
+	ti := textinput.NewModel()
+	ti.SetValue(addr.String())
+	item := Item{idx: 0, v: meta.ImportItem{TFResourceId: "/subscriptions/0/resourceGroups/rg", TFAddr: addr, TFAddrCache: addr}, textinput: ti}
+	m := Model{
+		listkeys: newListKeyMap(),
+		list:     list.NewModel([]list.Item{item}, list.NewDefaultDelegate(), 0, 0),
+	}
+
+	// Skip the item, then undo and redo it
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	require.True(t, m.importList(false)[0].Skip())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	require.Equal(t, addr, m.importList(false)[0].TFAddr)
+	require.Equal(t, addr.String(), m.list.Items()[0].(Item).textinput.Value())
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	require.True(t, m.importList(false)[0].Skip())
+	require.Equal(t, "", m.list.Items()[0].(Item).textinput.Value())
+
+	// Nothing more to redo
+	before := m.mappings()
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlY})
+	require.True(t, before.equal(m.mappings()))
+}
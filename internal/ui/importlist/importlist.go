@@ -3,8 +3,7 @@ package importlist
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"sort"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -17,10 +16,8 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/magodo/textinput"
-	"github.com/magodo/tfadd/providers/azapi"
-	"github.com/magodo/tfadd/providers/azurerm"
-	"github.com/magodo/tfadd/schema"
 )
 
 type Model struct {
@@ -29,24 +26,31 @@ type Model struct {
 	listkeys listKeyMap
 
 	list list.Model
+
+	// The window size, which is shared by the list and the detail pane
+	width  int
+	height int
+	// Whether to show the detail pane of the selected item aside the list
+	showDetail bool
+
+	history history
+	// The mappings prior to the user input (if in progress), which are recorded once the input is done, so that the input is undone as a whole.
+	inputBefore mappings
 }
 
 func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Model {
-	// Build candidate words for the textinput
-	var resourceSchemas map[string]*schema.Schema
-	switch c.ProviderName() {
-	case "azapi":
-		resourceSchemas = azapi.ProviderSchemaInfo.ResourceSchemas
-	case "azurerm":
-		resourceSchemas = azurerm.ProviderSchemaInfo.ResourceSchemas
-	}
-	candidates := make([]string, 0, len(resourceSchemas))
-	for rt := range resourceSchemas {
-		candidates = append(candidates, rt)
+	// Collect the TF resource types assigned to each ARM resource type, which are suggested for the other resources of the same ARM resource type
+	rts := resourceTypes(c.ProviderName())
+	assigned := map[string][]string{}
+	for _, item := range l {
+		if item.Skip() || item.AzureResourceID == nil {
+			continue
+		}
+		armType := strings.ToUpper(item.AzureResourceID.TypeString())
+		assigned[armType] = append(assigned[armType], item.TFAddr.Type)
 	}
-	sort.Strings(candidates)
 
-	// Build list items
+	// Build list items, whose candidate words for the textinput are set once focused
 	var items []list.Item
 	for idx, item := range l {
 		ti := textinput.NewModel()
@@ -54,40 +58,25 @@ func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Mode
 		if !item.Skip() {
 			ti.SetValue(item.TFAddr.String())
 		}
-		ti.CandidateWords = candidates
+		var armTypeAssigned []string
+		if item.AzureResourceID != nil {
+			armTypeAssigned = assigned[strings.ToUpper(item.AzureResourceID.TypeString())]
+		}
 		items = append(items, Item{
-			idx:       idx,
-			v:         item,
-			textinput: ti,
+			idx:         idx,
+			v:           item,
+			textinput:   ti,
+			suggestions: suggestTypes(item, armTypeAssigned, rts),
 		})
 	}
 
 	lst := list.NewModel(items, NewImportItemDelegate(c.ProviderName()), 0, 0)
 	lst.Title = " " + c.ScopeName() + " "
-	lst.Styles.Title = common.SubtitleStyle
+	lst.Styles = common.ListStyles(lst.Styles)
 	lst.StatusMessageLifetime = 3 * time.Second
 	lst.Select(idx)
-	lst.Filter = func(term string, targets []string) []list.Rank {
-		p, err := regexp.Compile(term)
-		if err != nil {
-			return nil
-		}
-		result := []list.Rank{}
-		for idx, tgt := range targets {
-			m := p.FindStringIndex(tgt)
-			if m == nil {
-				continue
-			}
-			rnk := list.Rank{
-				Index: idx,
-			}
-			for i := m[0]; i < m[1]; i++ {
-				rnk.MatchedIndexes = append(rnk.MatchedIndexes, i)
-			}
-			result = append(result, rnk)
-		}
-		return result
-	}
+	lst.Filter = filterItems
+	lst.FilterInput.Placeholder = filterPlaceholder
 
 	bindKeyHelps(&lst, newListKeyMap().ToBindings())
 
@@ -98,10 +87,11 @@ func NewModel(ctx context.Context, c meta.Meta, l meta.ImportList, idx int) Mode
 	)
 
 	return Model{
-		ctx:      ctx,
-		c:        c,
-		listkeys: newListKeyMap(),
-		list:     lst,
+		ctx:        ctx,
+		c:          c,
+		listkeys:   newListKeyMap(),
+		list:       lst,
+		showDetail: true,
 	}
 }
 
@@ -109,7 +99,46 @@ func (m Model) Init() tea.Cmd {
 	return nil
 }
 
+// Update records the mappings prior to each edit (e.g. skip, or the user input), so that the edits can be undone and redone.
+// Note that the import statuses (e.g. the imported resource is cleaned up from the state on editing) are not restored on undo.
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && !m.isUserTyping() {
+		switch {
+		case key.Matches(msg, m.listkeys.undo):
+			ms, ok := m.history.undo(m.mappings())
+			if !ok {
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render("Nothing to undo"))
+			}
+			m.setMappings(ms)
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render("Undone the last edit"))
+		case key.Matches(msg, m.listkeys.redo):
+			ms, ok := m.history.redo(m.mappings())
+			if !ok {
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render("Nothing to redo"))
+			}
+			m.setMappings(ms)
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render("Redone the last undone edit"))
+		}
+	}
+
+	before := m.mappings()
+	wasTyping := m.isUserTyping()
+	m, cmd := m.update(msg)
+	switch typing := m.isUserTyping(); {
+	case !wasTyping && typing:
+		m.inputBefore = before
+	case wasTyping && !typing:
+		if m.inputBefore != nil && !m.inputBefore.equal(m.mappings()) {
+			m.history.record(m.inputBefore)
+		}
+		m.inputBefore = nil
+	case !typing && !before.equal(m.mappings()):
+		m.history.record(before)
+	}
+	return m, cmd
+}
+
+func (m Model) update(msg tea.Msg) (Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
@@ -136,7 +165,39 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("One or more user input is invalid"))
 			}
 
-			return m, aztfexportclient.StartImport(m.importList(true))
+			// Only import the selected items (e.g. the failed ones to retry) if any, whose import errors are cleared
+			if !m.hasSelection() {
+				return m, aztfexportclient.StartImport(m.importList(true), nil)
+			}
+			selected := m.selectedIndexes()
+			if len(selected) == 0 {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("The selected resources are either imported or skipped, nothing to import"))
+			}
+			l := m.importList(false)
+			for _, idx := range selected {
+				l[idx].ImportError = nil
+			}
+			return m, aztfexportclient.StartImport(l, selected)
+		case key.Matches(msg, m.listkeys.selectItem):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			selItem.selected = !selItem.selected
+			m.list.SetItem(selItem.idx, selItem)
+			return m, nil
+		case key.Matches(msg, m.listkeys.selectFailed):
+			var n int
+			for _, item := range m.list.Items() {
+				item := item.(Item)
+				if item.v.ImportError != nil {
+					item.selected = true
+					m.list.SetItem(item.idx, item)
+					n++
+				}
+			}
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render(fmt.Sprintf("Selected %d failed resource(s)", n)))
 		case key.Matches(msg, m.listkeys.skip):
 			sel := m.list.SelectedItem()
 			if sel == nil {
@@ -154,6 +215,16 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 
 			m.list.SetItem(selItem.idx, selItem)
 			return m, nil
+		case key.Matches(msg, m.listkeys.applyType):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			if selItem.v.ValidateError != nil {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("The user input is invalid"))
+			}
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render(m.applyToARMType(selItem)))
 		case key.Matches(msg, m.listkeys.error):
 			sel := m.list.SelectedItem()
 			if sel == nil {
@@ -175,27 +246,78 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				return m, m.list.NewStatusMessage(common.InfoStyle.Render("No resource type recommendation is available..."))
 			}
 			return m, m.list.NewStatusMessage(common.InfoStyle.Render(fmt.Sprintf("Possible resource type(s): %s", strings.Join(selItem.v.Recommendations, ","))))
+		case key.Matches(msg, m.listkeys.preview):
+			sel := m.list.SelectedItem()
+			if sel == nil {
+				return m, nil
+			}
+			selItem := sel.(Item)
+			if selItem.v.Skip() {
+				return m, m.list.NewStatusMessage(common.InfoStyle.Render("The resource is skipped, nothing to preview"))
+			}
+			if selItem.v.ValidateError != nil {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render("The user input is invalid"))
+			}
+			return m, aztfexportclient.StartPreview(selItem.v, selItem.idx, m.importList(false))
+		case key.Matches(msg, m.listkeys.detail):
+			m.showDetail = !m.showDetail
+			m.setSize()
+			return m, nil
 		case key.Matches(msg, m.listkeys.save):
 			m.list.NewStatusMessage(common.InfoStyle.Render("Saving the resouce mapping..."))
 			err := m.c.ExportResourceMapping(m.ctx, m.importList(false))
 			if err == nil {
-				m.list.NewStatusMessage(common.InfoStyle.Render("Resource mapping saved"))
+				m.list.NewStatusMessage(common.InfoStyle.Render("Resource mapping saved to " + filepath.Join(m.c.Workspace(), meta.ResourceMappingFileName)))
 			} else {
 				m.list.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error()))
 			}
+		case key.Matches(msg, m.listkeys.exportCSV), key.Matches(msg, m.listkeys.exportJSON):
+			format := "csv"
+			if key.Matches(msg, m.listkeys.exportJSON) {
+				format = "json"
+			}
+			path, err := exportList(m.c.Workspace(), m.importList(false), format)
+			if err != nil {
+				return m, m.list.NewStatusMessage(common.ErrorMsgStyle.Render(err.Error()))
+			}
+			return m, m.list.NewStatusMessage(common.InfoStyle.Render("Import list exported to " + path))
+		case key.Matches(msg, m.listkeys.saveSession):
+			// Leave filter applied state before saving the import list
+			if m.list.FilterState() == list.FilterApplied {
+				m.list.ResetFilter()
+			}
+			return m, aztfexportclient.SaveSession(m.ctx, m.c, m.importList(false))
 		case key.Matches(msg, m.list.KeyMap.Quit):
 			return m, aztfexportclient.Quit(m.ctx, m.c)
 		}
 	case tea.WindowSizeMsg:
-		// The height here minus the height occupied by the title
-		m.list.SetSize(msg.Width, msg.Height-3)
+		m.width, m.height = msg.Width, msg.Height
+		m.setSize()
 	}
 	m.list, cmd = m.list.Update(msg)
 	return m, cmd
 }
 
 func (m Model) View() string {
-	return m.list.View()
+	w := m.detailWidth()
+	sel := m.list.SelectedItem()
+	if w == 0 || sel == nil {
+		return m.list.View()
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.list.View(), detailView(sel.(Item).v, w))
+}
+
+// detailWidth returns the width of the detail pane, which is zero if it is not shown.
+func (m Model) detailWidth() int {
+	if !m.showDetail {
+		return 0
+	}
+	return detailWidth(m.width)
+}
+
+func (m *Model) setSize() {
+	// The height here minus the height occupied by the title
+	m.list.SetSize(m.width-m.detailWidth(), m.height-3)
 }
 
 func bindKeyHelps(l *list.Model, bindings []key.Binding) {
@@ -243,6 +365,117 @@ func (m Model) userInputsAreValid() bool {
 	return true
 }
 
+// applyToARMType applies the decision of the selected item (i.e. either the TF resource type or skip) to the other items of the same ARM resource type,
+// except the imported ones. The items keep their own TF resource names, which are validated to be unique among the TF resource type.
+// It returns the message for the status bar.
+func (m *Model) applyToARMType(sel Item) string {
+	if sel.v.AzureResourceID == nil {
+		return "No ARM resource type is available..."
+	}
+	armType := sel.v.AzureResourceID.TypeString()
+	tfType := sel.v.TFAddr.Type
+
+	tfNames := map[string]bool{}
+	var targets []Item
+	for _, item := range m.list.Items() {
+		item := item.(Item)
+		if item.idx != sel.idx && !item.v.Imported && item.v.AzureResourceID != nil && strings.EqualFold(item.v.AzureResourceID.TypeString(), armType) {
+			targets = append(targets, item)
+			continue
+		}
+		if !item.v.Skip() && item.v.TFAddr.Type == tfType {
+			tfNames[item.v.TFAddr.Name] = true
+		}
+	}
+
+	var n int
+	for _, item := range targets {
+		if tfType == "" {
+			item.v.TFAddr = tfaddr.TFAddr{}
+			item.textinput.Model.SetValue("")
+		} else {
+			name := item.v.TFAddr.Name
+			if name == "" {
+				name = item.v.TFAddrCache.Name
+			}
+			if name == "" {
+				continue
+			}
+			addr := tfaddr.TFAddr{Type: tfType, Name: name}
+			item.v.ValidateError = nil
+			if tfNames[name] {
+				item.v.ValidateError = fmt.Errorf("%q already exists", addr)
+			}
+			tfNames[name] = true
+			item.v.IsRecommended = false
+			item.v.TFAddr = addr
+			item.v.TFAddrCache = addr
+			item.textinput.Model.SetValue(addr.String())
+		}
+		m.list.SetItem(item.idx, item)
+		n++
+	}
+
+	if tfType == "" {
+		return fmt.Sprintf("Skipped %d more resource(s) of %s", n, armType)
+	}
+	return fmt.Sprintf("Applied %s to %d more resource(s) of %s", tfType, n, armType)
+}
+
+func (m Model) mappings() mappings {
+	out := make(mappings, 0, len(m.list.Items()))
+	for _, item := range m.list.Items() {
+		v := item.(Item).v
+		out = append(out, mapping{
+			addr:          v.TFAddr,
+			addrCache:     v.TFAddrCache,
+			isRecommended: v.IsRecommended,
+			validateErr:   v.ValidateError,
+		})
+	}
+	return out
+}
+
+func (m *Model) setMappings(ms mappings) {
+	for i, item := range m.list.Items() {
+		if i >= len(ms) {
+			break
+		}
+		item := item.(Item)
+		item.v.TFAddr = ms[i].addr
+		item.v.TFAddrCache = ms[i].addrCache
+		item.v.IsRecommended = ms[i].isRecommended
+		item.v.ValidateError = ms[i].validateErr
+		if item.v.Skip() {
+			item.textinput.Model.SetValue("")
+		} else {
+			item.textinput.Model.SetValue(item.v.TFAddr.String())
+		}
+		m.list.SetItem(item.idx, item)
+	}
+}
+
+func (m Model) hasSelection() bool {
+	for _, item := range m.list.Items() {
+		if item.(Item).selected {
+			return true
+		}
+	}
+	return false
+}
+
+// selectedIndexes returns the indexes of the selected items to import, i.e. neither imported nor skipped.
+func (m Model) selectedIndexes() []int {
+	var out []int
+	for _, item := range m.list.Items() {
+		item := item.(Item)
+		if item.selected && !item.v.Imported && !item.v.Skip() {
+			out = append(out, item.idx)
+		}
+	}
+	return out
+}
+
 func (m Model) importList(clearErr bool) meta.ImportList {
 	out := make(meta.ImportList, 0, len(m.list.Items()))
 	for _, item := range m.list.Items() {
@@ -16,7 +16,9 @@ import (
 )
 
 func NewImportItemDelegate(providerName string) list.ItemDelegate {
+	rts := resourceTypes(providerName)
 	d := list.NewDefaultDelegate()
+	d.Styles = common.ListItemStyles(d.Styles)
 	d.UpdateFunc = func(msg tea.Msg, m *list.Model) (ret tea.Cmd) {
 		sel := m.SelectedItem()
 		if sel == nil {
@@ -53,6 +55,13 @@ func NewImportItemDelegate(providerName string) list.ItemDelegate {
 					// Clear the is recommended flag that were set.
 					selItem.v.IsRecommended = false
 
+					// Auto-complete the resource type with the suggested ones first, keeping the resource name
+					_, name, _ := strings.Cut(selItem.textinput.Value(), ".")
+					if name == "" {
+						name = selItem.v.TFAddrCache.Name
+					}
+					selItem.textinput.CandidateWords = candidateWords(selItem.suggestions, rts, name)
+
 					// "Enter" focus current selected item
 					setListKeyMapEnabled(m, false)
 					cmd := selItem.textinput.Focus()
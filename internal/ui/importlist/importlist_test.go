@@ -0,0 +1,84 @@
+package importlist
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/magodo/armid"
+	"github.com/magodo/textinput"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyToARMType(t *testing.T) {
+	rg := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+	newItem := func(idx int, id string, addr tfaddr.TFAddr) Item {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		ti := textinput.NewModel()
+		ti.SetValue(addr.String())
+		return Item{
+			idx:       idx,
+			v:         meta.ImportItem{AzureResourceID: azureId, TFResourceId: id, TFAddr: addr, TFAddrCache: addr},
+			textinput: ti,
+		}
+	}
+	items := []Item{
+		newItem(0, rg+"/providers/Microsoft.Compute/virtualMachines/vm1", tfaddr.TFAddr{Type: "azurerm_linux_virtual_machine", Name: "res-0"}),
+		newItem(1, rg+"/providers/Microsoft.Compute/virtualMachines/vm2", tfaddr.TFAddr{Name: "res-1"}),
+		newItem(2, rg+"/providers/microsoft.compute/virtualmachines/vm3", tfaddr.TFAddr{Type: "azurerm_windows_virtual_machine", Name: "res-0"}),
+		newItem(3, rg+"/providers/Microsoft.Compute/virtualMachines/vm4", tfaddr.TFAddr{Type: "azurerm_windows_virtual_machine", Name: "res-3"}),
+		newItem(4, rg+"/providers/Microsoft.Network/virtualNetworks/vnet", tfaddr.TFAddr{Type: "azurerm_virtual_network", Name: "res-4"}),
+	}
+	items[3].v.Imported = true
+	var litems []list.Item
+	for _, item := range items {
+		litems = append(litems, item)
+	}
+	m := Model{list: list.NewModel(litems, list.NewDefaultDelegate(), 0, 0)}
+
+	msg := m.applyToARMType(items[0])
+	require.Equal(t, "Applied azurerm_linux_virtual_machine to 2 more resource(s) of Microsoft.Compute/virtualMachines", msg)
+	got := m.importList(false)
+	require.Equal(t, "azurerm_linux_virtual_machine.res-1", got[1].TFAddr.String())
+	require.Equal(t, "azurerm_linux_virtual_machine.res-1", m.list.Items()[1].(Item).textinput.Value())
+	require.NoError(t, got[1].ValidateError)
+	// The name conflicts with the selected item
+	require.Equal(t, "azurerm_linux_virtual_machine.res-0", got[2].TFAddr.String())
+	require.Error(t, got[2].ValidateError)
+	// The imported item is untouched
+	require.Equal(t, "azurerm_windows_virtual_machine.res-3", got[3].TFAddr.String())
+	// The item of another ARM type is untouched
+	require.Equal(t, "azurerm_virtual_network.res-4", got[4].TFAddr.String())
+
+	// Skip is applied as well
+	skipped := m.list.Items()[1].(Item)
+	skipped.v.TFAddr = tfaddr.TFAddr{}
+	msg = m.applyToARMType(skipped)
+	require.Equal(t, "Skipped 2 more resource(s) of Microsoft.Compute/virtualMachines", msg)
+	got = m.importList(false)
+	require.True(t, got[0].Skip())
+	require.True(t, got[2].Skip())
+	require.False(t, got[3].Skip())
+	require.Equal(t, "azurerm_linux_virtual_machine.res-0", got[0].TFAddrCache.String())
+}
+
+func TestSelectedIndexes(t *testing.T) {
+	newItem := func(idx int, v meta.ImportItem, selected bool) list.Item {
+		return Item{idx: idx, v: v, selected: selected}
+	}
+	addr := tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res"}
+	m := Model{list: list.NewModel([]list.Item{
+		newItem(0, meta.ImportItem{TFAddr: addr, Imported: true}, true),
+		newItem(1, meta.ImportItem{}, true),
+		newItem(2, meta.ImportItem{TFAddr: addr}, true),
+		newItem(3, meta.ImportItem{TFAddr: addr}, false),
+	}, list.NewDefaultDelegate(), 0, 0)}
+	require.True(t, m.hasSelection())
+	// The imported and the skipped items are not imported, even selected
+	require.Equal(t, []int{2}, m.selectedIndexes())
+
+	m = Model{list: list.NewModel([]list.Item{newItem(0, meta.ImportItem{TFAddr: addr}, false)}, list.NewDefaultDelegate(), 0, 0)}
+	require.False(t, m.hasSelection())
+}
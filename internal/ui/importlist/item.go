@@ -1,6 +1,8 @@
 package importlist
 
 import (
+	"strings"
+
 	"github.com/Azure/aztfexport/internal/ui/common"
 	"github.com/Azure/aztfexport/pkg/meta"
 	"github.com/magodo/textinput"
@@ -10,6 +12,10 @@ type Item struct {
 	idx       int
 	v         meta.ImportItem
 	textinput textinput.Model
+	// The TF resource types that are most likely for this item
+	suggestions []string
+	// Whether this item is selected to import, only the selected items are imported if any
+	selected bool
 }
 
 func (i Item) Title() string {
@@ -30,17 +36,24 @@ func (i Item) Title() string {
 
 func (i Item) Description() string {
 	if i.textinput.Focused() {
+		if hints := hintSuggestions(i.suggestions, i.textinput.Value()); len(hints) != 0 {
+			return i.textinput.View() + "  " + common.HintStyle.Render("tab: "+strings.Join(hints, " | "))
+		}
 		return i.textinput.View()
 	}
+	mark := ""
+	if i.selected {
+		mark = common.HintStyle.Render("[selected] ")
+	}
 	if i.v.Skip() {
-		return "(Skip)"
+		return mark + "(Skip)"
 	}
-	return i.textinput.Value()
+	return mark + i.textinput.Value()
 }
 
 func (i Item) FilterValue() string {
 	if i.v.ValidateError == nil && i.v.ImportError == nil && !i.v.Imported && !i.v.IsRecommended {
-		return i.v.TFResourceId
+		return itemFilterValue(i.v, "")
 	}
-	return " " + i.v.TFResourceId
+	return itemFilterValue(i.v, " ")
 }
@@ -4,10 +4,20 @@ import "github.com/charmbracelet/bubbles/key"
 
 type listKeyMap struct {
 	skip           key.Binding
+	applyType      key.Binding
+	selectItem     key.Binding
+	selectFailed   key.Binding
 	error          key.Binding
 	recommendation key.Binding
+	preview        key.Binding
+	detail         key.Binding
 	apply          key.Binding
 	save           key.Binding
+	saveSession    key.Binding
+	exportCSV      key.Binding
+	exportJSON     key.Binding
+	undo           key.Binding
+	redo           key.Binding
 }
 
 func newListKeyMap() listKeyMap {
@@ -16,6 +26,18 @@ func newListKeyMap() listKeyMap {
 			key.WithKeys("delete"),
 			key.WithHelp("delete", "skip"),
 		),
+		applyType: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "apply to same ARM type"),
+		),
+		selectItem: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "select"),
+		),
+		selectFailed: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "select failed"),
+		),
 		error: key.NewBinding(
 			key.WithKeys("e"),
 			key.WithHelp("e", "show error"),
@@ -24,6 +46,14 @@ func newListKeyMap() listKeyMap {
 			key.WithKeys("r"),
 			key.WithHelp("r", "show recommendation"),
 		),
+		preview: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "preview config"),
+		),
+		detail: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "toggle detail"),
+		),
 		apply: key.NewBinding(
 			key.WithKeys("w"),
 			key.WithHelp("w", "import"),
@@ -32,15 +62,45 @@ func newListKeyMap() listKeyMap {
 			key.WithKeys("s"),
 			key.WithHelp("s", "save"),
 		),
+		saveSession: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "save session & quit"),
+		),
+		exportCSV: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "export list as CSV"),
+		),
+		exportJSON: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "export list as JSON"),
+		),
+		undo: key.NewBinding(
+			key.WithKeys("ctrl+z"),
+			key.WithHelp("ctrl+z", "undo"),
+		),
+		redo: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "redo"),
+		),
 	}
 }
 
 func (m listKeyMap) ToBindings() []key.Binding {
 	return []key.Binding{
 		m.skip,
+		m.applyType,
+		m.selectItem,
+		m.selectFailed,
 		m.error,
 		m.recommendation,
+		m.preview,
+		m.detail,
 		m.apply,
 		m.save,
+		m.saveSession,
+		m.exportCSV,
+		m.exportJSON,
+		m.undo,
+		m.redo,
 	}
 }
@@ -0,0 +1,166 @@
+package importlist
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/tfadd/providers/azapi"
+	"github.com/magodo/tfadd/providers/azurerm"
+	"github.com/magodo/tfadd/schema"
+)
+
+// maxSuggestions is the max count of the TF resource types suggested for an import item.
+const maxSuggestions = 10
+
+// maxHintSuggestions is the max count of the suggestions shown aside the text input.
+const maxHintSuggestions = 3
+
+// resourceTypes returns the known resource types of the provider in alphabetical order.
+func resourceTypes(providerName string) []string {
+	var resourceSchemas map[string]*schema.Schema
+	switch providerName {
+	case "azapi":
+		resourceSchemas = azapi.ProviderSchemaInfo.ResourceSchemas
+	case "azurerm":
+		resourceSchemas = azurerm.ProviderSchemaInfo.ResourceSchemas
+	}
+	rts := make([]string, 0, len(resourceSchemas))
+	for rt := range resourceSchemas {
+		rts = append(rts, rt)
+	}
+	sort.Strings(rts)
+	return rts
+}
+
+var camelCaseBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// singular returns the singular form of the (lower cased) word, e.g. "policies" -> "policy", "machines" -> "machine".
+func singular(w string) string {
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		return strings.TrimSuffix(w, "ies") + "y"
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && len(w) > 3:
+		return strings.TrimSuffix(w, "s")
+	}
+	return w
+}
+
+// armTypeWords returns the words of the ARM resource type, i.e. the words of the provider namespace (without "Microsoft.") and the last resource type,
+// e.g. "Microsoft.Compute/virtualMachines" -> ["compute", "virtual", "machine"].
+func armTypeWords(armType string) []string {
+	segs := strings.Split(armType, "/")
+	ns := strings.TrimPrefix(strings.ToLower(segs[0]), "microsoft.")
+	words := []string{ns}
+	if len(segs) > 1 {
+		for _, w := range strings.Fields(camelCaseBoundary.ReplaceAllString(segs[len(segs)-1], "$1 $2")) {
+			words = append(words, singular(strings.ToLower(w)))
+		}
+	}
+	return words
+}
+
+// suggestTypes returns the TF resource types that are most likely for the import item, in the order of:
+// the recommendations, the TF resource types assigned to the other items of the same ARM resource type, and the known resource types
+// that share the most words with the ARM resource type (the ones with less extra words first).
+func suggestTypes(item meta.ImportItem, assigned []string, rts []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(rt string) {
+		if rt == "" || seen[rt] || len(out) >= maxSuggestions {
+			return
+		}
+		seen[rt] = true
+		out = append(out, rt)
+	}
+	for _, rt := range item.Recommendations {
+		add(rt)
+	}
+	for _, rt := range assigned {
+		add(rt)
+	}
+	if item.AzureResourceID == nil {
+		return out
+	}
+
+	words := armTypeWords(item.AzureResourceID.TypeString())
+	type scored struct {
+		rt      string
+		score   int
+		nsMatch bool
+		extra   int
+	}
+	var l []scored
+	for _, rt := range rts {
+		segs := strings.Split(rt, "_")
+		if len(segs) < 2 {
+			continue
+		}
+		rtWords := map[string]bool{}
+		for _, w := range segs[1:] {
+			rtWords[singular(w)] = true
+		}
+		// The words of the resource type are scored, while the match on the namespace (e.g. "network") is too loose, which only breaks the tie.
+		var score int
+		for _, w := range words[1:] {
+			if rtWords[w] {
+				score++
+			}
+		}
+		nsMatch := rtWords[words[0]]
+		if score == 0 && (len(words) > 1 || !nsMatch) {
+			continue
+		}
+		extra := len(rtWords) - score
+		if nsMatch {
+			extra--
+		}
+		l = append(l, scored{rt: rt, score: score, nsMatch: nsMatch, extra: extra})
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].score != l[j].score {
+			return l[i].score > l[j].score
+		}
+		if l[i].extra != l[j].extra {
+			return l[i].extra < l[j].extra
+		}
+		return l[i].nsMatch && !l[j].nsMatch
+	})
+	for _, s := range l {
+		add(s.rt)
+	}
+	return out
+}
+
+// candidateWords returns the candidate words for the auto-completion of the text input, which are the suggested resource types followed by the rest known ones,
+// each with the TF resource name, so that the completed input is a valid resource address.
+func candidateWords(suggestions, rts []string, name string) []string {
+	out := make([]string, 0, len(rts))
+	seen := map[string]bool{}
+	for _, rt := range suggestions {
+		seen[rt] = true
+		out = append(out, rt+"."+name)
+	}
+	for _, rt := range rts {
+		if !seen[rt] {
+			out = append(out, rt+"."+name)
+		}
+	}
+	return out
+}
+
+// hintSuggestions returns the suggested resource types that match the resource type being input.
+func hintSuggestions(suggestions []string, input string) []string {
+	rt, _, _ := strings.Cut(strings.TrimSpace(input), ".")
+	var out []string
+	for _, s := range suggestions {
+		if s != rt && strings.HasPrefix(s, rt) {
+			out = append(out, s)
+		}
+		if len(out) == maxHintSuggestions {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package importlist
+
+import (
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/magodo/armid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArmTypeWords(t *testing.T) {
+	require.Equal(t, []string{"compute", "virtual", "machine"}, armTypeWords("Microsoft.Compute/virtualMachines"))
+	require.Equal(t, []string{"network", "subnet"}, armTypeWords("Microsoft.Network/virtualNetworks/subnets"))
+	require.Equal(t, []string{"authorization", "policy", "definition"}, armTypeWords("Microsoft.Authorization/policyDefinitions"))
+	require.Equal(t, []string{"resourcegroups"}, armTypeWords("resourceGroups"))
+}
+
+func TestSuggestTypes(t *testing.T) {
+	rts := resourceTypes("azurerm")
+	newItem := func(id string) meta.ImportItem {
+		azureId, err := armid.ParseResourceId(id)
+		require.NoError(t, err)
+		return meta.ImportItem{AzureResourceID: azureId, TFResourceId: id}
+	}
+	rg := "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/rg"
+
+	vm := newItem(rg + "/providers/Microsoft.Compute/virtualMachines/vm")
+	suggestions := suggestTypes(vm, nil, rts)
+	require.LessOrEqual(t, len(suggestions), maxSuggestions)
+	require.Contains(t, suggestions[:3], "azurerm_virtual_machine")
+	require.Contains(t, suggestions, "azurerm_linux_virtual_machine")
+	require.Contains(t, suggestions, "azurerm_windows_virtual_machine")
+
+	subnet := newItem(rg + "/providers/Microsoft.Network/virtualNetworks/vnet/subnets/subnet")
+	require.Equal(t, "azurerm_subnet", suggestTypes(subnet, nil, rts)[0])
+
+	// The recommendations and the assigned types go first
+	vm.Recommendations = []string{"azurerm_windows_virtual_machine"}
+	suggestions = suggestTypes(vm, []string{"azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine"}, rts)
+	require.Equal(t, []string{"azurerm_windows_virtual_machine", "azurerm_linux_virtual_machine"}, suggestions[:2])
+}
+
+func TestCandidateWords(t *testing.T) {
+	words := candidateWords([]string{"b"}, []string{"a", "b", "c"}, "res-0")
+	require.Equal(t, []string{"b.res-0", "a.res-0", "c.res-0"}, words)
+}
+
+func TestHintSuggestions(t *testing.T) {
+	suggestions := []string{"azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine", "azurerm_virtual_machine", "azurerm_virtual_machine_extension"}
+	require.Equal(t, suggestions[:maxHintSuggestions], hintSuggestions(suggestions, ""))
+	require.Equal(t, []string{"azurerm_virtual_machine", "azurerm_virtual_machine_extension"}, hintSuggestions(suggestions, "azurerm_vir.res-0"))
+	require.Equal(t, []string{"azurerm_virtual_machine_extension"}, hintSuggestions(suggestions, "azurerm_virtual_machine.res-0"))
+	require.Empty(t, hintSuggestions(suggestions, "azurerm_foo"))
+}
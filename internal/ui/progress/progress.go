@@ -3,61 +3,182 @@ package progress
 import (
 	"context"
 	"fmt"
+	"strings"
+
 	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/mitchellh/go-wordwrap"
 
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
 	"github.com/Azure/aztfexport/internal/ui/common"
+	"github.com/charmbracelet/bubbles/key"
 	prog "github.com/charmbracelet/bubbles/progress"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-type result struct {
-	item  meta.ImportItem
-	emoji string
+type status int
+
+const (
+	statusPending status = iota
+	statusRunning
+	statusSucceeded
+	statusFailed
+	statusSkipped
+)
+
+func (s status) String() string {
+	return [...]string{
+		"pending",
+		"running",
+		"succeeded",
+		"failed",
+		"skipped",
+	}[s]
+}
+
+// The height occupied by the views other than the table (e.g. the logo, the progress bar and the help).
+const nonTableHeight = 18
+
+// The min height of the table.
+const minTableHeight = 3
+
+type keyMap struct {
+	up    key.Binding
+	down  key.Binding
+	error key.Binding
+	back  key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		error: key.NewBinding(
+			key.WithKeys("enter", "e"),
+			key.WithHelp("enter", "show error"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("esc", "enter", "e"),
+			key.WithHelp("esc", "back"),
+		),
+	}
 }
 
 type Model struct {
 	ctx context.Context
 	c   meta.Meta
 	l   meta.ImportList
+	// The indexes of the items in l to be imported
+	pending []int
+	// The statuses of the pending items
+	statuses []status
+	// The emojis of the succeeded items
+	emojis []string
 
 	idx         int
 	parallelism int
 
-	results  []result
 	progress prog.Model
+	tracker  *common.ImportProgress
+	keys     keyMap
+
+	// The window size
+	width  int
+	height int
+	// The cursor of the table, which is the index of the pending items
+	cursor int
+	// The first row of the table to show
+	offset int
+	// Whether to show the error of the failed item under the cursor
+	showError bool
 }
 
-func NewModel(ctx context.Context, c meta.Meta, parallelism int, l meta.ImportList) Model {
+// NewModel returns the model that imports the items of the selected indexes in l, or all the items that are not imported yet if nothing is selected.
+func NewModel(ctx context.Context, c meta.Meta, parallelism int, l meta.ImportList, selected []int) Model {
+	pending := selected
+	if len(pending) == 0 {
+		for i, item := range l {
+			if !item.Imported {
+				pending = append(pending, i)
+			}
+		}
+	}
 	return Model{
 		ctx:         ctx,
 		c:           c,
 		l:           l,
+		pending:     pending,
+		statuses:    make([]status, len(pending)),
+		emojis:      make([]string, len(pending)),
 		idx:         0,
 		parallelism: parallelism,
-		results:     make([]result, common.ProgressShowLastResults),
-		progress:    prog.NewModel(prog.WithDefaultGradient()),
+		progress:    prog.NewModel(common.ProgressBarOptions()...),
+		tracker:     common.NewImportProgress(len(pending), 0),
+		keys:        newKeyMap(),
 	}
 }
 
-func (m Model) Init() tea.Cmd {
+func (m *Model) Init() tea.Cmd {
 	if m.iterationDone() {
 		return aztfexportclient.FinishImport(m.l)
 	}
+	return aztfexportclient.ImportItems(m.ctx, m.c, m.nextItems())
+}
 
+// nextItems returns the items of the next round to import, which are marked as running (unless skipped).
+func (m *Model) nextItems() []meta.ImportItem {
 	n := m.parallelism
-	if m.idx+m.parallelism > len(m.l) {
-		n = len(m.l) - m.idx
+	if m.idx+m.parallelism > len(m.pending) {
+		n = len(m.pending) - m.idx
 	}
-	return tea.Batch(
-		aztfexportclient.ImportItems(m.ctx, m.c, m.l[m.idx:m.idx+n]),
-	)
+	items := make([]meta.ImportItem, 0, n)
+	for i := m.idx; i < m.idx+n; i++ {
+		item := m.l[m.pending[i]]
+		if item.Skip() {
+			m.statuses[i] = statusSkipped
+		} else {
+			m.statuses[i] = statusRunning
+		}
+		items = append(items, item)
+	}
+	return items
 }
 
 func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.progress.Width = msg.Width - 4
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+		return m, nil
+	case tea.KeyMsg:
+		if m.showError {
+			if key.Matches(msg, m.keys.back) {
+				m.showError = false
+			}
+			return m, nil
+		}
+		switch {
+		case key.Matches(msg, m.keys.up):
+			if m.cursor > 0 {
+				m.cursor--
+			}
+			m.scroll()
+		case key.Matches(msg, m.keys.down):
+			if m.cursor < len(m.pending)-1 {
+				m.cursor++
+			}
+			m.scroll()
+		case key.Matches(msg, m.keys.error):
+			if len(m.pending) != 0 && m.statuses[m.cursor] == statusFailed {
+				m.showError = true
+			}
+		}
 		return m, nil
 	// FrameMsg is sent when the progress bar wants to animate itself
 	case prog.FrameMsg:
@@ -68,23 +189,23 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	case aztfexportclient.ImportItemsDoneMsg:
 		var cmds []tea.Cmd
 
-		// Update results
+		// Update statuses
 		items := msg.Items
 		for i := range items {
-			m.l[m.idx+i] = items[i]
-
-			emoji := common.RandomHappyEmoji()
-			if items[i].ImportError != nil {
-				emoji = common.WarningEmoji
-			}
-			res := result{
-				item:  items[i],
-				emoji: emoji,
+			m.l[m.pending[m.idx+i]] = items[i]
+			switch {
+			case items[i].Skip():
+				m.statuses[m.idx+i] = statusSkipped
+			case items[i].ImportError != nil:
+				m.statuses[m.idx+i] = statusFailed
+			default:
+				m.statuses[m.idx+i] = statusSucceeded
+				m.emojis[m.idx+i] = common.RandomHappyEmoji()
 			}
-			m.results = append(m.results[1:], res)
 		}
 
 		m.idx += m.parallelism
+		m.tracker.Record(len(items))
 
 		if m.iterationDone() {
 			cmds = append(cmds, m.progress.SetPercent(1))
@@ -92,13 +213,8 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			return m, tea.Batch(cmds...)
 		}
 
-		cmds = append(cmds, m.progress.SetPercent(float64(m.idx)/float64(len(m.l))))
-
-		n := m.parallelism
-		if m.idx+m.parallelism > len(m.l) {
-			n = len(m.l) - m.idx
-		}
-		cmds = append(cmds, aztfexportclient.ImportItems(m.ctx, m.c, m.l[m.idx:m.idx+n]))
+		cmds = append(cmds, m.progress.SetPercent(float64(m.idx)/float64(len(m.pending))))
+		cmds = append(cmds, aztfexportclient.ImportItems(m.ctx, m.c, m.nextItems()))
 		return m, tea.Batch(cmds...)
 
 	default:
@@ -106,10 +222,34 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	}
 }
 
+// tableHeight returns the count of the rows shown in the table.
+func (m Model) tableHeight() int {
+	h := m.height - nonTableHeight
+	if h < minTableHeight {
+		h = minTableHeight
+	}
+	return h
+}
+
+// scroll ensures the cursor is shown in the table.
+func (m *Model) scroll() {
+	h := m.tableHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
 func (m Model) View() string {
+	if m.showError {
+		return m.errorView()
+	}
+
 	msg := ""
-	if len(m.l) > m.idx {
-		item := m.l[m.idx]
+	if len(m.pending) > m.idx {
+		item := m.l[m.pending[m.idx]]
 		if item.Skip() {
 			msg = fmt.Sprintf(" Skipping %s...", item.TFResourceId)
 		} else {
@@ -118,29 +258,75 @@ func (m Model) View() string {
 	}
 
 	s := fmt.Sprintf(" %s\n\n", msg)
-	for _, res := range m.results {
-		// This indicates the state before the item is inserted as the to results.
-		if res.item.TFResourceId == "" {
-			s += "...\n"
-		} else {
-			switch {
-			case res.item.Skip():
-				s += fmt.Sprintf("%s %s skipped\n", res.emoji, res.item.TFResourceId)
-			default:
-				if res.item.ImportError == nil {
-					s += fmt.Sprintf("%s %s import successfully\n", res.emoji, res.item.TFResourceId)
-				} else {
-					s += fmt.Sprintf("%s %s import failed\n", res.emoji, res.item.TFResourceId)
-				}
-			}
+	s += " " + m.countsView() + "\n\n"
+	s += m.tableView()
+	s += "\n\n" + m.progress.View()
+	s += "\n\n " + m.tracker.String()
+	s += "\n\n " + common.QuitMsgStyle.Render(m.helpView())
+
+	return s
+}
+
+func (m Model) countsView() string {
+	counts := map[status]int{}
+	for _, st := range m.statuses {
+		counts[st]++
+	}
+	var l []string
+	for _, st := range []status{statusPending, statusRunning, statusSucceeded, statusFailed, statusSkipped} {
+		l = append(l, fmt.Sprintf("%s: %d", st, counts[st]))
+	}
+	return strings.Join(l, "  ")
+}
+
+func (m Model) tableView() string {
+	end := m.offset + m.tableHeight()
+	if end > len(m.pending) {
+		end = len(m.pending)
+	}
+	var rows []string
+	for i := m.offset; i < end; i++ {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+		row := fmt.Sprintf("%s%-9s %s", cursor, m.statuses[i], m.l[m.pending[i]].TFResourceId)
+		if m.emojis[i] != "" {
+			row += " " + m.emojis[i]
 		}
+		switch m.statuses[i] {
+		case statusFailed:
+			row = common.ErrorMsgStyle.Render(row)
+		case statusPending, statusSkipped:
+			row = common.QuitMsgStyle.Render(row)
+		}
+		rows = append(rows, row)
 	}
+	return strings.Join(rows, "\n")
+}
 
-	s += "\n\n" + m.progress.View()
+func (m Model) helpView() string {
+	l := []string{
+		m.keys.up.Help().Key + " " + m.keys.up.Help().Desc,
+		m.keys.down.Help().Key + " " + m.keys.down.Help().Desc,
+	}
+	if len(m.pending) != 0 && m.statuses[m.cursor] == statusFailed {
+		l = append(l, m.keys.error.Help().Key+" "+m.keys.error.Help().Desc)
+	}
+	return strings.Join(l, " • ")
+}
 
-	return s
+func (m Model) errorView() string {
+	item := m.l[m.pending[m.cursor]]
+	var errMsg string
+	if item.ImportError != nil {
+		errMsg = item.ImportError.Error()
+	}
+	// #nosec G115
+	s := " " + item.TFResourceId + "\n\n" + common.ErrorMsgStyle.Render(wordwrap.WrapString(errMsg, uint(m.width-4)))
+	return s + "\n\n " + common.QuitMsgStyle.Render(m.keys.back.Help().Key+" "+m.keys.back.Help().Desc)
 }
 
 func (m Model) iterationDone() bool {
-	return m.idx >= len(m.l)
+	return m.idx >= len(m.pending)
 }
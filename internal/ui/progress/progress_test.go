@@ -0,0 +1,63 @@
+package progress
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/aztfexport/internal/tfaddr"
+	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
+	"github.com/Azure/aztfexport/pkg/meta"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelStatuses(t *testing.T) {
+	addr := tfaddr.TFAddr{Type: "azurerm_resource_group", Name: "res"}
+	l := meta.ImportList{
+		{TFResourceId: "0", TFAddr: addr, Imported: true},
+		{TFResourceId: "1", TFAddr: addr},
+		{TFResourceId: "2"},
+		{TFResourceId: "3", TFAddr: addr},
+	}
+	m := NewModel(context.Background(), nil, 2, l, nil)
+	require.Equal(t, []int{1, 2, 3}, m.pending)
+
+	m.nextItems()
+	require.Equal(t, []status{statusRunning, statusSkipped, statusPending}, m.statuses)
+
+	done := []meta.ImportItem{l[1], l[2]}
+	done[0].ImportError = errors.New("import failed")
+	m, _ = m.Update(aztfexportclient.ImportItemsDoneMsg{Items: done})
+	require.Equal(t, []status{statusFailed, statusSkipped, statusRunning}, m.statuses)
+
+	// Only the failed item can be drilled down
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.False(t, m.showError)
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.True(t, m.showError)
+	require.Contains(t, m.View(), "import failed")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	require.False(t, m.showError)
+}
+
+func TestModelScroll(t *testing.T) {
+	var l meta.ImportList
+	for i := 0; i < 10; i++ {
+		l = append(l, meta.ImportItem{})
+	}
+	m := NewModel(context.Background(), nil, 1, l, nil)
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: nonTableHeight + 4})
+	for i := 0; i < 6; i++ {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	require.Equal(t, 6, m.cursor)
+	require.Equal(t, 3, m.offset)
+	for i := 0; i < 5; i++ {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	require.Equal(t, 1, m.cursor)
+	require.Equal(t, 1, m.offset)
+}
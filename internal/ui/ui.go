@@ -3,10 +3,13 @@ package ui
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/Azure/aztfexport/internal/config"
 	"github.com/Azure/aztfexport/internal/log"
 	internalmeta "github.com/Azure/aztfexport/internal/meta"
+	pkgconfig "github.com/Azure/aztfexport/pkg/config"
 	"github.com/Azure/aztfexport/pkg/meta"
 
 	"github.com/Azure/aztfexport/internal/ui/aztfexportclient"
@@ -25,6 +28,7 @@ import (
 const indentLevel = 2
 
 func NewProgram(ctx context.Context, cfg config.InteractiveModeConfig) (*tea.Program, error) {
+	common.SetTheme(cfg.Theme, cfg.NoColor)
 	m, err := newModel(ctx, cfg)
 	if err != nil {
 		return nil, err
@@ -37,15 +41,20 @@ type status int
 const (
 	statusInit status = iota
 	statusListingResource
+	statusLoadingSession
 	statusBuildingImportList
+	statusConfirmingImport
 	statusImporting
 	statusImportErrorMsg
+	statusPreviewing
+	statusPreviewMsg
 	statusGeneratingCfg
 	statusCleaningUpWorkspaceCfg
 	statusPushState
 	statusExportResourceMapping
 	statusExportSkippedResources
 	statusSummary
+	statusSessionSaved
 	statusQuitting
 	statusError
 )
@@ -54,15 +63,20 @@ func (s status) String() string {
 	return [...]string{
 		"initializing",
 		"listing Azure resources",
+		"loading session",
 		"building import list",
+		"confirming import",
 		"importing",
 		"import error message",
+		"previewing configuration",
+		"preview message",
 		"generating Terraform configuration",
 		"cleaning up output directory",
 		"pushing state",
 		"exporting resource mapping file",
 		"exporting skipped resources file",
 		"summary",
+		"session saved",
 		"quitting",
 		"error",
 	}[s]
@@ -72,9 +86,17 @@ type model struct {
 	ctx         context.Context
 	meta        meta.Meta
 	parallelism int
+	// Whether to restore the import list from the session file, instead of listing the resources
+	resume bool
+	// Whether to confirm the import summary before importing
+	confirm bool
+	// The config, which is summarized before importing
+	cfg pkgconfig.Config
 
 	status status
 	err    error
+	// Whether the state is rolled back to the backup, after an error
+	stateRolledBack bool
 
 	// winsize is used to keep track of current windows size, it is used to set the size for other models that are initialized in status (e.g. the importlist).
 	winsize tea.WindowSizeMsg
@@ -83,6 +105,8 @@ type model struct {
 	importlist     importlist.Model
 	progress       progress.Model
 	importerrormsg aztfexportclient.ShowImportErrorMsg
+	previewmsg     aztfexportclient.ShowPreviewMsg
+	startimportmsg aztfexportclient.StartImportMsg
 }
 
 func newModel(ctx context.Context, cfg config.InteractiveModeConfig) (*model, error) {
@@ -102,6 +126,9 @@ func newModel(ctx context.Context, cfg config.InteractiveModeConfig) (*model, er
 		ctx:         ctx,
 		meta:        c,
 		parallelism: cfg.Parallelism,
+		resume:      cfg.Resume,
+		confirm:     cfg.Confirm,
+		cfg:         cfg.Config,
 		status:      statusInit,
 		spinner:     s,
 	}
@@ -139,6 +166,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusInit
 		return m, aztfexportclient.Init(m.ctx, m.meta)
 	case aztfexportclient.InitProviderDoneMsg:
+		if m.resume {
+			m.status = statusLoadingSession
+			return m, aztfexportclient.LoadSession(m.ctx, m.meta)
+		}
 		m.status = statusListingResource
 		return m, aztfexportclient.ListResource(m.ctx, m.meta)
 	case aztfexportclient.ListResourceDoneMsg:
@@ -153,17 +184,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusImportErrorMsg
 		m.importerrormsg = msg
 		return m, nil
+	case aztfexportclient.StartPreviewMsg:
+		m.status = statusPreviewing
+		return m, aztfexportclient.PreviewCfg(m.ctx, m.meta, msg.Item, msg.Index, msg.List)
+	case aztfexportclient.ShowPreviewMsg:
+		m.status = statusPreviewMsg
+		m.previewmsg = msg
+		return m, nil
 	case aztfexportclient.StartImportMsg:
-		m.status = statusImporting
-		m.progress = progress.NewModel(m.ctx, m.meta, m.parallelism, msg.List)
-		return m, tea.Batch(
-			m.progress.Init(),
-			// Resize the progress bar
-			func() tea.Msg { return m.winsize },
-		)
+		if m.confirm {
+			m.status = statusConfirmingImport
+			m.startimportmsg = msg
+			return m, nil
+		}
+		return m.startImport(msg)
 	case aztfexportclient.ImportDoneMsg:
 		for idx, item := range msg.List {
-			if item.ImportError != nil {
+			// Go back to the import list for the failed items, or the ones that are not imported (e.g. not selected to import)
+			if item.ImportError != nil || (!item.Skip() && !item.Imported) {
 				m.status = statusBuildingImportList
 				m.importlist = importlist.NewModel(m.ctx, m.meta, msg.List, idx)
 				cmd := func() tea.Msg { return m.winsize }
@@ -187,6 +225,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case aztfexportclient.WorkspaceCleanupDoneMsg:
 		m.status = statusSummary
 		return m, nil
+	case aztfexportclient.SaveSessionDoneMsg:
+		m.status = statusSessionSaved
+		return m, nil
 	case aztfexportclient.QuitMsg:
 		return m, tea.Quit
 	case aztfexportclient.CleanTFStateMsg:
@@ -196,11 +237,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.status = statusError
 		m.err = msg
 		return m, nil
+	case aztfexportclient.RollbackStateDoneMsg:
+		m.stateRolledBack = true
+		return m, nil
 	}
 
 	return updateChildren(msg, m)
 }
 
+func (m model) startImport(msg aztfexportclient.StartImportMsg) (model, tea.Cmd) {
+	m.status = statusImporting
+	m.progress = progress.NewModel(m.ctx, m.meta, m.parallelism, msg.List, msg.Selected)
+	return m, tea.Batch(
+		m.progress.Init(),
+		// Resize the progress bar
+		func() tea.Msg { return m.winsize },
+	)
+}
+
 func updateChildren(msg tea.Msg, m model) (model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch m.status {
@@ -214,15 +268,35 @@ func updateChildren(msg tea.Msg, m model) (model, tea.Cmd) {
 			cmd = func() tea.Msg { return m.winsize }
 			return m, cmd
 		}
+	case statusPreviewMsg:
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m.status = statusBuildingImportList
+			m.importlist = importlist.NewModel(m.ctx, m.meta, m.previewmsg.List, m.previewmsg.Index)
+			cmd = func() tea.Msg { return m.winsize }
+			return m, cmd
+		}
+	case statusConfirmingImport:
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			if msg.String() == "y" {
+				return m.startImport(m.startimportmsg)
+			}
+			// Go back to the import list as is
+			m.status = statusBuildingImportList
+			return m, nil
+		}
 	case statusImporting:
 		m.progress, cmd = m.progress.Update(msg)
 		return m, cmd
-	case statusSummary:
+	case statusSummary, statusSessionSaved:
 		switch msg.(type) {
 		case tea.KeyMsg:
 			m.status = statusQuitting
 			return m, aztfexportclient.Quit(m.ctx, m.meta)
 		}
+	case statusError:
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "r" && m.meta.StateBackupFile() != "" && !m.stateRolledBack {
+			return m, aztfexportclient.RollbackState(m.ctx, m.meta)
+		}
 	}
 	return m, nil
 }
@@ -235,10 +309,18 @@ func (m model) View() string {
 		s += m.spinner.View() + " Initializing..."
 	case statusListingResource:
 		s += m.spinner.View() + " Listing Azure Resources..."
+	case statusLoadingSession:
+		s += m.spinner.View() + " Loading Session..."
 	case statusBuildingImportList:
 		s += m.importlist.View()
 	case statusImportErrorMsg:
 		s += importErrorView(m)
+	case statusPreviewing:
+		s += m.spinner.View() + " Previewing Terraform Configuration..."
+	case statusPreviewMsg:
+		s += previewView(m)
+	case statusConfirmingImport:
+		s += confirmImportView(m)
 	case statusImporting:
 		s += m.spinner.View() + m.progress.View()
 	case statusPushState:
@@ -253,6 +335,8 @@ func (m model) View() string {
 		s += m.spinner.View() + " Cleaning up the output directory..."
 	case statusSummary:
 		s += summaryView(m)
+	case statusSessionSaved:
+		s += sessionSavedView(m)
 	case statusError:
 		s += errorView(m)
 	}
@@ -269,11 +353,47 @@ func importErrorView(m model) string {
 	return m.importerrormsg.Item.TFResourceId + "\n\n" + common.ErrorMsgStyle.Render(wordwrap.WrapString(m.importerrormsg.Item.ImportError.Error(), uint(m.winsize.Width-indentLevel)))
 }
 
+func previewView(m model) string {
+	s := m.previewmsg.Item.TFResourceId + "\n\n"
+	if err := m.previewmsg.Err; err != nil {
+		// #nosec G115
+		s += common.ErrorMsgStyle.Render(wordwrap.WrapString(err.Error(), uint(m.winsize.Width-indentLevel)))
+	} else {
+		// Truncate the config to fit the window, leaving the room for the logo, the title and the hint.
+		lines := strings.Split(strings.TrimRight(m.previewmsg.Config, "\n"), "\n")
+		if max := m.winsize.Height - 10; max > 0 && len(lines) > max {
+			lines = append(lines[:max], fmt.Sprintf("... (%d more lines)", len(lines)-max))
+		}
+		s += strings.Join(lines, "\n")
+	}
+	return s + "\n\n" + common.QuitMsgStyle.Render("Press any key to go back\n")
+}
+
+func confirmImportView(m model) string {
+	s := common.NewImportSummary(m.cfg, m.startimportmsg.List, m.startimportmsg.Selected).String() + "\n\n"
+	return s + common.QuitMsgStyle.Render("Press \"y\" to start importing, or any other key to go back\n")
+}
+
 func summaryView(m model) string {
-	return fmt.Sprintf("Terraform state and the config are generated at: %s\n\n", m.meta.Workspace()) + common.QuitMsgStyle.Render("Press any key to quit\n")
+	s := fmt.Sprintf("Terraform state and the config are generated at: %s\n\n", m.meta.Workspace())
+	s += fmt.Sprintf("The resource mapping is exported at: %s, which can be reused via \"aztfexport mapping-file\"\n\n", filepath.Join(m.meta.Workspace(), meta.ResourceMappingFileName))
+	return s + common.QuitMsgStyle.Render("Press any key to quit\n")
+}
+
+func sessionSavedView(m model) string {
+	s := fmt.Sprintf("The session is saved at: %s, which can be restored via \"--resume\" with the same command\n\n", filepath.Join(m.meta.Workspace(), internalmeta.SessionFileName))
+	return s + common.QuitMsgStyle.Render("Press any key to quit\n")
 }
 
 func errorView(m model) string {
 	// #nosec G115
-	return common.ErrorMsgStyle.Render(wordwrap.WrapString(m.err.Error(), uint(m.winsize.Width-indentLevel)))
+	s := common.ErrorMsgStyle.Render(wordwrap.WrapString(m.err.Error(), uint(m.winsize.Width-indentLevel)))
+	backup := m.meta.StateBackupFile()
+	if backup == "" {
+		return s
+	}
+	if m.stateRolledBack {
+		return s + fmt.Sprintf("\n\nThe state is rolled back to the backup: %s\n\n", backup) + common.QuitMsgStyle.Render("Press ctrl+c to quit\n")
+	}
+	return s + fmt.Sprintf("\n\nThe state prior to the import is backed up to: %s\n\n", backup) + common.QuitMsgStyle.Render("Press \"r\" to roll back the state to the backup, or ctrl+c to quit\n")
 }
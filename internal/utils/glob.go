@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// GlobToRegexp converts a glob pattern, where "*" matches any sequence of characters, to a case insensitive regexp.
+func GlobToRegexp(p string) *regexp.Regexp {
+	segs := strings.Split(p, "*")
+	for i := range segs {
+		segs[i] = regexp.QuoteMeta(segs[i])
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(segs, ".*") + "$")
+}
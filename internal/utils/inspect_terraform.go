@@ -47,6 +47,9 @@ func InspecTerraformBlock(dir string) (*TerraformBlockDetail, error) {
 				switch block.Type {
 				case "backend":
 					detail.BackendType = block.Labels[0]
+				case "cloud":
+					// The cloud block is regarded as a pseudo backend
+					detail.BackendType = "cloud"
 				}
 			}
 			return &detail, nil
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/aztfexport/internal/cfgfile"
 	internalconfig "github.com/Azure/aztfexport/internal/config"
@@ -91,6 +92,25 @@ func prepareConfigFile(ctx *cli.Context) error {
 	return nil
 }
 
+// legacyEnvVarPrefix is the environment variable prefix used before the tool was renamed from aztfy to aztfexport.
+const legacyEnvVarPrefix = "AZTFY_"
+
+// applyLegacyEnvVars maps each of the AZTFY_* environment variables to its AZTFEXPORT_* counterpart, unless the latter is set.
+// Every option has an AZTFEXPORT_* environment variable, so scripts and pipelines that are still using the legacy prefix can keep working.
+func applyLegacyEnvVars(environ []string) {
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(k, legacyEnvVarPrefix) {
+			continue
+		}
+		nk := "AZTFEXPORT_" + strings.TrimPrefix(k, legacyEnvVarPrefix)
+		if _, ok := os.LookupEnv(nk); ok {
+			continue
+		}
+		os.Setenv(nk, v) // #nosec G104
+	}
+}
+
 func main() {
 	commonFlags := []cli.Flag{
 		&cli.StringFlag{
@@ -118,6 +138,12 @@ func main() {
 			Usage:       "Proceed with non-empty output directory, which is likely to pollute the directory and cause errors (use with caution)",
 			Destination: &flagset.flagOverwrite,
 		},
+		&cli.BoolFlag{
+			Name:        "force",
+			EnvVars:     []string{"AZTFEXPORT_FORCE"},
+			Usage:       `Proceed with non-empty output directory by replacing the existing config files, which are backed up to an "aztfexportBackup-<timestamp>" directory under the output directory`,
+			Destination: &flagset.flagForce,
+		},
 		&cli.BoolFlag{
 			Name:        "append",
 			EnvVars:     []string{"AZTFEXPORT_APPEND"},
@@ -136,6 +162,30 @@ func main() {
 			Usage:       fmt.Sprintf("The provider version to use for importing. Defaults to %q for azurerm, %s for azapi", azurerm.ProviderSchemaInfo.Version, azapi.ProviderSchemaInfo.Version),
 			Destination: &flagset.flagProviderVersion,
 		},
+		&cli.StringFlag{
+			Name:        "tf-bin",
+			EnvVars:     []string{"AZTFEXPORT_TF_BIN"},
+			Usage:       "The path to the terraform binary used for importing. Defaults to the one found in the PATH",
+			Destination: &flagset.flagTFBin,
+		},
+		&cli.StringFlag{
+			Name:        "tf-version",
+			EnvVars:     []string{"AZTFEXPORT_TF_VERSION"},
+			Usage:       "The exact terraform version used for importing. If the terraform binary isn't of this version, this version is looked up from the PATH and the user cache directory, or is downloaded into the user cache directory",
+			Destination: &flagset.flagTFVersion,
+		},
+		&cli.BoolFlag{
+			Name:        "tf-offline",
+			EnvVars:     []string{"AZTFEXPORT_TF_OFFLINE"},
+			Usage:       "Never download the terraform of the `--tf-version`, e.g. in air-gapped environments",
+			Destination: &flagset.flagTFOffline,
+		},
+		&cli.StringFlag{
+			Name:        "provider-mirror",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_MIRROR"},
+			Usage:       "The provider mirror to install the providers from, instead of the registry. This is either an https URL of a network mirror, or a directory of a filesystem mirror",
+			Destination: &flagset.flagProviderMirror,
+		},
 		&cli.StringFlag{
 			Name:        "provider-name",
 			EnvVars:     []string{"AZTFEXPORT_PROVIDER_NAME"},
@@ -143,6 +193,12 @@ func main() {
 			Value:       "azurerm",
 			Destination: &flagset.flagProviderName,
 		},
+		&cli.BoolFlag{
+			Name:        "pin-versions",
+			EnvVars:     []string{"AZTFEXPORT_PIN_VERSIONS"},
+			Usage:       "Pin the provider to exactly the version used for importing, and require the Terraform version used for importing as the minimum, in the generated terraform block",
+			Destination: &flagset.flagPinVersions,
+		},
 		&cli.StringFlag{
 			Name:        "backend-type",
 			EnvVars:     []string{"AZTFEXPORT_BACKEND_TYPE"},
@@ -155,10 +211,64 @@ func main() {
 			Usage:       "The Terraform backend config",
 			Destination: &flagset.flagBackendConfig,
 		},
+		&cli.StringFlag{
+			Name:        "backend-resource-group-name",
+			EnvVars:     []string{"AZTFEXPORT_BACKEND_RESOURCE_GROUP_NAME"},
+			Usage:       "The resource group name of the storage account, which is written to the generated azurerm backend block",
+			Destination: &flagset.flagBackendRGName,
+		},
+		&cli.StringFlag{
+			Name:        "backend-storage-account-name",
+			EnvVars:     []string{"AZTFEXPORT_BACKEND_STORAGE_ACCOUNT_NAME"},
+			Usage:       "The storage account name to store the state, which is written to the generated azurerm backend block",
+			Destination: &flagset.flagBackendSAName,
+		},
+		&cli.StringFlag{
+			Name:        "backend-container-name",
+			EnvVars:     []string{"AZTFEXPORT_BACKEND_CONTAINER_NAME"},
+			Usage:       "The storage container name to store the state, which is written to the generated azurerm backend block",
+			Destination: &flagset.flagBackendContainer,
+		},
+		&cli.StringFlag{
+			Name:        "backend-key",
+			EnvVars:     []string{"AZTFEXPORT_BACKEND_KEY"},
+			Usage:       "The name of the state blob in the storage container, which is written to the generated azurerm backend block",
+			Destination: &flagset.flagBackendKey,
+		},
+		&cli.BoolFlag{
+			Name:        "incremental-push",
+			EnvVars:     []string{"AZTFEXPORT_INCREMENTAL_PUSH"},
+			Usage:       "Push the state to the backend (with state locking) after each round of the parallel import, instead of once after all the imports, so that the imported resources are persisted in the (remote) backend along the way",
+			Destination: &flagset.flagIncrementalPush,
+		},
+		&cli.StringFlag{
+			Name:        "cloud-hostname",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_HOSTNAME"},
+			Usage:       "The hostname of Terraform Enterprise, which is written to the generated cloud block (default: app.terraform.io)",
+			Destination: &flagset.flagCloudHostname,
+		},
+		&cli.StringFlag{
+			Name:        "cloud-organization",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_ORGANIZATION"},
+			Usage:       "The organization of the Terraform Cloud (or Enterprise) workspace to store the state, which is written to the generated cloud block. Implies `--backend-type=cloud`",
+			Destination: &flagset.flagCloudOrganization,
+		},
+		&cli.StringFlag{
+			Name:        "cloud-workspace",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_WORKSPACE"},
+			Usage:       "The name of the Terraform Cloud (or Enterprise) workspace to store the state, which is written to the generated cloud block. Implies `--backend-type=cloud`",
+			Destination: &flagset.flagCloudWorkspace,
+		},
+		&cli.BoolFlag{
+			Name:        "cloud-upload-config",
+			EnvVars:     []string{"AZTFEXPORT_CLOUD_UPLOAD_CONFIG"},
+			Usage:       `Upload the generated config as a configuration version (without queuing a run) of the cloud workspace. The API token is read from "TF_TOKEN_<hostname>", or the credentials of "terraform login"`,
+			Destination: &flagset.flagCloudUploadConfig,
+		},
 		&cli.BoolFlag{
 			Name:        "full-properties",
 			EnvVars:     []string{"AZTFEXPORT_FULL_PROPERTIES"},
-			Usage:       "Includes all non-computed properties in the Terraform configuration. This may require manual modifications to produce a valid config",
+			Usage:       "Includes all non-computed properties in the Terraform configuration. By default, the computed, and the optional properties that equal to their defaults are omitted. This may require manual modifications to produce a valid config",
 			Value:       false,
 			Destination: &flagset.flagFullConfig,
 		},
@@ -172,15 +282,48 @@ func main() {
 		&cli.IntFlag{
 			Name:        "parallelism",
 			EnvVars:     []string{"AZTFEXPORT_PARALLELISM"},
-			Usage:       "Limit the number of parallel operations, i.e., resource discovery, import",
+			Usage:       "Limit the number of parallel operations, i.e., resource discovery, import (each parallel import runs in an isolated directory, whose state is merged afterwards)",
 			Value:       10,
 			Destination: &flagset.flagParallelism,
 		},
+		&cli.IntFlag{
+			Name:        "max-retries",
+			EnvVars:     []string{"AZTFEXPORT_MAX_RETRIES"},
+			Usage:       "The maximum number of retries of the Azure API calls on throttling or transient errors. Setting to 0 disables the retry",
+			Value:       defaultMaxRetries,
+			Destination: &flagset.flagMaxRetries,
+		},
+		&cli.DurationFlag{
+			Name:        "retry-delay",
+			EnvVars:     []string{"AZTFEXPORT_RETRY_DELAY"},
+			Usage:       "The initial delay before retrying the Azure API calls, which increases exponentially with jitter for each retry. This is ignored if the response has a Retry-After header",
+			Value:       defaultRetryDelay,
+			Destination: &flagset.flagRetryDelay,
+		},
+		&cli.DurationFlag{
+			Name:        "max-retry-delay",
+			EnvVars:     []string{"AZTFEXPORT_MAX_RETRY_DELAY"},
+			Usage:       "The maximum delay before retrying the Azure API calls, including the delay specified by the Retry-After header",
+			Value:       defaultMaxRetryDelay,
+			Destination: &flagset.flagMaxRetryDelay,
+		},
+		&cli.DurationFlag{
+			Name:        "timeout",
+			EnvVars:     []string{"AZTFEXPORT_TIMEOUT"},
+			Usage:       "The timeout of importing each resource, after which the resource is recorded as failed to import (and is skipped with `--continue`). 0 means no timeout",
+			Destination: &flagset.flagImportTimeout,
+		},
+		&cli.DurationFlag{
+			Name:        "deadline",
+			EnvVars:     []string{"AZTFEXPORT_DEADLINE"},
+			Usage:       "The deadline of the whole run, after which the run stops with the resources imported so far recorded in the session file, which can be resumed by `--resume` (non-interactive mode only). 0 means no deadline",
+			Destination: &flagset.flagDeadline,
+		},
 		&cli.BoolFlag{
 			Name:        "non-interactive",
 			EnvVars:     []string{"AZTFEXPORT_NON_INTERACTIVE"},
 			Aliases:     []string{"n"},
-			Usage:       "Non-interactive mode",
+			Usage:       "Non-interactive mode, which is switched to automatically (with the plain UI) when the stdout is not a terminal",
 			Destination: &flagset.flagNonInteractive,
 		},
 		&cli.BoolFlag{
@@ -189,6 +332,25 @@ func main() {
 			Usage:       "In non-interactive mode, print the progress information line by line, rather than the spinner UI. This can be used in OS that has no /dev/tty available",
 			Destination: &flagset.flagPlainUI,
 		},
+		&cli.BoolFlag{
+			Name:        "no-color",
+			EnvVars:     []string{"AZTFEXPORT_NO_COLOR"},
+			Usage:       "In interactive mode, disable the colors of the UI. The colors are also disabled if the NO_COLOR environment variable is set",
+			Destination: &flagset.flagNoColor,
+		},
+		&cli.StringFlag{
+			Name:        "theme",
+			EnvVars:     []string{"AZTFEXPORT_THEME"},
+			Usage:       `In interactive mode, the color theme of the UI. Possible values are "default" and "high-contrast" (readable on both the dark and the light terminals)`,
+			Destination: &flagset.flagTheme,
+		},
+		&cli.BoolFlag{
+			Name:        "yes",
+			EnvVars:     []string{"AZTFEXPORT_YES"},
+			Aliases:     []string{"y"},
+			Usage:       "In interactive mode, skip the confirmation of the import summary (e.g. the count of the resources to import, the output directory, the provider version and the backend) before importing",
+			Destination: &flagset.flagYes,
+		},
 		&cli.BoolFlag{
 			Name:        "continue",
 			EnvVars:     []string{"AZTFEXPORT_CONTINUE"},
@@ -196,31 +358,241 @@ func main() {
 			Usage:       "For non-interactive mode, continue on any import error",
 			Destination: &flagset.flagContinue,
 		},
+		&cli.BoolFlag{
+			Name:        "rollback-on-failure",
+			EnvVars:     []string{"AZTFEXPORT_ROLLBACK_ON_FAILURE"},
+			Usage:       "For non-interactive mode, roll back the state to the backup taken prior to the import on failure. Otherwise, the backup is kept in the output directory",
+			Destination: &flagset.flagRollbackOnFailure,
+		},
 		&cli.BoolFlag{
 			Name:        "generate-mapping-file",
 			Aliases:     []string{"g"},
 			EnvVars:     []string{"AZTFEXPORT_GENERATE_MAPPING_FILE"},
-			Usage:       "Only generate the resource mapping file, but does NOT import any resource",
+			Usage:       "Only generate the resource mapping file, but does NOT import any resource or initialize the output directory",
 			Destination: &flagset.flagGenerateMappingFile,
 		},
+		&cli.BoolFlag{
+			Name:        "resume",
+			EnvVars:     []string{"AZTFEXPORT_RESUME"},
+			Usage:       "Resume the interrupted run (or the interactive session saved on quit) from the session file in the output directory",
+			Destination: &flagset.flagResume,
+		},
+		&cli.BoolFlag{
+			Name:        "retry-failed",
+			EnvVars:     []string{"AZTFEXPORT_RETRY_FAILED"},
+			Usage:       "Only retry the failed imports recorded in the session file in the output directory, with the resource mapping file (if specified) overriding their mappings (non-interactive mode only)",
+			Destination: &flagset.flagRetryFailed,
+		},
+		&cli.BoolFlag{
+			Name:        "delta",
+			EnvVars:     []string{"AZTFEXPORT_DELTA"},
+			Usage:       "Only process the resources created since the last delta run, i.e. those neither managed in the state nor recorded in the inventory file (aztfexportInventory.json) of the output directory, which is updated with the imported resources afterwards",
+			Destination: &flagset.flagDelta,
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			EnvVars:     []string{"AZTFEXPORT_DRY_RUN"},
+			Usage:       "Only print the resources that would be imported and their TF resource addresses, but does NOT import any resource or write any file (non-interactive mode only)",
+			Destination: &flagset.flagDryRun,
+		},
+		&cli.StringFlag{
+			Name:        "dry-run-format",
+			EnvVars:     []string{"AZTFEXPORT_DRY_RUN_FORMAT"},
+			Usage:       `The output format of the dry-run result. Possible values are "text" (default) and "json"`,
+			Destination: &flagset.flagDryRunFormat,
+		},
 		&cli.BoolFlag{
 			Name:        "hcl-only",
 			EnvVars:     []string{"AZTFEXPORT_HCL_ONLY"},
 			Usage:       "Only generates HCL code (and mapping file), but not the files for resource management (e.g. the state file)",
 			Destination: &flagset.flagHCLOnly,
 		},
+		&cli.BoolFlag{
+			Name:        "state-only",
+			EnvVars:     []string{"AZTFEXPORT_STATE_ONLY"},
+			Usage:       "Only imports the resources to the state, but not generates the HCL code. This is useful to populate the state for the hand-written config (usually together with `--append`)",
+			Destination: &flagset.flagStateOnly,
+		},
+		&cli.StringFlag{
+			Name:        "state",
+			EnvVars:     []string{"AZTFEXPORT_STATE"},
+			Usage:       "The path to an existing state file, whose copy (with the imported resources added) is pushed to the output directory. The resources already managed in it are skipped, and the address collisions are detected",
+			Destination: &flagset.flagStateFile,
+		},
+		&cli.BoolFlag{
+			Name:        "verify",
+			EnvVars:     []string{"AZTFEXPORT_VERIFY"},
+			Usage:       `Run "terraform validate" and "terraform plan" after generating the config, and write a report of the resources that have diff to "aztfexportVerifyReport.txt" of the output directory`,
+			Destination: &flagset.flagVerify,
+		},
 		&cli.StringFlag{
 			Name:        "module-path",
 			EnvVars:     []string{"AZTFEXPORT_MODULE_PATH"},
 			Usage:       `The path of the module (e.g. "module1.module2") where the resources will be imported and config generated. Note that only modules whose "source" is local path is supported. Defaults to the root module.`,
 			Destination: &flagset.flagModulePath,
 		},
+		&cli.BoolFlag{
+			Name:        "as-module",
+			EnvVars:     []string{"AZTFEXPORT_AS_MODULE"},
+			Usage:       `Generate the resources as a child module (under "modules/resources", with variables and outputs), and a thin root module that instantiates it and holds the terraform, provider and import blocks. Implies "--extract-variables" and "--generate-outputs"`,
+			Destination: &flagset.flagAsModule,
+		},
+		&cli.BoolFlag{
+			Name:        "terragrunt",
+			EnvVars:     []string{"AZTFEXPORT_TERRAGRUNT"},
+			Usage:       `Generate the "terragrunt.hcl" with the "remote_state" and "inputs" wiring, so that the output directory can be placed as a unit of a terragrunt live repo. Implies "--extract-variables"`,
+			Destination: &flagset.flagTerragrunt,
+		},
+		&cli.StringFlag{
+			Name:        "environment",
+			EnvVars:     []string{"AZTFEXPORT_ENVIRONMENT"},
+			Usage:       `The environment (e.g. "dev", "prod") of the exported resources, which is used as the Terraform workspace of the output directory. The extracted variable values are written to "<environment>.tfvars", while the config is shared by the environments exported (with "--append") to the same directory. Implies "--extract-variables"`,
+			Destination: &flagset.flagEnvironment,
+		},
+		&cli.StringFlag{
+			Name:        "output-layout",
+			EnvVars:     []string{"AZTFEXPORT_OUTPUT_LAYOUT"},
+			Usage:       `How the generated resource configs are laid out in files. Possible values are "single" (default, all in the main file), "resource" (one file per resource, e.g. "azurerm_resource_group.rg.tf"), "type" (one file per resource type, e.g. "azurerm_subnet.tf") and "service" (one file per Azure service, e.g. "network.tf")`,
+			Destination: &flagset.flagOutputLayout,
+		},
+		&cli.BoolFlag{
+			Name:        "extract-variables",
+			EnvVars:     []string{"AZTFEXPORT_EXTRACT_VARIABLES"},
+			Usage:       "Lift the literal values shared by multiple resources (location, resource group name, tags and SKU names) into variables with the values as defaults, and reference them in the resources",
+			Destination: &flagset.flagExtractVariables,
+		},
+		&cli.BoolFlag{
+			Name:        "extract-sensitive",
+			EnvVars:     []string{"AZTFEXPORT_EXTRACT_SENSITIVE"},
+			Usage:       `Replace the sensitive values (e.g. passwords, keys, connection strings) in the generated config by sensitive variables, and write the "terraform.tfvars.example" with placeholder values`,
+			Destination: &flagset.flagExtractSensitive,
+		},
+		&cli.BoolFlag{
+			Name:        "generate-outputs",
+			EnvVars:     []string{"AZTFEXPORT_GENERATE_OUTPUTS"},
+			Usage:       "Generate the outputs.tf that exposes the key attributes of the resources (id, name, and the connection relevant attributes of some resource types, e.g. the login server of a container registry)",
+			Destination: &flagset.flagGenerateOutputs,
+		},
+		&cli.BoolFlag{
+			Name:        "common-tags",
+			EnvVars:     []string{"AZTFEXPORT_COMMON_TAGS"},
+			Usage:       `Lift the tags shared by multiple resources into the "common_tags" local value, and rewrite the tags of the resources to reference (or merge with) it`,
+			Destination: &flagset.flagCommonTags,
+		},
+		&cli.BoolFlag{
+			Name:        "arm-dependency",
+			EnvVars:     []string{"AZTFEXPORT_ARM_DEPENDENCY"},
+			Usage:       `Add the "depends_on" of the resources from the dependencies declared in the exported ARM templates of their resource groups, where they can't be implied by the references`,
+			Destination: &flagset.flagARMDependency,
+		},
+		&cli.BoolFlag{
+			Name:        "cache",
+			EnvVars:     []string{"AZTFEXPORT_CACHE"},
+			Usage:       `Cache the resolved resource types and the exported ARM templates in the user cache directory between runs, which are keyed by the etags of the resources`,
+			Destination: &flagset.flagCache,
+		},
+		&cli.BoolFlag{
+			Name:        "azapi-fallback",
+			EnvVars:     []string{"AZTFEXPORT_AZAPI_FALLBACK"},
+			Usage:       `Export the resources that have no azurerm resource type mapped as "azapi_resource" (with the body from the Azure resource) by the azapi provider, instead of skipping them`,
+			Destination: &flagset.flagAzAPIFallback,
+		},
+		&cli.BoolFlag{
+			Name:        "coverage-report",
+			EnvVars:     []string{"AZTFEXPORT_COVERAGE_REPORT"},
+			Usage:       `Write the "aztfexportCoverageReport.json" that lists the discovered resources that are not imported, together with the reasons (no mapping, unsupported, user skipped, already managed or import error)`,
+			Destination: &flagset.flagCoverageReport,
+		},
+		&cli.BoolFlag{
+			Name:        "source-metadata",
+			EnvVars:     []string{"AZTFEXPORT_SOURCE_METADATA"},
+			Usage:       "Add a comment header above each generated resource with the Azure resource id, the ARM resource type and the import time",
+			Destination: &flagset.flagSourceMetadata,
+		},
+		&cli.BoolFlag{
+			Name:        "json-syntax",
+			EnvVars:     []string{"AZTFEXPORT_JSON_SYNTAX"},
+			Usage:       `Write the generated config in the Terraform JSON syntax (".tf.json") instead of HCL. The comments in the generated config are not preserved`,
+			Destination: &flagset.flagJSONSyntax,
+		},
+		&cli.StringFlag{
+			Name:        "cdktf-language",
+			EnvVars:     []string{"AZTFEXPORT_CDKTF_LANGUAGE"},
+			Usage:       `Also convert the generated config to the CDK for Terraform code of the language (one of "typescript", "python", "csharp", "java" and "go") into the "cdktf" directory, by "cdktf convert" (the "cdktf" CLI is required)`,
+			Destination: &flagset.flagCDKTFLanguage,
+		},
 		&cli.BoolFlag{
 			Name:        "generate-import-block",
 			EnvVars:     []string{"AZTFEXPORT_GENERATE_IMPORT_BLOCK"},
 			Usage:       `Whether to generate the import.tf that contains the "import" blocks for the Terraform official plannable importing`,
 			Destination: &flagset.flagGenerateImportBlock,
 		},
+		&cli.BoolFlag{
+			Name:        "import-via-plan",
+			EnvVars:     []string{"AZTFEXPORT_IMPORT_VIA_PLAN"},
+			Usage:       `Import all the resources via a single "terraform plan" with the "import" blocks and "terraform apply", instead of "terraform import" per resource. Falls back to the latter if the plan does more than importing (Terraform v1.5.0 or later is required)`,
+			Destination: &flagset.flagImportViaPlan,
+		},
+		&cli.BoolFlag{
+			Name:        "resolve-address-conflict",
+			EnvVars:     []string{"AZTFEXPORT_RESOLVE_ADDRESS_CONFLICT"},
+			Usage:       "Rename the resources whose addresses conflict with the ones already managed in the state of the output directory (or with each other) by appending a counter, instead of failing before the import",
+			Destination: &flagset.flagResolveAddrConflict,
+		},
+		&cli.StringSliceFlag{
+			Name:        "type-resolver-file",
+			EnvVars:     []string{"AZTFEXPORT_TYPE_RESOLVER_FILE"},
+			Usage:       `The file (or directory) of the additional rules to resolve the Terraform resource types (azurerm only), which take precedence over the builtin ones. The format is the same as the resource mapping file, keyed by Azure resource ids or patterns with the "resource_type" required`,
+			Destination: &flagset.flagTypeResolverFiles,
+		},
+		&cli.StringFlag{
+			Name:        "type-resolver-command",
+			EnvVars:     []string{"AZTFEXPORT_TYPE_RESOLVER_COMMAND"},
+			Usage:       `The command to resolve the Terraform resource types (azurerm only), which is invoked with the Azure resource id as the last argument, and outputs a JSON object with the "resource_type" and the optional "resource_id", or nothing if not resolved. The command and its arguments are either separated by whitespaces, or a JSON array (e.g. '["python3", "/path with space/resolver.py"]'). Each invocation times out after 30 seconds. It is used after the "--type-resolver-file" and before the builtin rules`,
+			Destination: &flagset.flagTypeResolverCommand,
+		},
+		&cli.StringSliceFlag{
+			Name:        "include-type",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_TYPE"},
+			Usage:       `Only export resources whose type matches any of the patterns. A pattern is either an Azure resource type (e.g. "Microsoft.Network/virtualNetworks"), or a Terraform resource type (e.g. "azurerm_subnet"), where "*" matches any characters`,
+			Destination: &flagset.flagIncludeTypes,
+		},
+		&cli.StringSliceFlag{
+			Name:        "exclude-type",
+			EnvVars:     []string{"AZTFEXPORT_EXCLUDE_TYPE"},
+			Usage:       `Don't export resources whose type matches any of the patterns. The pattern format is the same as "--include-type"`,
+			Destination: &flagset.flagExcludeTypes,
+		},
+		&cli.StringFlag{
+			Name:        "name-filter",
+			EnvVars:     []string{"AZTFEXPORT_NAME_FILTER"},
+			Usage:       "Only export resources whose Azure resource name matches the regular expression. Child resources and extension resources (e.g. role assignments) follow the name of their parent resource or scope, and a resource group is kept if any resource within it is exported",
+			Destination: &flagset.flagNameFilter,
+		},
+		&cli.StringFlag{
+			Name:        "skip-file",
+			EnvVars:     []string{"AZTFEXPORT_SKIP_FILE"},
+			Usage:       `The file of the resources that are always excluded, one Azure resource id per line, where "*" matches any characters (e.g. "/subscriptions/*/resourceGroups/MC_*"). A line prefixed by "regex:" is a regular expression. Empty lines and lines starting with "#" are ignored`,
+			Destination: &flagset.flagSkipFile,
+		},
+		&cli.StringFlag{
+			Name:        "lifecycle-file",
+			EnvVars:     []string{"AZTFEXPORT_LIFECYCLE_FILE"},
+			Usage:       `The YAML or JSON file of the rules that inject "lifecycle { ignore_changes = [...] }" to the resources, each with a "resource_type" (where "*" matches any characters, e.g. "azurerm_*") and the "ignore_changes" attributes (e.g. "default_node_pool[0].node_count")`,
+			Destination: &flagset.flagLifecycleFile,
+		},
+		&cli.StringFlag{
+			Name:        "provider-config-file",
+			EnvVars:     []string{"AZTFEXPORT_PROVIDER_CONFIG_FILE"},
+			Usage:       `The HCL file of the "provider" blocks to generate instead of the minimal ones (e.g. with the "features" sub-blocks, "skip_provider_registration", "partner_id" or the aliased providers). The auth related attributes are still populated to the default provider block, unless defined`,
+			Destination: &flagset.flagProviderConfigFile,
+		},
+		&cli.StringFlag{
+			Name:        "override-dir",
+			EnvVars:     []string{"AZTFEXPORT_OVERRIDE_DIR"},
+			Usage:       `The directory of the Go templates named "<TF resource type>.tf.tmpl" (e.g. "azurerm_storage_account.tf.tmpl"), whose rendered output replaces the generated config of the resources of that type. The template can refer to the generated block via "{{ .Block }}", or its content via "{{ .Body }}"`,
+			Destination: &flagset.flagOverrideDir,
+		},
 		&cli.StringFlag{
 			Name:        "log-path",
 			EnvVars:     []string{"AZTFEXPORT_LOG_PATH"},
@@ -234,15 +606,45 @@ func main() {
 			Destination: &flagset.flagLogLevel,
 			Value:       "INFO",
 		},
+		&cli.StringFlag{
+			Name:        "config",
+			EnvVars:     []string{"AZTFEXPORT_CONFIG"},
+			Usage:       `The path to a YAML or JSON file that maps the option names to their default values, which are overridden by the command line and the environment variables. Defaults to "aztfexport.yaml", "aztfexport.yml" or "aztfexport.json" in the current directory, if exists`,
+			Destination: &flagset.flagOptionFile,
+		},
+		&cli.StringFlag{
+			Name:        "partner-id",
+			EnvVars:     []string{"AZTFEXPORT_PARTNER_ID", "ARM_PARTNER_ID"},
+			Usage:       "The GUID for the customer usage attribution, which is sent in the User-Agent header of all the Azure API calls",
+			Destination: &flagset.flagPartnerId,
+		},
+		&cli.StringFlag{
+			Name:        "user-agent-suffix",
+			EnvVars:     []string{"AZTFEXPORT_USER_AGENT_SUFFIX"},
+			Usage:       "The string appended to the User-Agent header of all the Azure API calls",
+			Destination: &flagset.flagUserAgentSuffix,
+		},
 
 		// Common flags (auth)
 		&cli.StringFlag{
 			Name:        "env",
 			EnvVars:     []string{"AZTFEXPORT_ENV", "ARM_ENVIRONMENT"},
-			Usage:       `The cloud environment, can be one of "public", "usgovernment" and "china"`,
+			Usage:       `The cloud environment, can be one of "public", "usgovernment" and "china" (the Azure CLI cloud names, e.g. "AzureUSGovernment", are also accepted)`,
 			Destination: &flagset.flagEnv,
 			Value:       "public",
 		},
+		&cli.StringFlag{
+			Name:        "metadata-host",
+			EnvVars:     []string{"AZTFEXPORT_METADATA_HOST", "ARM_METADATA_HOSTNAME"},
+			Usage:       `The hostname of the Azure Resource Manager of a custom cloud (e.g. Azure Stack Hub), which is used to retrieve the endpoints of the cloud specified by "--env". Only works for the azurerm provider`,
+			Destination: &flagset.flagMetadataHost,
+		},
+		&cli.StringFlag{
+			Name:        "ca-bundle",
+			EnvVars:     []string{"AZTFEXPORT_CA_BUNDLE"},
+			Usage:       "The path to a PEM encoded CA bundle, whose certificates are trusted in addition to the system ones (e.g. for a proxy doing TLS inspection). It is also exposed to the terraform processes via SSL_CERT_FILE (if not set). The proxy is set via HTTPS_PROXY and NO_PROXY",
+			Destination: &flagset.flagCABundle,
+		},
 		&cli.StringFlag{
 			Name:        "tenant-id",
 			EnvVars:     []string{"AZTFEXPORT_TENANT_ID", "ARM_TENANT_ID"},
@@ -276,7 +678,7 @@ func main() {
 		&cli.StringFlag{
 			Name:        "client-certificate-path",
 			EnvVars:     []string{"AZTFEXPORT_CLIENT_CERTIFICATE_PATH", "ARM_CLIENT_CERTIFICATE_PATH"},
-			Usage:       "The path to the Client Certificate (PKCS#12) associated with the Service Principal which should be used",
+			Usage:       "The path to the Client Certificate (PKCS#12, or PEM that contains both the certificate and the private key) associated with the Service Principal which should be used",
 			Destination: &flagset.flagClientCertificatePath,
 		},
 		&cli.StringFlag{
@@ -299,13 +701,13 @@ func main() {
 		},
 		&cli.StringFlag{
 			Name:        "oidc-request-token",
-			EnvVars:     []string{"AZTFEXPORT_OIDC_REQUEST_TOKEN", "ARM_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN"},
+			EnvVars:     []string{"AZTFEXPORT_OIDC_REQUEST_TOKEN", "ARM_OIDC_REQUEST_TOKEN", "ACTIONS_ID_TOKEN_REQUEST_TOKEN", "SYSTEM_ACCESSTOKEN"},
 			Usage:       "The bearer token for the request to the OIDC provider",
 			Destination: &flagset.flagOIDCRequestToken,
 		},
 		&cli.StringFlag{
 			Name:        "oidc-request-url",
-			EnvVars:     []string{"AZTFEXPORT_OIDC_REQUEST_URL", "ARM_OIDC_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_URL"},
+			EnvVars:     []string{"AZTFEXPORT_OIDC_REQUEST_URL", "ARM_OIDC_REQUEST_URL", "ACTIONS_ID_TOKEN_REQUEST_URL", "SYSTEM_OIDCREQUESTURI"},
 			Usage:       "The URL for the OIDC provider from which to request an ID token",
 			Destination: &flagset.flagOIDCRequestURL,
 		},
@@ -321,10 +723,16 @@ func main() {
 			Usage:       "The ID token when authenticating using OIDC",
 			Destination: &flagset.flagOIDCToken,
 		},
+		&cli.StringFlag{
+			Name:        "oidc-azure-service-connection-id",
+			EnvVars:     []string{"AZTFEXPORT_OIDC_AZURE_SERVICE_CONNECTION_ID", "ARM_OIDC_AZURE_SERVICE_CONNECTION_ID", "ARM_ADO_PIPELINE_SERVICE_CONNECTION_ID"},
+			Usage:       "The Azure DevOps service connection id, which is used to request the ID token from the Azure DevOps pipeline when authenticating using OIDC",
+			Destination: &flagset.flagOIDCServiceConnectionId,
+		},
 		&cli.BoolFlag{
 			Name:        "use-managed-identity-cred",
 			EnvVars:     []string{"AZTFEXPORT_USE_MANAGED_IDENTITY_CRED", "ARM_USE_MSI"},
-			Usage:       "Explicitly use the managed identity that is provided by the Azure host to do authentication",
+			Usage:       "Explicitly use the managed identity that is provided by the Azure host to do authentication. The user assigned identity is used when `--client-id` is specified, otherwise, the system assigned identity is used",
 			Destination: &flagset.flagUseManagedIdentityCred,
 			Value:       false,
 		},
@@ -342,6 +750,19 @@ func main() {
 			Destination: &flagset.flagUseOIDCCred,
 			Value:       false,
 		},
+		&cli.BoolFlag{
+			Name:        "use-token-cache",
+			EnvVars:     []string{"AZTFEXPORT_USE_TOKEN_CACHE"},
+			Usage:       "Cache the acquired access tokens (encrypted) in the user cache directory, keyed by the tenant, client and the Azure CLI account, to reuse them across runs. Note that the encryption key is stored in plaintext under ~/.aztfexport, which only guards the tokens from being read casually",
+			Destination: &flagset.flagUseTokenCache,
+			Value:       false,
+		},
+		&cli.StringFlag{
+			Name:        "tfclient-plugin-path",
+			EnvVars:     []string{"AZTFEXPORT_TFCLIENT_PLUGIN_PATH"},
+			Usage:       "The path of the provider plugin binary to import with, which talks to the provider plugin directly instead of running `terraform import` per resource (the terraform binary is still used to manage the state, unless `--hcl-only`). This is opt-in, and doesn't support the resources residing in other subscriptions",
+			Destination: &flagset.flagTFClientPluginPath,
+		},
 
 		// Hidden flags
 		&cli.BoolFlag{
@@ -358,13 +779,6 @@ func main() {
 			Hidden:      true,
 			Destination: &flagset.hflagProfile,
 		},
-		&cli.StringFlag{
-			Name:        "tfclient-plugin-path",
-			EnvVars:     []string{"AZTFEXPORT_TFCLIENT_PLUGIN_PATH"},
-			Usage:       "Replace terraform binary with terraform-client-go for importing (must be used with `--hcl-only`)",
-			Hidden:      true,
-			Destination: &flagset.hflagTFClientPluginPath,
-		},
 	}
 
 	resourceFlags := append([]cli.Flag{
@@ -384,10 +798,23 @@ func main() {
 			Name:        "name-pattern",
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
-			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp() (only works for multi-resource mode).`,
+			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp(). Alternatively, it can be a template that contains any of the tokens "{name}", "{type}", "{rg}" and "{index}" (e.g. "{type}_{name}"), where the result is sanitized to a valid HCL identifier and de-duplicated (only works for multi-resource mode).`,
 			Value:       "res-",
 			Destination: &flagset.flagPattern,
 		},
+		&cli.BoolFlag{
+			Name:        "recursive",
+			EnvVars:     []string{"AZTFEXPORT_RECURSIVE"},
+			Aliases:     []string{"r"},
+			Usage:       "Recursively lists child resources of the specified resources",
+			Destination: &flagset.flagRecursive,
+		},
+		&cli.BoolFlag{
+			Name:        "no-children",
+			EnvVars:     []string{"AZTFEXPORT_NO_CHILDREN"},
+			Usage:       "Exclude the child resources (e.g. subnets) that are embedded in the schema of their parent resource (e.g. the virtual network), when the parent is also exported. Other child resources (e.g. SQL databases) are kept",
+			Destination: &flagset.flagNoChildren,
+		},
 	}, commonFlags...)
 
 	resourceGroupFlags := append([]cli.Flag{
@@ -395,7 +822,7 @@ func main() {
 			Name:        "name-pattern",
 			EnvVars:     []string{"AZTFEXPORT_NAME_PATTERN"},
 			Aliases:     []string{"p"},
-			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp()`,
+			Usage:       `The pattern of the resource name. The semantic of a pattern is the same as Go's os.CreateTemp(). Alternatively, it can be a template that contains any of the tokens "{name}", "{type}", "{rg}" and "{index}" (e.g. "{type}_{name}"), where the result is sanitized to a valid HCL identifier and de-duplicated.`,
 			Value:       "res-",
 			Destination: &flagset.flagPattern,
 		},
@@ -405,8 +832,55 @@ func main() {
 			Usage:       `Whether to include role assignemnts assigned to the resources exported`,
 			Destination: &flagset.flagIncludeRoleAssignment,
 		},
+		&cli.BoolFlag{
+			Name:        "include-lock",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_LOCK"},
+			Usage:       `Whether to include management locks scoped to the resources exported`,
+			Destination: &flagset.flagIncludeLock,
+		},
+		&cli.BoolFlag{
+			Name:        "data-plane",
+			EnvVars:     []string{"AZTFEXPORT_DATA_PLANE"},
+			Usage:       `Whether to include the data plane resources that are not listed by the control plane (e.g. the App Configuration key-values, the Key Vault certificates)`,
+			Destination: &flagset.flagDataPlane,
+		},
+		&cli.StringSliceFlag{
+			Name:        "include-tag",
+			EnvVars:     []string{"AZTFEXPORT_INCLUDE_TAG"},
+			Usage:       "Only export resources that have all of the specified tags, each in the form of `key=value`, `key=` (matches the empty value) or `key` (matches any value). Child resources and extension resources (e.g. role assignments) follow their parent resource or scope",
+			Destination: &flagset.flagIncludeTags,
+		},
+		&cli.StringSliceFlag{
+			Name:        "exclude-tag",
+			EnvVars:     []string{"AZTFEXPORT_EXCLUDE_TAG"},
+			Usage:       "Do not export resources that have any of the specified tags, each in the form of `key=value`, `key=` (matches the empty value) or `key` (matches any value). Child resources and extension resources (e.g. role assignments) follow their parent resource or scope",
+			Destination: &flagset.flagExcludeTags,
+		},
+		&cli.BoolFlag{
+			Name:        "no-children",
+			EnvVars:     []string{"AZTFEXPORT_NO_CHILDREN"},
+			Usage:       "Exclude the child resources (e.g. subnets) that are embedded in the schema of their parent resource (e.g. the virtual network), when the parent is also exported. Other child resources (e.g. SQL databases) are kept",
+			Destination: &flagset.flagNoChildren,
+		},
+		&cli.BoolFlag{
+			Name:        "expand-embedded",
+			EnvVars:     []string{"AZTFEXPORT_EXPAND_EMBEDDED"},
+			Usage:       "Export the child resources that are embedded in their parent resource's properties as separate resources (e.g. key vault access policies as azurerm_key_vault_access_policy), instead of as part of the parent resource. The access policies of a key vault are kept inline if any of them is bound to an application",
+			Destination: &flagset.flagExpandEmbedded,
+		},
 	}, commonFlags...)
 
+	subscriptionFlags := append([]cli.Flag{}, resourceGroupFlags...)
+
+	managementGroupFlags := append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:        "workspace-per-subscription",
+			EnvVars:     []string{"AZTFEXPORT_WORKSPACE_PER_SUBSCRIPTION"},
+			Usage:       "Export each subscription under the management group to its own workspace, which is a sub-directory of the output directory named by the subscription id (local backend only)",
+			Destination: &flagset.flagWorkspacePerSubscription,
+		},
+	}, resourceGroupFlags...)
+
 	queryFlags := append([]cli.Flag{
 		&cli.BoolFlag{
 			Name:        "recursive",
@@ -507,7 +981,7 @@ func main() {
 				Usage:     "Exporting one or more resources. The arguments can be resource ids, or path to files (prefixed with `@`) that contain resource id in each line.",
 				UsageText: "aztfexport resource [option] [<resourceId> | @<resourceIdFile>...]",
 				Flags:     resourceFlags,
-				Before:    commandBeforeFunc(&flagset, ModeResource),
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeResource)),
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
 						return fmt.Errorf("No resource id specified")
@@ -553,14 +1027,16 @@ func main() {
 
 					// Initialize the config
 					cfg := config.Config{
-						CommonConfig:        commonConfig,
-						ResourceIds:         resIds,
-						TFResourceName:      flagset.flagResName,
-						TFResourceType:      flagset.flagResType,
-						ResourceNamePattern: flagset.flagPattern,
+						CommonConfig:          commonConfig,
+						ResourceIds:           resIds,
+						TFResourceName:        flagset.flagResName,
+						TFResourceType:        flagset.flagResType,
+						ResourceNamePattern:   flagset.flagPattern,
+						RecursiveQuery:        flagset.flagRecursive,
+						ExcludeChildResources: flagset.flagNoChildren,
 					}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResource), flagset.hflagTFClientPluginPath)
+					return realMain(c.Context, cfg, flagset.buildRealMainOption(ModeResource))
 				},
 			},
 			{
@@ -569,7 +1045,7 @@ func main() {
 				Usage:     "Exporting a resource group and the nested resources resides within it.",
 				UsageText: "aztfexport resource-group [option] <resource group name>",
 				Flags:     resourceGroupFlags,
-				Before:    commandBeforeFunc(&flagset, ModeResourceGroup),
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeResourceGroup)),
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
 						return fmt.Errorf("No resource group specified")
@@ -585,24 +1061,125 @@ func main() {
 						return err
 					}
 
+					includeTags, excludeTags, err := flagset.buildTagFilters()
+					if err != nil {
+						return err
+					}
+
 					// Initialize the config
 					cfg := config.Config{
-						CommonConfig:          commonConfig,
-						ResourceGroupName:     rg,
-						ResourceNamePattern:   flagset.flagPattern,
-						RecursiveQuery:        true,
-						IncludeRoleAssignment: flagset.flagIncludeRoleAssignment,
+						CommonConfig:            commonConfig,
+						ResourceGroupName:       rg,
+						ResourceNamePattern:     flagset.flagPattern,
+						RecursiveQuery:          true,
+						IncludeRoleAssignment:   flagset.flagIncludeRoleAssignment,
+						IncludeLock:             flagset.flagIncludeLock,
+						IncludeDataPlane:        flagset.flagDataPlane,
+						IncludeTags:             includeTags,
+						ExcludeTags:             excludeTags,
+						ExcludeChildResources:   flagset.flagNoChildren,
+						ExpandEmbeddedResources: flagset.flagExpandEmbedded,
+					}
+
+					return realMain(c.Context, cfg, flagset.buildRealMainOption(ModeResourceGroup))
+				},
+			},
+			{
+				Name:      string(ModeSubscription),
+				Aliases:   []string{"sub"},
+				Usage:     "Exporting all the resource groups and the nested resources resides within the subscription.",
+				UsageText: "aztfexport subscription [option]",
+				Flags:     subscriptionFlags,
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeSubscription)),
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 0 {
+						return fmt.Errorf("No argument is expected, use `--subscription-id` to specify the subscription")
+					}
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					includeTags, excludeTags, err := flagset.buildTagFilters()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig:            commonConfig,
+						SubscriptionScope:       true,
+						ResourceNamePattern:     flagset.flagPattern,
+						IncludeRoleAssignment:   flagset.flagIncludeRoleAssignment,
+						IncludeLock:             flagset.flagIncludeLock,
+						IncludeDataPlane:        flagset.flagDataPlane,
+						IncludeTags:             includeTags,
+						ExcludeTags:             excludeTags,
+						ExcludeChildResources:   flagset.flagNoChildren,
+						ExpandEmbeddedResources: flagset.flagExpandEmbedded,
+					}
+
+					return realMain(c.Context, cfg, flagset.buildRealMainOption(ModeSubscription))
+				},
+			},
+			{
+				Name:      string(ModeManagementGroup),
+				Aliases:   []string{"mg"},
+				Usage:     "Exporting all the resource groups and the nested resources resides within the subscriptions under a management group (including the descendant management groups).",
+				UsageText: "aztfexport management-group [option] <management group name>",
+				Flags:     managementGroupFlags,
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeManagementGroup)),
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return fmt.Errorf("No management group specified")
+					}
+					if c.NArg() > 1 {
+						return fmt.Errorf("More than one management groups specified")
 					}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeResourceGroup), flagset.hflagTFClientPluginPath)
+					mg := c.Args().First()
+
+					commonConfig, err := flagset.BuildCommonConfig()
+					if err != nil {
+						return err
+					}
+
+					includeTags, excludeTags, err := flagset.buildTagFilters()
+					if err != nil {
+						return err
+					}
+
+					// Initialize the config
+					cfg := config.Config{
+						CommonConfig:            commonConfig,
+						ManagementGroupName:     mg,
+						ResourceNamePattern:     flagset.flagPattern,
+						IncludeRoleAssignment:   flagset.flagIncludeRoleAssignment,
+						IncludeLock:             flagset.flagIncludeLock,
+						IncludeDataPlane:        flagset.flagDataPlane,
+						IncludeTags:             includeTags,
+						ExcludeTags:             excludeTags,
+						ExcludeChildResources:   flagset.flagNoChildren,
+						ExpandEmbeddedResources: flagset.flagExpandEmbedded,
+					}
+
+					opt := flagset.buildRealMainOption(ModeManagementGroup)
+					if flagset.flagWorkspacePerSubscription {
+						return exportWorkspacePerSubscription(c.Context, cfg, opt.dryRun, func(cfg config.Config) error {
+							return realMain(c.Context, cfg, opt)
+						})
+					}
+
+					return realMain(c.Context, cfg, opt)
 				},
 			},
 			{
 				Name:      string(ModeQuery),
-				Usage:     "Exporting a customized scope of resources determined by an Azure Resource Graph where predicate.",
-				UsageText: "aztfexport query [option] <ARG where predicate>",
+				Usage:     "Exporting a customized scope of resources determined by an Azure Resource Graph where predicate. The argument can be the predicate, or path to a file (prefixed with `@`) that contains the predicate.",
+				UsageText: "aztfexport query [option] [<ARG where predicate> | @<ARG where predicate file>]",
 				Flags:     queryFlags,
-				Before:    commandBeforeFunc(&flagset, ModeQuery),
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeQuery)),
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
 						return fmt.Errorf("No query specified")
@@ -612,12 +1189,29 @@ func main() {
 					}
 
 					predicate := c.Args().First()
+					if strings.HasPrefix(predicate, "@") {
+						path := strings.TrimPrefix(predicate, "@")
+						// #nosec G304
+						b, err := os.ReadFile(path)
+						if err != nil {
+							return fmt.Errorf("failed to read file %q: %v", path, err)
+						}
+						predicate = strings.TrimSpace(string(b))
+						if predicate == "" {
+							return fmt.Errorf("No query specified in %q", path)
+						}
+					}
 
 					commonConfig, err := flagset.BuildCommonConfig()
 					if err != nil {
 						return err
 					}
 
+					includeTags, excludeTags, err := flagset.buildTagFilters()
+					if err != nil {
+						return err
+					}
+
 					// Initialize the config
 					cfg := config.Config{
 						CommonConfig:                commonConfig,
@@ -625,28 +1219,31 @@ func main() {
 						ResourceNamePattern:         flagset.flagPattern,
 						RecursiveQuery:              flagset.flagRecursive,
 						IncludeRoleAssignment:       flagset.flagIncludeRoleAssignment,
+						IncludeLock:                 flagset.flagIncludeLock,
+						IncludeDataPlane:            flagset.flagDataPlane,
 						IncludeResourceGroup:        flagset.flagIncludeResourceGroup,
 						ARGTable:                    flagset.flagARGTable,
 						ARGAuthorizationScopeFilter: flagset.flagARGAuthorizationScopeFilter,
+						IncludeTags:                 includeTags,
+						ExcludeTags:                 excludeTags,
+						ExcludeChildResources:       flagset.flagNoChildren,
+						ExpandEmbeddedResources:     flagset.flagExpandEmbedded,
 					}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeQuery), flagset.hflagTFClientPluginPath)
+					return realMain(c.Context, cfg, flagset.buildRealMainOption(ModeQuery))
 				},
 			},
 			{
 				Name:      string(ModeMappingFile),
 				Aliases:   []string{"map"},
-				Usage:     "Exporting a customized scope of resources determined by the resource mapping file.",
-				UsageText: "aztfexport mapping-file [option] <resource mapping file>",
+				Usage:     "Exporting a customized scope of resources determined by the resource mapping file(s), which is in JSON, YAML (.yaml, .yml) or CSV (.csv) format. Multiple files (or directories of files) are merged in order, where the later ones take precedence. The pattern rules (keys with \"*\" or prefixed by \"regex:\") only fill in the resource types of the resources listed in the files, use `--type-resolver-file` in other modes to apply them to the discovered resources.",
+				UsageText: "aztfexport mapping-file [option] <resource mapping file | directory>...",
 				Flags:     mappingFileFlags,
-				Before:    commandBeforeFunc(&flagset, ModeMappingFile),
+				Before:    withOptionFile(&flagset, commandBeforeFunc(&flagset, ModeMappingFile)),
 				Action: func(c *cli.Context) error {
 					if c.NArg() == 0 {
 						return fmt.Errorf("No resource mapping file specified")
 					}
-					if c.NArg() > 1 {
-						return fmt.Errorf("More than one resource mapping files specified")
-					}
 
 					mapFile := c.Args().First()
 
@@ -657,11 +1254,12 @@ func main() {
 
 					// Initialize the config
 					cfg := config.Config{
-						CommonConfig: commonConfig,
-						MappingFile:  mapFile,
+						CommonConfig:           commonConfig,
+						MappingFile:            mapFile,
+						AdditionalMappingFiles: c.Args().Tail(),
 					}
 
-					return realMain(c.Context, cfg, flagset.flagNonInteractive, flagset.hflagMockClient, flagset.flagPlainUI, flagset.flagGenerateMappingFile, flagset.hflagProfile, flagset.DescribeCLI(ModeMappingFile), flagset.hflagTFClientPluginPath)
+					return realMain(c.Context, cfg, flagset.buildRealMainOption(ModeMappingFile))
 				},
 			},
 		},
@@ -669,16 +1267,70 @@ func main() {
 
 	sort.Sort(cli.FlagsByName(app.Flags))
 
+	applyLegacyEnvVars(os.Environ())
+
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func subscriptionIdFromCLI() (string, error) {
+// subscriptionIdFromCLI returns the subscription id from the azure cli. If the tenant id is specified, the subscription is picked from the ones belong to that tenant,
+// preferring the current active one, so that the subscription used is consistent with the tenant.
+func subscriptionIdFromCLI(tenantId string) (string, error) {
+	if tenantId == "" {
+		stdout, err := runAzCLI("account", "show", "--output", "json", "--query", "id")
+		if err != nil {
+			return "", err
+		}
+		if stdout == "" {
+			return "", fmt.Errorf("subscription id is not specified")
+		}
+		return strconv.Unquote(stdout)
+	}
+
+	stdout, err := runAzCLI("account", "list", "--output", "json", "--query", "[].{id:id,tenantId:tenantId,isDefault:isDefault}")
+	if err != nil {
+		return "", err
+	}
+	var subs []cliSubscription
+	if err := json.Unmarshal([]byte(stdout), &subs); err != nil {
+		return "", fmt.Errorf("unmarshalling the subscriptions from azure cli: %v", err)
+	}
+	return pickSubscriptionForTenant(subs, tenantId)
+}
+
+type cliSubscription struct {
+	Id        string `json:"id"`
+	TenantId  string `json:"tenantId"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+func pickSubscriptionForTenant(subs []cliSubscription, tenantId string) (string, error) {
+	var ids []string
+	for _, sub := range subs {
+		if !strings.EqualFold(sub.TenantId, tenantId) {
+			continue
+		}
+		if sub.IsDefault {
+			return sub.Id, nil
+		}
+		ids = append(ids, sub.Id)
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no subscription found in azure cli for tenant %s", tenantId)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("multiple subscriptions found in azure cli for tenant %s, please specify one via `--subscription-id`", tenantId)
+	}
+}
+
+func runAzCLI(args ...string) (string, error) {
 	var stderr bytes.Buffer
 	var stdout bytes.Buffer
-	cmd := exec.Command("az", "account", "show", "--output", "json", "--query", "id")
+	cmd := exec.Command("az", args...)
 	cmd.Stderr = &stderr
 	cmd.Stdout = &stdout
 	if err := cmd.Run(); err != nil {
@@ -688,32 +1340,72 @@ func subscriptionIdFromCLI() (string, error) {
 		}
 		return "", err
 	}
-	if stdout.String() == "" {
-		return "", fmt.Errorf("subscription id is not specified")
-	}
-	return strconv.Unquote(strings.TrimSpace(stdout.String()))
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// realMainOption is the option of realMain, which is built from the flag set by the command actions.
+type realMainOption struct {
+	batch        bool
+	mockMeta     bool
+	plainUI      bool
+	genMapFile   bool
+	resume       bool
+	retryFailed  bool
+	deadline     time.Duration
+	dryRun       bool
+	dryRunFormat string
+	profileType  string
+	// The description of the CLI, which is recorded in the telemetry
+	effectiveCLI       string
+	tfClientPluginPath string
+	// Whether to skip the confirmation of the import summary in interactive mode
+	yes     bool
+	noColor bool
+	theme   string
+	// The backup directory of the existing config files in the output directory, which is empty if nothing is backed up
+	backupDir string
+	// Whether to review and confirm the changes to the backed up config files at the end
+	confirmConfigChanges bool
 }
 
-func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI, genMapFile bool, profileType string, effectiveCLI string, tfClientPluginPath string) (result error) {
-	switch strings.ToLower(profileType) {
+func realMain(ctx context.Context, cfg config.Config, opt realMainOption) (result error) {
+	switch strings.ToLower(opt.profileType) {
 	case "cpu":
 		defer profile.Start(profile.CPUProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
 	case "mem":
 		defer profile.Start(profile.MemProfile, profile.ProfilePath("."), profile.NoShutdownHook).Stop()
 	}
 
+	// The existing config files backed up when checking the flags are reviewed once the export succeeds, otherwise they are left in the backup directory, which is pointed out in the error.
+	defer func() {
+		result = backupOnFailure(result, opt.backupDir)
+	}()
+
 	// Initialize the TFClient
-	if tfClientPluginPath != "" {
+	if opt.tfClientPluginPath != "" {
 		// #nosec G204
-		cmd := exec.Command(flagset.hflagTFClientPluginPath)
+		cmd := exec.Command(opt.tfClientPluginPath)
 		cmd.Env = append(cmd.Env,
 			// Disable AzureRM provider's enahnced validation, which will cause RP listing, that is expensive.
 			// The setting for with_tf version is done during the init_tf function of meta Init phase.
 			"ARM_PROVIDER_ENHANCED_VALIDATION=false",
 			// AzureRM provider will honor env.var "AZURE_HTTP_USER_AGENT" when constructing for HTTP "User-Agent" header.
 			// The setting for with_tf version is done during the init_tf function of meta Init phase.
-			"AZURE_HTTP_USER_AGENT="+cfg.AzureSDKClientOption.Telemetry.ApplicationID,
+			"AZURE_HTTP_USER_AGENT="+strings.TrimSpace(cfg.AzureSDKClientOption.Telemetry.ApplicationID+" "+cfg.UserAgentSuffix),
 		)
+		// The providers append the partner id to the HTTP "User-Agent" header by themselves.
+		// The setting for with_tf version is done during the init_tf function of meta Init phase.
+		if v := cfg.PartnerId; v != "" {
+			cmd.Env = append(cmd.Env, "ARM_PARTNER_ID="+v)
+		}
+		// The providers read the Azure DevOps service connection id for OIDC authentication from the environment variables.
+		// The setting for with_tf version is done during the init_tf function of meta Init phase.
+		if v := cfg.AuthConfig.OIDCAzureServiceConnectionID; v != "" {
+			cmd.Env = append(cmd.Env,
+				"ARM_OIDC_AZURE_SERVICE_CONNECTION_ID="+v,
+				"ARM_ADO_PIPELINE_SERVICE_CONNECTION_ID="+v,
+			)
+		}
 		tfc, err := tfclient.New(tfclient.Option{
 			Cmd:    cmd,
 			Logger: slog2hclog.New(cfg.Logger.WithGroup("provider"), nil),
@@ -740,27 +1432,36 @@ func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI,
 
 	cfg.Logger.Info("aztfexport starts", "config", fmt.Sprintf("%#v", cfg))
 	tc.Trace(telemetry.Info, "aztfexport starts")
-	tc.Trace(telemetry.Info, "Effective CLI: "+effectiveCLI)
+	tc.Trace(telemetry.Info, "Effective CLI: "+opt.effectiveCLI)
 
 	// Run in non-interactive mode
-	if batch {
+	if opt.batch {
 		nicfg := internalconfig.NonInteractiveModeConfig{
-			MockMeta:           mockMeta,
+			MockMeta:           opt.mockMeta,
 			Config:             cfg,
-			PlainUI:            plainUI,
-			GenMappingFileOnly: genMapFile,
+			PlainUI:            opt.plainUI,
+			GenMappingFileOnly: opt.genMapFile,
+			Resume:             opt.resume,
+			RetryFailed:        opt.retryFailed,
+			Deadline:           opt.deadline,
+			DryRun:             opt.dryRun,
+			DryRunFormat:       opt.dryRunFormat,
 		}
 		if err := internal.BatchImport(ctx, nicfg); err != nil {
 			result = err
 			return
 		}
-		return nil
+		return reviewBackup(cfg.OutputDir, opt)
 	}
 
 	// Run in interactive mode
 	icfg := internalconfig.InteractiveModeConfig{
 		Config:   cfg,
-		MockMeta: mockMeta,
+		MockMeta: opt.mockMeta,
+		Resume:   opt.resume,
+		NoColor:  opt.noColor,
+		Theme:    opt.theme,
+		Confirm:  !opt.yes,
 	}
 	prog, err := ui.NewProgram(ctx, icfg)
 	if err != nil {
@@ -771,5 +1472,13 @@ func realMain(ctx context.Context, cfg config.Config, batch, mockMeta, plainUI,
 		result = err
 		return
 	}
-	return nil
+	return reviewBackup(cfg.OutputDir, opt)
+}
+
+// reviewBackup reviews the changes to the existing config files that are backed up (if any) when checking the flags.
+func reviewBackup(outputDir string, opt realMainOption) error {
+	if opt.backupDir == "" {
+		return nil
+	}
+	return reviewConfigChanges(os.Stdout, os.Stdin, outputDir, opt.backupDir, opt.confirmConfigChanges)
 }
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickSubscriptionForTenant(t *testing.T) {
+	cases := []struct {
+		name   string
+		subs   []cliSubscription
+		tenant string
+		expect string
+		err    bool
+	}{
+		{
+			name: "active subscription in the tenant",
+			subs: []cliSubscription{
+				{Id: "sub1", TenantId: "tenant1"},
+				{Id: "sub2", TenantId: "tenant1", IsDefault: true},
+			},
+			tenant: "TENANT1",
+			expect: "sub2",
+		},
+		{
+			name: "single subscription in the tenant",
+			subs: []cliSubscription{
+				{Id: "sub1", TenantId: "tenant1", IsDefault: true},
+				{Id: "sub2", TenantId: "tenant2"},
+			},
+			tenant: "tenant2",
+			expect: "sub2",
+		},
+		{
+			name: "multiple subscriptions in the tenant",
+			subs: []cliSubscription{
+				{Id: "sub1", TenantId: "tenant1", IsDefault: true},
+				{Id: "sub2", TenantId: "tenant2"},
+				{Id: "sub3", TenantId: "tenant2"},
+			},
+			tenant: "tenant2",
+			err:    true,
+		},
+		{
+			name: "no subscription in the tenant",
+			subs: []cliSubscription{
+				{Id: "sub1", TenantId: "tenant1", IsDefault: true},
+			},
+			tenant: "tenant2",
+			err:    true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := pickSubscriptionForTenant(tt.subs, tt.tenant)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, id)
+		})
+	}
+}
+
+func TestApplyLegacyEnvVars(t *testing.T) {
+	t.Setenv("AZTFEXPORT_OUTPUT_DIR", "new")
+	t.Cleanup(func() { os.Unsetenv("AZTFEXPORT_TEST_PARALLELISM") })
+	applyLegacyEnvVars([]string{
+		"AZTFY_OUTPUT_DIR=legacy",
+		"AZTFY_TEST_PARALLELISM=5",
+		"OTHER=foo",
+	})
+	require.Equal(t, "new", os.Getenv("AZTFEXPORT_OUTPUT_DIR"))
+	require.Equal(t, "5", os.Getenv("AZTFEXPORT_TEST_PARALLELISM"))
+}
+
+func TestReadSkipFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skip.txt")
+	require.NoError(t, os.WriteFile(path, []byte(`# AKS managed resources
+/subscriptions/*/resourceGroups/MC_*
+
+  regex:/diagnosticSettings/[^/]+$  
+`), 0600))
+	patterns, err := readSkipFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"/subscriptions/*/resourceGroups/MC_*", "regex:/diagnosticSettings/[^/]+$"}, patterns)
+
+	_, err = readSkipFile(filepath.Join(t.TempDir(), "not-exist.txt"))
+	require.Error(t, err)
+}
+
+func TestReadLifecycleFile(t *testing.T) {
+	expect := []config.LifecycleRule{
+		{ResourceType: "azurerm_kubernetes_cluster", IgnoreChanges: []string{"default_node_pool[0].node_count"}},
+		{ResourceType: "*", IgnoreChanges: []string{"tags"}},
+	}
+
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "lifecycle.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`- resource_type: azurerm_kubernetes_cluster
+  ignore_changes:
+    - default_node_pool[0].node_count
+- resource_type: "*"
+  ignore_changes: [tags]
+`), 0600))
+	rules, err := readLifecycleFile(yamlPath)
+	require.NoError(t, err)
+	require.Equal(t, expect, rules)
+
+	jsonPath := filepath.Join(dir, "lifecycle.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`[
+  {"resource_type": "azurerm_kubernetes_cluster", "ignore_changes": ["default_node_pool[0].node_count"]},
+  {"resource_type": "*", "ignore_changes": ["tags"]}
+]`), 0600))
+	rules, err = readLifecycleFile(jsonPath)
+	require.NoError(t, err)
+	require.Equal(t, expect, rules)
+
+	txtPath := filepath.Join(dir, "lifecycle.txt")
+	require.NoError(t, os.WriteFile(txtPath, nil, 0600))
+	_, err = readLifecycleFile(txtPath)
+	require.ErrorContains(t, err, "unsupported lifecycle file extension")
+}
+
+func TestReadOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "azurerm_resource_group.tf.tmpl"), []byte(`{{ .Block }}`), 0600))
+	// Other files and directories are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), nil, 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "foo.tf.tmpl"), 0700))
+
+	tpls, err := readOverrideDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"azurerm_resource_group": `{{ .Block }}`}, tpls)
+
+	_, err = readOverrideDir(filepath.Join(dir, "not-exist"))
+	require.ErrorContains(t, err, "reading the override directory")
+}
+
+func TestParseTagFilter(t *testing.T) {
+	empty, prod := "", "prod"
+	m, err := parseTagFilter([]string{"env=prod", "team", "owner="})
+	require.NoError(t, err)
+	require.Equal(t, map[string]*string{
+		"env":   &prod,
+		"team":  nil,
+		"owner": &empty,
+	}, m)
+
+	m, err = parseTagFilter(nil)
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	_, err = parseTagFilter([]string{"=prod"})
+	require.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// optionFileNames are the option files looked up in the current working directory, when `--config` is not specified.
+var optionFileNames = []string{"aztfexport.yaml", "aztfexport.yml", "aztfexport.json"}
+
+// withOptionFile returns a BeforeFunc that applies the options from the option file before running the before function.
+func withOptionFile(fset *FlagSet, before cli.BeforeFunc) cli.BeforeFunc {
+	return func(ctx *cli.Context) error {
+		path := fset.flagOptionFile
+		if path == "" {
+			for _, name := range optionFileNames {
+				if _, err := os.Stat(name); err == nil {
+					path = name
+					break
+				}
+			}
+		}
+		if path != "" {
+			opts, err := loadOptionFile(path)
+			if err != nil {
+				return err
+			}
+			if err := applyOptions(ctx, opts); err != nil {
+				return fmt.Errorf("applying the options from %s: %v", path, err)
+			}
+		}
+		return before(ctx)
+	}
+}
+
+// loadOptionFile loads the option file in YAML or JSON (determined by the file extension), which is a map from the (long) option name to its value.
+// The value is either a scalar, or a list for the options that can be specified multiple times.
+func loadOptionFile(path string) (map[string]interface{}, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the option file %s: %v", path, err)
+	}
+	var opts map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &opts)
+	case ".json":
+		err = json.Unmarshal(b, &opts)
+	default:
+		return nil, fmt.Errorf("unsupported option file extension %q, must be one of %q, %q and %q", ext, ".yaml", ".yml", ".json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unmarshalling the option file %s: %v", path, err)
+	}
+	return opts, nil
+}
+
+// applyOptions sets the options of the current command that are not set by the command line or the environment variables.
+// The options that are not defined by the current command are ignored, as the option file can be shared by different commands.
+// While the options that are not defined by any command are regarded as typos.
+func applyOptions(ctx *cli.Context, opts map[string]interface{}) error {
+	known := map[string]bool{}
+	var collect func(cmds []*cli.Command)
+	collect = func(cmds []*cli.Command) {
+		for _, cmd := range cmds {
+			for _, flag := range cmd.Flags {
+				known[flag.Names()[0]] = true
+			}
+			collect(cmd.Subcommands)
+		}
+	}
+	collect(ctx.App.Commands)
+
+	for name := range opts {
+		if name == "config" {
+			return fmt.Errorf("option %q can't be specified in the option file", name)
+		}
+		if !known[name] {
+			return fmt.Errorf("unknown option %q", name)
+		}
+	}
+
+	for _, flag := range ctx.Command.Flags {
+		name := flag.Names()[0]
+		v, ok := opts[name]
+		if !ok || ctx.IsSet(name) {
+			continue
+		}
+		values, ok := v.([]interface{})
+		if !ok {
+			values = []interface{}{v}
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("invalid value of option %q: must be a scalar or a list of scalars", name)
+			}
+			if err := ctx.Set(name, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("setting option %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
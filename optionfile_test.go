@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestWithOptionFile(t *testing.T) {
+	cases := []struct {
+		name       string
+		file       string
+		content    string
+		args       []string
+		env        map[string]string
+		expectDir  string
+		expectPar  int
+		expectTags []string
+		err        string
+	}{
+		{
+			name:       "yaml",
+			file:       "opt.yaml",
+			content:    "output-dir: foo\nparallelism: 5\ninclude-tag:\n  - a=b\n  - c=d\n",
+			expectDir:  "foo",
+			expectPar:  5,
+			expectTags: []string{"a=b", "c=d"},
+		},
+		{
+			name:      "json",
+			file:      "opt.json",
+			content:   `{"output-dir": "foo", "parallelism": 5}`,
+			expectDir: "foo",
+			expectPar: 5,
+		},
+		{
+			name:      "command line and env override the option file",
+			file:      "opt.yaml",
+			content:   "output-dir: foo\nparallelism: 5\n",
+			args:      []string{"--output-dir", "bar"},
+			env:       map[string]string{"TEST_PARALLELISM": "3"},
+			expectDir: "bar",
+			expectPar: 3,
+		},
+		{
+			name:      "option of other commands is ignored",
+			file:      "opt.yaml",
+			content:   "other: foo\n",
+			expectPar: 10,
+		},
+		{
+			name:    "unknown option",
+			file:    "opt.yaml",
+			content: "foo: bar\n",
+			err:     `unknown option "foo"`,
+		},
+		{
+			name:    "unsupported extension",
+			file:    "opt.toml",
+			content: "",
+			err:     "unsupported option file extension",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			var (
+				fset FlagSet
+				dir  string
+				par  int
+				tags cli.StringSlice
+			)
+			app := &cli.App{
+				Commands: []*cli.Command{
+					{
+						Name: "test",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "output-dir", Destination: &dir},
+							&cli.IntFlag{Name: "parallelism", EnvVars: []string{"TEST_PARALLELISM"}, Value: 10, Destination: &par},
+							&cli.StringSliceFlag{Name: "include-tag", Destination: &tags},
+						},
+						Before: withOptionFile(&fset, func(*cli.Context) error { return nil }),
+						Action: func(*cli.Context) error { return nil },
+					},
+					{
+						Name:  "other",
+						Flags: []cli.Flag{&cli.StringFlag{Name: "other"}},
+					},
+				},
+			}
+			fset.flagOptionFile = path
+			err := app.Run(append([]string{"aztfexport", "test"}, tt.args...))
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectDir, dir)
+			require.Equal(t, tt.expectPar, par)
+			require.Equal(t, tt.expectTags, tags.Value())
+		})
+	}
+}
@@ -5,6 +5,9 @@ type AuthConfig struct {
 	TenantID           string
 	AuxiliaryTenantIDs []string
 
+	// The metadata host of a custom cloud (e.g. Azure Stack Hub), where the Environment is one of the clouds returned by its metadata endpoint
+	MetadataHost string
+
 	ClientID                  string
 	ClientSecret              string
 	ClientCertificateEncoded  string
@@ -13,6 +16,8 @@ type AuthConfig struct {
 	OIDCTokenRequestToken string
 	OIDCTokenRequestURL   string
 	OIDCAssertionToken    string
+	// The Azure DevOps service connection id, which is used to request the ID token from the Azure DevOps pipeline's OIDC provider
+	OIDCAzureServiceConnectionID string
 
 	UseAzureCLI        bool
 	UseManagedIdentity bool
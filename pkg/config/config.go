@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
@@ -13,6 +14,13 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// TypeResolver resolves the Terraform resource type and id of an Azure resource.
+// An empty type is returned if the resource is not resolved by it, in which case the builtin resolution is used.
+// The context is cancelled when the run is cancelled.
+type TypeResolver interface {
+	ResolveType(ctx context.Context, azureId string) (tfType string, tfId string, err error)
+}
+
 type ImportItem struct {
 	// Azure resource Id
 	AzureResourceID armid.ResourceId
@@ -38,8 +46,34 @@ type OutputFileNames struct {
 	MainFileName string
 	// The filename for the generated "import.tf" (default)
 	ImportBlockFileName string
+	// The filename for the generated "variables.tf" (default)
+	VariableFileName string
+	// The filename for the generated "outputs.tf" (default)
+	OutputFileName string
+	// The filename for the generated "locals.tf" (default)
+	LocalsFileName string
+}
+
+// LifecycleRule specifies the attributes to ignore changes for the resources of the matched types, which are injected as `lifecycle { ignore_changes = [...] }`.
+type LifecycleRule struct {
+	// ResourceType is the Terraform resource type, where "*" matches any sequence of characters (e.g. "azurerm_*").
+	ResourceType string `json:"resource_type" yaml:"resource_type"`
+	// IgnoreChanges are the attribute paths to ignore changes, e.g. "tags", "default_node_pool[0].node_count".
+	IgnoreChanges []string `json:"ignore_changes" yaml:"ignore_changes"`
 }
 
+// The layouts of the generated resource configs.
+const (
+	// OutputLayoutSingle writes all the resources to the main file (default).
+	OutputLayoutSingle = "single"
+	// OutputLayoutResource writes each resource to its own file, named after the resource address, e.g. "azurerm_resource_group.rg.tf".
+	OutputLayoutResource = "resource"
+	// OutputLayoutType writes the resources of the same type to one file, named after the resource type, e.g. "azurerm_subnet.tf".
+	OutputLayoutType = "type"
+	// OutputLayoutService writes the resources of the same Azure service to one file, named after the service, e.g. "network.tf", "compute.tf".
+	OutputLayoutService = "service"
+)
+
 type CommonConfig struct {
 	Logger *slog.Logger
 	// AuthConfig specifies the authentication config for provider
@@ -55,8 +89,48 @@ type CommonConfig struct {
 	OutputDir string
 	// OutputFileNames specifies the output terraform filenames
 	OutputFileNames OutputFileNames
+	// OutputLayout specifies how the generated resource configs are laid out in files, which is one of the OutputLayout* constants. Defaults to OutputLayoutSingle.
+	// The aliased provider blocks are always written to the main file.
+	OutputLayout string
+	// ExtractVariables specifies whether to lift the recurring literal values (e.g. location, resource group name, tags, SKU names) of the generated resources into variables,
+	// which are written to the variable file with the values as defaults.
+	ExtractVariables bool
+	// ExtractSensitive specifies whether to replace the values of the sensitive attributes (e.g. passwords, keys, connection strings) of the generated resources by references to sensitive variables,
+	// which are written to the variable file without defaults, together with an example variable definitions file (terraform.tfvars.example) in the output directory.
+	ExtractSensitive bool
+	// GenerateOutputs specifies whether to generate the outputs of the key attributes (e.g. id, name and the connection relevant attributes) of the generated resources,
+	// which are written to the output file.
+	GenerateOutputs bool
+	// CommonTags specifies whether to lift the tags shared by the generated resources into a local value, which is written to the locals file.
+	// The tags of the resources are rewritten to reference the local value, merged with the rest tags of each resource (if any).
+	CommonTags bool
+	// ARMDependency specifies whether to add the "depends_on" of the generated resources from the dependencies declared in the exported ARM templates of their resource groups,
+	// for the dependencies that aren't established by the references.
+	ARMDependency bool
+	// Cache specifies whether to cache the results of the Azure calls between runs in the user cache directory, including the TF resource types resolved
+	// (which might GET the resources) and the exported ARM templates (for ARMDependency). The results are keyed by the etags of the resources, so the changed resources are always queried again.
+	Cache bool
+	// Delta specifies whether to only process the resources created since the last delta run, i.e. those neither managed in the state nor recorded in the inventory file of the output directory.
+	// The processed (imported or skipped) resources are added to the inventory file after the config is generated, while the failed ones are left to the next delta run.
+	Delta bool
+	// AzAPIFallback specifies whether to export the resources that have no azurerm resource type mapped as "azapi_resource" by the azapi provider, instead of skipping them.
+	// The azapi provider is then used together with the azurerm provider. This only applies to the azurerm provider, and can't be used together with the TFClient.
+	AzAPIFallback bool
+	// CoverageReport specifies whether to write a JSON report (aztfexportCoverageReport.json) to the output directory, which lists the discovered resources that are not imported,
+	// each with the reason (e.g. no resource type mapped, filtered out by the user, import error).
+	CoverageReport bool
 	// ProviderVersion specifies the provider version used for importing. If this is not set, it will use `{azurerm|azapi}.ProviderSchemaInfo.Version` for importing in order to be consistent with tfadd.
 	ProviderVersion string
+	// TerraformBinary specifies the path to the terraform binary used for importing. If this is not set, the terraform executable is looked up from the PATH.
+	TerraformBinary string
+	// TerraformVersion specifies the exact terraform version used for importing. The TerraformBinary is only used if it is of this version, otherwise this version is looked up from the PATH
+	// and the user cache directory, which is downloaded into the user cache directory if not found.
+	TerraformVersion string
+	// TerraformOffline specifies whether to never download the terraform of the TerraformVersion, e.g. in air-gapped environments.
+	TerraformOffline bool
+	// ProviderMirror specifies the provider mirror to install the providers from, instead of the registry, which is either an https URL of a network mirror, or a directory of a filesystem mirror.
+	// This conflicts with DevProvider.
+	ProviderMirror string
 	// DevProvider specifies whether users have configured the `dev_overrides` for the provider, which then uses a development provider built locally rather than using a version pinned provider from official Terraform registry.
 	// Meanwhile, it will also avoid running `terraform init` during `Init()` for the import directories to avoid caculating the provider hash and populating the lock file (See: https://developer.hashicorp.com/terraform/language/files/dependency-lock). Though the init for the output directory is still needed for initializing the backend.
 	DevProvider bool
@@ -64,21 +138,63 @@ type CommonConfig struct {
 	ProviderName string
 	// ContinueOnError specifies whether continue the progress even hit an import error.
 	ContinueOnError bool
+	// RollbackOnFailure specifies whether to roll back the state to the backup taken prior to the import, when the export fails in the non-interactive mode.
+	// Otherwise, the timestamped backup (if the state isn't empty) is kept in the output directory.
+	RollbackOnFailure bool
 	// BackendType specifies the Terraform backend type.
 	BackendType string
 	// BackendConfig specifies an array of Terraform backend configs.
 	BackendConfig []string
+	// BackendBlock specifies the attributes (e.g. the storage account, container and key of the azurerm backend) that are written to the backend block of the generated terraform block.
+	// Different from the BackendConfig, these are persisted in the generated config, so that the output directory is initialized against the same backend later on. Don't put secrets here.
+	// This only takes effect when the terraform block is generated, and the BackendType is not "local".
+	BackendBlock map[string]string
+	// IncrementalPush specifies whether to push the state to the backend (with state locking) after each round of the parallel import, instead of once after all the imports.
+	// This makes the imports written into the (remote) backend along the way, so that the imported resources are not lost when the progress is interrupted.
+	IncrementalPush bool
+	// CloudHostname, CloudOrganization and CloudWorkspace specify the Terraform Cloud (or Enterprise) workspace, which is written to the cloud block of the generated terraform block for state.
+	// The BackendType must be "cloud" then. The CloudHostname defaults to "app.terraform.io".
+	// As the plan runs remotely in the workspace, this conflicts with Verify. It also conflicts with Environment, as the workspace is determined by the cloud block.
+	CloudHostname     string
+	CloudOrganization string
+	CloudWorkspace    string
+	// CloudUploadConfig specifies whether to upload the generated config as a configuration version (without queuing a run) of the cloud workspace via the API.
+	// The API token is read from the "TF_TOKEN_<hostname>" environment variable, or the credentials file written by "terraform login".
+	CloudUploadConfig bool
+	// PinVersions specifies whether to pin the versions used for the import in the generated terraform block of the output directory, so that later plans are run against the same provider schema.
+	// The provider is pinned to exactly the version that is installed, and the Terraform version is pinned as the minimum required version.
+	PinVersions bool
+	// Verify specifies whether to run "terraform validate" and "terraform plan" against the output directory after generating the config,
+	// and write a report of the diagnostics and the per resource attribute differences to "aztfexportVerifyReport.txt" of the output directory.
+	// The provider in the output directory is expected to authenticate via the environment variables (e.g. ARM_CLIENT_SECRET) or the Azure CLI, as the secrets are not written to the provider config.
+	Verify bool
+	// SourceMetadata specifies whether to add a comment header above each generated resource, which records the Azure resource id, the ARM resource type (and the API version for the azapi resources),
+	// and the import time, so that the generated config can be traced back to the Azure resources.
+	SourceMetadata bool
+	// JSONSyntax specifies whether to write the generated config in the Terraform JSON syntax (i.e. the ".tf.json" files) instead of the native syntax, for the tools that manipulate the config programmatically.
+	// All the ".tf" files in the output directory (and the module directory) are converted after the config is generated, hence this shouldn't be used when there are existing config files. The comments are not preserved.
+	JSONSyntax bool
+	// CDKTFLanguage specifies the CDK for Terraform language (one of "typescript", "python", "csharp", "java" and "go") to convert the generated config of the output directory to,
+	// which is written to "cdktf/main.<ext>" of the output directory. This requires the "cdktf" CLI to be installed, as the conversion is done by "cdktf convert".
+	CDKTFLanguage string
 	// ProviderConfig specifies key value pairs that will be expanded to the terraform-provider-{azurerm|azapi} settings (e.g. `azurerm {}` block)
 	// Currently, only the attributes (rather than blocks) are supported.
 	// This is not used directly by aztfexport as the provider configs can be set by environment variable already.
 	// While it is useful for module users that want support multi-users scenarios in one process (in which case changing env vars affect the whole process).
 	ProviderConfig map[string]cty.Value
+	// ProviderBlocks specifies the HCL of the "provider" blocks to generate, instead of the minimal ones. E.g. to configure the "features" sub-blocks, "skip_provider_registration", "partner_id", or the aliased providers.
+	// The default (i.e. non-aliased) block of the provider in use is still populated with the attributes from the ProviderConfig and the AuthConfig, unless they are defined by it.
+	// The azapi provider block can also be specified when the azapi resources are exported together with the azurerm ones.
+	ProviderBlocks string
 	// FullConfig specifies whether to export all (non computed-only) Terarform properties when generating TF configs.
+	// By default, the generated configs are minimal, where the computed-only, the optional+computed, and the optional attributes that equal to their defaults (or zero values) are omitted.
 	FullConfig bool
 	// MaskSensitive specifies whether to mask sensitive attributes when generating TF configs.
 	MaskSensitive bool
 	// Parallelism specifies the parallelism for the process
 	Parallelism int
+	// ImportTimeout specifies the timeout of importing each resource, after which the import is regarded as failed. 0 means no timeout.
+	ImportTimeout time.Duration
 	// PreImportHook is called before each resource is imported during ParallelImport
 	PreImportHook ImportCallback
 	// PostImportHook is called after each resource is imported during ParallelImport
@@ -86,16 +202,67 @@ type CommonConfig struct {
 	// ModulePath specifies the path of the module (e.g. "module1.module2") where the resources will be imported and config generated.
 	// Note that only modules whose "source" is local path is supported. By default, it is the root module.
 	ModulePath string
+	// AsModule specifies whether to generate the resources as a child module (under "modules/resources" of the output directory), with a thin root module that instantiates it.
+	// The root module holds the terraform, provider and import blocks, while the child module holds the resources together with the extracted variables and the generated outputs (i.e. implies ExtractVariables and GenerateOutputs).
+	// This conflicts with ModulePath.
+	AsModule bool
+	// Terragrunt specifies whether to generate the "terragrunt.hcl" in the output directory, so that it can be placed as a unit (e.g. "live/<env>/<component>") of a terragrunt live repo.
+	// The "remote_state" is wired with the BackendType and BackendBlock, and the "inputs" with the extracted variables (i.e. implies ExtractVariables). This conflicts with AsModule and ModulePath.
+	Terragrunt bool
+	// Environment specifies the environment (e.g. "dev", "prod") of the exported resources, which is used as the Terraform workspace of the output directory (created if not exists).
+	// The values of the extracted variables (i.e. implies ExtractVariables) are written to the "<Environment>.tfvars" in the output directory, rather than as the defaults, so that the config is shared by the environments.
+	// When appending to the output directory of other environments, the resources that are already declared are not generated again, which are matched by the TF resource type and the resource id,
+	// regardless of the subscription id and the environment name within the resource names (e.g. "rg-prod" of the "prod" environment matches "rg-dev" of the "dev" environment).
+	// This conflicts with HCLOnly, AsModule, ModulePath, Terragrunt, ExtractSensitive, CommonTags and GenerateImportBlock.
+	Environment string
 	// HCLOnly is a strange field, which is only used internally by aztfexport to indicate whether to remove other files other than TF config at the end.
 	// External Go modules should just ignore it.
 	HCLOnly bool
-	// TFClient is the terraform-client-go client used to replace terraform binary for importing resources.
-	// This can only be used together with HCLOnly as tfclient can't replace terraform for state file management.
+	// StateOnly specifies whether to only import the resources to the state, without generating the config (the other config generation options take no effect), e.g. the config is hand-written.
+	// This conflicts with HCLOnly, AsModule, Terragrunt and GenerateImportBlock.
+	StateOnly bool
+	// StateFile specifies the path to an existing state file, which is used as the base state instead of the current state of the output directory.
+	// The state file itself is not modified, its copy (with the imported resources added) is pushed to the output directory. If the output directory has a state already,
+	// the state file must have the same lineage, and must not be older than it. This conflicts with HCLOnly.
+	StateFile string
+	// TFClient is the terraform-client-go client used to replace terraform binary for importing resources, which talks to the provider directly without the per resource terraform CLI overhead.
+	// Unless HCLOnly is set, the terraform binary is still used to manage the state of the output directory, where the imported states are merged to.
 	TFClient tfclient.Client
 	// TelemetryClient is a client to send telemetry
 	TelemetryClient telemetry.Client
 	// GenerateImportBlock controls whether the export process ends up with a import.tf file that contains the "import" blocks
 	GenerateImportBlock bool
+	// ImportViaPlan specifies whether to import the resources of each round via a single "terraform plan" (with the import blocks, whose config is generated by the plan) and "terraform apply",
+	// instead of running "terraform import" per resource. The plan is only applied if it does nothing but importing, otherwise the resources are imported one by one instead.
+	// This requires Terraform v1.5.0 or later, and conflicts with AsModule, ModulePath and TFClient.
+	ImportViaPlan bool
+	// TypeResolver resolves the Terraform resource type and id of the Azure resources in the azurerm mode, which takes precedence over the builtin resolution.
+	// This allows to cover the resource types that are not known to aztfexport yet.
+	TypeResolver TypeResolver
+	// ResolveAddressConflict specifies whether to rename the resources whose TF addresses conflict with the ones managed in the state (or with each other) by appending a counter,
+	// instead of reporting the conflicts as an error.
+	ResolveAddressConflict bool
+	// IncludeTypes specifies the resource type patterns that the exported resources must match any of. Each pattern is either an Azure resource type or a Terraform resource type, where "*" matches any sequence of characters.
+	IncludeTypes []string
+	// ExcludeTypes specifies the resource type patterns that the exported resources must not match any of. The pattern format is the same as IncludeTypes.
+	ExcludeTypes []string
+	// NameFilter specifies the regular expression that the Azure resource name (i.e. the last segment of the resource id) of the exported resources must match.
+	// The child resources and the extension resources (e.g. role assignments) follow the name of their parent resource or scope, and a resource group is kept if any resource within it is exported.
+	NameFilter string
+	// SkipResources specifies the Azure resource id patterns of the resources that are always excluded. Each pattern is either an Azure resource id,
+	// where "*" matches any sequence of characters (including "/"), or a regular expression prefixed by "regex:". The match is case insensitive.
+	SkipResources []string
+	// LifecycleRules specifies the rules of the attributes to ignore changes for the generated resources. The attributes of all the matched rules are combined.
+	LifecycleRules []LifecycleRule
+	// OverrideTemplates specifies the Go templates (https://pkg.go.dev/text/template) keyed by the TF resource types, whose rendered output replaces the generated config of the resources of that type.
+	// The templates are executed with the fields: Type, Name, AzureResourceId, TFResourceId, Block (the HCL of the generated resource block) and Body (the HCL inside the braces of the generated resource block),
+	// so that a template can either replace the generated block, or wrap it (e.g. adding the standard tags, or calling a module). The rendered output must be valid HCL.
+	OverrideTemplates map[string]string
+	// PartnerId specifies the partner GUID for the customer usage attribution of the Azure API calls made by the providers.
+	// The Azure API calls made by aztfexport itself are controlled by the AzureSDKClientOption field.
+	PartnerId string
+	// UserAgentSuffix specifies the string appended to the User-Agent header of the Azure API calls made by the providers.
+	UserAgentSuffix string
 }
 
 type Config struct {
@@ -110,19 +277,44 @@ type Config struct {
 	// ARGPredicate specifies the ARG where predicate, this indicates the query mode.
 	ARGPredicate string
 	// MappingFile specifies the path of mapping file, this indicates the map file mode.
+	// It can also be a directory, in which case the mapping files directly under it are merged in lexical order.
 	MappingFile string
+	// AdditionalMappingFiles specifies the paths of mapping files (or directories) that are merged above the MappingFile in order, where the later ones take precedence.
+	AdditionalMappingFiles []string
+	// SubscriptionScope specifies whether to export all the resource groups (and their nested resources) of the subscription, this indicates the subscription mode.
+	SubscriptionScope bool
+	// ManagementGroupName specifies the name of the management group, this indicates the management group mode, which exports all the resource groups (and their nested resources) of the subscriptions under the management group.
+	ManagementGroupName string
+	// ManagementGroupSubscriptionId specifies the only subscription under the management group to export, which is used for exporting one workspace per subscription.
+	// All the subscriptions under the management group are exported if not specified.
+	ManagementGroupSubscriptionId string
 
 	/////////////////////////
-	// Scope: rg, res (multi), query
+	// Scope: rg, sub, mg, res (multi), query
 
-	// ResourceNamePattern specifies the resource name pattern
+	// ResourceNamePattern specifies the resource name pattern, which is either a prefix (and suffix, separated by the last "*") of the resource index,
+	// or a template containing any of the tokens "{name}", "{type}", "{rg}" and "{index}".
 	ResourceNamePattern string
+	// ExcludeChildResources specifies whether to exclude the child resources (e.g. subnets) that are embedded in the schema of their parent resource (e.g. the virtual network), when the parent is also exported
+	ExcludeChildResources bool
 
 	/////////////////////////
-	// Scope: rg, query
+	// Scope: rg, sub, mg, query
 
 	// IncludeRoleAssignment specifies whether to include the role assginments assigned to the exported resources
 	IncludeRoleAssignment bool
+	// IncludeLock specifies whether to include the management locks scoped to the exported resources
+	IncludeLock bool
+	// IncludeDataPlane specifies whether to include the data plane resources that are not listed by the control plane (e.g. the App Configuration key-values, the Key Vault certificates)
+	IncludeDataPlane bool
+	// IncludeTags specifies the tags that the exported resources must all have. The key is the tag name (case insensitive), the value is the tag value, where a nil value matches any value (while an empty value only matches the empty value).
+	// The resources that can't be tagged (e.g. child resources, role assignments) follow the decision of their parent resource or scope.
+	IncludeTags map[string]*string
+	// ExcludeTags specifies the tags that the exported resources must not have any of. The key is the tag name (case insensitive), the value is the tag value, where a nil value matches any value (while an empty value only matches the empty value).
+	// The resources that can't be tagged (e.g. child resources, role assignments) follow the decision of their parent resource or scope.
+	ExcludeTags map[string]*string
+	// ExpandEmbeddedResources specifies whether to export the child resources that are embedded in the properties of their parent resource as separate resources (e.g. key vault access policies as azurerm_key_vault_access_policy)
+	ExpandEmbeddedResources bool
 
 	/////////////////////////
 	// Scope: res (single)
@@ -133,10 +325,14 @@ type Config struct {
 	TFResourceType string
 
 	/////////////////////////
-	// Scope: query
+	// Scope: query, res
 
-	// RecursiveQuery specifies whether to recursively list the child/proxy resources of the ARG resulted resource list
+	// RecursiveQuery specifies whether to recursively list the child/proxy resources of the ARG resulted resource list (query), or of the specified resources (res)
 	RecursiveQuery bool
+
+	/////////////////////////
+	// Scope: query
+
 	// IncludeResourceGroup specifies whether to include the resource groups that the exported resources belong to
 	IncludeResourceGroup bool
 	// ARGTable specifies the ARG table name, which defaults to the "Resources" table
@@ -11,6 +11,9 @@ import (
 type ImportItem = meta.ImportItem
 type ImportList = meta.ImportList
 
+// ResourceMappingFileName is the name of the resource mapping file exported to the output directory.
+const ResourceMappingFileName = meta.ResourceMappingFileName
+
 type Meta interface {
 	meta.BaseMeta
 	// ScopeName returns a string indicating current scope/mode.
@@ -19,6 +22,11 @@ type Meta interface {
 	ListResource(ctx context.Context) (meta.ImportList, error)
 }
 
+// ListManagementGroupSubscriptions lists the ids of all the subscriptions under the management group specified in the config, including those under its descendant management groups.
+func ListManagementGroupSubscriptions(ctx context.Context, cfg config.Config) ([]string, error) {
+	return meta.ListManagementGroupSubscriptions(ctx, cfg)
+}
+
 func NewMeta(cfg config.Config) (Meta, error) {
 	switch {
 	case cfg.ResourceGroupName != "":
@@ -27,6 +35,10 @@ func NewMeta(cfg config.Config) (Meta, error) {
 		return meta.NewMetaQuery(cfg)
 	case cfg.MappingFile != "":
 		return meta.NewMetaMap(cfg)
+	case cfg.SubscriptionScope:
+		return meta.NewMetaSubscription(cfg)
+	case cfg.ManagementGroupName != "":
+		return meta.NewMetaManagementGroup(cfg)
 	case len(cfg.ResourceIds) != 0:
 		return meta.NewMetaResource(cfg)
 	default:
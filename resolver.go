@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Azure/aztfexport/internal/resmap"
+	"github.com/Azure/aztfexport/pkg/config"
+)
+
+// fileTypeResolver resolves the TF resource types by the resolution rules defined in files, whose format is the same as the resource mapping file.
+// Each entry is keyed by either an Azure resource id, or a pattern rule (see resmap.IsRule), where the "resource_type" is required.
+type fileTypeResolver struct {
+	resolver *resmap.Resolver
+}
+
+func newFileTypeResolver(paths ...string) (*fileTypeResolver, error) {
+	resolver, err := resmap.LoadResolver(paths...)
+	if err != nil {
+		return nil, fmt.Errorf("loading the type resolver file: %v", err)
+	}
+	return &fileTypeResolver{resolver: resolver}, nil
+}
+
+func (r *fileTypeResolver) ResolveType(_ context.Context, azureId string) (string, string, error) {
+	tfType, tfId := r.resolver.Resolve(azureId)
+	return tfType, tfId, nil
+}
+
+// commandTypeResolverTimeout is the timeout of each invocation of the type resolver command.
+const commandTypeResolverTimeout = 30 * time.Second
+
+// commandTypeResolver resolves the TF resource types by an external command, which is invoked with the Azure resource id as its last argument.
+// The command is expected to output a JSON object with the "resource_type" and the optional "resource_id" to its stdout, or nothing if it can't resolve the resource.
+type commandTypeResolver struct {
+	name    string
+	args    []string
+	timeout time.Duration
+}
+
+type commandTypeResolverOutput struct {
+	ResourceType string `json:"resource_type"`
+	ResourceId   string `json:"resource_id"`
+}
+
+// newCommandTypeResolver builds the type resolver of the command, which is either a JSON array of the command and its arguments (e.g. `["python3", "/path with space/resolver.py"]`),
+// or a string of them separated by whitespaces (e.g. `python3 resolver.py`).
+func newCommandTypeResolver(command string) (*commandTypeResolver, error) {
+	var argv []string
+	if strings.HasPrefix(strings.TrimSpace(command), "[") {
+		if err := json.Unmarshal([]byte(command), &argv); err != nil {
+			return nil, fmt.Errorf("unmarshalling the type resolver command as a JSON array: %v", err)
+		}
+	} else {
+		argv = strings.Fields(command)
+	}
+	if len(argv) == 0 || argv[0] == "" {
+		return nil, fmt.Errorf("empty type resolver command")
+	}
+	return &commandTypeResolver{name: argv[0], args: argv[1:], timeout: commandTypeResolverTimeout}, nil
+}
+
+func (r *commandTypeResolver) ResolveType(ctx context.Context, azureId string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	// #nosec G204
+	cmd := exec.CommandContext(ctx, r.name, append(slices.Clone(r.args), azureId)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", r.timeout)
+		}
+		return "", "", fmt.Errorf("running the type resolver command %q: %v: %s", r.name, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return "", "", nil
+	}
+	var output commandTypeResolverOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return "", "", fmt.Errorf("unmarshalling the output of the type resolver command %q: %v: %s", r.name, err, strings.TrimSpace(stderr.String()))
+	}
+	return output.ResourceType, output.ResourceId, nil
+}
+
+// typeResolvers chains the type resolvers, where the first one that resolves the resource wins.
+type typeResolvers []config.TypeResolver
+
+func (l typeResolvers) ResolveType(ctx context.Context, azureId string) (string, string, error) {
+	for _, r := range l {
+		tfType, tfId, err := r.ResolveType(ctx, azureId)
+		if err != nil {
+			return "", "", err
+		}
+		if tfType != "" {
+			return tfType, tfId, nil
+		}
+	}
+	return "", "", nil
+}
+
+// buildTypeResolver builds the type resolver from the FlagSet, where the resolver files take precedence over the resolver command.
+// A nil resolver is returned if neither is specified.
+func (f FlagSet) buildTypeResolver() (config.TypeResolver, error) {
+	var l typeResolvers
+	if files := f.flagTypeResolverFiles.Value(); len(files) != 0 {
+		r, err := newFileTypeResolver(files...)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, r)
+	}
+	if f.flagTypeResolverCommand != "" {
+		r, err := newCommandTypeResolver(f.flagTypeResolverCommand)
+		if err != nil {
+			return nil, err
+		}
+		l = append(l, r)
+	}
+	if len(l) == 0 {
+		return nil, nil
+	}
+	return l, nil
+}
+
+var _ config.TypeResolver = typeResolvers(nil)
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeResolvers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the resolver command is a shell script")
+	}
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "resolver.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1:
+  resource_type: azurerm_foo
+  resource_id: /subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1/custom
+"*/providers/Microsoft.Foo/foos/*":
+  resource_type: azurerm_foo
+`), 0600))
+	command := filepath.Join(dir, "resolver.sh")
+	require.NoError(t, os.WriteFile(command, []byte(`#!/bin/sh
+case "$1" in
+  */Microsoft.Bar/*) echo '{"resource_type": "azurerm_bar"}' ;;
+  */Microsoft.Baz/*) echo 'invalid'; echo 'failed to resolve' >&2; exit 1 ;;
+esac
+`), 0700))
+
+	fset := FlagSet{flagTypeResolverCommand: command}
+	require.NoError(t, fset.flagTypeResolverFiles.Set(file))
+	resolver, err := fset.buildTypeResolver()
+	require.NoError(t, err)
+
+	cases := []struct {
+		id     string
+		tfType string
+		tfId   string
+		err    string
+	}{
+		{
+			id:     "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1",
+			tfType: "azurerm_foo",
+			tfId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo1/custom",
+		},
+		{
+			id:     "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2",
+			tfType: "azurerm_foo",
+			tfId:   "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Foo/foos/foo2",
+		},
+		{
+			id:     "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Bar/bars/bar",
+			tfType: "azurerm_bar",
+		},
+		{
+			id: "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Qux/quxs/qux",
+		},
+		{
+			id:  "/subscriptions/123/resourceGroups/rg/providers/Microsoft.Baz/bazs/baz",
+			err: "failed to resolve",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.id, func(t *testing.T) {
+			tfType, tfId, err := resolver.ResolveType(context.Background(), tt.id)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.tfType, tfType)
+			require.Equal(t, tt.tfId, tfId)
+		})
+	}
+}
+
+func TestNewCommandTypeResolver(t *testing.T) {
+	r, err := newCommandTypeResolver(`python3  resolver.py --flag`)
+	require.NoError(t, err)
+	require.Equal(t, "python3", r.name)
+	require.Equal(t, []string{"resolver.py", "--flag"}, r.args)
+
+	r, err = newCommandTypeResolver(`["python3", "/path with space/resolver.py"]`)
+	require.NoError(t, err)
+	require.Equal(t, "python3", r.name)
+	require.Equal(t, []string{"/path with space/resolver.py"}, r.args)
+
+	_, err = newCommandTypeResolver(`["python3", `)
+	require.Error(t, err)
+	_, err = newCommandTypeResolver(`[]`)
+	require.Error(t, err)
+	_, err = newCommandTypeResolver(` `)
+	require.Error(t, err)
+}
+
+func TestCommandTypeResolverTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the resolver command is a shell script")
+	}
+
+	r, err := newCommandTypeResolver(`["sh", "-c", "sleep 10", "sh"]`)
+	require.NoError(t, err)
+	r.timeout = 100 * time.Millisecond
+	_, _, err = r.ResolveType(context.Background(), "/subscriptions/123")
+	require.ErrorContains(t, err, "timed out")
+
+	// The cancellation of the run also stops the command.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = r.ResolveType(ctx, "/subscriptions/123")
+	require.Error(t, err)
+}
+
+func TestBuildTypeResolverEmpty(t *testing.T) {
+	resolver, err := FlagSet{}.buildTypeResolver()
+	require.NoError(t, err)
+	require.Nil(t, resolver)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// newCABundleTransport returns a HTTP transport that trusts the CA certificates in the PEM encoded CA bundle file, in addition to the system ones.
+// The transport honors the proxy environment variables (i.e. HTTPS_PROXY, NO_PROXY), as the default transport does.
+func newCABundleTransport(path string) (*http.Transport, error) {
+	// #nosec G304
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the CA bundle %q: %v", path, err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("no PEM encoded certificate found in the CA bundle %q", path)
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{
+		RootCAs:    pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	return tr, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// userAgentPolicy appends the partner id and the user agent suffix to the User-Agent header of the Azure API calls.
+// It is a per call policy, which runs after the telemetry policy that sets the application id.
+type userAgentPolicy struct {
+	partnerId string
+	suffix    string
+}
+
+func (p userAgentPolicy) Do(req *policy.Request) (*http.Response, error) {
+	ua := []string{req.Raw().Header.Get("User-Agent")}
+	ua = append(ua, userAgentAppendix(p.partnerId, p.suffix)...)
+	req.Raw().Header.Set("User-Agent", strings.TrimSpace(strings.Join(ua, " ")))
+	return req.Next()
+}
+
+// userAgentAppendix returns the strings to be appended to the User-Agent header, where the partner id is in the form of "pid-<GUID>", as is done by the providers.
+func userAgentAppendix(partnerId, suffix string) []string {
+	var out []string
+	if partnerId != "" {
+		out = append(out, "pid-"+partnerId)
+	}
+	if suffix != "" {
+		out = append(out, suffix)
+	}
+	return out
+}
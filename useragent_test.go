@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserAgentPolicy(t *testing.T) {
+	var ua string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	pl := runtime.NewPipeline("module", "v1.0.0", runtime.PipelineOptions{}, &policy.ClientOptions{
+		Telemetry:       policy.TelemetryOptions{ApplicationID: "aztfexport(azurerm)"},
+		PerCallPolicies: []policy.Policy{userAgentPolicy{partnerId: "00000000-0000-0000-0000-000000000000", suffix: "my-tool/1.0"}},
+	})
+	req, err := runtime.NewRequest(context.Background(), http.MethodGet, srv.URL)
+	require.NoError(t, err)
+	_, err = pl.Do(req)
+	require.NoError(t, err)
+	require.Regexp(t, `^aztfexport\(azurerm\) .* pid-00000000-0000-0000-0000-000000000000 my-tool/1.0$`, ua)
+}
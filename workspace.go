@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/meta"
+	"github.com/Azure/aztfexport/pkg/telemetry"
+)
+
+// listManagementGroupSubscriptions is a variable so that it can be replaced in tests.
+var listManagementGroupSubscriptions = meta.ListManagementGroupSubscriptions
+
+// nopCloseTelemetryClient is a telemetry client whose Close is a no-op, which is shared by the exports of multiple workspaces and closed once all of them end.
+type nopCloseTelemetryClient struct {
+	telemetry.Client
+}
+
+func (nopCloseTelemetryClient) Close() {}
+
+// exportWorkspacePerSubscription exports each subscription under the management group to its own workspace, which is the sub-directory of the output directory named by the subscription id.
+// The sub-directories are not created in dry-run mode, as nothing is written then.
+func exportWorkspacePerSubscription(ctx context.Context, cfg config.Config, dryRun bool, export func(cfg config.Config) error) error {
+	tc := cfg.TelemetryClient
+	defer tc.Close()
+
+	subIds, err := listManagementGroupSubscriptions(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	for _, subId := range subIds {
+		subCfg := cfg
+		subCfg.SubscriptionId = subId
+		subCfg.ManagementGroupSubscriptionId = subId
+		subCfg.OutputDir = filepath.Join(cfg.OutputDir, subId)
+		subCfg.TelemetryClient = nopCloseTelemetryClient{tc}
+		if !dryRun {
+			if err := os.MkdirAll(subCfg.OutputDir, 0750); err != nil {
+				return fmt.Errorf("creating output directory %q: %v", subCfg.OutputDir, err)
+			}
+		}
+		cfg.Logger.Info("Export the subscription to its own workspace", "subscription", subId, "dir", subCfg.OutputDir)
+		if err := export(subCfg); err != nil {
+			return fmt.Errorf("exporting subscription %s to %s: %v", subId, subCfg.OutputDir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aztfexport/pkg/config"
+	"github.com/Azure/aztfexport/pkg/telemetry"
+	"github.com/stretchr/testify/require"
+)
+
+type countingTelemetryClient struct {
+	telemetry.NullClient
+	closed int
+}
+
+func (c *countingTelemetryClient) Close() { c.closed++ }
+
+func TestExportWorkspacePerSubscription(t *testing.T) {
+	origList := listManagementGroupSubscriptions
+	listManagementGroupSubscriptions = func(context.Context, config.Config) ([]string, error) {
+		return []string{"sub1", "sub2"}, nil
+	}
+	t.Cleanup(func() { listManagementGroupSubscriptions = origList })
+
+	dir := t.TempDir()
+	tc := &countingTelemetryClient{}
+	cfg := config.Config{
+		CommonConfig: config.CommonConfig{
+			OutputDir:       dir,
+			SubscriptionId:  "default",
+			Logger:          slog.New(slog.NewTextHandler(os.Stderr, nil)),
+			TelemetryClient: tc,
+		},
+		ManagementGroupName: "mg",
+	}
+
+	var cfgs []config.Config
+	err := exportWorkspacePerSubscription(context.Background(), cfg, false, func(cfg config.Config) error {
+		cfg.TelemetryClient.Close()
+		cfgs = append(cfgs, cfg)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+	for i, subId := range []string{"sub1", "sub2"} {
+		require.Equal(t, subId, cfgs[i].SubscriptionId)
+		require.Equal(t, subId, cfgs[i].ManagementGroupSubscriptionId)
+		require.Equal(t, filepath.Join(dir, subId), cfgs[i].OutputDir)
+		require.DirExists(t, cfgs[i].OutputDir)
+	}
+	require.Equal(t, 1, tc.closed)
+
+	// The export stops at the first failed subscription
+	cfgs = nil
+	err = exportWorkspacePerSubscription(context.Background(), cfg, true, func(cfg config.Config) error {
+		cfgs = append(cfgs, cfg)
+		return errors.New("failed")
+	})
+	require.ErrorContains(t, err, "exporting subscription sub1")
+	require.Len(t, cfgs, 1)
+}